@@ -146,7 +146,7 @@ class SerenadaSession internal constructor(
     private val signalingMessageRouter = SignalingMessageRouter(
         getClientId = { clientId },
         getHostCid = { hostCid },
-        onJoined = { cid, _, roomState, turnToken, turnTTL, newReconnectToken ->
+        onJoined = { cid, _, roomState, turnToken, turnTTL, newReconnectToken, sessionId ->
             clientId = cid
             updateState(_state.value.copy(localCid = clientId))
             newReconnectToken?.let { reconnectToken = it }
@@ -157,7 +157,7 @@ class SerenadaSession internal constructor(
                 updateParticipants(roomState)
             }
             if (!turnToken.isNullOrBlank()) {
-                turnManager.fetchTurnCredentials(turnToken)
+                turnManager.fetchTurnCredentials(turnToken, sessionId)
             } else {
                 turnManager.applyDefaultIceServers()
             }
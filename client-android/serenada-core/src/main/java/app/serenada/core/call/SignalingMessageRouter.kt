@@ -15,7 +15,7 @@ internal class SignalingMessageRouter(
     private val getClientId: () -> String?,
     private val getHostCid: () -> String?,
     // Mutation callbacks
-    private val onJoined: (clientId: String, hostCid: String?, roomState: RoomState?, turnToken: String?, turnTTL: Long?, reconnectToken: String?) -> Unit,
+    private val onJoined: (clientId: String, hostCid: String?, roomState: RoomState?, turnToken: String?, turnTTL: Long?, reconnectToken: String?, sessionId: String?) -> Unit,
     private val onRoomStateUpdated: (RoomState) -> Unit,
     private val onError: (CallError) -> Unit,
     private val onRoomEnded: () -> Unit,
@@ -70,7 +70,7 @@ internal class SignalingMessageRouter(
 
         val roomState = parseRoomState(msg.payload)
 
-        onJoined(cid, roomState?.hostCid, roomState, turnToken, turnTTL, reconnectToken)
+        onJoined(cid, roomState?.hostCid, roomState, turnToken, turnTTL, reconnectToken, msg.sid)
     }
 
     private fun handleRoomState(msg: SignalingMessage) {
@@ -35,6 +35,10 @@ internal class SseSignalingTransport(
     private val postCallsLock = Any()
     @Volatile
     private var currentHost: String? = null
+    // ID of the last dispatched event, sent as Last-Event-ID when the stream is
+    // reopened so the server replays what this session missed.
+    @Volatile
+    private var lastEventId: String? = null
     @Volatile
     private var onMessageCallback: ((SignalingMessage) -> Unit)? = null
     @Volatile
@@ -52,6 +56,7 @@ internal class SseSignalingTransport(
         val request = Request.Builder()
             .url(buildSseUrl(host, sid))
             .header("Accept", "text/event-stream")
+            .apply { lastEventId?.let { header("Last-Event-ID", it) } }
             .build()
         val call = sseStreamClient.newCall(request)
         streamCall = call
@@ -127,6 +132,7 @@ internal class SseSignalingTransport(
 
     override fun resetSession() {
         sid = createSid()
+        lastEventId = null
         currentHost = null
     }
 
@@ -135,15 +141,23 @@ internal class SseSignalingTransport(
         onClosed: (String) -> Unit
     ) {
         val dataBuffer = StringBuilder()
+        var eventId: String? = null
         try {
             while (true) {
                 val rawLine = source.readUtf8Line() ?: break
                 val line = rawLine.trimEnd('\r')
                 if (line.isEmpty()) {
+                    if (dataBuffer.isNotEmpty() && eventId != null) {
+                        lastEventId = eventId
+                    }
                     dispatchSseMessage(dataBuffer)
                     continue
                 }
                 if (line.startsWith(":")) continue
+                if (line.startsWith("id:")) {
+                    eventId = line.removePrefix("id:").removePrefix(" ")
+                    continue
+                }
                 if (line.startsWith("data:")) {
                     var dataPart = line.removePrefix("data:")
                     if (dataPart.startsWith(" ")) {
@@ -17,15 +17,17 @@ internal class TurnManager(
 ) {
     private var turnRefreshRunnable: Runnable? = null
     private var turnTokenTTLMs: Long? = null
+    private var sessionId: String? = null
 
-    fun fetchTurnCredentials(token: String) {
+    fun fetchTurnCredentials(token: String, sessionId: String? = this.sessionId) {
+        this.sessionId = sessionId
         var resolved = false
         val timeoutRunnable = Runnable {
             if (resolved) return@Runnable; resolved = true
             applyDefaultIceServers()
         }
         handler.postDelayed(timeoutRunnable, WebRtcResilienceConstants.TURN_FETCH_TIMEOUT_MS)
-        apiClient.fetchTurnCredentials(serverHost, token) { result ->
+        apiClient.fetchTurnCredentials(serverHost, token, sessionId) { result ->
             handler.post {
                 handler.removeCallbacks(timeoutRunnable)
                 if (resolved) return@post; resolved = true
@@ -52,6 +54,7 @@ internal class TurnManager(
     fun reset() {
         cancelRefresh()
         turnTokenTTLMs = null
+        sessionId = null
     }
 
     fun cancelRefresh() {
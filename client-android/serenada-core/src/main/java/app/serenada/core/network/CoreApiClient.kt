@@ -88,8 +88,9 @@ class CoreApiClient(private val okHttpClient: OkHttpClient) : SessionAPIClient {
         })
     }
 
-    override fun fetchTurnCredentials(host: String, token: String, onResult: (Result<TurnCredentials>) -> Unit) {
-        val url = buildHttpsUrl(host, "/api/turn-credentials", mapOf("token" to token))
+    override fun fetchTurnCredentials(host: String, token: String, sessionId: String?, onResult: (Result<TurnCredentials>) -> Unit) {
+        val query = if (sessionId.isNullOrBlank()) mapOf("token" to token) else mapOf("token" to token, "sid" to sessionId)
+        val url = buildHttpsUrl(host, "/api/turn-credentials", query)
         if (url == null) {
             onResult(Result.failure(IllegalArgumentException("Invalid host")))
             return
@@ -1,5 +1,6 @@
 package app.serenada.core.network
 
 internal interface SessionAPIClient {
-    fun fetchTurnCredentials(host: String, token: String, onResult: (Result<TurnCredentials>) -> Unit)
+    /** [sessionId] is the signaling session's sid; the server requires it for call tokens. */
+    fun fetchTurnCredentials(host: String, token: String, sessionId: String? = null, onResult: (Result<TurnCredentials>) -> Unit)
 }
@@ -18,6 +18,7 @@ internal class FakeAPIClient : SessionAPIClient {
     override fun fetchTurnCredentials(
         host: String,
         token: String,
+        sessionId: String?,
         onResult: (Result<TurnCredentials>) -> Unit
     ) {
         fetchTurnCredentialsCalls.add(host to token)
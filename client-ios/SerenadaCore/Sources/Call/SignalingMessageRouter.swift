@@ -6,7 +6,7 @@ final class SignalingMessageRouter {
     private let getClientId: () -> String?
 
     // Callbacks for mutations
-    private let onJoined: (_ cid: String?, _ payload: JoinedPayload, _ rawPayload: JSONValue?) -> Void
+    private let onJoined: (_ cid: String?, _ sid: String?, _ payload: JoinedPayload, _ rawPayload: JSONValue?) -> Void
     private let onRoomState: (_ payload: JSONValue?) -> Void
     private let onRoomEnded: () -> Void
     private let onPong: () -> Void
@@ -18,7 +18,7 @@ final class SignalingMessageRouter {
 
     init(
         getClientId: @escaping () -> String?,
-        onJoined: @escaping (_ cid: String?, _ payload: JoinedPayload, _ rawPayload: JSONValue?) -> Void,
+        onJoined: @escaping (_ cid: String?, _ sid: String?, _ payload: JoinedPayload, _ rawPayload: JSONValue?) -> Void,
         onRoomState: @escaping (_ payload: JSONValue?) -> Void,
         onRoomEnded: @escaping () -> Void,
         onPong: @escaping () -> Void,
@@ -46,7 +46,7 @@ final class SignalingMessageRouter {
         switch message.type {
         case "joined":
             let payload = JoinedPayload(from: message.payload)
-            onJoined(message.cid, payload, message.payload)
+            onJoined(message.cid, message.sid, payload, message.payload)
         case "room_state":
             onRoomState(message.payload)
         case "room_ended":
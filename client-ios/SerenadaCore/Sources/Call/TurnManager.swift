@@ -17,6 +17,7 @@ final class TurnManager {
     private var turnTokenTTLMs: Int64?
     private var hasInitializedIceSetupForAttempt = false
     private var lastTurnTokenForAttempt: String?
+    private var sessionId: String?
 
     init(
         clock: SessionClock,
@@ -42,7 +43,8 @@ final class TurnManager {
         self.sendTurnRefresh = sendTurnRefresh
     }
 
-    func ensureIceSetupIfNeeded(turnToken: String?) {
+    func ensureIceSetupIfNeeded(turnToken: String?, sessionId: String? = nil) {
+        if let sessionId, !sessionId.isEmpty { self.sessionId = sessionId }
         let normalizedToken = turnToken?.trimmingCharacters(in: .whitespacesAndNewlines)
 
         if !hasInitializedIceSetupForAttempt {
@@ -79,6 +81,7 @@ final class TurnManager {
         hasInitializedIceSetupForAttempt = false
         lastTurnTokenForAttempt = nil
         turnTokenTTLMs = nil
+        sessionId = nil
     }
 
     func cancelRefresh() {
@@ -98,9 +101,9 @@ final class TurnManager {
 
         Task {
             let outcome = await withTaskGroup(of: TurnFetchOutcome.self) { group in
-                group.addTask { [apiClient, serverHost] in
+                group.addTask { [apiClient, serverHost, sessionId] in
                     do {
-                        return .success(try await apiClient.fetchTurnCredentials(host: serverHost, token: token))
+                        return .success(try await apiClient.fetchTurnCredentials(host: serverHost, token: token, sessionId: sessionId))
                     } catch {
                         return .failed
                     }
@@ -37,8 +37,10 @@ final class CoreAPIClient: SessionAPIClient {
         return try parseRoomIdResponse(data)
     }
 
-    func fetchTurnCredentials(host: String, token: String) async throws -> TurnCredentials {
-        guard let url = buildHTTPSURL(host: host, path: "/api/turn-credentials", query: ["token": token]) else {
+    func fetchTurnCredentials(host: String, token: String, sessionId: String?) async throws -> TurnCredentials {
+        var query = ["token": token]
+        if let sessionId, !sessionId.isEmpty { query["sid"] = sessionId }
+        guard let url = buildHTTPSURL(host: host, path: "/api/turn-credentials", query: query) else {
             throw APIError.invalidHost
         }
         var request = URLRequest(url: url)
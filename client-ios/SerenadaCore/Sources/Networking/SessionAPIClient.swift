@@ -1,5 +1,12 @@
 import Foundation
 
 protocol SessionAPIClient {
-    func fetchTurnCredentials(host: String, token: String) async throws -> TurnCredentials
+    /// `sessionId` is the signaling session's sid; the server requires it for call tokens.
+    func fetchTurnCredentials(host: String, token: String, sessionId: String?) async throws -> TurnCredentials
+}
+
+extension SessionAPIClient {
+    func fetchTurnCredentials(host: String, token: String) async throws -> TurnCredentials {
+        try await fetchTurnCredentials(host: host, token: token, sessionId: nil)
+    }
 }
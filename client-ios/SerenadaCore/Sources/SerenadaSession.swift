@@ -373,7 +373,7 @@ public final class SerenadaSession: ObservableObject {
 
     // MARK: - Signaling Message Handling
 
-    private func handleJoined(cid: String?, payload: JoinedPayload, rawPayload: JSONValue?) {
+    private func handleJoined(cid: String?, sid: String?, payload: JoinedPayload, rawPayload: JSONValue?) {
         joinFlowCoordinator?.clearAllTimers()
         hasJoinAcknowledgedCurrentAttempt = true
 
@@ -382,7 +382,7 @@ public final class SerenadaSession: ObservableObject {
 
         if let token = payload.reconnectToken, !token.isEmpty { reconnectToken = token }
         if let ttl = payload.turnTokenTTLMs { turnManager?.handleJoinedTTL(ttlMs: Int64(ttl)) }
-        turnManager?.ensureIceSetupIfNeeded(turnToken: payload.turnToken)
+        turnManager?.ensureIceSetupIfNeeded(turnToken: payload.turnToken, sessionId: sid)
 
         if let roomState = signalingMessageRouter?.parseRoomState(payload: rawPayload, fallbackHostCid: hostCid) {
             hostCid = roomState.hostCid
@@ -635,7 +635,7 @@ public final class SerenadaSession: ObservableObject {
     private func buildSubEngines() {
         signalingMessageRouter = SignalingMessageRouter(
             getClientId: { [weak self] in self?.clientId },
-            onJoined: { [weak self] cid, payload, rawPayload in self?.handleJoined(cid: cid, payload: payload, rawPayload: rawPayload) },
+            onJoined: { [weak self] cid, sid, payload, rawPayload in self?.handleJoined(cid: cid, sid: sid, payload: payload, rawPayload: rawPayload) },
             onRoomState: { [weak self] payload in self?.handleRoomState(payload: payload) },
             onRoomEnded: { [weak self] in self?.cleanupCall(reason: .remoteEnded, transitionToEnding: true) },
             onPong: { [weak self] in self?.signalingClient.recordPong() },
@@ -6,6 +6,9 @@ final class SseSignalingTransport: SignalingTransport {
     private var sid = SseSignalingTransport.createSid()
     private var streamTask: Task<Void, Never>?
     private var currentHost: String?
+    // ID of the last dispatched event, sent as Last-Event-ID when the stream is
+    // reopened so the server replays what this session missed.
+    private var lastEventId: String?
     private var onMessageCallback: ((SignalingMessage) -> Void)?
     private var onClosedCallback: ((String) -> Void)?
     private var didClose = false
@@ -31,6 +34,9 @@ final class SseSignalingTransport: SignalingTransport {
         var request = URLRequest(url: url)
         request.httpMethod = "GET"
         request.setValue("text/event-stream", forHTTPHeaderField: "Accept")
+        if let lastEventId {
+            request.setValue(lastEventId, forHTTPHeaderField: "Last-Event-ID")
+        }
         request.timeoutInterval = 0
 
         streamTask = Task { [weak self] in
@@ -50,15 +56,27 @@ final class SseSignalingTransport: SignalingTransport {
                 onOpen()
 
                 var dataBuffer = String()
+                var eventId: String?
                 for try await rawLine in bytes.lines {
                     let line = rawLine.replacingOccurrences(of: "\r", with: "")
                     if line.isEmpty {
+                        if !dataBuffer.isEmpty, let eventId {
+                            self.lastEventId = eventId
+                        }
                         self.dispatchMessage(dataBuffer: &dataBuffer)
                         continue
                     }
                     if line.hasPrefix(":") {
                         continue
                     }
+                    if line.hasPrefix("id:") {
+                        var value = String(line.dropFirst(3))
+                        if value.hasPrefix(" ") {
+                            value.removeFirst()
+                        }
+                        eventId = value
+                        continue
+                    }
                     guard line.hasPrefix("data:") else {
                         continue
                     }
@@ -114,6 +132,7 @@ final class SseSignalingTransport: SignalingTransport {
 
     public func resetSession() {
         sid = SseSignalingTransport.createSid()
+        lastEventId = nil
         currentHost = nil
     }
 
@@ -8,7 +8,7 @@ final class FakeAPIClient: SessionAPIClient {
 
     private(set) var fetchTurnCredentialsCalls: [(host: String, token: String)] = []
 
-    func fetchTurnCredentials(host: String, token: String) async throws -> TurnCredentials {
+    func fetchTurnCredentials(host: String, token: String, sessionId: String?) async throws -> TurnCredentials {
         fetchTurnCredentialsCalls.append((host: host, token: token))
         return try turnCredentialsResult.get()
     }
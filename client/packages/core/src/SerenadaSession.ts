@@ -107,7 +107,7 @@ export class SerenadaSession implements SerenadaSessionHandle {
             this.media.updateSignalingConnected(this.signaling.isConnected);
 
             if (this.signaling.turnToken) {
-                this.media.updateTurnToken(this.signaling.turnToken);
+                this.media.updateTurnToken(this.signaling.turnToken, this.signaling.sessionId);
             }
 
             this.media.updateRoomState(this.signaling.roomState, this.signaling.clientId);
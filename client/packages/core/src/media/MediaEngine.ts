@@ -122,14 +122,15 @@ export class MediaEngine {
         }
     }
 
-    updateTurnToken(token: string): void {
+    /** Fetches ICE servers for a call TURN token. The server only accepts it with the sid of a session in the token's room. */
+    updateTurnToken(token: string, sessionId: string | null = null): void {
         if (token === this.appliedTurnToken || token === this.turnTokenInFlight) {
             return;
         }
         this.turnFetchController?.abort();
         this.turnFetchController = new AbortController();
         this.turnTokenInFlight = token;
-        void this.fetchIceServers(token, this.turnFetchController.signal).then((applied) => {
+        void this.fetchIceServers(token, sessionId, this.turnFetchController.signal).then((applied) => {
             if (applied) {
                 this.appliedTurnToken = token;
             }
@@ -724,13 +725,14 @@ export class MediaEngine {
         }, CONNECTION_RETRYING_DELAY_MS);
     }
 
-    private async fetchIceServers(token: string, signal: AbortSignal): Promise<boolean> {
+    private async fetchIceServers(token: string, sessionId: string | null, signal: AbortSignal): Promise<boolean> {
         const fetchController = new AbortController();
         const timeoutTimer = setTimeout(() => fetchController.abort(), TURN_FETCH_TIMEOUT_MS);
         const onExternalAbort = () => fetchController.abort();
         signal.addEventListener('abort', onExternalAbort);
         try {
-            const apiUrl = buildApiUrl(this.serverHost, `/api/turn-credentials?token=${encodeURIComponent(token)}`);
+            const sidParam = sessionId ? `&sid=${encodeURIComponent(sessionId)}` : '';
+            const apiUrl = buildApiUrl(this.serverHost, `/api/turn-credentials?token=${encodeURIComponent(token)}${sidParam}`);
 
             const res = await fetch(apiUrl, { signal: fetchController.signal });
 
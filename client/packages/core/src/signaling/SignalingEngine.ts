@@ -18,6 +18,17 @@ import {
     TURN_REFRESH_TRIGGER_RATIO,
 } from '../constants.js';
 
+// WebSocket close codes the server sends after a join rejection or kick that
+// reconnecting would not fix (see protocol §1.1). 4011 (server draining) is
+// left out: reconnecting reaches another instance.
+const NO_RECONNECT_CLOSE_CODES = new Set([4001, 4002, 4003, 4004, 4005, 4006, 4007, 4008, 4009, 4010, 4012]);
+
+function closeCodeOf(err: unknown): number | undefined {
+    if (typeof err !== 'object' || err === null) return undefined;
+    const code = (err as { code?: unknown }).code;
+    return typeof code === 'number' ? code : undefined;
+}
+
 export interface SignalingEngineConfig {
     wsUrl: string;
     httpBaseUrl: string;
@@ -33,6 +44,8 @@ export class SignalingEngine {
     isConnected = false;
     activeTransport: TransportKind | null = null;
     clientId: string | null = null;
+    /** Server session ID from `joined`; binds TURN credential requests to the room. */
+    sessionId: string | null = null;
     roomState: RoomState | null = null;
     turnToken: string | null = null;
     turnTokenTTLMs: number | null = null;
@@ -198,6 +211,7 @@ export class SignalingEngine {
             this.clearReconnectStorage();
         }
         this.clientId = null;
+        this.sessionId = null;
         this.roomState = null;
         this.turnToken = null;
         this.turnTokenTTLMs = null;
@@ -242,6 +256,7 @@ export class SignalingEngine {
         switch (msg.type) {
             case 'joined': {
                 if (msg.cid) this.clientId = msg.cid;
+                if (msg.sid) this.sessionId = msg.sid;
                 const joined = parseJoinedPayload(msg.payload);
                 if (!joined) break;
                 this.clearJoinTimers();
@@ -274,6 +289,12 @@ export class SignalingEngine {
                         this.turnTokenTTLMs = turnRefreshed.turnTokenTTLMs;
                         this.scheduleTurnRefresh();
                     }
+                    // Reconnect tokens expire; the server renews ours with each TURN refresh.
+                    if (turnRefreshed.reconnectToken) {
+                        this.reconnectToken = turnRefreshed.reconnectToken;
+                        this.reconnectTokenRoomId = msg.rid || this.currentRoomId;
+                        this.persistReconnectStorage();
+                    }
                     this.logger?.log('debug', 'Signaling', 'TURN credentials refreshed');
                 }
                 break;
@@ -384,6 +405,14 @@ export class SignalingEngine {
                 this.transport = null;
                 this.needsRejoin = !!this.currentRoomId;
 
+                const closeCode = reason === 'close' ? closeCodeOf(err) : undefined;
+                if (closeCode !== undefined && NO_RECONNECT_CLOSE_CODES.has(closeCode)) {
+                    this.logger?.log('warning', 'Signaling', `Server closed connection with ${closeCode}, not reconnecting`);
+                    this.needsRejoin = false;
+                    this.notifyStateChange();
+                    return;
+                }
+
                 if (this.shouldFallback(targetKind, reason) && this.tryNextTransport(reason)) {
                     this.notifyStateChange();
                     return;
@@ -400,6 +429,7 @@ export class SignalingEngine {
             wsUrl: this.wsUrl,
             httpBaseUrl: this.httpBaseUrl,
             sseSid: this.sseSid || undefined,
+            sseReconnectToken: this.reconnectToken && this.reconnectTokenRoomId === this.currentRoomId ? this.reconnectToken : undefined,
             logger: this.logger,
         });
 
@@ -17,6 +17,7 @@ export interface ErrorPayload {
 export interface TurnRefreshedPayload {
     turnToken: string;
     turnTokenTTLMs?: number;
+    reconnectToken?: string;
 }
 
 export interface OfferPayload {
@@ -90,6 +91,7 @@ export function parseTurnRefreshedPayload(raw: Record<string, unknown> | undefin
     return {
         turnToken: raw.turnToken,
         turnTokenTTLMs: typeof raw.turnTokenTTLMs === 'number' ? raw.turnTokenTTLMs : undefined,
+        reconnectToken: typeof raw.reconnectToken === 'string' && raw.reconnectToken !== '' ? raw.reconnectToken : undefined,
     };
 }
 
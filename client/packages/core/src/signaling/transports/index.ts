@@ -11,6 +11,7 @@ export interface CreateTransportOptions {
     wsUrl: string;
     httpBaseUrl: string;
     sseSid?: string;
+    sseReconnectToken?: string;
     logger?: SerenadaLogger;
 }
 
@@ -20,7 +21,7 @@ export const createSignalingTransport = (
     options: CreateTransportOptions,
 ): SignalingTransport => {
     if (kind === 'sse') {
-        return new SseTransport(handlers, options.httpBaseUrl, { sid: options.sseSid, logger: options.logger });
+        return new SseTransport(handlers, options.httpBaseUrl, { sid: options.sseSid, reconnectToken: options.sseReconnectToken, logger: options.logger });
     }
     return new WebSocketTransport(handlers, options.wsUrl, options.logger);
 };
@@ -20,12 +20,16 @@ export class SseTransport implements SignalingTransport {
     private open = false;
     private sid: string;
     private sseUrl: string;
+    private reconnectToken?: string;
+    private lastEventId = '';
+    private connectAttempt = 0;
     private connectTimeout: number | null = null;
     private logger?: SerenadaLogger;
 
-    constructor(handlers: TransportHandlers, baseUrl: string, options?: { sid?: string; logger?: SerenadaLogger }) {
+    constructor(handlers: TransportHandlers, baseUrl: string, options?: { sid?: string; reconnectToken?: string; logger?: SerenadaLogger }) {
         this.handlers = handlers;
         this.sid = options?.sid || createSid();
+        this.reconnectToken = options?.reconnectToken;
         this.sseUrl = `${baseUrl}/sse`;
         this.logger = options?.logger;
     }
@@ -53,20 +57,62 @@ export class SseTransport implements SignalingTransport {
             this.handlers.onClose('unsupported');
             return;
         }
+        const attempt = ++this.connectAttempt;
+        this.connectTimeout = window.setTimeout(() => {
+            if (attempt !== this.connectAttempt || this.open) return;
+            this.logger?.log('warning', 'Transport', `SSE connection timeout after ${CONNECT_TIMEOUT_MS}ms`);
+            this.connectAttempt++;
+            this.es?.close();
+            this.es = null;
+            this.open = false;
+            this.handlers.onClose('timeout');
+        }, CONNECT_TIMEOUT_MS);
+
+        if (!this.reconnectToken) {
+            this.openStream();
+            return;
+        }
+        // Resuming an in-room session on servers that verify sid takeovers: trade the
+        // reconnect token for a one-time ticket so the token never appears in a URL.
+        void this.requestResumeTicket(this.reconnectToken).then((ticket) => {
+            if (attempt === this.connectAttempt) {
+                this.openStream(ticket);
+            }
+        });
+    }
+
+    private async requestResumeTicket(reconnectToken: string): Promise<string | undefined> {
+        const url = new URL(`${this.sseUrl}/resume`);
+        url.searchParams.set('sid', this.sid);
+        try {
+            const res = await fetch(url.toString(), {
+                method: 'POST',
+                headers: {
+                    'Content-Type': 'application/json',
+                },
+                body: JSON.stringify({ reconnectToken })
+            });
+            if (!res.ok) return undefined;
+            const body = await res.json() as { resumeTicket?: string };
+            return body.resumeTicket;
+        } catch (err) {
+            this.logger?.log('warning', 'Transport', `Failed to get SSE resume ticket: ${formatError(err)}`);
+            return undefined;
+        }
+    }
+
+    private openStream(resumeTicket?: string) {
         const url = new URL(this.sseUrl);
         url.searchParams.set('sid', this.sid);
+        if (resumeTicket) {
+            url.searchParams.set('resumeTicket', resumeTicket);
+        }
+        // EventSource resends Last-Event-ID on its own retries; reopening the stream needs it in the URL.
+        if (this.lastEventId) {
+            url.searchParams.set('lastEventId', this.lastEventId);
+        }
         this.es = new EventSource(url.toString());
 
-        this.connectTimeout = window.setTimeout(() => {
-            if (this.es && this.es.readyState !== EventSource.OPEN) {
-                this.logger?.log('warning', 'Transport', `SSE connection timeout after ${CONNECT_TIMEOUT_MS}ms`);
-                this.es.close();
-                this.es = null;
-                this.open = false;
-                this.handlers.onClose('timeout');
-            }
-        }, CONNECT_TIMEOUT_MS);
-
         this.es.onopen = () => {
             this.clearConnectTimeout();
             this.open = true;
@@ -83,6 +129,9 @@ export class SseTransport implements SignalingTransport {
         };
 
         this.es.onmessage = (event) => {
+            if (event.lastEventId) {
+                this.lastEventId = event.lastEventId;
+            }
             try {
                 const msg: SignalingMessage = JSON.parse(event.data);
                 this.handlers.onMessage(msg);
@@ -93,6 +142,7 @@ export class SseTransport implements SignalingTransport {
     }
 
     close() {
+        this.connectAttempt++;
         this.clearConnectTimeout();
         const es = this.es;
         this.es = null;
@@ -104,6 +154,7 @@ export class SseTransport implements SignalingTransport {
     }
 
     forceClose(reason: string) {
+        this.connectAttempt++;
         this.clearConnectTimeout();
         const es = this.es;
         this.es = null;
@@ -53,8 +53,8 @@ export class FakeTransport implements SignalingTransport {
         this.handlers.onMessage(msg);
     }
 
-    simulateClose(reason = 'transport-closed'): void {
+    simulateClose(reason = 'transport-closed', err?: unknown): void {
         this._isOpen = false;
-        this.handlers.onClose(reason);
+        this.handlers.onClose(reason, err);
     }
 }
@@ -377,3 +377,37 @@ describe('pending join', () => {
         engine.destroy();
     });
 });
+
+// ---------------------------------------------------------------------------
+// 8. Server close codes
+// ---------------------------------------------------------------------------
+describe('server close codes', () => {
+    it('does not reconnect after a permanent join rejection close', () => {
+        const engine = createEngine(['ws', 'sse']);
+        engine.connect();
+        const ws = lastTransport();
+        ws.simulateOpen();
+        engine.joinRoom('room-full');
+
+        ws.simulateClose('close', { code: 4001, reason: 'ROOM_FULL' });
+
+        vi.advanceTimersByTime(10_000);
+        expect(transports).toHaveLength(1);
+        expect(engine.isConnected).toBe(false);
+
+        engine.destroy();
+    });
+
+    it('reconnects after a server draining close', () => {
+        const engine = createEngine(['ws']);
+        engine.connect();
+        lastTransport().simulateOpen();
+
+        lastTransport().simulateClose('close', { code: 4011, reason: 'SERVER_DRAINING' });
+
+        vi.advanceTimersByTime(500);
+        expect(transports).toHaveLength(2);
+
+        engine.destroy();
+    });
+});
@@ -164,6 +164,14 @@ describe('parseTurnRefreshedPayload', () => {
         });
     });
 
+    it('includes a renewed reconnectToken when present', () => {
+        expect(parseTurnRefreshedPayload({ turnToken: 'tok', reconnectToken: 'v2.1.abc' })).toEqual({
+            turnToken: 'tok',
+            turnTokenTTLMs: undefined,
+            reconnectToken: 'v2.1.abc',
+        });
+    });
+
     it('returns null for undefined input', () => {
         expect(parseTurnRefreshedPayload(undefined)).toBeNull();
     });
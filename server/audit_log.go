@@ -0,0 +1,187 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultAuditLogMaxBytes caps the live audit file when
+	// AUDIT_LOG_MAX_BYTES is unset.
+	defaultAuditLogMaxBytes = 16 << 20
+	// defaultAuditLogBackups is how many rotated files are kept when
+	// AUDIT_LOG_BACKUPS is unset.
+	defaultAuditLogBackups = 5
+)
+
+// auditRecord is one line of the audit log.
+type auditRecord struct {
+	AtMs   int64  `json:"at"` // unix ms
+	Event  string `json:"event"`
+	SID    string `json:"sid"`
+	CID    string `json:"cid"`
+	RID    string `json:"rid"`
+	IP     string `json:"ip"`               // maskIP of the client address
+	Reason string `json:"reason,omitempty"` // room_ended reason
+}
+
+// auditLog records which connection held which CID in which room as JSON
+// Lines, so abuse reports can be traced back to a client after the fact. IPs
+// are masked with a per-process secret salt: records from one process can be
+// correlated by address, but the address itself is never written and the
+// masks change on restart. The file is rotated to path.1, path.2, ... when it
+// reaches maxBytes.
+type auditLog struct {
+	path     string
+	maxBytes int64
+	backups  int
+	salt     []byte
+
+	mu    sync.Mutex
+	file  *os.File
+	bytes int64
+}
+
+// newAuditLogFromEnv returns nil unless AUDIT_LOG_FILE is set.
+func newAuditLogFromEnv() (*auditLog, error) {
+	path := strings.TrimSpace(os.Getenv("AUDIT_LOG_FILE"))
+	if path == "" {
+		return nil, nil
+	}
+	maxBytes := int64(defaultAuditLogMaxBytes)
+	if raw := strings.TrimSpace(os.Getenv("AUDIT_LOG_MAX_BYTES")); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid AUDIT_LOG_MAX_BYTES %q", raw)
+		}
+		maxBytes = parsed
+	}
+	backups := defaultAuditLogBackups
+	if raw := strings.TrimSpace(os.Getenv("AUDIT_LOG_BACKUPS")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("invalid AUDIT_LOG_BACKUPS %q", raw)
+		}
+		backups = parsed
+	}
+	return newAuditLog(path, maxBytes, backups)
+}
+
+func newAuditLog(path string, maxBytes int64, backups int) (*auditLog, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	a := &auditLog{path: path, maxBytes: maxBytes, backups: backups, salt: salt}
+	if err := a.open(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a *auditLog) open() error {
+	f, err := os.OpenFile(a.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	a.file = f
+	a.bytes = info.Size()
+	return nil
+}
+
+// maskIP returns a stable, non-reversible token for ip within this process.
+func (a *auditLog) maskIP(ip string) string {
+	mac := hmac.New(sha256.New, a.salt)
+	mac.Write([]byte(ip))
+	return hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// record appends an event for c holding cid in rid. Safe to call with hub and
+// room locks held; a.mu is never held while taking them.
+func (a *auditLog) record(event string, c *Client, cid, rid, reason string) {
+	if a == nil {
+		return
+	}
+	line, err := json.Marshal(auditRecord{
+		AtMs:   time.Now().UnixMilli(),
+		Event:  event,
+		SID:    c.sid,
+		CID:    cid,
+		RID:    rid,
+		IP:     a.maskIP(c.ip),
+		Reason: reason,
+	})
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.file == nil {
+		return
+	}
+	if a.bytes > 0 && a.bytes+int64(len(line)) > a.maxBytes {
+		if err := a.rotate(); err != nil {
+			log.Printf("[AUDIT] Rotating %s failed: %v", a.path, err)
+			if a.file == nil {
+				return
+			}
+		}
+	}
+	n, err := a.file.Write(line)
+	a.bytes += int64(n)
+	if err != nil {
+		log.Printf("[AUDIT] Writing %s failed: %v", a.path, err)
+	}
+}
+
+// rotate shifts path.N-1 to path.N down to path to path.1, dropping the
+// oldest, and reopens an empty path. With no backups the file is truncated.
+// The file is reopened even if shifting failed, so recording continues.
+func (a *auditLog) rotate() error {
+	a.file.Close()
+	a.file = nil
+	var err error
+	if a.backups == 0 {
+		err = os.Truncate(a.path, 0)
+	} else {
+		for i := a.backups - 1; i >= 1; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", a.path, i), fmt.Sprintf("%s.%d", a.path, i+1))
+		}
+		err = os.Rename(a.path, a.path+".1")
+	}
+	if openErr := a.open(); openErr != nil {
+		return openErr
+	}
+	return err
+}
+
+func (a *auditLog) Close() error {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.file == nil {
+		return nil
+	}
+	err := a.file.Close()
+	a.file = nil
+	return err
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readAuditRecords(t *testing.T, path string) []auditRecord {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open audit log: %v", err)
+	}
+	defer f.Close()
+	var records []auditRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec auditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("invalid audit line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+func TestAuditLogRecordsJoinReconnectAndLeave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	audit, err := newAuditLog(path, defaultAuditLogMaxBytes, 1)
+	if err != nil {
+		t.Fatalf("newAuditLog: %v", err)
+	}
+	defer audit.Close()
+
+	rid := mustTestRoomID(t)
+	hub := newHub(4)
+	hub.audit = audit
+	host := fakeClient(hub)
+	host.ip = "203.0.113.7"
+	hub.registerClient(host)
+	hub.handleMessage(host, joinPayload(rid, 4, 4))
+	hostCID := host.cid
+
+	// The same participant reconnects from a new connection and address.
+	again := fakeClient(hub)
+	again.ip = "198.51.100.2"
+	hub.registerClient(again)
+	reconnect, _ := json.Marshal(map[string]string{"reconnectCid": hostCID})
+	msg, _ := json.Marshal(Message{V: 1, Type: "join", RID: rid, Payload: reconnect})
+	hub.handleMessage(again, msg)
+	hub.handleMessage(again, []byte(`{"v":1,"type":"leave"}`))
+
+	records := readAuditRecords(t, path)
+	var events []string
+	for _, rec := range records {
+		events = append(events, rec.Event)
+		if rec.CID != hostCID || rec.RID != rid {
+			t.Fatalf("expected every record for %s in %s, got %+v", hostCID, rid, rec)
+		}
+		if strings.Contains(rec.IP, "203.0.113") || strings.Contains(rec.IP, "198.51.100") || rec.IP == "" {
+			t.Fatalf("expected a masked IP, got %q", rec.IP)
+		}
+	}
+	if got := strings.Join(events, ","); got != "join,reconnect,leave" {
+		t.Fatalf("expected join,reconnect,leave, got %s", got)
+	}
+	if records[0].SID != host.sid || records[1].SID != again.sid {
+		t.Fatalf("expected each record to carry its connection's SID, got %+v", records)
+	}
+	if records[0].IP == records[1].IP || records[1].IP != records[2].IP {
+		t.Fatalf("expected masks to match per address, got %q %q %q", records[0].IP, records[1].IP, records[2].IP)
+	}
+}
+
+func TestAuditLogRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	audit, err := newAuditLog(path, 300, 2)
+	if err != nil {
+		t.Fatalf("newAuditLog: %v", err)
+	}
+	defer audit.Close()
+
+	c := &Client{sid: "S-1", ip: "192.0.2.1"}
+	for i := 0; i < 10; i++ {
+		audit.record("join", c, "C-1", "R-1", "")
+	}
+
+	for _, p := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatalf("expected %s to exist: %v", p, err)
+		}
+		if info.Size() > 300 {
+			t.Fatalf("expected %s within the size limit, got %d bytes", p, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("expected only 2 backups kept, stat .3: %v", err)
+	}
+}
+
+func TestAuditLogDisabledByDefault(t *testing.T) {
+	t.Setenv("AUDIT_LOG_FILE", "")
+	audit, err := newAuditLogFromEnv()
+	if err != nil || audit != nil {
+		t.Fatalf("expected no audit log without AUDIT_LOG_FILE, got %v, %v", audit, err)
+	}
+	// A nil audit log is safe to record to.
+	audit.record("join", &Client{}, "C-1", "R-1", "")
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// requiredCapabilities lists join capabilities (keys of the join payload's
+// "capabilities" object) every client must declare, letting operators enforce
+// a minimum client version during a migration. Empty by default. Set from
+// REQUIRED_CLIENT_CAPABILITIES at startup.
+var requiredCapabilities []string
+
+func parseRequiredCapabilities(raw string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(raw, ",") {
+		name := strings.TrimSpace(part)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// missingCapabilities returns the entries of required that the join payload
+// does not declare. A capability counts as declared when it is present with a
+// value other than null, false, 0 or "".
+func missingCapabilities(payload json.RawMessage, required []string) []string {
+	if len(required) == 0 {
+		return nil
+	}
+
+	var join struct {
+		Capabilities map[string]json.RawMessage `json:"capabilities"`
+	}
+	if len(payload) > 0 {
+		_ = json.Unmarshal(payload, &join)
+	}
+
+	var missing []string
+	for _, name := range required {
+		value, ok := join.Capabilities[name]
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		switch strings.TrimSpace(string(value)) {
+		case "null", "false", "0", `""`:
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
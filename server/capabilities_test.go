@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseRequiredCapabilities(t *testing.T) {
+	got := parseRequiredCapabilities(" maxParticipants, ,relayReceipts,maxParticipants")
+	want := []string{"maxParticipants", "relayReceipts"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if got := parseRequiredCapabilities(""); len(got) != 0 {
+		t.Fatalf("expected no requirements, got %v", got)
+	}
+}
+
+func TestMissingCapabilities(t *testing.T) {
+	required := []string{"maxParticipants", "relayReceipts"}
+	cases := []struct {
+		payload string
+		missing []string
+	}{
+		{`{"capabilities":{"maxParticipants":4,"relayReceipts":true}}`, nil},
+		{`{"capabilities":{"maxParticipants":4}}`, []string{"relayReceipts"}},
+		{`{"capabilities":{"maxParticipants":0,"relayReceipts":false}}`, []string{"maxParticipants", "relayReceipts"}},
+		{`{}`, []string{"maxParticipants", "relayReceipts"}},
+		{``, []string{"maxParticipants", "relayReceipts"}},
+	}
+	for _, tc := range cases {
+		got := missingCapabilities(json.RawMessage(tc.payload), required)
+		if !reflect.DeepEqual(got, tc.missing) {
+			t.Errorf("payload %s: expected missing %v, got %v", tc.payload, tc.missing, got)
+		}
+	}
+}
+
+func TestJoinRejectsMissingRequiredCapability(t *testing.T) {
+	original := requiredCapabilities
+	requiredCapabilities = []string{"maxParticipants"}
+	defer func() { requiredCapabilities = original }()
+
+	hub := newHub(4)
+	rid := mustTestRoomID(t)
+
+	legacy := fakeClient(hub)
+	hub.registerClient(legacy)
+	hub.handleMessage(legacy, legacyJoinPayload(rid))
+	if code := errorCode(findMessage(drainMessages(legacy), "error")); code != "CAPABILITY_REQUIRED" {
+		t.Fatalf("expected CAPABILITY_REQUIRED, got %q", code)
+	}
+	hub.mu.RLock()
+	_, exists := hub.rooms[rid]
+	hub.mu.RUnlock()
+	if exists {
+		t.Fatal("rejected join must not create the room")
+	}
+
+	modern := fakeClient(hub)
+	hub.registerClient(modern)
+	hub.handleMessage(modern, joinPayload(rid, 4, 4))
+	if findMessage(drainMessages(modern), "joined") == nil {
+		t.Fatal("expected client declaring the capability to join")
+	}
+}
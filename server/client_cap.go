@@ -0,0 +1,54 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"serenada/server/internal/stats"
+)
+
+// MAX_CONCURRENT_CLIENTS bounds how many WebSocket and SSE clients the hub
+// holds at once, so memory stays bounded under load. SSE reconnects that take
+// over an existing sid reuse its slot and are never rejected.
+
+// tryRegisterClient registers c unless the hub already holds maxClients
+// clients. The check and insert share h.mu, so concurrent connections cannot
+// both take the last slot.
+func (h *Hub) tryRegisterClient(c *Client) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.maxClients > 0 && len(h.clients) >= h.maxClients {
+		return false
+	}
+	h.clients[c] = true
+	h.clientsBySID[c.sid] = c
+	return true
+}
+
+// unregisterClient drops a client that never got a working connection.
+func (h *Hub) unregisterClient(c *Client) {
+	h.mu.Lock()
+	delete(h.clients, c)
+	if h.clientsBySID[c.sid] == c {
+		delete(h.clientsBySID, c.sid)
+	}
+	h.mu.Unlock()
+}
+
+func parseMaxConcurrentClients(raw string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// rejectAtClientCap answers a new connection with 503 when the hub is full.
+func rejectAtClientCap(w http.ResponseWriter, kind, ip string) {
+	log.Printf("[%s] Rejected connection from %s: at MAX_CONCURRENT_CLIENTS", strings.ToUpper(kind), ip)
+	stats.IncClientCapRejected()
+	stats.IncConnectionFailure(kind)
+	http.Error(w, "Server at connection capacity", http.StatusServiceUnavailable)
+}
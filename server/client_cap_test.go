@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"serenada/server/internal/stats"
+)
+
+func TestParseMaxConcurrentClients(t *testing.T) {
+	cases := map[string]int{"": 0, "bogus": 0, "-3": 0, "0": 0, " 250 ": 250}
+	for raw, want := range cases {
+		if got := parseMaxConcurrentClients(raw); got != want {
+			t.Errorf("parseMaxConcurrentClients(%q) = %d, want %d", raw, got, want)
+		}
+	}
+}
+
+func TestTryRegisterClientIsAtomic(t *testing.T) {
+	hub := newHub(4)
+	hub.maxClients = 10
+
+	var admitted atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if hub.tryRegisterClient(fakeClient(hub)) {
+				admitted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if admitted.Load() != 10 || len(hub.clients) != 10 {
+		t.Fatalf("expected exactly 10 clients admitted, got %d (hub holds %d)", admitted.Load(), len(hub.clients))
+	}
+}
+
+func TestServeAtClientCapReturns503(t *testing.T) {
+	hub := newHub(4)
+	hub.maxClients = 1
+	hub.registerClient(fakeClient(hub))
+	before := stats.SnapshotNow().Counters.ClientCapRejected
+
+	rec := httptest.NewRecorder()
+	serveSSE(hub, rec, httptest.NewRequest(http.MethodGet, "/sse", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("sse: expected 503, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	serveWs(hub, rec, httptest.NewRequest(http.MethodGet, "/ws", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("ws: expected 503, got %d", rec.Code)
+	}
+
+	if len(hub.clients) != 1 {
+		t.Fatalf("expected rejected connections not to be registered, hub holds %d", len(hub.clients))
+	}
+	if after := stats.SnapshotNow().Counters.ClientCapRejected; after-before != 2 {
+		t.Fatalf("expected 2 cap rejections counted, got %d", after-before)
+	}
+}
+
+func TestServeSSETakeoverIgnoresClientCap(t *testing.T) {
+	hub := newHub(4)
+	hub.maxClients = 1
+	existing := fakeClient(hub)
+	existing.transport = TransportSSE
+	hub.registerClient(existing)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveSSE(hub, w, r)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"?sid="+existing.sid, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("sse request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the takeover to be accepted at the cap, got %d", resp.StatusCode)
+	}
+	if current := hub.getClientBySID(existing.sid); current == nil || current == existing {
+		t.Fatal("expected the new stream to replace the existing session")
+	}
+}
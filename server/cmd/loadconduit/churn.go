@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const minCallDuration = time.Second
+
+// CallDurationDist is a parsed --call-duration-dist. The zero value keeps
+// every room for the whole steady window.
+type CallDurationDist struct {
+	Kind        string
+	MeanSeconds float64
+}
+
+// parseCallDurationDist accepts "" (static rooms) or "exp:<meanSeconds>".
+func parseCallDurationDist(raw string) (CallDurationDist, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return CallDurationDist{}, nil
+	}
+	kind, param, ok := strings.Cut(raw, ":")
+	if !ok || kind != "exp" {
+		return CallDurationDist{}, fmt.Errorf("call-duration-dist must be exp:<meanSeconds>, got %q", raw)
+	}
+	mean, err := strconv.ParseFloat(param, 64)
+	if err != nil || mean <= 0 {
+		return CallDurationDist{}, fmt.Errorf("call-duration-dist mean must be > 0, got %q", param)
+	}
+	return CallDurationDist{Kind: kind, MeanSeconds: mean}, nil
+}
+
+func (d CallDurationDist) enabled() bool {
+	return d.Kind != ""
+}
+
+func (d CallDurationDist) sample(rng *rand.Rand) time.Duration {
+	duration := time.Duration(rng.ExpFloat64() * d.MeanSeconds * float64(time.Second))
+	if duration < minCallDuration {
+		duration = minCallDuration
+	}
+	return duration
+}
+
+// churnPool owns the clients created for replacement rooms so the step can
+// close them once the steady window ends.
+type churnPool struct {
+	cfg     Config
+	metrics *StepMetrics
+
+	mu      sync.Mutex
+	nextID  int
+	clients []*loadClient
+}
+
+func (p *churnPool) newGroup(roomID string) roomGroup {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	group := newRoomGroup(p.cfg, p.nextID, roomID, p.metrics)
+	p.nextID += len(group.members)
+	p.clients = append(p.clients, group.members...)
+	return group
+}
+
+func (p *churnPool) closeAll() {
+	p.mu.Lock()
+	clients := append([]*loadClient(nil), p.clients...)
+	p.mu.Unlock()
+	for _, client := range clients {
+		client.leaveAndClose()
+	}
+}
+
+// startChurnLoops replaces startRelayLoops when a call duration distribution
+// is configured. Each room slot relays as usual until its sampled call length
+// elapses, then all of its clients leave and a fresh room joins in its place, so the
+// target concurrency is held while rooms are created and torn down.
+func startChurnLoops(ctx context.Context, cfg Config, dist CallDurationDist, rooms []roomGroup, pool *churnPool, rng *rand.Rand) (context.CancelFunc, *sync.WaitGroup) {
+	churnCtx, cancel := context.WithCancel(ctx)
+	wg := &sync.WaitGroup{}
+
+	for _, room := range rooms {
+		// Per-slot RNGs drawn in order keep runs reproducible for a given seed.
+		slotRNG := rand.New(rand.NewSource(rng.Int63()))
+		wg.Add(1)
+		go func(group roomGroup) {
+			defer wg.Done()
+			runChurnSlot(churnCtx, cfg, dist, group, pool, slotRNG)
+		}(room)
+	}
+
+	return cancel, wg
+}
+
+func runChurnSlot(ctx context.Context, cfg Config, dist CallDurationDist, group roomGroup, pool *churnPool, rng *rand.Rand) {
+	var relayTick <-chan time.Time
+	if interval := relayInterval(cfg); interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		relayTick = ticker.C
+	}
+
+	var counter int64
+	for {
+		callEnd := time.NewTimer(dist.sample(rng))
+	call:
+		for {
+			select {
+			case <-ctx.Done():
+				callEnd.Stop()
+				return
+			case <-relayTick:
+				counter++
+				for _, sender := range group.relaySenders(cfg) {
+					sender.sendRelay(ctx, cfg, rng, counter)
+				}
+			case <-callEnd.C:
+				break call
+			}
+		}
+
+		for _, client := range group.members {
+			client.leaveAndClose()
+		}
+		pool.metrics.roomsChurned.Add(1)
+
+		var ok bool
+		if group, ok = replaceChurnedRoom(ctx, cfg, pool); !ok {
+			return
+		}
+	}
+}
+
+// replaceChurnedRoom joins a fresh room in place of one whose call ended. A
+// replacement that does not fully join is counted in churnJoinFailures, so
+// the slot stays visible in the report. It reports false when there is no
+// room to continue with.
+func replaceChurnedRoom(ctx context.Context, cfg Config, pool *churnPool) (roomGroup, bool) {
+	roomIDs, err := generateRoomIDs(ctx, cfg, 1)
+	if err != nil {
+		if ctx.Err() == nil {
+			// None of the room's clients got to join, so count their joins here.
+			members := int64(cfg.clientsPerRoom())
+			pool.metrics.joinAttempts.Add(members)
+			pool.metrics.joinFailures.Add(members)
+			pool.metrics.churnJoinFailures.Add(1)
+		}
+		return roomGroup{}, false
+	}
+
+	group := pool.newGroup(roomIDs[0])
+	failed := false
+	for _, client := range group.members {
+		joinCtx, joinCancel := context.WithTimeout(ctx, time.Duration(cfg.JoinTimeoutSeconds)*time.Second)
+		if err := client.connectAndJoin(joinCtx, ""); err != nil && ctx.Err() == nil {
+			failed = true
+		}
+		joinCancel()
+	}
+	if failed {
+		pool.metrics.churnJoinFailures.Add(1)
+	}
+	return group, true
+}
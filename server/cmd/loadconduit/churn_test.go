@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseCallDurationDist(t *testing.T) {
+	dist, err := parseCallDurationDist("exp:30")
+	if err != nil {
+		t.Fatalf("expected valid distribution, got %v", err)
+	}
+	if !dist.enabled() || dist.MeanSeconds != 30 {
+		t.Fatalf("unexpected distribution: %+v", dist)
+	}
+
+	if dist, err := parseCallDurationDist(""); err != nil || dist.enabled() {
+		t.Fatalf("expected empty value to disable churn, got %+v %v", dist, err)
+	}
+
+	for _, raw := range []string{"exp", "exp:0", "exp:-5", "normal:30", "exp:abc"} {
+		if _, err := parseCallDurationDist(raw); err == nil {
+			t.Fatalf("expected error for %q", raw)
+		}
+	}
+}
+
+func TestCallDurationSamplesAreSeededAndNearMean(t *testing.T) {
+	dist := CallDurationDist{Kind: "exp", MeanSeconds: 60}
+
+	first := rand.New(rand.NewSource(7))
+	second := rand.New(rand.NewSource(7))
+	var total time.Duration
+	const samples = 5000
+	for i := 0; i < samples; i++ {
+		a, b := dist.sample(first), dist.sample(second)
+		if a != b {
+			t.Fatalf("sample %d differs for the same seed: %v vs %v", i, a, b)
+		}
+		if a < minCallDuration {
+			t.Fatalf("sample %v below minimum", a)
+		}
+		total += a
+	}
+
+	mean := total.Seconds() / samples
+	if mean < 55 || mean > 65 {
+		t.Fatalf("expected mean near 60s, got %.1fs", mean)
+	}
+}
+
+func TestParseConfigRejectsInvalidCallDurationDist(t *testing.T) {
+	_, err := parseConfig([]string{
+		"--base-url", "http://localhost",
+		"--call-duration-dist", "uniform:10",
+	})
+	if err == nil {
+		t.Fatalf("expected error for unsupported call-duration-dist")
+	}
+}
+
+func TestReplaceChurnedRoomCountsFailedRejoins(t *testing.T) {
+	cfg := Config{RoomsMode: "paired", RoomSize: 2, WSURL: "ws://127.0.0.1:1/ws", JoinTimeoutSeconds: 1, RoomIDSecret: "test-secret"}
+	metrics := &StepMetrics{}
+	pool := &churnPool{cfg: cfg, metrics: metrics}
+
+	group, ok := replaceChurnedRoom(context.Background(), cfg, pool)
+	if !ok || len(group.members) != 2 {
+		t.Fatalf("expected a replacement room to continue with, got ok=%v", ok)
+	}
+	result := metrics.ToStepResult(2, 1, time.Now(), time.Now())
+	if result.ChurnJoinFailures != 1 || result.ConnectFailures != 2 {
+		t.Fatalf("expected the unreachable replacement counted, got %+v", result)
+	}
+}
+
+func TestReplaceChurnedRoomCountsRoomIDFailureAsJoinFailures(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "room ID secret not configured", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	cfg := Config{RoomsMode: "paired", RoomSize: 2, BaseURL: srv.URL, JoinTimeoutSeconds: 1}
+	metrics := &StepMetrics{}
+	pool := &churnPool{cfg: cfg, metrics: metrics}
+
+	if _, ok := replaceChurnedRoom(context.Background(), cfg, pool); ok {
+		t.Fatal("expected no replacement without a room ID")
+	}
+	result := metrics.ToStepResult(2, 1, time.Now(), time.Now())
+	if result.ChurnJoinFailures != 1 || result.JoinAttempts != 2 || result.JoinFailures != 2 {
+		t.Fatalf("expected the room's joins counted as failed, got %+v", result)
+	}
+}
@@ -7,8 +7,6 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
-
-	"github.com/gorilla/websocket"
 )
 
 type signalingEnvelope struct {
@@ -24,6 +22,8 @@ type signalingEnvelope struct {
 type joinResult struct {
 	LatencyMs int64
 	CID       string
+	TurnToken string
+	SID       string
 	Err       error
 }
 
@@ -34,16 +34,27 @@ type loadClient struct {
 	metrics *StepMetrics
 
 	joinTimeout time.Duration
+	roomSize    int    // mesh room capacity requested on join; 0 for paired rooms
+	sseURL      string // with --transport=sse, signal over SSE instead of wsURL
 
 	writeMu sync.Mutex
 	connMu  sync.Mutex
-	conn    *websocket.Conn
+	conn    signalConn
 
 	expectedCloseSeq atomic.Int64
 	joined           atomic.Bool
 	cidValue         atomic.Value
+	turnTokenValue   atomic.Value // from the last joined payload; see checkTurnCredentials
+	sidValue         atomic.Value // session id from the last joined message
 
 	generation atomic.Int64
+
+	malformedSeq     atomic.Int64
+	pendingMalformed [malformedCategoryCount]atomic.Int64 // malformed frames still awaiting their expected reply
+	rejoinNeeded     atomic.Bool                          // server closed the connection for an oversized frame
+
+	hostWatch  atomic.Pointer[hostTransferWatch] // armed while waiting to be promoted to host
+	sendPaused atomic.Bool                       // relay loop skips this client (host transfer in progress)
 }
 
 func newLoadClient(id int, roomID, wsURL string, joinTimeout time.Duration, metrics *StepMetrics) *loadClient {
@@ -55,6 +66,8 @@ func newLoadClient(id int, roomID, wsURL string, joinTimeout time.Duration, metr
 		metrics:     metrics,
 	}
 	c.cidValue.Store("")
+	c.turnTokenValue.Store("")
+	c.sidValue.Store("")
 	return c
 }
 
@@ -63,10 +76,26 @@ func (c *loadClient) cid() string {
 	return cid
 }
 
+func (c *loadClient) turnToken() string {
+	token, _ := c.turnTokenValue.Load().(string)
+	return token
+}
+
+func (c *loadClient) sid() string {
+	sid, _ := c.sidValue.Load().(string)
+	return sid
+}
+
+func (c *loadClient) dial(ctx context.Context) (signalConn, error) {
+	if c.sseURL != "" {
+		return dialSSESignal(ctx, c.sseURL)
+	}
+	return dialWSSignal(ctx, c.wsURL)
+}
+
 func (c *loadClient) connectAndJoin(ctx context.Context, reconnectCID string) error {
 	c.metrics.connectAttempts.Add(1)
-	dialer := websocket.Dialer{HandshakeTimeout: 10 * time.Second}
-	conn, _, err := dialer.DialContext(ctx, c.wsURL, nil)
+	conn, err := c.dial(ctx)
 	if err != nil {
 		c.metrics.connectFailures.Add(1)
 		return err
@@ -95,6 +124,10 @@ func (c *loadClient) connectAndJoin(ctx context.Context, reconnectCID string) er
 		"device":       "loadtest",
 		"capabilities": map[string]any{"trickleIce": true},
 	}
+	if c.roomSize > 2 {
+		payload["capabilities"] = map[string]any{"trickleIce": true, "maxParticipants": c.roomSize}
+		payload["createMaxParticipants"] = c.roomSize
+	}
 	if reconnectCID != "" {
 		payload["reconnectCid"] = reconnectCID
 	}
@@ -129,19 +162,23 @@ func (c *loadClient) connectAndJoin(ctx context.Context, reconnectCID string) er
 		c.metrics.joinSuccess.Add(1)
 		c.metrics.AddJoinLatency(result.LatencyMs)
 		c.cidValue.Store(result.CID)
+		c.turnTokenValue.Store(result.TurnToken)
+		c.sidValue.Store(result.SID)
 		c.joined.Store(true)
 		return nil
 	}
 }
 
-func (c *loadClient) readLoop(seq int64, conn *websocket.Conn, joinedCh chan<- joinResult, readDone chan<- struct{}, joinSentAt time.Time) {
+func (c *loadClient) readLoop(seq int64, conn signalConn, joinedCh chan<- joinResult, readDone chan<- struct{}, joinSentAt time.Time) {
 	defer close(readDone)
 	joinReported := false
 
 	for {
-		_, payload, err := conn.ReadMessage()
+		payload, err := conn.read()
 		if err != nil {
-			if !c.isExpectedClose(seq) {
+			if c.consumeOversizeClose(err) {
+				c.joined.Store(false)
+			} else if !c.isExpectedClose(seq) {
 				c.metrics.unexpectedDisconnect.Add(1)
 			}
 			if !joinReported {
@@ -155,6 +192,9 @@ func (c *loadClient) readLoop(seq int64, conn *websocket.Conn, joinedCh chan<- j
 		if err := json.Unmarshal(payload, &msg); err != nil {
 			continue
 		}
+		if c.consumeMalformedReply(msg) {
+			continue
+		}
 
 		switch msg.Type {
 		case "joined":
@@ -162,7 +202,11 @@ func (c *loadClient) readLoop(seq int64, conn *websocket.Conn, joinedCh chan<- j
 				continue
 			}
 			latencyMs := time.Since(joinSentAt).Milliseconds()
-			joinedCh <- joinResult{CID: msg.CID, LatencyMs: latencyMs}
+			var joined struct {
+				TurnToken string `json:"turnToken"`
+			}
+			_ = json.Unmarshal(msg.Payload, &joined)
+			joinedCh <- joinResult{CID: msg.CID, LatencyMs: latencyMs, TurnToken: joined.TurnToken, SID: msg.SID}
 			joinReported = true
 		case "error":
 			c.metrics.serverErrorMessages.Add(1)
@@ -172,6 +216,8 @@ func (c *loadClient) readLoop(seq int64, conn *websocket.Conn, joinedCh chan<- j
 			}
 		case "offer", "answer", "ice":
 			c.metrics.relayReceived.Add(1)
+		case "room_state":
+			c.observeRoomState(msg)
 		}
 	}
 }
@@ -191,6 +237,14 @@ func (c *loadClient) pingLoop(seq int64, done <-chan struct{}) {
 }
 
 func (c *loadClient) writeSignal(msg signalingEnvelope) error {
+	frame, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return c.writeRaw(frame)
+}
+
+func (c *loadClient) writeRaw(frame []byte) error {
 	c.connMu.Lock()
 	conn := c.conn
 	c.connMu.Unlock()
@@ -200,11 +254,7 @@ func (c *loadClient) writeSignal(msg signalingEnvelope) error {
 
 	c.writeMu.Lock()
 	defer c.writeMu.Unlock()
-
-	if err := conn.SetWriteDeadline(time.Now().Add(5 * time.Second)); err != nil {
-		return err
-	}
-	return conn.WriteJSON(msg)
+	return conn.write(frame)
 }
 
 func (c *loadClient) sendRelayICE(counter int64) error {
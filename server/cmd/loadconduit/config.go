@@ -13,10 +13,15 @@ import (
 type Config struct {
 	BaseURL  string
 	WSURL    string
+	SSEURL   string
 	StatsURL string
 
+	Transport string
+
 	StatsToken string
 
+	ProfileSteps bool
+
 	StartClients int
 	StepClients  int
 	MaxClients   int
@@ -27,13 +32,31 @@ type Config struct {
 	PreRampStabilizeSeconds int
 
 	RoomsMode string
+	RoomSize  int
+
+	Search          string
+	SearchTolerance int
 
 	OfferRatePerRoom float64
+	CallDurationDist string
+	MalformedRate    float64
+	InjectRelayLoss  float64
 
 	ReconnectStormPercent  float64
 	ReconnectStormAtSecond int
+	ReconnectConcurrency   int
+
+	HostTransferPercent  float64
+	HostTransferAtSecond int
+
+	TurnCheckPercent float64
+
+	ReplayFile  string
+	ReplaySpeed float64
 
 	ReportJSON string
+	ReportMD   string
+	ReportCSV  string
 
 	JoinTimeoutSeconds int
 
@@ -41,6 +64,7 @@ type Config struct {
 	MaxJoinErrorRate  float64
 	MaxJoinP95Ms      int64
 	MaxSendQueueDrops int64
+	MaxTurnErrorRate  float64
 
 	RoomIDSecret string
 	RoomIDEnv    string
@@ -56,8 +80,10 @@ func parseConfig(args []string) (Config, error) {
 
 	fs.StringVar(&cfg.BaseURL, "base-url", "http://localhost", "Base HTTP URL of the server")
 	fs.StringVar(&cfg.WSURL, "ws-url", "", "WebSocket URL override (defaults to <base-url>/ws)")
+	fs.StringVar(&cfg.Transport, "transport", transportWS, "Signaling transport for load clients: ws, or sse (GET <base-url>/sse stream plus a POST per message)")
 	fs.StringVar(&cfg.StatsURL, "stats-url", "/api/internal/stats", "Internal stats endpoint path or absolute URL")
 	fs.StringVar(&cfg.StatsToken, "stats-token", "", "Optional token for X-Internal-Token header")
+	fs.BoolVar(&cfg.ProfileSteps, "profile-steps", false, "Capture a server CPU profile for each step's steady window via /api/internal/profile (requires stats-token and ENABLE_INTERNAL_PROFILE on the server)")
 
 	fs.IntVar(&cfg.StartClients, "start-clients", 20, "Initial concurrent clients")
 	fs.IntVar(&cfg.StepClients, "step-clients", 20, "Clients added per step")
@@ -68,18 +94,36 @@ func parseConfig(args []string) (Config, error) {
 	fs.IntVar(&cfg.CooldownSeconds, "cooldown-seconds", 15, "Cooldown duration between steps in seconds")
 	fs.IntVar(&cfg.PreRampStabilizeSeconds, "pre-ramp-stabilize-seconds", 10, "Wait time before each step ramp to allow server to stabilize")
 
-	fs.StringVar(&cfg.RoomsMode, "rooms-mode", "paired", "Room population mode (paired)")
+	fs.StringVar(&cfg.Search, "search", searchLinear, "Capacity search: linear (step by step-clients until a step fails) or bisect (then binary-search between the last passing and first failing step)")
+	fs.IntVar(&cfg.SearchTolerance, "search-tolerance", 0, "With search=bisect, stop once the passing and failing client counts are within this many clients (0 = one room)")
+	fs.StringVar(&cfg.RoomsMode, "rooms-mode", "paired", "Room population mode: paired (host relays to one peer) or mesh (room-size clients that all relay to each other)")
+	fs.IntVar(&cfg.RoomSize, "room-size", 2, "Clients per room with rooms-mode=mesh; start-clients and step-clients must be multiples of it, and the server's MAX_PARTICIPANTS must allow it")
 	fs.Float64Var(&cfg.OfferRatePerRoom, "offer-rate-per-room", 0.2, "Relay message rate per room per second")
+	fs.StringVar(&cfg.CallDurationDist, "call-duration-dist", "", "Per-room call duration distribution during steady window (exp:<meanSeconds>); rooms that end are replaced to hold concurrency")
+	fs.Float64Var(&cfg.MalformedRate, "malformed-rate", 0, "Fraction (0-1) of relay sends replaced by malformed frames (truncated, wrong version, unknown type, oversized) to exercise server error paths")
+	fs.Float64Var(&cfg.InjectRelayLoss, "inject-relay-loss", 0, "Fraction (0-1) of relay sends skipped on the sender side to simulate a lossy channel; skipped sends are not counted as sent")
 	fs.Float64Var(&cfg.ReconnectStormPercent, "reconnect-storm-percent", 0, "Percent of clients to reconnect during steady window")
 	fs.IntVar(&cfg.ReconnectStormAtSecond, "reconnect-storm-at-second", 0, "Second offset into steady window to trigger reconnect storm")
+	fs.IntVar(&cfg.ReconnectConcurrency, "reconnect-concurrency", 0, "Maximum reconnects in flight during the storm (0 = all selected clients at once)")
+
+	fs.Float64Var(&cfg.HostTransferPercent, "host-transfer-percent", 0, "Percent of rooms whose host leaves during steady window; the peer must be promoted to host")
+	fs.IntVar(&cfg.HostTransferAtSecond, "host-transfer-at-second", 0, "Second offset into steady window to force host transfers")
+
+	fs.Float64Var(&cfg.TurnCheckPercent, "turn-check-percent", 0, "Percent of clients that exchange their joined turnToken at /api/turn-credentials after the initial join")
+
+	fs.StringVar(&cfg.ReplayFile, "replay", "", "Replay a server session capture (/api/internal/capture) in a fresh room instead of running a sweep")
+	fs.Float64Var(&cfg.ReplaySpeed, "replay-speed", 1, "Replay time scale (2 sends captured messages twice as fast)")
 
 	fs.StringVar(&cfg.ReportJSON, "report-json", "", "Optional path to write JSON report")
+	fs.StringVar(&cfg.ReportMD, "report-md", "", "Optional path to write a Markdown summary report (steps, breaking point, SLO headroom, config)")
+	fs.StringVar(&cfg.ReportCSV, "report-csv", "", "Optional path to write a CSV report with one row per step (target clients, error rate, client/server join p95, send queue drops, pass/fail)")
 	fs.IntVar(&cfg.JoinTimeoutSeconds, "join-timeout-seconds", 20, "Per-client join timeout in seconds")
 
 	fs.Float64Var(&cfg.MaxErrorRate, "max-error-rate", 0.01, "Step pass threshold: max error rate")
 	fs.Float64Var(&cfg.MaxJoinErrorRate, "max-join-error-rate", 0, "Step pass threshold: max join miss rate ((target-joinSuccess)/target)")
 	fs.Int64Var(&cfg.MaxJoinP95Ms, "max-join-p95-ms", 2000, "Step pass threshold: max join p95 in ms")
 	fs.Int64Var(&cfg.MaxSendQueueDrops, "max-send-queue-drops", 0, "Step pass threshold: max send queue drops in step")
+	fs.Float64Var(&cfg.MaxTurnErrorRate, "max-turn-error-rate", 0.01, "Step pass threshold: max failed share of TURN credential checks (with turn-check-percent)")
 
 	defaultRoomIDSecret := strings.TrimSpace(os.Getenv("ROOM_ID_SECRET"))
 	defaultRoomIDEnv := strings.TrimSpace(os.Getenv("ROOM_ID_ENV"))
@@ -88,7 +132,7 @@ func parseConfig(args []string) (Config, error) {
 	}
 	fs.StringVar(&cfg.RoomIDSecret, "room-id-secret", defaultRoomIDSecret, "Optional room ID secret to generate room IDs locally")
 	fs.StringVar(&cfg.RoomIDEnv, "room-id-env", defaultRoomIDEnv, "Room ID env context (used only with --room-id-secret)")
-	fs.Int64Var(&cfg.RandomSeed, "random-seed", 1, "Deterministic seed for reconnect-storm sampling")
+	fs.Int64Var(&cfg.RandomSeed, "random-seed", 1, "Deterministic seed for reconnect-storm and host-transfer sampling, call durations and relay fault injection")
 
 	if err := fs.Parse(args); err != nil {
 		return Config{}, err
@@ -105,6 +149,9 @@ func parseConfig(args []string) (Config, error) {
 	cfg.RoomIDSecret = strings.TrimSpace(cfg.RoomIDSecret)
 	cfg.RoomIDEnv = strings.TrimSpace(cfg.RoomIDEnv)
 	cfg.ReportJSON = strings.TrimSpace(cfg.ReportJSON)
+	cfg.ReportMD = strings.TrimSpace(cfg.ReportMD)
+	cfg.ReportCSV = strings.TrimSpace(cfg.ReportCSV)
+	cfg.ReplayFile = strings.TrimSpace(cfg.ReplayFile)
 
 	if cfg.WSURL == "" {
 		base, _ := url.Parse(cfg.BaseURL)
@@ -114,10 +161,17 @@ func parseConfig(args []string) (Config, error) {
 		}
 		cfg.WSURL = fmt.Sprintf("%s://%s/ws", scheme, base.Host)
 	}
+	cfg.SSEURL = sseURLFromBase(cfg.BaseURL)
 
 	if cfg.ReportJSON != "" {
 		cfg.ReportJSON = filepath.Clean(cfg.ReportJSON)
 	}
+	if cfg.ReportMD != "" {
+		cfg.ReportMD = filepath.Clean(cfg.ReportMD)
+	}
+	if cfg.ReportCSV != "" {
+		cfg.ReportCSV = filepath.Clean(cfg.ReportCSV)
+	}
 
 	return cfg, nil
 }
@@ -140,6 +194,13 @@ func (c Config) validate() error {
 		}
 	}
 
+	if c.Transport != transportWS && c.Transport != transportSSE {
+		return errors.New("transport must be ws or sse")
+	}
+	if c.Transport == transportSSE && c.MalformedRate > 0 {
+		return errors.New("malformed-rate requires transport=ws")
+	}
+
 	if strings.TrimSpace(c.StatsURL) == "" {
 		return errors.New("stats-url is required")
 	}
@@ -149,6 +210,10 @@ func (c Config) validate() error {
 		}
 	}
 
+	if c.ProfileSteps && strings.TrimSpace(c.StatsToken) == "" {
+		return errors.New("profile-steps requires stats-token")
+	}
+
 	if c.StartClients <= 0 || c.StepClients <= 0 || c.MaxClients <= 0 {
 		return errors.New("start-clients, step-clients and max-clients must be > 0")
 	}
@@ -164,20 +229,72 @@ func (c Config) validate() error {
 		return errors.New("join-timeout-seconds must be > 0")
 	}
 
-	if c.RoomsMode != "paired" {
-		return errors.New("rooms-mode must be paired")
+	if c.Search != searchLinear && c.Search != searchBisect {
+		return errors.New("search must be linear or bisect")
+	}
+	if c.SearchTolerance < 0 {
+		return errors.New("search-tolerance must be >= 0")
+	}
+
+	switch c.RoomsMode {
+	case "paired":
+		if c.RoomSize != 2 {
+			return errors.New("room-size requires rooms-mode=mesh")
+		}
+	case "mesh":
+		if c.RoomSize < 2 {
+			return errors.New("room-size must be >= 2")
+		}
+		if c.StartClients%c.RoomSize != 0 || c.StepClients%c.RoomSize != 0 {
+			return errors.New("start-clients and step-clients must be multiples of room-size")
+		}
+	default:
+		return errors.New("rooms-mode must be paired or mesh")
 	}
 
 	if c.OfferRatePerRoom < 0 {
 		return errors.New("offer-rate-per-room must be >= 0")
 	}
 
+	if _, err := parseCallDurationDist(c.CallDurationDist); err != nil {
+		return err
+	}
+
+	if c.MalformedRate < 0 || c.MalformedRate > 1 {
+		return errors.New("malformed-rate must be between 0 and 1")
+	}
+
+	if c.InjectRelayLoss < 0 || c.InjectRelayLoss > 1 {
+		return errors.New("inject-relay-loss must be between 0 and 1")
+	}
+
 	if c.ReconnectStormPercent < 0 || c.ReconnectStormPercent > 100 {
 		return errors.New("reconnect-storm-percent must be between 0 and 100")
 	}
 	if c.ReconnectStormAtSecond < 0 {
 		return errors.New("reconnect-storm-at-second must be >= 0")
 	}
+	if c.ReconnectConcurrency < 0 {
+		return errors.New("reconnect-concurrency must be >= 0")
+	}
+
+	if c.HostTransferPercent < 0 || c.HostTransferPercent > 100 {
+		return errors.New("host-transfer-percent must be between 0 and 100")
+	}
+	if c.HostTransferAtSecond < 0 {
+		return errors.New("host-transfer-at-second must be >= 0")
+	}
+	if c.HostTransferPercent > 0 && strings.TrimSpace(c.CallDurationDist) != "" {
+		return errors.New("host-transfer-percent cannot be combined with call-duration-dist")
+	}
+
+	if c.TurnCheckPercent < 0 || c.TurnCheckPercent > 100 {
+		return errors.New("turn-check-percent must be between 0 and 100")
+	}
+
+	if c.ReplaySpeed <= 0 {
+		return errors.New("replay-speed must be > 0")
+	}
 
 	if c.MaxErrorRate < 0 || c.MaxErrorRate > 1 {
 		return errors.New("max-error-rate must be between 0 and 1")
@@ -191,6 +308,17 @@ func (c Config) validate() error {
 	if c.MaxSendQueueDrops < 0 {
 		return errors.New("max-send-queue-drops must be >= 0")
 	}
+	if c.MaxTurnErrorRate < 0 || c.MaxTurnErrorRate > 1 {
+		return errors.New("max-turn-error-rate must be between 0 and 1")
+	}
 
 	return nil
 }
+
+// clientsPerRoom is how many clients runStep puts in each room.
+func (c Config) clientsPerRoom() int {
+	if c.RoomsMode == "mesh" {
+		return c.RoomSize
+	}
+	return 2
+}
@@ -38,13 +38,42 @@ func TestParseConfigRejectsInvalidStepMath(t *testing.T) {
 func TestParseConfigRejectsInvalidRoomsMode(t *testing.T) {
 	_, err := parseConfig([]string{
 		"--base-url", "http://localhost",
-		"--rooms-mode", "mesh",
+		"--rooms-mode", "star",
 	})
 	if err == nil {
 		t.Fatalf("expected error for unsupported rooms mode")
 	}
 }
 
+func TestParseConfigMeshRoomSize(t *testing.T) {
+	cfg, err := parseConfig([]string{
+		"--base-url", "http://localhost",
+		"--rooms-mode", "mesh",
+		"--room-size", "4",
+		"--start-clients", "8",
+		"--step-clients", "12",
+		"--max-clients", "50",
+	})
+	if err != nil {
+		t.Fatalf("expected valid mesh config, got error: %v", err)
+	}
+	if cfg.clientsPerRoom() != 4 {
+		t.Fatalf("expected 4 clients per mesh room, got %d", cfg.clientsPerRoom())
+	}
+
+	invalid := [][]string{
+		{"--rooms-mode", "mesh", "--room-size", "1"},
+		{"--rooms-mode", "mesh", "--room-size", "3", "--start-clients", "20", "--step-clients", "21"},
+		{"--rooms-mode", "mesh", "--room-size", "3", "--start-clients", "21", "--step-clients", "20"},
+		{"--room-size", "4"},
+	}
+	for _, args := range invalid {
+		if _, err := parseConfig(append([]string{"--base-url", "http://localhost"}, args...)); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
+
 func TestParseConfigRejectsInvalidJoinErrorRate(t *testing.T) {
 	_, err := parseConfig([]string{
 		"--base-url", "http://localhost",
@@ -64,3 +93,59 @@ func TestParseConfigRejectsNegativePreRampStabilizeSeconds(t *testing.T) {
 		t.Fatalf("expected error for negative pre-ramp-stabilize-seconds")
 	}
 }
+
+func TestParseConfigRejectsInvalidMalformedRate(t *testing.T) {
+	_, err := parseConfig([]string{
+		"--base-url", "http://localhost",
+		"--malformed-rate", "1.5",
+	})
+	if err == nil {
+		t.Fatalf("expected error for invalid malformed-rate")
+	}
+}
+
+func TestParseConfigRejectsInvalidInjectRelayLoss(t *testing.T) {
+	_, err := parseConfig([]string{
+		"--base-url", "http://localhost",
+		"--inject-relay-loss", "-0.1",
+	})
+	if err == nil {
+		t.Fatalf("expected error for invalid inject-relay-loss")
+	}
+}
+
+func TestParseConfigRejectsHostTransferWithChurn(t *testing.T) {
+	_, err := parseConfig([]string{
+		"--base-url", "http://localhost",
+		"--host-transfer-percent", "10",
+		"--call-duration-dist", "exp:30",
+	})
+	if err == nil {
+		t.Fatalf("expected error combining host-transfer-percent with call-duration-dist")
+	}
+}
+
+func TestParseConfigProfileStepsRequiresToken(t *testing.T) {
+	if _, err := parseConfig([]string{"--base-url", "http://localhost", "--profile-steps"}); err == nil {
+		t.Fatalf("expected error for profile-steps without stats-token")
+	}
+	cfg, err := parseConfig([]string{"--base-url", "http://localhost", "--profile-steps", "--stats-token", "t"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.ProfileSteps {
+		t.Fatalf("expected profile-steps to be set")
+	}
+}
+
+func TestParseConfigRejectsNegativeReconnectConcurrency(t *testing.T) {
+	if _, err := parseConfig([]string{"--base-url", "http://localhost", "--reconnect-concurrency", "-1"}); err == nil {
+		t.Fatalf("expected error for negative reconnect-concurrency")
+	}
+}
+
+func TestParseConfigRejectsNonPositiveReplaySpeed(t *testing.T) {
+	if _, err := parseConfig([]string{"--base-url", "http://localhost", "--replay", "capture.jsonl", "--replay-speed", "0"}); err == nil {
+		t.Fatal("expected error for replay-speed 0")
+	}
+}
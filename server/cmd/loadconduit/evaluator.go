@@ -28,9 +28,20 @@ func evaluateStep(cfg Config, step StepResult) StepResult {
 	if step.ServerStatsAvailable && step.SendQueueDropDelta > cfg.MaxSendQueueDrops {
 		failure = fmt.Sprintf("send queue drops %d exceed %d", step.SendQueueDropDelta, cfg.MaxSendQueueDrops)
 	}
+	if step.TurnCheckAttempts > 0 && step.TurnCheckErrorRate > cfg.MaxTurnErrorRate {
+		failure = fmt.Sprintf("turn credential error rate %.4f exceeds %.4f", step.TurnCheckErrorRate, cfg.MaxTurnErrorRate)
+	}
 
 	if failure == "" {
 		step.Passed = true
+		step.SLOHeadroom = sloHeadroom(cfg, step, joinP95)
+		for name, headroom := range step.SLOHeadroom {
+			if step.TightestSLO == "" || headroom < step.TightestSLOHeadroom ||
+				(headroom == step.TightestSLOHeadroom && name < step.TightestSLO) {
+				step.TightestSLO = name
+				step.TightestSLOHeadroom = headroom
+			}
+		}
 		return step
 	}
 
@@ -38,3 +49,32 @@ func evaluateStep(cfg Config, step StepResult) StepResult {
 	step.FailReason = failure
 	return step
 }
+
+// sloHeadroom returns, per SLO, how far the step's value sits below its
+// threshold as a fraction of the threshold (1 = unused, 0 = at the limit).
+// Zero-tolerance thresholds have no gradient to report and are left out, as
+// is the queue-drop SLO when server stats are unavailable and the TURN SLO
+// when no credential checks ran.
+func sloHeadroom(cfg Config, step StepResult, joinP95 float64) map[string]float64 {
+	headroom := make(map[string]float64)
+	put := func(name string, value, threshold float64) {
+		if threshold <= 0 {
+			return
+		}
+		headroom[name] = (threshold - value) / threshold
+	}
+
+	put("error_rate", step.ErrorRate, cfg.MaxErrorRate)
+	put("join_error_rate", step.JoinErrorRate, cfg.MaxJoinErrorRate)
+	put("join_p95", joinP95, float64(cfg.MaxJoinP95Ms))
+	if step.ServerStatsAvailable {
+		put("queue_drops", float64(step.SendQueueDropDelta), float64(cfg.MaxSendQueueDrops))
+	}
+	if step.TurnCheckAttempts > 0 {
+		put("turn_error_rate", step.TurnCheckErrorRate, cfg.MaxTurnErrorRate)
+	}
+	if len(headroom) == 0 {
+		return nil
+	}
+	return headroom
+}
@@ -79,3 +79,36 @@ func TestEvaluateStepAllowsConfiguredJoinErrorRate(t *testing.T) {
 		t.Fatalf("expected step to pass, got failure: %s", got.FailReason)
 	}
 }
+
+func TestEvaluateStepReportsTightestSLO(t *testing.T) {
+	cfg := Config{MaxErrorRate: 0.01, MaxJoinErrorRate: 0.1, MaxJoinP95Ms: 2000, MaxSendQueueDrops: 0}
+	step := StepResult{
+		TargetClients:        20,
+		JoinSuccess:          20,
+		ErrorRate:            0.002,
+		ServerStatsAvailable: true,
+		ServerJoinP95Ms:      1500,
+	}
+
+	got := evaluateStep(cfg, step)
+	if !got.Passed {
+		t.Fatalf("expected step to pass, got failure: %s", got.FailReason)
+	}
+	if _, ok := got.SLOHeadroom["queue_drops"]; ok {
+		t.Fatalf("expected zero-tolerance queue drop SLO to be left out, got %v", got.SLOHeadroom)
+	}
+	if got.SLOHeadroom["error_rate"] < 0.79 || got.SLOHeadroom["error_rate"] > 0.81 {
+		t.Fatalf("expected error rate headroom 0.8, got %v", got.SLOHeadroom)
+	}
+	if got.TightestSLO != "join_p95" || got.TightestSLOHeadroom != 0.25 {
+		t.Fatalf("expected join_p95 with 0.25 headroom to be tightest, got %s %.2f", got.TightestSLO, got.TightestSLOHeadroom)
+	}
+}
+
+func TestEvaluateStepOmitsHeadroomForFailingSteps(t *testing.T) {
+	cfg := Config{MaxErrorRate: 0.01, MaxJoinErrorRate: 0.1, MaxJoinP95Ms: 2000}
+	got := evaluateStep(cfg, StepResult{TargetClients: 20, JoinSuccess: 20, ClientJoinP95Ms: 2500})
+	if got.Passed || got.SLOHeadroom != nil || got.TightestSLO != "" {
+		t.Fatalf("expected failing step without headroom, got %+v", got)
+	}
+}
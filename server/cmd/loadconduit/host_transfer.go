@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// hostTransferWatch is armed on a peer before its host leaves and fires when
+// a room_state names the peer as the new host.
+type hostTransferWatch struct {
+	startedAt time.Time
+	promoted  chan time.Duration
+}
+
+// observeRoomState completes a pending host-transfer watch if msg promotes
+// this client.
+func (c *loadClient) observeRoomState(msg signalingEnvelope) {
+	watch := c.hostWatch.Load()
+	if watch == nil {
+		return
+	}
+	var state struct {
+		HostCID string `json:"hostCid"`
+	}
+	if err := json.Unmarshal(msg.Payload, &state); err != nil || state.HostCID == "" || state.HostCID != c.cid() {
+		return
+	}
+	if c.hostWatch.CompareAndSwap(watch, nil) {
+		watch.promoted <- time.Since(watch.startedAt)
+	}
+}
+
+// forceHostTransfer makes group's host leave and waits up to timeout for one
+// of the other members to be promoted. The old host then rejoins as a regular participant so
+// the room keeps relaying; its relay sends are paused meanwhile so the gap is
+// not counted as send failures.
+func forceHostTransfer(ctx context.Context, group roomGroup, timeout time.Duration, metrics *StepMetrics) {
+	metrics.hostTransferAttempts.Add(1)
+
+	host, peers := group.host(), group.members[1:]
+	watch := &hostTransferWatch{startedAt: time.Now(), promoted: make(chan time.Duration, 1)}
+	for _, peer := range peers {
+		peer.hostWatch.Store(watch)
+	}
+	defer func() {
+		for _, peer := range peers {
+			peer.hostWatch.CompareAndSwap(watch, nil)
+		}
+	}()
+	host.sendPaused.Store(true)
+	defer host.sendPaused.Store(false)
+
+	host.leaveAndClose()
+
+	timer := time.NewTimer(timeout)
+	select {
+	case latency := <-watch.promoted:
+		timer.Stop()
+		metrics.hostTransferSuccess.Add(1)
+		metrics.AddHostTransferLatency(latency.Milliseconds())
+	case <-timer.C:
+		metrics.hostTransferFailures.Add(1)
+	case <-ctx.Done():
+		timer.Stop()
+		return
+	}
+
+	rejoinCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	_ = host.connectAndJoin(rejoinCtx, "")
+}
+
+// startHostTransfers forces host transfers in the selected rooms concurrently
+// and returns a WaitGroup that completes once every transfer and rejoin has
+// finished.
+func startHostTransfers(ctx context.Context, groups []roomGroup, timeout time.Duration, metrics *StepMetrics) *sync.WaitGroup {
+	wg := &sync.WaitGroup{}
+	for _, group := range groups {
+		wg.Add(1)
+		go func(g roomGroup) {
+			defer wg.Done()
+			forceHostTransfer(ctx, g, timeout, metrics)
+		}(group)
+	}
+	return wg
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestObserveRoomStateCompletesHostWatchOnPromotion(t *testing.T) {
+	metrics := &StepMetrics{}
+	peer := newLoadClient(1, "room", "ws://example.invalid/ws", time.Second, metrics)
+	peer.cidValue.Store("C-peer")
+
+	watch := &hostTransferWatch{startedAt: time.Now(), promoted: make(chan time.Duration, 1)}
+	peer.hostWatch.Store(watch)
+
+	peer.observeRoomState(signalingEnvelope{V: 1, Type: "room_state", Payload: mustRawJSON(map[string]any{"hostCid": "C-other"})})
+	select {
+	case <-watch.promoted:
+		t.Fatal("expected room_state naming another host not to complete the watch")
+	default:
+	}
+
+	peer.observeRoomState(signalingEnvelope{V: 1, Type: "room_state", Payload: mustRawJSON(map[string]any{"hostCid": "C-peer"})})
+	select {
+	case <-watch.promoted:
+	default:
+		t.Fatal("expected promotion to complete the watch")
+	}
+	if peer.hostWatch.Load() != nil {
+		t.Fatal("expected watch to be cleared after promotion")
+	}
+}
+
+func TestHostTransferMetricsInStepResult(t *testing.T) {
+	metrics := &StepMetrics{}
+	metrics.hostTransferAttempts.Add(3)
+	metrics.hostTransferSuccess.Add(2)
+	metrics.hostTransferFailures.Add(1)
+	metrics.connectAttempts.Add(10)
+	metrics.AddHostTransferLatency(40)
+	metrics.AddHostTransferLatency(90)
+
+	result := metrics.ToStepResult(10, 5, time.Now(), time.Now())
+	if result.HostTransferAttempts != 3 || result.HostTransferSuccess != 2 || result.HostTransferFailures != 1 {
+		t.Fatalf("unexpected host transfer counts: %+v", result)
+	}
+	if result.HostTransferP95Ms != 90 {
+		t.Fatalf("expected p95 of 90ms, got %.1f", result.HostTransferP95Ms)
+	}
+	if result.ErrorRate != 0.1 {
+		t.Fatalf("expected failed host transfer to count as an error, got %.2f", result.ErrorRate)
+	}
+}
@@ -18,6 +18,23 @@ func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
+	if cfg.ReplayFile != "" {
+		summary, err := runReplay(ctx, cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "replay failed: %v\n", err)
+			os.Exit(1)
+		}
+		printReplaySummary(summary)
+		if cfg.ReportJSON != "" {
+			if err := writeReplayReport(cfg.ReportJSON, summary); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to write report: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("report: %s\n", cfg.ReportJSON)
+		}
+		return
+	}
+
 	report, err := runSweep(ctx, cfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "load sweep failed: %v\n", err)
@@ -26,6 +43,7 @@ func main() {
 	fmt.Printf("\nlast passing concurrency: %d clients\n", report.LastPassingClients)
 	fmt.Printf("stopped at: %d clients\n", report.StoppedAtClients)
 	fmt.Printf("final reason: %s\n", report.FinalReason)
+	printRecommendedProfile(report.RecommendedProfile)
 
 	if cfg.ReportJSON != "" {
 		if err := writeJSONReport(cfg.ReportJSON, report); err != nil {
@@ -34,6 +52,20 @@ func main() {
 		}
 		fmt.Printf("report: %s\n", cfg.ReportJSON)
 	}
+	if cfg.ReportMD != "" {
+		if err := writeMarkdownReport(cfg.ReportMD, report); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write markdown report: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("markdown report: %s\n", cfg.ReportMD)
+	}
+	if cfg.ReportCSV != "" {
+		if err := writeCSVReport(cfg.ReportCSV, report); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write csv report: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("csv report: %s\n", cfg.ReportCSV)
+	}
 
 	if err != nil {
 		os.Exit(1)
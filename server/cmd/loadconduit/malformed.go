@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gorilla/websocket"
+)
+
+// malformedCategory is one kind of deliberately broken frame sent in place of
+// a relay when --malformed-rate is set.
+type malformedCategory int
+
+const (
+	malformedTruncated    malformedCategory = iota // expect error BAD_REQUEST
+	malformedWrongVersion                          // expect error UNSUPPORTED_VERSION
+	malformedUnknownType                           // expect no reply; a follow-up ping must still get pong
+	malformedOversized                             // expect close 1009, after which the client rejoins
+	malformedCategoryCount
+)
+
+var malformedCategoryNames = [malformedCategoryCount]string{
+	"truncated",
+	"wrong_version",
+	"unknown_type",
+	"oversized",
+}
+
+// malformedOversizeBytes is comfortably above the server's 64KB maxMessageSize.
+const malformedOversizeBytes = 96 * 1024
+
+func (k malformedCategory) String() string {
+	if k < 0 || k >= malformedCategoryCount {
+		return "unknown"
+	}
+	return malformedCategoryNames[k]
+}
+
+func malformedFrame(category malformedCategory, roomID, cid string) []byte {
+	switch category {
+	case malformedTruncated:
+		frame := mustRawJSON(signalingEnvelope{V: 1, Type: "ice", RID: roomID, CID: cid, Payload: mustRawJSON(map[string]any{"candidate": "candidate:0"})})
+		return frame[:len(frame)/2]
+	case malformedWrongVersion:
+		return mustRawJSON(signalingEnvelope{V: 2, Type: "ice", RID: roomID, CID: cid})
+	case malformedUnknownType:
+		return mustRawJSON(signalingEnvelope{V: 1, Type: "loadconduit_unknown", RID: roomID, CID: cid})
+	case malformedOversized:
+		return mustRawJSON(signalingEnvelope{V: 1, Type: "ice", RID: roomID, CID: cid, Payload: mustRawJSON(map[string]any{"candidate": strings.Repeat("x", malformedOversizeBytes)})})
+	}
+	return nil
+}
+
+// sendRelay sends the room's next relay message. With probability
+// cfg.InjectRelayLoss the send is skipped, as if lost on the client's side of
+// the channel; skipped relays are not counted as sent, so relayReceived is
+// still expected to match relaySent. Otherwise, with probability
+// cfg.MalformedRate a malformed frame goes out in its place. A client whose
+// connection was closed by an oversized frame rejoins on its next turn
+// instead. rng must be owned by the calling relay loop.
+func (c *loadClient) sendRelay(ctx context.Context, cfg Config, rng *rand.Rand, counter int64) {
+	if c.rejoinNeeded.CompareAndSwap(true, false) {
+		reconnectCtx, cancel := context.WithTimeout(ctx, c.joinTimeout)
+		defer cancel()
+		_ = c.reconnect(reconnectCtx)
+		return
+	}
+	if c.sendPaused.Load() {
+		return
+	}
+	if cfg.InjectRelayLoss > 0 && rng.Float64() < cfg.InjectRelayLoss {
+		c.metrics.relayInjectedLoss.Add(1)
+		return
+	}
+	if cfg.MalformedRate > 0 && rng.Float64() < cfg.MalformedRate {
+		category := malformedCategory((c.malformedSeq.Add(1) - 1) % int64(malformedCategoryCount))
+		_ = c.sendMalformed(category)
+		return
+	}
+	_ = c.sendRelayICE(counter)
+}
+
+func (c *loadClient) sendMalformed(category malformedCategory) error {
+	pending := &c.pendingMalformed[category]
+	pending.Add(1)
+	if err := c.writeRaw(malformedFrame(category, c.roomID, c.cid())); err != nil {
+		pending.Add(-1)
+		return err
+	}
+	c.metrics.malformedSent[category].Add(1)
+
+	if category == malformedUnknownType {
+		// Unknown types get no reply, so probe that the connection still works.
+		return c.writeSignal(signalingEnvelope{V: 1, Type: "ping", RID: c.roomID, CID: c.cid()})
+	}
+	return nil
+}
+
+// consumeMalformedReply reports whether msg is the expected server reply to a
+// malformed frame this client sent, recording it if so. Such replies must not
+// count as server errors.
+func (c *loadClient) consumeMalformedReply(msg signalingEnvelope) bool {
+	var category malformedCategory
+	switch msg.Type {
+	case "error":
+		var payload struct {
+			Code string `json:"code"`
+		}
+		_ = json.Unmarshal(msg.Payload, &payload)
+		switch payload.Code {
+		case "BAD_REQUEST":
+			category = malformedTruncated
+		case "UNSUPPORTED_VERSION":
+			category = malformedWrongVersion
+		default:
+			return false
+		}
+	case "pong":
+		category = malformedUnknownType
+	default:
+		return false
+	}
+	if !decrementIfPositive(&c.pendingMalformed[category]) {
+		return false
+	}
+	c.metrics.malformedAsExpected[category].Add(1)
+	return true
+}
+
+// consumeOversizeClose reports whether a read error is the server closing the
+// connection for an oversized frame this client sent.
+func (c *loadClient) consumeOversizeClose(err error) bool {
+	if !websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+		return false
+	}
+	if !decrementIfPositive(&c.pendingMalformed[malformedOversized]) {
+		return false
+	}
+	c.metrics.malformedAsExpected[malformedOversized].Add(1)
+	c.rejoinNeeded.Store(true)
+	return true
+}
+
+func decrementIfPositive(v *atomic.Int64) bool {
+	for {
+		current := v.Load()
+		if current <= 0 {
+			return false
+		}
+		if v.CompareAndSwap(current, current-1) {
+			return true
+		}
+	}
+}
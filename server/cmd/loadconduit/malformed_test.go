@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestMalformedFrames(t *testing.T) {
+	var envelope signalingEnvelope
+
+	if err := json.Unmarshal(malformedFrame(malformedTruncated, "room", "C-1"), &envelope); err == nil {
+		t.Fatal("expected truncated frame to be invalid JSON")
+	}
+
+	if err := json.Unmarshal(malformedFrame(malformedWrongVersion, "room", "C-1"), &envelope); err != nil || envelope.V == 1 {
+		t.Fatalf("expected well-formed frame with unsupported version, got v=%d err=%v", envelope.V, err)
+	}
+
+	envelope = signalingEnvelope{}
+	if err := json.Unmarshal(malformedFrame(malformedUnknownType, "room", "C-1"), &envelope); err != nil || envelope.V != 1 || envelope.Type == "ice" {
+		t.Fatalf("expected v1 frame with unknown type, got %+v err=%v", envelope, err)
+	}
+
+	if size := len(malformedFrame(malformedOversized, "room", "C-1")); size <= 64*1024 {
+		t.Fatalf("expected oversized frame above the server limit, got %d bytes", size)
+	}
+}
+
+func TestConsumeMalformedReplyMatchesPendingFrames(t *testing.T) {
+	metrics := &StepMetrics{}
+	c := newLoadClient(0, "room", "ws://example.invalid/ws", time.Second, metrics)
+	badRequest := signalingEnvelope{V: 1, Type: "error", Payload: mustRawJSON(map[string]string{"code": "BAD_REQUEST"})}
+
+	if c.consumeMalformedReply(badRequest) {
+		t.Fatal("expected error without a pending malformed frame to be a real server error")
+	}
+
+	c.pendingMalformed[malformedTruncated].Add(1)
+	if !c.consumeMalformedReply(badRequest) {
+		t.Fatal("expected BAD_REQUEST to answer the pending truncated frame")
+	}
+	if c.consumeMalformedReply(badRequest) {
+		t.Fatal("expected a second BAD_REQUEST not to be consumed")
+	}
+
+	c.pendingMalformed[malformedUnknownType].Add(1)
+	if !c.consumeMalformedReply(signalingEnvelope{V: 1, Type: "pong"}) {
+		t.Fatal("expected pong to confirm the connection survived an unknown type")
+	}
+
+	c.pendingMalformed[malformedOversized].Add(1)
+	if c.consumeOversizeClose(&websocket.CloseError{Code: websocket.CloseAbnormalClosure}) {
+		t.Fatal("expected only a 1009 close to match an oversized frame")
+	}
+	if !c.consumeOversizeClose(&websocket.CloseError{Code: websocket.CloseMessageTooBig}) {
+		t.Fatal("expected 1009 close to match the pending oversized frame")
+	}
+	if !c.rejoinNeeded.Load() {
+		t.Fatal("expected client to be flagged for rejoin after an oversize close")
+	}
+
+	metrics.malformedSent[malformedTruncated].Add(2)
+	metrics.malformedSent[malformedOversized].Add(1)
+	result := metrics.ToStepResult(2, 1, time.Now(), time.Now())
+	if got := result.Malformed["truncated"]; got.Sent != 2 || got.AsExpected != 1 {
+		t.Fatalf("unexpected truncated outcome: %+v", got)
+	}
+	if got := result.Malformed["oversized"]; got.Sent != 1 || got.AsExpected != 1 {
+		t.Fatalf("unexpected oversized outcome: %+v", got)
+	}
+	if _, ok := result.Malformed["wrong_version"]; ok {
+		t.Fatal("expected categories with nothing sent to be omitted")
+	}
+	if result.ServerErrorMessages != 0 {
+		t.Fatalf("expected malformed replies not to count as server errors, got %d", result.ServerErrorMessages)
+	}
+}
+
+func TestSendRelayInjectsLossReproducibly(t *testing.T) {
+	cfg := Config{InjectRelayLoss: 0.5}
+	run := func() (lost, attempted int64) {
+		metrics := &StepMetrics{}
+		c := newLoadClient(0, "room", "ws://example.invalid/ws", time.Second, metrics)
+		rng := rand.New(rand.NewSource(7))
+		for i := int64(1); i <= 200; i++ {
+			c.sendRelay(context.Background(), cfg, rng, i)
+		}
+		// Not connected, so every relay that was not dropped fails to send.
+		return metrics.relayInjectedLoss.Load(), metrics.relaySendFailures.Load()
+	}
+
+	lost, attempted := run()
+	if lost+attempted != 200 {
+		t.Fatalf("expected every relay to be either dropped or attempted, got %d + %d", lost, attempted)
+	}
+	if lost < 60 || lost > 140 {
+		t.Fatalf("expected roughly half the relays dropped, got %d", lost)
+	}
+	if again, _ := run(); again != lost {
+		t.Fatalf("expected the same seed to drop the same relays, got %d then %d", lost, again)
+	}
+}
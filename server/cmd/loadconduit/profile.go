@@ -0,0 +1,83 @@
+package main
+
+import "fmt"
+
+const (
+	// profileHeadroom scales the last passing concurrency down so the
+	// suggested limit leaves room for bursts the sweep did not model.
+	profileHeadroom = 0.8
+	// defaultServerSendBufferSize is the server's default SEND_QUEUE_SIZE,
+	// assumed when its stats do not report the configured size.
+	defaultServerSendBufferSize = 256
+	// sendBufferHeadroom scales the deepest send queue the server saw, for
+	// bursts the sweep did not reach.
+	sendBufferHeadroom = 2
+	// minSuggestedSendBufferSize keeps light sweeps from suggesting a buffer
+	// too small for a normal join.
+	minSuggestedSendBufferSize = 64
+)
+
+// RecommendedProfile is an advisory tuning suggestion derived from the last
+// passing step. It is a heuristic, not a guarantee of capacity.
+type RecommendedProfile struct {
+	Heuristic bool   `json:"heuristic"`
+	Note      string `json:"note"`
+
+	BasedOnClients int `json:"basedOnClients"`
+
+	MaxConcurrentClients int `json:"maxConcurrentClients"`
+	SendBufferSize       int `json:"sendBufferSize"`
+
+	HeapBytesPerClient  uint64  `json:"heapBytesPerClient,omitempty"`
+	GoroutinesPerClient float64 `json:"goroutinesPerClient,omitempty"`
+}
+
+func buildRecommendedProfile(report SweepReport) *RecommendedProfile {
+	step := lastPassingStep(report)
+	if step == nil {
+		return nil
+	}
+
+	profile := &RecommendedProfile{
+		Heuristic:            true,
+		Note:                 fmt.Sprintf("advisory heuristic: %.0f%% of last passing concurrency; verify against production traffic", profileHeadroom*100),
+		BasedOnClients:       step.TargetClients,
+		MaxConcurrentClients: int(float64(step.TargetClients) * profileHeadroom),
+		SendBufferSize:       defaultServerSendBufferSize,
+	}
+	if profile.MaxConcurrentClients < 1 {
+		profile.MaxConcurrentClients = 1
+	}
+	// Size the buffer from the deepest queue the server saw by this step (a
+	// queue that overflowed reports capacity+1). Servers that do not report it
+	// keep their configured size.
+	if g := step.ServerGauges; g != nil {
+		switch {
+		case g.SendQueueHighWater > 0:
+			profile.SendBufferSize = max(g.SendQueueHighWater*sendBufferHeadroom, minSuggestedSendBufferSize)
+		case g.SendQueueSize > 0:
+			profile.SendBufferSize = g.SendQueueSize
+		}
+	}
+
+	if g := step.ServerGauges; g != nil && g.ActiveClients > 0 {
+		profile.HeapBytesPerClient = g.HeapInuse / uint64(g.ActiveClients)
+		profile.GoroutinesPerClient = float64(g.Goroutines) / float64(g.ActiveClients)
+	}
+
+	return profile
+}
+
+func printRecommendedProfile(profile *RecommendedProfile) {
+	if profile == nil {
+		return
+	}
+	fmt.Printf("\nsuggested profile (heuristic, based on %d clients):\n", profile.BasedOnClients)
+	fmt.Printf("  max concurrent clients: %d\n", profile.MaxConcurrentClients)
+	fmt.Printf("  send buffer size: %d\n", profile.SendBufferSize)
+	if profile.HeapBytesPerClient > 0 {
+		fmt.Printf("  heap per client: %d bytes\n", profile.HeapBytesPerClient)
+		fmt.Printf("  goroutines per client: %.1f\n", profile.GoroutinesPerClient)
+	}
+	fmt.Printf("  note: %s\n", profile.Note)
+}
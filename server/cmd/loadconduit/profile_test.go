@@ -0,0 +1,75 @@
+package main
+
+import "testing"
+
+func TestBuildRecommendedProfileFromLastPassingStep(t *testing.T) {
+	report := SweepReport{
+		LastPassingClients: 100,
+		Steps: []StepResult{
+			{TargetClients: 50, Passed: true},
+			{
+				TargetClients:        100,
+				Passed:               true,
+				ServerStatsAvailable: true,
+				SendQueueDropDelta:   3,
+				ServerGauges: &ServerGaugeSample{
+					ActiveClients:      100,
+					Goroutines:         250,
+					HeapInuse:          10_000_000,
+					SendQueueSize:      256,
+					SendQueueHighWater: 257,
+				},
+			},
+			{TargetClients: 150, Passed: false},
+		},
+	}
+
+	profile := buildRecommendedProfile(report)
+	if profile == nil {
+		t.Fatal("expected a profile")
+	}
+	if !profile.Heuristic || profile.Note == "" {
+		t.Fatalf("expected profile to be labeled as a heuristic: %+v", profile)
+	}
+	if profile.BasedOnClients != 100 || profile.MaxConcurrentClients != 80 {
+		t.Fatalf("unexpected concurrency recommendation: %+v", profile)
+	}
+	if profile.SendBufferSize != 514 {
+		t.Fatalf("expected twice the overflowing queue's high-water mark, got %d", profile.SendBufferSize)
+	}
+	if profile.HeapBytesPerClient != 100_000 || profile.GoroutinesPerClient != 2.5 {
+		t.Fatalf("unexpected per-client resource estimates: %+v", profile)
+	}
+}
+
+func TestBuildRecommendedProfileNilWithoutPassingStep(t *testing.T) {
+	report := SweepReport{Steps: []StepResult{{TargetClients: 20, Passed: false}}}
+	if profile := buildRecommendedProfile(report); profile != nil {
+		t.Fatalf("expected no profile, got %+v", profile)
+	}
+}
+
+func TestBuildRecommendedProfileSendBufferFromServerStats(t *testing.T) {
+	cases := []struct {
+		name   string
+		gauges *ServerGaugeSample
+		want   int
+	}{
+		{"high-water", &ServerGaugeSample{SendQueueSize: 256, SendQueueHighWater: 90}, 180},
+		{"light sweep", &ServerGaugeSample{SendQueueSize: 256, SendQueueHighWater: 3}, minSuggestedSendBufferSize},
+		{"no high-water", &ServerGaugeSample{SendQueueSize: 128}, 128},
+		{"no gauges", nil, defaultServerSendBufferSize},
+	}
+	for _, tc := range cases {
+		report := SweepReport{LastPassingClients: 40, Steps: []StepResult{{
+			TargetClients:        40,
+			Passed:               true,
+			ServerStatsAvailable: tc.gauges != nil,
+			ServerGauges:         tc.gauges,
+		}}}
+		profile := buildRecommendedProfile(report)
+		if profile == nil || profile.SendBufferSize != tc.want {
+			t.Fatalf("%s: expected send buffer %d, got %+v", tc.name, tc.want, profile)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// startReconnectStorm reconnects clients through at most concurrency workers
+// (0 means one per client, i.e. all at once) and adds the storm to wg. Once
+// every reconnect has been issued, the issuance window is recorded so the
+// step can report the effective reconnect rate the server saw.
+func startReconnectStorm(ctx context.Context, clients []*loadClient, concurrency int, timeout time.Duration, metrics *StepMetrics, wg *sync.WaitGroup) {
+	if len(clients) == 0 {
+		return
+	}
+	if concurrency <= 0 || concurrency > len(clients) {
+		concurrency = len(clients)
+	}
+
+	jobs := make(chan *loadClient)
+	started := time.Now()
+	var lastIssuedMu sync.Mutex
+	lastIssued := started
+
+	workers := &sync.WaitGroup{}
+	for i := 0; i < concurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for client := range jobs {
+				now := time.Now()
+				lastIssuedMu.Lock()
+				if now.After(lastIssued) {
+					lastIssued = now
+				}
+				lastIssuedMu.Unlock()
+
+				reconnectCtx, reconnectCancel := context.WithTimeout(ctx, timeout)
+				_ = client.reconnect(reconnectCtx)
+				reconnectCancel()
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		issued := 0
+	dispatch:
+		for _, client := range clients {
+			select {
+			case <-ctx.Done():
+				break dispatch
+			case jobs <- client:
+				issued++
+			}
+		}
+		close(jobs)
+		workers.Wait()
+
+		lastIssuedMu.Lock()
+		window := lastIssued.Sub(started)
+		lastIssuedMu.Unlock()
+		metrics.recordReconnectIssuance(issued, window)
+	}()
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReconnectStormBoundsConcurrency(t *testing.T) {
+	var inFlight, peak atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(30 * time.Millisecond)
+		inFlight.Add(-1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	metrics := &StepMetrics{}
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+	clients := make([]*loadClient, 12)
+	for i := range clients {
+		clients[i] = newLoadClient(i, "room", wsURL, time.Second, metrics)
+	}
+
+	wg := &sync.WaitGroup{}
+	startReconnectStorm(context.Background(), clients, 3, time.Second, metrics, wg)
+	wg.Wait()
+
+	if got := peak.Load(); got > 3 {
+		t.Fatalf("expected at most 3 reconnects in flight, saw %d", got)
+	}
+	if got := metrics.reconnectAttempts.Load(); got != 12 {
+		t.Fatalf("expected 12 reconnect attempts, got %d", got)
+	}
+	result := metrics.ToStepResult(12, 6, time.Now(), time.Now())
+	// 12 reconnects through 3 workers take at least three 30ms rounds to issue.
+	if result.ReconnectIssueRate <= 0 || result.ReconnectIssueRate > 12/0.09 {
+		t.Fatalf("unexpected reconnect issue rate %.1f/s", result.ReconnectIssueRate)
+	}
+}
+
+func TestReconnectIssueRateFloorsWindow(t *testing.T) {
+	metrics := &StepMetrics{}
+	if metrics.ReconnectIssueRate() != 0 {
+		t.Fatal("expected no rate without a storm")
+	}
+	metrics.recordReconnectIssuance(50, 0)
+	if got := metrics.ReconnectIssueRate(); got != 50000 {
+		t.Fatalf("expected 50 reconnects over the 1ms floor to be 50000/s, got %.1f", got)
+	}
+}
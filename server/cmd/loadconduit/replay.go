@@ -0,0 +1,463 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"serenada/server/internal/fsutil"
+)
+
+// replaySettle is how long replay connections stay open after the last
+// captured message is sent, so late replies are still counted.
+var replaySettle = 2 * time.Second
+
+// captureHeader and captureRecord mirror the server's session capture file
+// (/api/internal/capture): a header line, then one record per message.
+type captureHeader struct {
+	Capture   int    `json:"capture"`
+	RID       string `json:"rid"`
+	StartedAt int64  `json:"startedAt"`
+	Scrubbed  bool   `json:"scrubbed"`
+}
+
+type captureRecord struct {
+	AtMs int64           `json:"at"`
+	Dir  string          `json:"dir"`
+	SID  string          `json:"sid"`
+	CID  string          `json:"cid,omitempty"`
+	Msg  json.RawMessage `json:"msg"`
+}
+
+// ReplaySummary compares what the server sent during the capture with what it
+// sent during the replay, keyed by message type ("error:<code>" for errors).
+type ReplaySummary struct {
+	CaptureRID   string           `json:"captureRid"`
+	ReplayRID    string           `json:"replayRid"`
+	Sessions     int              `json:"sessions"`
+	Sent         int64            `json:"sent"`
+	SendFailures int64            `json:"sendFailures"`
+	Expected     map[string]int64 `json:"expected"`
+	Received     map[string]int64 `json:"received"`
+	Mismatches   []string         `json:"mismatches,omitempty"` // keys whose counts differ, sorted
+}
+
+func loadCapture(path string) (captureHeader, []captureRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return captureHeader{}, nil, err
+	}
+	defer f.Close()
+
+	var header captureHeader
+	var records []captureRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if line == 1 {
+			if err := json.Unmarshal(scanner.Bytes(), &header); err != nil || header.Capture != 1 || header.RID == "" {
+				return captureHeader{}, nil, fmt.Errorf("%s is not a session capture (version 1)", path)
+			}
+			continue
+		}
+		var record captureRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return captureHeader{}, nil, fmt.Errorf("%s line %d: %w", path, line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return captureHeader{}, nil, err
+	}
+	if header.RID == "" {
+		return captureHeader{}, nil, fmt.Errorf("%s is empty", path)
+	}
+	return header, records, nil
+}
+
+// replayMessageKey is the ReplaySummary key for a server message.
+func replayMessageKey(raw []byte) string {
+	var msg struct {
+		Type    string `json:"type"`
+		Payload struct {
+			Code string `json:"code"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(raw, &msg); err != nil || msg.Type == "" {
+		return "invalid"
+	}
+	if msg.Type == "error" && msg.Payload.Code != "" {
+		return "error:" + msg.Payload.Code
+	}
+	return msg.Type
+}
+
+// capturedJoin is one joined message the server sent a captured session.
+type capturedJoin struct {
+	cid   string
+	token string
+}
+
+func parseJoined(raw []byte) (capturedJoin, bool) {
+	var msg struct {
+		Type    string `json:"type"`
+		CID     string `json:"cid"`
+		Payload struct {
+			ReconnectToken string `json:"reconnectToken"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(raw, &msg); err != nil || msg.Type != "joined" {
+		return capturedJoin{}, false
+	}
+	return capturedJoin{cid: msg.CID, token: msg.Payload.ReconnectToken}, true
+}
+
+// replayRewriter maps captured identifiers (room ID, CIDs, reconnect tokens)
+// to the ones the server assigned during the replay.
+type replayRewriter struct {
+	mu       sync.Mutex
+	replace  map[string]string // captured value -> replay value
+	tokens   map[string]string // replay cid -> latest reconnectToken
+	replacer *strings.Replacer
+}
+
+func newReplayRewriter(capturedRID, replayRID string) *replayRewriter {
+	r := &replayRewriter{replace: map[string]string{capturedRID: replayRID}, tokens: make(map[string]string)}
+	r.rebuildLocked()
+	return r
+}
+
+func (r *replayRewriter) rebuildLocked() {
+	pairs := make([]string, 0, 2*len(r.replace))
+	for from, to := range r.replace {
+		pairs = append(pairs, from, to)
+	}
+	r.replacer = strings.NewReplacer(pairs...)
+}
+
+// learnJoin records that captured is what the server now calls replayed.
+func (r *replayRewriter) learnJoin(captured, replayed capturedJoin) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if captured.cid != "" && replayed.cid != "" {
+		r.replace[captured.cid] = replayed.cid
+	}
+	if captured.token != "" && captured.token != scrubbedCaptureValue && replayed.token != "" {
+		r.replace[captured.token] = replayed.token
+	}
+	if replayed.cid != "" {
+		r.tokens[replayed.cid] = replayed.token
+	}
+	r.rebuildLocked()
+}
+
+// scrubbedCaptureValue is what the server writes for scrubbed fields.
+const scrubbedCaptureValue = "[scrubbed]"
+
+// rewrite maps identifiers in raw. A join that reclaims a CID gets the replay
+// token for it, since scrubbed or unmapped captured tokens cannot validate.
+func (r *replayRewriter) rewrite(raw []byte) ([]byte, string) {
+	r.mu.Lock()
+	rewritten := []byte(r.replacer.Replace(string(raw)))
+	r.mu.Unlock()
+
+	var msg map[string]json.RawMessage
+	if err := json.Unmarshal(rewritten, &msg); err != nil {
+		return rewritten, ""
+	}
+	var msgType string
+	_ = json.Unmarshal(msg["type"], &msgType)
+	if msgType != "join" {
+		return rewritten, msgType
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(msg["payload"], &payload); err != nil {
+		return rewritten, msgType
+	}
+	reconnectCID, _ := payload["reconnectCid"].(string)
+	if reconnectCID == "" {
+		return rewritten, msgType
+	}
+	r.mu.Lock()
+	token, ok := r.tokens[reconnectCID]
+	r.mu.Unlock()
+	if ok && token != "" {
+		payload["reconnectToken"] = token
+	} else {
+		delete(payload, "reconnectToken")
+	}
+	msg["payload"] = mustRawJSON(payload)
+	out, err := json.Marshal(msg)
+	if err != nil {
+		return rewritten, msgType
+	}
+	return out, msgType
+}
+
+// replaySession replays one captured connection (one server sid) over its
+// own WebSocket, opened when its first message is due.
+type replaySession struct {
+	capturedSID string
+	queue       chan []byte
+	joins       []capturedJoin // captured joined messages, in order
+
+	conn    *websocket.Conn
+	replies chan capturedJoin // joined (ok) or error (empty) while a join is pending
+	readWG  sync.WaitGroup
+}
+
+type replayRun struct {
+	wsURL       string
+	joinTimeout time.Duration
+	rewriter    *replayRewriter
+
+	mu       sync.Mutex
+	summary  ReplaySummary
+	sessions []*replaySession
+}
+
+func (run *replayRun) countReceived(key string) {
+	run.mu.Lock()
+	run.summary.Received[key]++
+	run.mu.Unlock()
+}
+
+func (run *replayRun) countSent(err error) {
+	run.mu.Lock()
+	if err != nil {
+		run.summary.SendFailures++
+	} else {
+		run.summary.Sent++
+	}
+	run.mu.Unlock()
+}
+
+// runReplay replays a session capture against cfg's server in a fresh room,
+// keeping the captured spacing between client messages (divided by
+// cfg.ReplaySpeed).
+func runReplay(ctx context.Context, cfg Config) (ReplaySummary, error) {
+	header, records, err := loadCapture(cfg.ReplayFile)
+	if err != nil {
+		return ReplaySummary{}, err
+	}
+	roomIDs, err := generateRoomIDs(ctx, cfg, 1)
+	if err != nil {
+		return ReplaySummary{}, fmt.Errorf("replay room ID: %w", err)
+	}
+	return replayCapture(ctx, header, records, roomIDs[0], cfg.WSURL, time.Duration(cfg.JoinTimeoutSeconds)*time.Second, cfg.ReplaySpeed)
+}
+
+func replayCapture(ctx context.Context, header captureHeader, records []captureRecord, replayRID, wsURL string, joinTimeout time.Duration, speed float64) (ReplaySummary, error) {
+	run := &replayRun{
+		wsURL:       wsURL,
+		joinTimeout: joinTimeout,
+		rewriter:    newReplayRewriter(header.RID, replayRID),
+		summary: ReplaySummary{
+			CaptureRID: header.RID,
+			ReplayRID:  replayRID,
+			Expected:   make(map[string]int64),
+			Received:   make(map[string]int64),
+		},
+	}
+
+	sessions := make(map[string]*replaySession)
+	var inbound []captureRecord
+	for _, record := range records {
+		session := sessions[record.SID]
+		if session == nil {
+			session = &replaySession{capturedSID: record.SID, replies: make(chan capturedJoin, 16)}
+			sessions[record.SID] = session
+			run.sessions = append(run.sessions, session)
+		}
+		switch record.Dir {
+		case "in":
+			inbound = append(inbound, record)
+		case "out":
+			run.summary.Expected[replayMessageKey(record.Msg)]++
+			if joined, ok := parseJoined(record.Msg); ok {
+				session.joins = append(session.joins, joined)
+			}
+		}
+	}
+	if len(inbound) == 0 {
+		return run.summary, errors.New("capture has no client messages to replay")
+	}
+
+	wg := &sync.WaitGroup{}
+	for _, session := range run.sessions {
+		session.queue = make(chan []byte, len(inbound))
+		wg.Add(1)
+		go func(session *replaySession) {
+			defer wg.Done()
+			run.drive(ctx, session)
+		}(session)
+	}
+
+	start := time.Now()
+	firstAt := inbound[0].AtMs
+	for _, record := range inbound {
+		due := time.Duration(float64(time.Duration(record.AtMs-firstAt)*time.Millisecond) / speed)
+		if wait := due - time.Since(start); wait > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(wait):
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		sessions[record.SID].queue <- record.Msg
+	}
+	for _, session := range run.sessions {
+		close(session.queue)
+	}
+	wg.Wait()
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(replaySettle):
+	}
+	for _, session := range run.sessions {
+		if session.conn != nil {
+			_ = session.conn.Close()
+			session.readWG.Wait()
+			run.summary.Sessions++
+		}
+	}
+
+	run.summary.Mismatches = replayMismatches(run.summary.Expected, run.summary.Received)
+	return run.summary, ctx.Err()
+}
+
+// drive sends session's messages in order. After a join it waits for the
+// joined reply so later messages can use the replay CID.
+func (run *replayRun) drive(ctx context.Context, session *replaySession) {
+	for raw := range session.queue {
+		if ctx.Err() != nil {
+			continue
+		}
+		if session.conn == nil {
+			dialer := websocket.Dialer{HandshakeTimeout: 10 * time.Second}
+			conn, _, err := dialer.DialContext(ctx, run.wsURL, nil)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "replay: session %s: %v\n", session.capturedSID, err)
+				run.countSent(err)
+				continue
+			}
+			session.conn = conn
+			session.readWG.Add(1)
+			go run.readLoop(session)
+		}
+
+		msg, msgType := run.rewriter.rewrite(raw)
+		if msgType == "join" {
+			drainReplies(session.replies)
+		}
+		err := session.conn.WriteMessage(websocket.TextMessage, msg)
+		run.countSent(err)
+		if err != nil || msgType != "join" {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(run.joinTimeout):
+			fmt.Fprintf(os.Stderr, "replay: session %s: join timeout after %s\n", session.capturedSID, run.joinTimeout)
+		case replayed := <-session.replies:
+			if replayed.cid != "" && len(session.joins) > 0 {
+				run.rewriter.learnJoin(session.joins[0], replayed)
+				session.joins = session.joins[1:]
+			}
+		}
+	}
+}
+
+func drainReplies(replies <-chan capturedJoin) {
+	for {
+		select {
+		case <-replies:
+		default:
+			return
+		}
+	}
+}
+
+func (run *replayRun) readLoop(session *replaySession) {
+	defer session.readWG.Done()
+	for {
+		_, payload, err := session.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		key := replayMessageKey(payload)
+		run.countReceived(key)
+
+		var reply capturedJoin
+		if joined, ok := parseJoined(payload); ok {
+			reply = joined
+		} else if !strings.HasPrefix(key, "error") {
+			continue
+		}
+		select {
+		case session.replies <- reply:
+		default:
+		}
+	}
+}
+
+func replayMismatches(expected, received map[string]int64) []string {
+	var keys []string
+	for key, n := range expected {
+		if received[key] != n {
+			keys = append(keys, key)
+		}
+	}
+	for key := range received {
+		if _, ok := expected[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func writeReplayReport(path string, summary ReplaySummary) error {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.AtomicWriteFile(path, data)
+}
+
+func printReplaySummary(summary ReplaySummary) {
+	fmt.Printf("replayed capture of room %s as %s: %d sessions, %d messages sent (%d failed)\n",
+		summary.CaptureRID, summary.ReplayRID, summary.Sessions, summary.Sent, summary.SendFailures)
+
+	keys := make([]string, 0, len(summary.Expected))
+	for key := range summary.Expected {
+		keys = append(keys, key)
+	}
+	for key := range summary.Received {
+		if _, ok := summary.Expected[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	fmt.Printf("%-28s %9s %9s\n", "server message", "captured", "replayed")
+	for _, key := range keys {
+		marker := ""
+		if summary.Expected[key] != summary.Received[key] {
+			marker = "  <- differs"
+		}
+		fmt.Printf("%-28s %9d %9d%s\n", key, summary.Expected[key], summary.Received[key], marker)
+	}
+}
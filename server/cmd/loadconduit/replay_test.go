@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func writeTestCapture(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "capture.jsonl")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadCaptureRejectsNonCapture(t *testing.T) {
+	if _, _, err := loadCapture(writeTestCapture(t, `{"steps":[]}`)); err == nil {
+		t.Fatal("expected an error for a file without a capture header")
+	}
+}
+
+func TestReplayRemapsRoomCIDAndReconnectToken(t *testing.T) {
+	prevSettle := replaySettle
+	replaySettle = 50 * time.Millisecond
+	defer func() { replaySettle = prevSettle }()
+
+	var mu sync.Mutex
+	var received []signalingEnvelope
+	var joins []map[string]string
+	nextCID := 0
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var msg signalingEnvelope
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			mu.Lock()
+			received = append(received, msg)
+			if msg.Type != "join" {
+				mu.Unlock()
+				continue
+			}
+			var payload map[string]string
+			_ = json.Unmarshal(msg.Payload, &payload)
+			joins = append(joins, payload)
+			cid := payload["reconnectCid"]
+			if cid == "" {
+				nextCID++
+				cid = fmt.Sprintf("R-%d", nextCID)
+			} else if payload["reconnectToken"] != "tok-"+cid {
+				mu.Unlock()
+				_ = conn.WriteJSON(map[string]any{"v": 1, "type": "error", "payload": map[string]string{"code": "INVALID_RECONNECT_TOKEN"}})
+				continue
+			}
+			mu.Unlock()
+			_ = conn.WriteJSON(map[string]any{"v": 1, "type": "joined", "rid": msg.RID, "cid": cid, "payload": map[string]string{"reconnectToken": "tok-" + cid}})
+		}
+	}))
+	defer srv.Close()
+
+	path := writeTestCapture(t,
+		`{"capture":1,"rid":"CAPTURED","startedAt":1000,"scrubbed":true}`,
+		`{"at":1000,"dir":"in","sid":"S-1","msg":{"v":1,"type":"join","rid":"CAPTURED","payload":{"device":"web"}}}`,
+		`{"at":1001,"dir":"out","sid":"S-1","cid":"C-old","msg":{"v":1,"type":"joined","rid":"CAPTURED","cid":"C-old","payload":{"reconnectToken":"[scrubbed]"}}}`,
+		`{"at":1010,"dir":"in","sid":"S-1","cid":"C-old","msg":{"v":1,"type":"offer","rid":"CAPTURED","cid":"C-old","payload":{"sdp":"[scrubbed]"}}}`,
+		`{"at":1020,"dir":"in","sid":"S-2","msg":{"v":1,"type":"join","rid":"CAPTURED","payload":{"reconnectCid":"C-old","reconnectToken":"[scrubbed]"}}}`,
+		`{"at":1021,"dir":"out","sid":"S-2","cid":"C-old","msg":{"v":1,"type":"joined","rid":"CAPTURED","cid":"C-old","payload":{"reconnectToken":"[scrubbed]"}}}`,
+	)
+	header, records, err := loadCapture(path)
+	if err != nil {
+		t.Fatalf("load capture: %v", err)
+	}
+
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+	summary, err := replayCapture(context.Background(), header, records, "REPLAY", wsURL, time.Second, 10)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+
+	if summary.Sessions != 2 || summary.Sent != 3 || summary.SendFailures != 0 {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+	if len(summary.Mismatches) != 0 || summary.Received["joined"] != 2 {
+		t.Fatalf("expected replay to match the capture, got %+v", summary)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, msg := range received {
+		if msg.RID != "REPLAY" {
+			t.Fatalf("expected captured room ID rewritten, got %+v", msg)
+		}
+		if msg.Type == "offer" && msg.CID != "R-1" {
+			t.Fatalf("expected captured CID rewritten to R-1, got %q", msg.CID)
+		}
+	}
+	if len(joins) != 2 || joins[1]["reconnectCid"] != "R-1" || joins[1]["reconnectToken"] != "tok-R-1" {
+		t.Fatalf("expected reconnect join with replay CID and token, got %+v", joins)
+	}
+}
+
+func TestReplayMismatchesReportsDifferences(t *testing.T) {
+	got := replayMismatches(
+		map[string]int64{"joined": 2, "error:ROOM_FULL": 1},
+		map[string]int64{"joined": 2, "error:ROOM_LOCKED": 1},
+	)
+	if strings.Join(got, ",") != "error:ROOM_FULL,error:ROOM_LOCKED" {
+		t.Fatalf("unexpected mismatches %v", got)
+	}
+}
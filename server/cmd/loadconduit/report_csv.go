@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+
+	"serenada/server/internal/fsutil"
+)
+
+// csvReportHeader names the --report-csv columns after the matching
+// StepResult JSON fields.
+var csvReportHeader = []string{
+	"targetClients",
+	"errorRate",
+	"clientJoinP95Ms",
+	"serverJoinP95Ms",
+	"sendQueueDropDelta",
+	"passed",
+	"failReason",
+}
+
+// renderCSVReport emits one row per step. Server-side columns are left empty
+// when the step's stats could not be fetched, so they are not read as zero.
+func renderCSVReport(report SweepReport) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(csvReportHeader); err != nil {
+		return nil, err
+	}
+	for _, step := range report.Steps {
+		serverJoinP95, sendQueueDrops := "", ""
+		if step.ServerStatsAvailable {
+			serverJoinP95 = formatCSVFloat(step.ServerJoinP95Ms)
+			sendQueueDrops = strconv.FormatInt(step.SendQueueDropDelta, 10)
+		}
+		if err := w.Write([]string{
+			strconv.Itoa(step.TargetClients),
+			formatCSVFloat(step.ErrorRate),
+			formatCSVFloat(step.ClientJoinP95Ms),
+			serverJoinP95,
+			sendQueueDrops,
+			strconv.FormatBool(step.Passed),
+			step.FailReason,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func formatCSVFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func writeCSVReport(path string, report SweepReport) error {
+	data, err := renderCSVReport(report)
+	if err != nil {
+		return err
+	}
+	return fsutil.AtomicWriteFile(path, data)
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"serenada/server/internal/fsutil"
+)
+
+// renderMarkdownReport renders a sweep as a Markdown summary for pasting into
+// a PR or incident doc. It carries the full command line (secrets redacted)
+// so the run can be reproduced from the report alone.
+func renderMarkdownReport(report SweepReport) string {
+	var b strings.Builder
+
+	b.WriteString("# Load sweep report\n\n")
+	fmt.Fprintf(&b, "Generated: %s\n\n", report.GeneratedAtRFC3339)
+	fmt.Fprintf(&b, "- Last passing concurrency: **%d clients**\n", report.LastPassingClients)
+	fmt.Fprintf(&b, "- Stopped at: %d clients\n", report.StoppedAtClients)
+	fmt.Fprintf(&b, "- Final reason: %s\n", markdownCell(report.FinalReason))
+
+	b.WriteString("\n## Steps\n\n")
+	b.WriteString("| Clients | Rooms | Error rate | Join error rate | Join p95 (ms) | Queue drops | Result | Reason |\n")
+	b.WriteString("|---:|---:|---:|---:|---:|---:|---|---|\n")
+	for _, step := range report.Steps {
+		joinP95 := step.ClientJoinP95Ms
+		if step.ServerStatsAvailable {
+			joinP95 = step.ServerJoinP95Ms
+		}
+		drops := "n/a"
+		if step.ServerStatsAvailable {
+			drops = strconv.FormatInt(step.SendQueueDropDelta, 10)
+		}
+		result := "PASS"
+		if !step.Passed {
+			result = "FAIL"
+		}
+		fmt.Fprintf(&b, "| %d | %d | %.4f | %.4f | %.1f | %s | %s | %s |\n",
+			step.TargetClients,
+			step.TargetRooms,
+			step.ErrorRate,
+			step.JoinErrorRate,
+			joinP95,
+			drops,
+			result,
+			markdownCell(step.FailReason),
+		)
+	}
+
+	if step := lastPassingStep(report); step != nil && len(step.SLOHeadroom) > 0 {
+		fmt.Fprintf(&b, "\n## SLO headroom at %d clients\n\n", step.TargetClients)
+		b.WriteString("| SLO | Headroom |\n")
+		b.WriteString("|---|---:|\n")
+		slos := make([]string, 0, len(step.SLOHeadroom))
+		for slo := range step.SLOHeadroom {
+			slos = append(slos, slo)
+		}
+		sort.Slice(slos, func(i, j int) bool {
+			return step.SLOHeadroom[slos[i]] < step.SLOHeadroom[slos[j]]
+		})
+		for _, slo := range slos {
+			name := slo
+			if slo == step.TightestSLO {
+				name += " (tightest)"
+			}
+			fmt.Fprintf(&b, "| %s | %.0f%% |\n", name, step.SLOHeadroom[slo]*100)
+		}
+	}
+
+	b.WriteString("\n## Configuration\n\n")
+	b.WriteString("```\ngo run ./cmd/loadconduit \\\n")
+	args := configArgs(report.Config)
+	for i, arg := range args {
+		b.WriteString("  " + arg)
+		if i < len(args)-1 {
+			b.WriteString(" \\")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("```\n")
+
+	return b.String()
+}
+
+// lastPassingStep returns the step that set report.LastPassingClients, or nil
+// if no step passed.
+func lastPassingStep(report SweepReport) *StepResult {
+	var step *StepResult
+	for i := range report.Steps {
+		if report.Steps[i].Passed && report.Steps[i].TargetClients == report.LastPassingClients {
+			step = &report.Steps[i]
+		}
+	}
+	return step
+}
+
+// configArgs renders cfg back into loadconduit flags. Tokens and secrets are
+// redacted; report paths are left out since they do not affect the run.
+func configArgs(cfg Config) []string {
+	args := []string{
+		"--base-url " + cfg.BaseURL,
+		"--ws-url " + cfg.WSURL,
+		"--stats-url " + cfg.StatsURL,
+	}
+	if cfg.Transport == transportSSE {
+		args = append(args, "--transport "+cfg.Transport)
+	}
+	if cfg.StatsToken != "" {
+		args = append(args, "--stats-token <redacted>")
+	}
+	if cfg.ProfileSteps {
+		args = append(args, "--profile-steps")
+	}
+	if cfg.RoomIDSecret != "" {
+		args = append(args, "--room-id-secret <redacted>", "--room-id-env "+cfg.RoomIDEnv)
+	}
+	args = append(args,
+		fmt.Sprintf("--start-clients %d", cfg.StartClients),
+		fmt.Sprintf("--step-clients %d", cfg.StepClients),
+		fmt.Sprintf("--max-clients %d", cfg.MaxClients),
+		fmt.Sprintf("--ramp-seconds %d", cfg.RampSeconds),
+		fmt.Sprintf("--steady-seconds %d", cfg.SteadySeconds),
+		fmt.Sprintf("--cooldown-seconds %d", cfg.CooldownSeconds),
+		fmt.Sprintf("--pre-ramp-stabilize-seconds %d", cfg.PreRampStabilizeSeconds),
+		"--rooms-mode "+cfg.RoomsMode,
+	)
+	if cfg.Search == searchBisect {
+		args = append(args, "--search "+cfg.Search, fmt.Sprintf("--search-tolerance %d", cfg.SearchTolerance))
+	}
+	if cfg.RoomsMode == "mesh" {
+		args = append(args, fmt.Sprintf("--room-size %d", cfg.RoomSize))
+	}
+	args = append(args, "--offer-rate-per-room "+formatFloatArg(cfg.OfferRatePerRoom))
+	if cfg.CallDurationDist != "" {
+		args = append(args, "--call-duration-dist "+cfg.CallDurationDist)
+	}
+	if cfg.MalformedRate > 0 {
+		args = append(args, "--malformed-rate "+formatFloatArg(cfg.MalformedRate))
+	}
+	if cfg.InjectRelayLoss > 0 {
+		args = append(args, "--inject-relay-loss "+formatFloatArg(cfg.InjectRelayLoss))
+	}
+	if cfg.ReconnectStormPercent > 0 {
+		args = append(args,
+			"--reconnect-storm-percent "+formatFloatArg(cfg.ReconnectStormPercent),
+			fmt.Sprintf("--reconnect-storm-at-second %d", cfg.ReconnectStormAtSecond),
+			fmt.Sprintf("--reconnect-concurrency %d", cfg.ReconnectConcurrency),
+		)
+	}
+	if cfg.TurnCheckPercent > 0 {
+		args = append(args,
+			"--turn-check-percent "+formatFloatArg(cfg.TurnCheckPercent),
+			"--max-turn-error-rate "+formatFloatArg(cfg.MaxTurnErrorRate),
+		)
+	}
+	if cfg.HostTransferPercent > 0 {
+		args = append(args,
+			"--host-transfer-percent "+formatFloatArg(cfg.HostTransferPercent),
+			fmt.Sprintf("--host-transfer-at-second %d", cfg.HostTransferAtSecond),
+		)
+	}
+	args = append(args,
+		fmt.Sprintf("--join-timeout-seconds %d", cfg.JoinTimeoutSeconds),
+		"--max-error-rate "+formatFloatArg(cfg.MaxErrorRate),
+		"--max-join-error-rate "+formatFloatArg(cfg.MaxJoinErrorRate),
+		fmt.Sprintf("--max-join-p95-ms %d", cfg.MaxJoinP95Ms),
+		fmt.Sprintf("--max-send-queue-drops %d", cfg.MaxSendQueueDrops),
+		fmt.Sprintf("--random-seed %d", cfg.RandomSeed),
+	)
+	return args
+}
+
+func formatFloatArg(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// markdownCell keeps free text from breaking a table row.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+func writeMarkdownReport(path string, report SweepReport) error {
+	return fsutil.AtomicWriteFile(path, []byte(renderMarkdownReport(report)))
+}
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -53,3 +55,85 @@ func TestWriteJSONReport(t *testing.T) {
 		t.Fatalf("unexpected report content: %+v", parsed)
 	}
 }
+
+func TestRenderMarkdownReport(t *testing.T) {
+	report := SweepReport{
+		GeneratedAtRFC3339: "2026-02-15T00:00:00Z",
+		Config: Config{
+			BaseURL:      "http://localhost",
+			StatsToken:   "secret-token",
+			StartClients: 20,
+			StepClients:  20,
+			MaxClients:   40,
+			RoomsMode:    "paired",
+			MaxErrorRate: 0.01,
+			RandomSeed:   7,
+		},
+		Steps: []StepResult{
+			{TargetClients: 20, TargetRooms: 10, Passed: true, SLOHeadroom: map[string]float64{"error_rate": 0.5, "join_p95": 0.2}, TightestSLO: "join_p95"},
+			{TargetClients: 40, TargetRooms: 20, FailReason: "join_p95_ms 2500 > 2000 | x"},
+		},
+		LastPassingClients: 20,
+		StoppedAtClients:   40,
+		FinalReason:        "threshold exceeded",
+	}
+
+	md := renderMarkdownReport(report)
+	for _, want := range []string{
+		"Last passing concurrency: **20 clients**",
+		"| 20 | 10 | 0.0000 | 0.0000 | 0.0 | n/a | PASS |  |",
+		"| 40 | 20 |",
+		"join_p95_ms 2500 > 2000 \\| x",
+		"## SLO headroom at 20 clients",
+		"| join_p95 (tightest) | 20% |",
+		"--stats-token <redacted>",
+		"--random-seed 7",
+	} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("expected markdown to contain %q:\n%s", want, md)
+		}
+	}
+	if strings.Contains(md, "secret-token") {
+		t.Fatal("markdown report must not leak the stats token")
+	}
+	if strings.Index(md, "join_p95 (tightest)") > strings.Index(md, "| error_rate |") {
+		t.Fatal("expected SLOs ordered by headroom, tightest first")
+	}
+}
+
+func TestWriteCSVReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.csv")
+	report := SweepReport{
+		Steps: []StepResult{
+			{TargetClients: 20, ErrorRate: 0.0025, ClientJoinP95Ms: 120.5, ServerStatsAvailable: true, ServerJoinP95Ms: 80, SendQueueDropDelta: 3, Passed: true},
+			{TargetClients: 40, ErrorRate: 0.2, ClientJoinP95Ms: 2500, FailReason: "join_p95_ms 2500 > 2000, error_rate 0.2"},
+		},
+	}
+
+	if err := writeCSVReport(path, report); err != nil {
+		t.Fatalf("writeCSVReport failed: %v", err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open report: %v", err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid report csv: %v", err)
+	}
+
+	want := [][]string{
+		csvReportHeader,
+		{"20", "0.0025", "120.5", "80", "3", "true", ""},
+		{"40", "0.2", "2500", "", "", "false", "join_p95_ms 2500 > 2000, error_rate 0.2"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("expected %d rows, got %d: %v", len(want), len(rows), rows)
+	}
+	for i := range want {
+		if strings.Join(rows[i], "|") != strings.Join(want[i], "|") {
+			t.Fatalf("row %d: expected %v, got %v", i, want[i], rows[i])
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -47,6 +48,11 @@ func generateRoomIDLocal(secret string, env string) (string, error) {
 	return base64.RawURLEncoding.EncodeToString(token), nil
 }
 
+// errRoomIDNotConfigured marks the server's own 503 for a missing room ID
+// secret, so retrying cannot help. Timeouts and proxies also answer 503, hence
+// the body check.
+var errRoomIDNotConfigured = errors.New("room-id endpoint not configured")
+
 func createRoomIDHTTP(ctx context.Context, baseURL string, client *http.Client) (string, error) {
 	url := strings.TrimRight(strings.TrimSpace(baseURL), "/") + "/api/room-id"
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
@@ -62,7 +68,11 @@ func createRoomIDHTTP(ctx context.Context, baseURL string, client *http.Client)
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
-		return "", fmt.Errorf("room-id endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		err := fmt.Errorf("room-id endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		if resp.StatusCode == http.StatusServiceUnavailable && strings.Contains(string(body), "not configured") {
+			return "", fmt.Errorf("%w: %w", errRoomIDNotConfigured, err)
+		}
+		return "", err
 	}
 
 	var payload struct {
@@ -100,7 +110,7 @@ func generateRoomIDs(ctx context.Context, cfg Config, count int) ([]string, erro
 		var err error
 		for attempt := 0; attempt < 3; attempt++ {
 			roomID, err = createRoomIDHTTP(ctx, cfg.BaseURL, httpClient)
-			if err == nil {
+			if err == nil || errors.Is(err, errRoomIDNotConfigured) {
 				break
 			}
 			select {
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestGenerateRoomIDsStopsOnUnconfiguredServer(t *testing.T) {
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "Room ID service not configured", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	if _, err := generateRoomIDs(context.Background(), Config{BaseURL: server.URL}, 1); err == nil {
+		t.Fatal("expected an error from an unconfigured server")
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("expected no retries against an unconfigured server, got %d calls", calls.Load())
+	}
+}
+
+func TestGenerateRoomIDsRetriesTransientFailure(t *testing.T) {
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			http.Error(w, "Room ID generation failed", http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`{"roomId":"abc"}`))
+	}))
+	defer server.Close()
+
+	ids, err := generateRoomIDs(context.Background(), Config{BaseURL: server.URL}, 1)
+	if err != nil || len(ids) != 1 || ids[0] != "abc" {
+		t.Fatalf("expected retry to succeed, got %v, %v", ids, err)
+	}
+}
@@ -4,14 +4,46 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"net/http"
+	"os"
 	"sync"
 	"time"
 )
 
-type roomPair struct {
-	roomID string
-	host   *loadClient
-	peer   *loadClient
+// roomGroup is one room's clients. members[0] joins first and hosts the
+// room; paired rooms have two members, mesh rooms cfg.RoomSize.
+type roomGroup struct {
+	roomID  string
+	members []*loadClient
+}
+
+func newRoomGroup(cfg Config, firstID int, roomID string, metrics *StepMetrics) roomGroup {
+	size := cfg.clientsPerRoom()
+	group := roomGroup{roomID: roomID, members: make([]*loadClient, 0, size)}
+	for i := 0; i < size; i++ {
+		c := newLoadClient(firstID+i, roomID, cfg.WSURL, time.Duration(cfg.JoinTimeoutSeconds)*time.Second, metrics)
+		if cfg.RoomsMode == "mesh" {
+			c.roomSize = size
+		}
+		if cfg.Transport == transportSSE {
+			c.sseURL = cfg.SSEURL
+		}
+		group.members = append(group.members, c)
+	}
+	return group
+}
+
+func (g roomGroup) host() *loadClient {
+	return g.members[0]
+}
+
+// relaySenders are the members that relay on each tick: the host in paired
+// rooms, and every member in mesh rooms so ICE fans out from all of them.
+func (g roomGroup) relaySenders(cfg Config) []*loadClient {
+	if cfg.RoomsMode == "mesh" {
+		return g.members
+	}
+	return g.members[:1]
 }
 
 func runSweep(ctx context.Context, cfg Config) (SweepReport, error) {
@@ -28,6 +60,7 @@ func runSweep(ctx context.Context, cfg Config) (SweepReport, error) {
 	lastPassing := 0
 	stoppedAt := 0
 	finalReason := "max clients reached"
+	sloFailed := false
 
 	for target := cfg.StartClients; target <= cfg.MaxClients; target += cfg.StepClients {
 		stepResult, err := runStep(ctx, cfg, target, statsClient, rng)
@@ -57,6 +90,7 @@ func runSweep(ctx context.Context, cfg Config) (SweepReport, error) {
 		} else {
 			finalReason = "SLO threshold failed"
 		}
+		sloFailed = true
 		break
 	}
 
@@ -67,6 +101,12 @@ func runSweep(ctx context.Context, cfg Config) (SweepReport, error) {
 	report.LastPassingClients = lastPassing
 	report.StoppedAtClients = stoppedAt
 	report.FinalReason = finalReason
+	if sloFailed && cfg.Search == searchBisect {
+		bisectCapacity(&report, cfg, lastPassing, stoppedAt, func(targetClients int) (StepResult, error) {
+			return runStep(ctx, cfg, targetClients, statsClient, rng)
+		})
+	}
+	report.RecommendedProfile = buildRecommendedProfile(report)
 
 	return report, nil
 }
@@ -76,14 +116,17 @@ func runStep(parent context.Context, cfg Config, requestedClients int, statsClie
 	stepCtx, cancel := context.WithCancel(parent)
 	defer cancel()
 
-	targetClients := requestedClients
-	if targetClients%2 != 0 {
-		targetClients--
-	}
+	roomSize := cfg.clientsPerRoom()
+	targetClients := requestedClients - requestedClients%roomSize
 	if targetClients <= 0 {
-		targetClients = 2
+		targetClients = roomSize
+	}
+	targetRooms := targetClients / roomSize
+
+	dist, err := parseCallDurationDist(cfg.CallDurationDist)
+	if err != nil {
+		return StepResult{TargetClients: targetClients, TargetRooms: targetRooms, StartedAtRFC3339: started.UTC().Format(time.RFC3339), EndedAtRFC3339: time.Now().UTC().Format(time.RFC3339), DurationSeconds: int64(time.Since(started).Seconds()), FailReason: fmt.Sprintf("invalid call duration distribution: %v", err)}, err
 	}
-	targetRooms := targetClients / 2
 
 	metrics := &StepMetrics{}
 	var serverStatsStart InternalStatsSnapshot
@@ -98,14 +141,21 @@ func runStep(parent context.Context, cfg Config, requestedClients int, statsClie
 	}
 	serverStatsStart, startStatsErr = fetchStats(stepCtx, statsClient)
 
-	pairs := make([]roomPair, 0, targetRooms)
+	groups := make([]roomGroup, 0, targetRooms)
 	clients := make([]*loadClient, 0, targetClients)
 	for i := 0; i < targetRooms; i++ {
-		host := newLoadClient(i*2, roomIDs[i], cfg.WSURL, time.Duration(cfg.JoinTimeoutSeconds)*time.Second, metrics)
-		peer := newLoadClient(i*2+1, roomIDs[i], cfg.WSURL, time.Duration(cfg.JoinTimeoutSeconds)*time.Second, metrics)
-		pairs = append(pairs, roomPair{roomID: roomIDs[i], host: host, peer: peer})
-		clients = append(clients, host, peer)
+		group := newRoomGroup(cfg, i*roomSize, roomIDs[i], metrics)
+		groups = append(groups, group)
+		clients = append(clients, group.members...)
+	}
+
+	// Clients that exchange their turnToken after the initial join.
+	turnChecked := make(map[*loadClient]bool)
+	for _, c := range pickPercent(clients, cfg.TurnCheckPercent, rng) {
+		turnChecked[c] = true
 	}
+	turnHTTP := &http.Client{Timeout: turnCheckTimeout}
+	turnURL := turnCredentialsURL(cfg.BaseURL)
 
 	var rampWG sync.WaitGroup
 	rampInterval := time.Duration(0)
@@ -129,7 +179,9 @@ rampLoop:
 			defer rampWG.Done()
 			joinCtx, joinCancel := context.WithTimeout(stepCtx, time.Duration(cfg.JoinTimeoutSeconds)*time.Second)
 			defer joinCancel()
-			_ = c.connectAndJoin(joinCtx, "")
+			if err := c.connectAndJoin(joinCtx, ""); err == nil && turnChecked[c] {
+				c.checkTurnCredentials(stepCtx, turnHTTP, turnURL)
+			}
 		}(client)
 	}
 	rampWG.Wait()
@@ -148,7 +200,14 @@ rampLoop:
 		}, err
 	}
 
-	relayCancel, relayWG := startRelayLoops(stepCtx, cfg, pairs)
+	churn := &churnPool{cfg: cfg, metrics: metrics, nextID: targetClients}
+	var relayCancel context.CancelFunc
+	var relayWG *sync.WaitGroup
+	if dist.enabled() {
+		relayCancel, relayWG = startChurnLoops(stepCtx, cfg, dist, groups, churn, rng)
+	} else {
+		relayCancel, relayWG = startRelayLoops(stepCtx, cfg, groups, rng)
+	}
 	defer func() {
 		relayCancel()
 		relayWG.Wait()
@@ -164,17 +223,37 @@ rampLoop:
 				return
 			case <-stormTimer.C:
 				selected := pickReconnectClients(clients, cfg.ReconnectStormPercent, rng)
-				for _, c := range selected {
-					reconnectWG.Add(1)
-					go func(client *loadClient) {
-						defer reconnectWG.Done()
-						reconnectCtx, reconnectCancel := context.WithTimeout(stepCtx, time.Duration(cfg.JoinTimeoutSeconds)*time.Second)
-						defer reconnectCancel()
-						_ = client.reconnect(reconnectCtx)
-					}(c)
-				}
+				startReconnectStorm(stepCtx, selected, cfg.ReconnectConcurrency, time.Duration(cfg.JoinTimeoutSeconds)*time.Second, metrics, reconnectWG)
+			}
+		}()
+	}
+
+	hostTransferDone := make(chan *sync.WaitGroup, 1)
+	if cfg.HostTransferPercent > 0 && cfg.HostTransferAtSecond < cfg.SteadySeconds {
+		// Rooms are drawn now: rng is not safe to share with the storm goroutine.
+		selected := pickPercent(groups, cfg.HostTransferPercent, rng)
+		transferTimer := time.NewTimer(time.Duration(cfg.HostTransferAtSecond) * time.Second)
+		go func() {
+			defer transferTimer.Stop()
+			select {
+			case <-stepCtx.Done():
+				hostTransferDone <- &sync.WaitGroup{}
+			case <-transferTimer.C:
+				hostTransferDone <- startHostTransfers(stepCtx, selected, time.Duration(cfg.JoinTimeoutSeconds)*time.Second, metrics)
 			}
 		}()
+	} else {
+		hostTransferDone <- &sync.WaitGroup{}
+	}
+
+	// Server CPU profiles are keyed by the step's target client count.
+	profiling := false
+	if cfg.ProfileSteps {
+		if _, err := profileStep(statsClient, "start", targetClients); err != nil {
+			fmt.Fprintf(os.Stderr, "step %d: server CPU profile not started: %v\n", targetClients, err)
+		} else {
+			profiling = true
+		}
 	}
 
 	steadyTimer := time.NewTimer(time.Duration(cfg.SteadySeconds) * time.Second)
@@ -184,15 +263,26 @@ rampLoop:
 	case <-steadyTimer.C:
 	}
 
+	serverProfile := ""
+	if profiling {
+		path, err := profileStep(statsClient, "stop", targetClients)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "step %d: server CPU profile not written: %v\n", targetClients, err)
+		}
+		serverProfile = path
+	}
+
 	relayCancel()
 	relayWG.Wait()
 	reconnectWG.Wait()
+	(<-hostTransferDone).Wait()
 
 	serverStatsEnd, endStatsErr := fetchStats(stepCtx, statsClient)
 
 	for _, client := range clients {
 		client.leaveAndClose()
 	}
+	churn.closeAll()
 	if cfg.CooldownSeconds > 0 {
 		select {
 		case <-stepCtx.Done():
@@ -203,12 +293,19 @@ rampLoop:
 	ended := time.Now()
 	result := metrics.ToStepResult(targetClients, targetRooms, started, ended)
 	result.ServerStatsAvailable = startStatsErr == nil && endStatsErr == nil
+	result.ServerProfile = serverProfile
 	if result.ServerStatsAvailable {
-		result.SendQueueDropDelta = serverStatsEnd.Counters.SendQueueDropTotal - serverStatsStart.Counters.SendQueueDropTotal
-		if result.SendQueueDropDelta < 0 {
-			result.SendQueueDropDelta = 0
-		}
+		result.ServerDeltas = diffServerCounters(serverStatsStart, serverStatsEnd)
+		result.SendQueueDropDelta = result.ServerDeltas["sendQueueDropTotal"]
 		result.ServerJoinP95Ms = estimateJoinP95DeltaMs(serverStatsStart, serverStatsEnd)
+		result.ServerGauges = &ServerGaugeSample{
+			ActiveClients:      serverStatsEnd.Gauges.ActiveClients,
+			ActiveRooms:        serverStatsEnd.Gauges.ActiveRooms,
+			Goroutines:         serverStatsEnd.Runtime.Goroutines,
+			HeapInuse:          serverStatsEnd.Runtime.HeapInuse,
+			SendQueueSize:      serverStatsEnd.SendQueueSize,
+			SendQueueHighWater: serverStatsEnd.SendQueueHighWater,
+		}
 	}
 
 	result = evaluateStep(cfg, result)
@@ -262,21 +359,20 @@ func waitForServerStabilization(ctx context.Context, cfg Config, client *StatsCl
 	}
 }
 
-func startRelayLoops(ctx context.Context, cfg Config, rooms []roomPair) (context.CancelFunc, *sync.WaitGroup) {
+func startRelayLoops(ctx context.Context, cfg Config, rooms []roomGroup, rng *rand.Rand) (context.CancelFunc, *sync.WaitGroup) {
 	relayCtx, cancel := context.WithCancel(ctx)
 	wg := &sync.WaitGroup{}
 
-	if cfg.OfferRatePerRoom <= 0 {
+	interval := relayInterval(cfg)
+	if interval <= 0 {
 		return cancel, wg
 	}
 
-	interval := time.Duration(float64(time.Second) / cfg.OfferRatePerRoom)
-	if interval < 50*time.Millisecond {
-		interval = 50 * time.Millisecond
-	}
-
 	for _, room := range rooms {
 		r := room
+		// Per-room RNGs drawn in order keep relay fault injection reproducible
+		// for a given seed.
+		roomRNG := rand.New(rand.NewSource(rng.Int63()))
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
@@ -289,7 +385,9 @@ func startRelayLoops(ctx context.Context, cfg Config, rooms []roomPair) (context
 					return
 				case <-ticker.C:
 					counter++
-					_ = r.host.sendRelayICE(counter)
+					for _, sender := range r.relaySenders(cfg) {
+						sender.sendRelay(relayCtx, cfg, roomRNG, counter)
+					}
 				}
 			}
 		}()
@@ -298,19 +396,38 @@ func startRelayLoops(ctx context.Context, cfg Config, rooms []roomPair) (context
 	return cancel, wg
 }
 
+// relayInterval is the per-room relay period for cfg.OfferRatePerRoom, or 0
+// when relaying is disabled.
+func relayInterval(cfg Config) time.Duration {
+	if cfg.OfferRatePerRoom <= 0 {
+		return 0
+	}
+	interval := time.Duration(float64(time.Second) / cfg.OfferRatePerRoom)
+	if interval < 50*time.Millisecond {
+		interval = 50 * time.Millisecond
+	}
+	return interval
+}
+
 func pickReconnectClients(clients []*loadClient, percent float64, rng *rand.Rand) []*loadClient {
-	if percent <= 0 || len(clients) == 0 {
+	return pickPercent(clients, percent, rng)
+}
+
+// pickPercent returns a random percent of items (at least one when percent is
+// positive).
+func pickPercent[T any](items []T, percent float64, rng *rand.Rand) []T {
+	if percent <= 0 || len(items) == 0 {
 		return nil
 	}
-	count := int(float64(len(clients))*percent/100.0 + 0.5)
+	count := int(float64(len(items))*percent/100.0 + 0.5)
 	if count <= 0 {
 		count = 1
 	}
-	if count > len(clients) {
-		count = len(clients)
+	if count > len(items) {
+		count = len(items)
 	}
 
-	indices := make([]int, len(clients))
+	indices := make([]int, len(items))
 	for i := range indices {
 		indices[i] = i
 	}
@@ -318,9 +435,9 @@ func pickReconnectClients(clients []*loadClient, percent float64, rng *rand.Rand
 		indices[i], indices[j] = indices[j], indices[i]
 	})
 
-	selected := make([]*loadClient, 0, count)
+	selected := make([]T, 0, count)
 	for i := 0; i < count; i++ {
-		selected = append(selected, clients[indices[i]])
+		selected = append(selected, items[indices[i]])
 	}
 	return selected
 }
@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestNewRoomGroupSizesAndRelaySenders(t *testing.T) {
+	metrics := &StepMetrics{}
+
+	paired := Config{RoomsMode: "paired", RoomSize: 2, WSURL: "ws://example.invalid/ws", JoinTimeoutSeconds: 1}
+	group := newRoomGroup(paired, 10, "room-a", metrics)
+	if len(group.members) != 2 || group.host().id != 10 || group.members[1].id != 11 {
+		t.Fatalf("unexpected paired group: %+v", group.members)
+	}
+	if senders := group.relaySenders(paired); len(senders) != 1 || senders[0] != group.host() {
+		t.Fatalf("expected only the host to relay in paired rooms, got %d senders", len(senders))
+	}
+	if group.host().roomSize != 0 {
+		t.Fatalf("expected paired clients to keep the default room capacity, got %d", group.host().roomSize)
+	}
+
+	mesh := Config{RoomsMode: "mesh", RoomSize: 4, WSURL: "ws://example.invalid/ws", JoinTimeoutSeconds: 1}
+	group = newRoomGroup(mesh, 0, "room-b", metrics)
+	if len(group.members) != 4 || len(group.relaySenders(mesh)) != 4 {
+		t.Fatalf("expected 4 members all relaying, got %d members", len(group.members))
+	}
+	for _, member := range group.members {
+		if member.roomID != "room-b" || member.roomSize != 4 {
+			t.Fatalf("unexpected mesh member %+v", member)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import "fmt"
+
+const (
+	searchLinear = "linear"
+	searchBisect = "bisect"
+)
+
+// SearchProbe is one --search=bisect step: the concurrency tried, whether it
+// passed, and the passing/failing bracket that remained afterwards.
+type SearchProbe struct {
+	TargetClients int  `json:"targetClients"`
+	Passed        bool `json:"passed"`
+	LowClients    int  `json:"lowClients"`
+	HighClients   int  `json:"highClients"`
+}
+
+// nextBisectTarget returns the client count halfway between a passing low and
+// a failing high, in whole rooms, or 0 once they are within tolerance (at
+// least one room) of each other.
+func nextBisectTarget(low, high, roomSize, tolerance int) int {
+	if high-low <= max(tolerance, roomSize) {
+		return 0
+	}
+	lowRooms, highRooms := low/roomSize, high/roomSize
+	mid := (lowRooms + highRooms) / 2
+	if mid <= lowRooms || mid >= highRooms {
+		return 0
+	}
+	return mid * roomSize
+}
+
+// bisectCapacity narrows the bracket left by the linear ramp, running steps
+// between the last passing (low) and first failing (high) client counts. It
+// appends each step and probe to report and leaves LastPassingClients and
+// StoppedAtClients at the final bracket. A step that errors out ends the
+// search without moving the bracket.
+func bisectCapacity(report *SweepReport, cfg Config, low, high int, run func(targetClients int) (StepResult, error)) {
+	for {
+		target := nextBisectTarget(low, high, cfg.clientsPerRoom(), cfg.SearchTolerance)
+		if target == 0 {
+			break
+		}
+
+		step, err := run(target)
+		if err != nil {
+			step.Passed = false
+			if step.FailReason == "" {
+				step.FailReason = err.Error()
+			}
+			report.Steps = append(report.Steps, step)
+			printStepResult(step, true)
+			report.FinalReason = fmt.Sprintf("search interrupted: %s", step.FailReason)
+			break
+		}
+
+		report.Steps = append(report.Steps, step)
+		printStepResult(step, true)
+		if step.Passed {
+			low = step.TargetClients
+		} else {
+			high = step.TargetClients
+			report.FinalReason = step.FailReason
+			if report.FinalReason == "" {
+				report.FinalReason = "SLO threshold failed"
+			}
+		}
+		report.SearchTrajectory = append(report.SearchTrajectory, SearchProbe{
+			TargetClients: step.TargetClients,
+			Passed:        step.Passed,
+			LowClients:    low,
+			HighClients:   high,
+		})
+	}
+
+	report.LastPassingClients = low
+	report.StoppedAtClients = high
+}
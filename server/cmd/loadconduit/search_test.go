@@ -0,0 +1,78 @@
+package main
+
+import "testing"
+
+func TestNextBisectTarget(t *testing.T) {
+	cases := []struct {
+		low, high, roomSize, tolerance, want int
+	}{
+		{40, 60, 2, 0, 50},
+		{40, 50, 2, 0, 44},
+		{40, 42, 2, 0, 0},
+		{40, 50, 2, 10, 0},
+		{0, 20, 2, 0, 10},
+		{0, 2, 2, 0, 0},
+		{12, 24, 4, 0, 16},
+		{16, 24, 4, 0, 20},
+		{20, 24, 4, 0, 0},
+	}
+	for _, tc := range cases {
+		if got := nextBisectTarget(tc.low, tc.high, tc.roomSize, tc.tolerance); got != tc.want {
+			t.Errorf("nextBisectTarget(%d, %d, %d, %d) = %d, want %d", tc.low, tc.high, tc.roomSize, tc.tolerance, got, tc.want)
+		}
+	}
+}
+
+func TestBisectCapacityNarrowsToTolerance(t *testing.T) {
+	cfg := Config{RoomsMode: "paired", RoomSize: 2, Search: searchBisect}
+	report := SweepReport{LastPassingClients: 40, StoppedAtClients: 60, FinalReason: "error_rate too high"}
+
+	var tried []int
+	bisectCapacity(&report, cfg, 40, 60, func(targetClients int) (StepResult, error) {
+		tried = append(tried, targetClients)
+		passed := targetClients <= 47
+		result := StepResult{TargetClients: targetClients, Passed: passed}
+		if !passed {
+			result.FailReason = "join_p95_ms over threshold"
+		}
+		return result, nil
+	})
+
+	wantTried := []int{50, 44, 46, 48}
+	if len(tried) != len(wantTried) {
+		t.Fatalf("expected probes %v, got %v", wantTried, tried)
+	}
+	for i := range wantTried {
+		if tried[i] != wantTried[i] {
+			t.Fatalf("expected probes %v, got %v", wantTried, tried)
+		}
+	}
+	if report.LastPassingClients != 46 || report.StoppedAtClients != 48 {
+		t.Fatalf("expected bracket [46, 48], got [%d, %d]", report.LastPassingClients, report.StoppedAtClients)
+	}
+	if len(report.Steps) != 4 || len(report.SearchTrajectory) != 4 {
+		t.Fatalf("expected 4 steps and probes, got %d and %d", len(report.Steps), len(report.SearchTrajectory))
+	}
+	last := report.SearchTrajectory[3]
+	if last.TargetClients != 48 || last.Passed || last.LowClients != 46 || last.HighClients != 48 {
+		t.Fatalf("unexpected final probe %+v", last)
+	}
+	if report.FinalReason != "join_p95_ms over threshold" {
+		t.Fatalf("expected final reason from the lowest failing step, got %q", report.FinalReason)
+	}
+}
+
+func TestParseConfigSearchMode(t *testing.T) {
+	cfg, err := parseConfig([]string{"--base-url", "http://localhost"})
+	if err != nil || cfg.Search != searchLinear {
+		t.Fatalf("expected linear search by default, got %q (err %v)", cfg.Search, err)
+	}
+	if _, err := parseConfig([]string{"--base-url", "http://localhost", "--search", "bisect", "--search-tolerance", "4"}); err != nil {
+		t.Fatalf("expected valid bisect config, got error: %v", err)
+	}
+	for _, args := range [][]string{{"--search", "golden"}, {"--search", "bisect", "--search-tolerance", "-1"}} {
+		if _, err := parseConfig(append([]string{"--base-url", "http://localhost"}, args...)); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	transportWS  = "ws"
+	transportSSE = "sse"
+)
+
+const (
+	signalDialTimeout  = 10 * time.Second
+	signalWriteTimeout = 5 * time.Second
+)
+
+// signalConn is one signaling connection as seen by loadClient: a WebSocket,
+// or an SSE stream for inbound messages plus a POST per outbound one.
+type signalConn interface {
+	// read blocks for the next inbound message.
+	read() ([]byte, error)
+	// write sends one frame; loadClient serializes calls.
+	write(frame []byte) error
+	Close() error
+}
+
+type wsSignalConn struct {
+	conn *websocket.Conn
+}
+
+func dialWSSignal(ctx context.Context, wsURL string) (signalConn, error) {
+	dialer := websocket.Dialer{HandshakeTimeout: signalDialTimeout}
+	conn, _, err := dialer.DialContext(ctx, wsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &wsSignalConn{conn: conn}, nil
+}
+
+func (w *wsSignalConn) read() ([]byte, error) {
+	_, payload, err := w.conn.ReadMessage()
+	return payload, err
+}
+
+func (w *wsSignalConn) write(frame []byte) error {
+	if err := w.conn.SetWriteDeadline(time.Now().Add(signalWriteTimeout)); err != nil {
+		return err
+	}
+	return w.conn.WriteMessage(websocket.TextMessage, frame)
+}
+
+func (w *wsSignalConn) Close() error {
+	return w.conn.Close()
+}
+
+// sseHTTPClient is shared by every SSE load client so POSTs reuse
+// connections instead of exhausting ephemeral ports.
+var sseHTTPClient = func() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = 1024
+	return &http.Client{Transport: transport}
+}()
+
+// sseSignalConn drives the server's SSE endpoint: GET <sseURL>?sid= streams
+// messages and POST <sseURL>?sid= sends them. Each connection uses a fresh
+// sid, as a new WebSocket would.
+type sseSignalConn struct {
+	sseURL string
+	cancel context.CancelFunc
+	body   io.ReadCloser
+	reader *bufio.Reader
+
+	mu  sync.Mutex
+	sid string
+}
+
+func dialSSESignal(ctx context.Context, sseURL string) (signalConn, error) {
+	sid, err := newSSESessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	// The stream must outlive ctx, which only bounds the dial.
+	streamCtx, cancel := context.WithCancel(context.Background())
+	stop := context.AfterFunc(ctx, cancel)
+	timer := time.AfterFunc(signalDialTimeout, cancel)
+
+	req, err := http.NewRequestWithContext(streamCtx, http.MethodGet, sseSessionURL(sseURL, sid), nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := sseHTTPClient.Do(req)
+	dialCanceled := !stop() || !timer.Stop()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if dialCanceled {
+		resp.Body.Close()
+		cancel()
+		return nil, errors.New("sse dial canceled")
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("sse stream returned %s", resp.Status)
+	}
+
+	return &sseSignalConn{
+		sseURL: sseURL,
+		cancel: cancel,
+		body:   resp.Body,
+		reader: bufio.NewReaderSize(resp.Body, 64*1024),
+		sid:    sid,
+	}, nil
+}
+
+func newSSESessionID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "S-" + hex.EncodeToString(b), nil
+}
+
+func sseSessionURL(sseURL, sid string) string {
+	return sseURL + "?sid=" + url.QueryEscape(sid)
+}
+
+// read returns the data of the next SSE event, skipping comments (": ready",
+// ": ping") and event IDs. A session_renewed message moves later POSTs to the
+// new sid.
+func (s *sseSignalConn) read() ([]byte, error) {
+	var data []byte
+	for {
+		line, err := s.reader.ReadBytes('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		line = bytes.TrimRight(line, "\r\n")
+		if len(line) == 0 {
+			if data == nil {
+				continue
+			}
+			s.observeSessionRenewed(data)
+			return data, nil
+		}
+		if value, ok := bytes.CutPrefix(line, []byte("data:")); ok {
+			value = bytes.TrimPrefix(value, []byte(" "))
+			if data != nil {
+				data = append(data, '\n')
+			}
+			data = append(data, value...)
+		}
+	}
+}
+
+func (s *sseSignalConn) observeSessionRenewed(data []byte) {
+	if !bytes.Contains(data, []byte(`"session_renewed"`)) {
+		return
+	}
+	var msg struct {
+		Type    string `json:"type"`
+		Payload struct {
+			SID string `json:"sid"`
+		} `json:"payload"`
+	}
+	if json.Unmarshal(data, &msg) != nil || msg.Type != "session_renewed" || msg.Payload.SID == "" {
+		return
+	}
+	s.mu.Lock()
+	s.sid = msg.Payload.SID
+	s.mu.Unlock()
+}
+
+func (s *sseSignalConn) write(frame []byte) error {
+	s.mu.Lock()
+	sid := s.sid
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), signalWriteTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sseSessionURL(s.sseURL, sid), bytes.NewReader(frame))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := sseHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("sse post returned %s", resp.Status)
+	}
+	return nil
+}
+
+func (s *sseSignalConn) Close() error {
+	s.cancel()
+	return s.body.Close()
+}
+
+// sseURLFromBase derives the SSE endpoint from --base-url.
+func sseURLFromBase(baseURL string) string {
+	return strings.TrimRight(baseURL, "/") + "/sse"
+}
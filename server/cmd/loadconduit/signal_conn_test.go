@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSSEServer answers join with joined and echoes ice back on the sender's
+// stream, which is enough to drive loadClient over SSE.
+type fakeSSEServer struct {
+	mu      sync.Mutex
+	streams map[string]chan []byte
+	posts   []string
+}
+
+func newFakeSSEServer() *fakeSSEServer {
+	return &fakeSSEServer{streams: make(map[string]chan []byte)}
+}
+
+func (f *fakeSSEServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	sid := r.URL.Query().Get("sid")
+	switch r.Method {
+	case http.MethodGet:
+		stream := make(chan []byte, 16)
+		f.mu.Lock()
+		f.streams[sid] = stream
+		f.mu.Unlock()
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": ready\n\n")
+		w.(http.Flusher).Flush()
+		for id := 1; ; id++ {
+			select {
+			case <-r.Context().Done():
+				return
+			case msg := <-stream:
+				fmt.Fprintf(w, "id: %d\ndata: %s\n\n", id, msg)
+				w.(http.Flusher).Flush()
+			}
+		}
+	case http.MethodPost:
+		body, _ := io.ReadAll(r.Body)
+		var msg signalingEnvelope
+		if err := json.Unmarshal(body, &msg); err != nil {
+			http.Error(w, "bad json", http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		f.posts = append(f.posts, msg.Type)
+		stream := f.streams[sid]
+		f.mu.Unlock()
+		if stream == nil {
+			http.Error(w, "Unknown SSE session", http.StatusGone)
+			return
+		}
+		switch msg.Type {
+		case "join":
+			stream <- mustRawJSON(signalingEnvelope{V: 1, Type: "joined", RID: msg.RID, CID: "C-" + sid, Payload: mustRawJSON(map[string]any{"turnToken": "tok"})})
+		case "ice":
+			stream <- body
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (f *fakeSSEServer) postTypes() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.posts...)
+}
+
+func TestLoadClientJoinsRelaysAndReconnectsOverSSE(t *testing.T) {
+	fake := newFakeSSEServer()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	metrics := &StepMetrics{}
+	c := newLoadClient(1, "room", "", time.Second, metrics)
+	c.sseURL = sseURLFromBase(srv.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.connectAndJoin(ctx, ""); err != nil {
+		t.Fatalf("join over SSE failed: %v", err)
+	}
+	firstCID := c.cid()
+	if !strings.HasPrefix(firstCID, "C-S-") || c.turnToken() != "tok" {
+		t.Fatalf("unexpected join result cid=%q token=%q", firstCID, c.turnToken())
+	}
+
+	if err := c.sendRelayICE(1); err != nil {
+		t.Fatalf("relay over SSE failed: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for metrics.relayReceived.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if metrics.relaySent.Load() != 1 || metrics.relayReceived.Load() != 1 {
+		t.Fatalf("expected 1 relay sent and received, got %d/%d", metrics.relaySent.Load(), metrics.relayReceived.Load())
+	}
+
+	if err := c.reconnect(ctx); err != nil {
+		t.Fatalf("reconnect over SSE failed: %v", err)
+	}
+	if c.cid() == firstCID {
+		t.Fatal("expected the reconnect to open a new SSE session")
+	}
+	c.leaveAndClose()
+
+	if got := strings.Join(fake.postTypes(), ","); got != "join,ice,join,leave" {
+		t.Fatalf("unexpected POST sequence %q", got)
+	}
+	if metrics.joinSuccess.Load() != 2 || metrics.reconnectSuccess.Load() != 1 || metrics.unexpectedDisconnect.Load() != 0 {
+		t.Fatalf("unexpected metrics: joins=%d reconnects=%d unexpected=%d", metrics.joinSuccess.Load(), metrics.reconnectSuccess.Load(), metrics.unexpectedDisconnect.Load())
+	}
+}
+
+func TestParseConfigTransport(t *testing.T) {
+	cfg, err := parseConfig([]string{"--base-url", "http://localhost:8080/", "--transport", "sse"})
+	if err != nil {
+		t.Fatalf("expected valid sse config, got error: %v", err)
+	}
+	if cfg.SSEURL != "http://localhost:8080/sse" {
+		t.Fatalf("unexpected SSE URL %q", cfg.SSEURL)
+	}
+	for _, args := range [][]string{{"--transport", "quic"}, {"--transport", "sse", "--malformed-rate", "0.1"}} {
+		if _, err := parseConfig(append([]string{"--base-url", "http://localhost"}, args...)); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
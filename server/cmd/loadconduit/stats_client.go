@@ -8,26 +8,50 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+
+	"serenada/server/internal/stats"
 )
 
 type InternalStatsSnapshot struct {
-	TimestampMs int64 `json:"timestampMs"`
+	TimestampMs        int64 `json:"timestampMs"`
+	SendQueueSize      int   `json:"sendQueueSize"`
+	SendQueueHighWater int   `json:"sendQueueHighWater"`
 
 	Gauges struct {
 		ActiveClients    int64 `json:"activeClients"`
 		ActiveWSClients  int64 `json:"activeWsClients"`
 		ActiveSSEClients int64 `json:"activeSseClients"`
+		ActiveRooms      int64 `json:"activeRooms"`
 	} `json:"gauges"`
 
 	Counters struct {
-		SendQueueDropTotal int64 `json:"sendQueueDropTotal"`
+		ConnectionAttemptsWS  int64 `json:"connectionAttemptsWs"`
+		ConnectionSuccessWS   int64 `json:"connectionSuccessWs"`
+		ConnectionFailuresWS  int64 `json:"connectionFailuresWs"`
+		ConnectionAttemptsSSE int64 `json:"connectionAttemptsSse"`
+		ConnectionSuccessSSE  int64 `json:"connectionSuccessSse"`
+		ConnectionFailuresSSE int64 `json:"connectionFailuresSse"`
+		SendQueueDropTotal    int64 `json:"sendQueueDropTotal"`
+		SendAfterCloseTotal   int64 `json:"sendAfterCloseTotal"`
 	} `json:"counters"`
 
+	Messages struct {
+		RxTotal int64 `json:"rxTotal"`
+		TxTotal int64 `json:"txTotal"`
+	} `json:"messages"`
+
+	Disconnects map[string]int64 `json:"disconnects"`
+
 	JoinLatency struct {
 		BoundariesMs []int64 `json:"boundariesMs"`
 		BucketCounts []int64 `json:"bucketCounts"`
 		Total        int64   `json:"total"`
 	} `json:"joinLatency"`
+
+	Runtime struct {
+		Goroutines int    `json:"goroutines"`
+		HeapInuse  uint64 `json:"heapInuse"`
+	} `json:"runtime"`
 }
 
 type StatsClient struct {
@@ -50,11 +74,20 @@ func (c *StatsClient) endpointURL() (string, error) {
 	if strings.HasPrefix(c.statsURL, "http://") || strings.HasPrefix(c.statsURL, "https://") {
 		return c.statsURL, nil
 	}
-	base, err := url.Parse(c.baseURL)
+	return c.internalURL(c.statsURL)
+}
+
+// internalURL resolves path against the server the stats endpoint lives on:
+// the absolute stats URL's host if one was given, otherwise the base URL.
+func (c *StatsClient) internalURL(path string) (string, error) {
+	origin := c.baseURL
+	if strings.HasPrefix(c.statsURL, "http://") || strings.HasPrefix(c.statsURL, "https://") {
+		origin = c.statsURL
+	}
+	base, err := url.Parse(origin)
 	if err != nil {
 		return "", err
 	}
-	path := c.statsURL
 	if !strings.HasPrefix(path, "/") {
 		path = "/" + path
 	}
@@ -116,6 +149,36 @@ func parseInternalStatsSnapshot(raw []byte) (InternalStatsSnapshot, error) {
 	return snapshot, nil
 }
 
+// diffServerCounters returns end-start for every server counter, keyed by the
+// counter's stats JSON name (disconnect reasons as "disconnects.<reason>").
+// Negative deltas, e.g. across a server restart, are clamped to zero.
+func diffServerCounters(start, end InternalStatsSnapshot) map[string]int64 {
+	deltas := make(map[string]int64)
+	put := func(key string, startValue, endValue int64) {
+		d := endValue - startValue
+		if d < 0 {
+			d = 0
+		}
+		deltas[key] = d
+	}
+
+	put("connectionAttemptsWs", start.Counters.ConnectionAttemptsWS, end.Counters.ConnectionAttemptsWS)
+	put("connectionSuccessWs", start.Counters.ConnectionSuccessWS, end.Counters.ConnectionSuccessWS)
+	put("connectionFailuresWs", start.Counters.ConnectionFailuresWS, end.Counters.ConnectionFailuresWS)
+	put("connectionAttemptsSse", start.Counters.ConnectionAttemptsSSE, end.Counters.ConnectionAttemptsSSE)
+	put("connectionSuccessSse", start.Counters.ConnectionSuccessSSE, end.Counters.ConnectionSuccessSSE)
+	put("connectionFailuresSse", start.Counters.ConnectionFailuresSSE, end.Counters.ConnectionFailuresSSE)
+	put("sendQueueDropTotal", start.Counters.SendQueueDropTotal, end.Counters.SendQueueDropTotal)
+	put("sendAfterCloseTotal", start.Counters.SendAfterCloseTotal, end.Counters.SendAfterCloseTotal)
+	put("messagesRxTotal", start.Messages.RxTotal, end.Messages.RxTotal)
+	put("messagesTxTotal", start.Messages.TxTotal, end.Messages.TxTotal)
+	for reason, value := range end.Disconnects {
+		put("disconnects."+reason, start.Disconnects[reason], value)
+	}
+
+	return deltas
+}
+
 func estimateJoinP95DeltaMs(start, end InternalStatsSnapshot) float64 {
 	if len(start.JoinLatency.BucketCounts) == 0 || len(end.JoinLatency.BucketCounts) == 0 {
 		return 0
@@ -125,37 +188,12 @@ func estimateJoinP95DeltaMs(start, end InternalStatsSnapshot) float64 {
 	}
 
 	delta := make([]int64, len(end.JoinLatency.BucketCounts))
-	var total int64
 	for i := range end.JoinLatency.BucketCounts {
 		d := end.JoinLatency.BucketCounts[i] - start.JoinLatency.BucketCounts[i]
 		if d < 0 {
 			d = 0
 		}
 		delta[i] = d
-		total += d
-	}
-	if total == 0 {
-		return 0
 	}
-
-	threshold := int64(float64(total)*0.95 + 0.999999)
-	if threshold <= 0 {
-		threshold = 1
-	}
-
-	var cumulative int64
-	for i, c := range delta {
-		cumulative += c
-		if cumulative >= threshold {
-			if i < len(end.JoinLatency.BoundariesMs) {
-				return float64(end.JoinLatency.BoundariesMs[i])
-			}
-			if len(end.JoinLatency.BoundariesMs) == 0 {
-				return 0
-			}
-			return float64(end.JoinLatency.BoundariesMs[len(end.JoinLatency.BoundariesMs)-1] + 1)
-		}
-	}
-
-	return 0
+	return stats.HistogramPercentile(end.JoinLatency.BoundariesMs, delta, 0.95)
 }
@@ -30,3 +30,35 @@ func TestEstimateJoinP95DeltaMs(t *testing.T) {
 		t.Fatalf("expected p95=200, got %.1f", p95)
 	}
 }
+
+func TestDiffServerCounters(t *testing.T) {
+	start, err := parseInternalStatsSnapshot([]byte(`{"counters":{"connectionAttemptsWs":10,"connectionSuccessSse":4,"sendQueueDropTotal":5},"messages":{"rxTotal":100,"txTotal":200},"disconnects":{"ws":2}}`))
+	if err != nil {
+		t.Fatalf("parse start: %v", err)
+	}
+	end, err := parseInternalStatsSnapshot([]byte(`{"counters":{"connectionAttemptsWs":25,"connectionSuccessSse":9,"sendQueueDropTotal":3},"messages":{"rxTotal":160,"txTotal":290},"disconnects":{"ws":7,"sse_stale":1}}`))
+	if err != nil {
+		t.Fatalf("parse end: %v", err)
+	}
+
+	deltas := diffServerCounters(start, end)
+	want := map[string]int64{
+		"connectionAttemptsWs":  15,
+		"connectionSuccessSse":  5,
+		"connectionFailuresWs":  0,
+		"sendQueueDropTotal":    0, // counter went backwards (restart) and is clamped
+		"messagesRxTotal":       60,
+		"messagesTxTotal":       90,
+		"disconnects.ws":        5,
+		"disconnects.sse_stale": 1,
+	}
+	for key, value := range want {
+		got, ok := deltas[key]
+		if !ok {
+			t.Fatalf("missing delta %q", key)
+		}
+		if got != value {
+			t.Fatalf("delta %q = %d, want %d", key, got, value)
+		}
+	}
+}
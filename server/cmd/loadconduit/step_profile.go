@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Profile starts or stops (action "start"/"stop") the server's CPU profile
+// capture for step and returns the profile path the server reports.
+func (c *StatsClient) Profile(ctx context.Context, action string, step int) (string, error) {
+	endpoint, err := c.internalURL("/api/internal/profile/" + action)
+	if err != nil {
+		return "", err
+	}
+	endpoint += "?" + url.Values{"step": {strconv.Itoa(step)}}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	if c.token != "" {
+		req.Header.Set("X-Internal-Token", c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("profile %s returned %d: %s", action, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	var out struct {
+		Path string `json:"path"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return "", err
+	}
+	return out.Path, nil
+}
+
+// profileStep calls Profile with its own short timeout so a stop still
+// reaches the server when the step context has already been canceled.
+func profileStep(client *StatsClient, action string, step int) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	return client.Profile(ctx, action, step)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStatsClientProfile(t *testing.T) {
+	var gotPath, gotStep, gotToken string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		gotPath = r.URL.Path
+		gotStep = r.URL.Query().Get("step")
+		gotToken = r.Header.Get("X-Internal-Token")
+		if r.URL.Path == "/api/internal/profile/stop" {
+			http.Error(w, "no CPU profile is being captured for this step", http.StatusConflict)
+			return
+		}
+		_, _ = w.Write([]byte(`{"step":40,"path":"/tmp/cpu-step-40.pprof"}`))
+	}))
+	defer srv.Close()
+
+	client := NewStatsClient(srv.URL, "/api/internal/stats", "secret")
+	path, err := client.Profile(context.Background(), "start", 40)
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if path != "/tmp/cpu-step-40.pprof" {
+		t.Fatalf("unexpected path %q", path)
+	}
+	if gotPath != "/api/internal/profile/start" || gotStep != "40" || gotToken != "secret" {
+		t.Fatalf("unexpected request path=%q step=%q token=%q", gotPath, gotStep, gotToken)
+	}
+
+	if _, err := client.Profile(context.Background(), "stop", 40); err == nil {
+		t.Fatalf("expected error on non-200 stop")
+	}
+}
+
+func TestStatsClientInternalURLFollowsAbsoluteStatsURL(t *testing.T) {
+	client := NewStatsClient("http://localhost", "https://stats.example:8443/api/internal/stats", "")
+	got, err := client.internalURL("/api/internal/profile/start")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "https://stats.example:8443/api/internal/profile/start" {
+		t.Fatalf("unexpected url %q", got)
+	}
+}
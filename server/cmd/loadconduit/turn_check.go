@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// turnCheckTimeout bounds one /api/turn-credentials request.
+const turnCheckTimeout = 10 * time.Second
+
+func turnCredentialsURL(baseURL string) string {
+	return strings.TrimRight(strings.TrimSpace(baseURL), "/") + "/api/turn-credentials"
+}
+
+// fetchTurnCredentials exchanges a join's turnToken, with the joined session's
+// sid, for TURN credentials and checks the response is usable: a username, a
+// password and at least one URI.
+func fetchTurnCredentials(ctx context.Context, client *http.Client, endpoint, token, sid string) error {
+	if token == "" {
+		return fmt.Errorf("joined without turnToken")
+	}
+	query := url.Values{"token": {token}, "sid": {sid}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("turn-credentials endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var creds struct {
+		Username string   `json:"username"`
+		Password string   `json:"password"`
+		URIs     []string `json:"uris"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&creds); err != nil {
+		return err
+	}
+	if creds.Username == "" || creds.Password == "" || len(creds.URIs) == 0 {
+		return fmt.Errorf("turn-credentials response missing username, password or uris")
+	}
+	return nil
+}
+
+// checkTurnCredentials runs fetchTurnCredentials for a joined client and
+// records the outcome and latency in metrics.
+func (c *loadClient) checkTurnCredentials(ctx context.Context, client *http.Client, endpoint string) {
+	ctx, cancel := context.WithTimeout(ctx, turnCheckTimeout)
+	defer cancel()
+
+	c.metrics.turnCheckAttempts.Add(1)
+	startedAt := time.Now()
+	if err := fetchTurnCredentials(ctx, client, endpoint, c.turnToken(), c.sid()); err != nil {
+		c.metrics.turnCheckFailures.Add(1)
+		return
+	}
+	c.metrics.AddTurnCheckLatency(time.Since(startedAt).Milliseconds())
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCheckTurnCredentialsRecordsOutcome(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/turn-credentials" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("token") != "good" || r.URL.Query().Get("sid") != "sid-1" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"username": "u", "password": "p", "uris": []string{"stun:example"}, "ttl": 900})
+	}))
+	defer server.Close()
+
+	metrics := &StepMetrics{}
+	endpoint := turnCredentialsURL(server.URL + "/")
+	for _, token := range []string{"good", "good", "bad", ""} {
+		c := newLoadClient(1, "room", "ws://example.invalid/ws", time.Second, metrics)
+		c.turnTokenValue.Store(token)
+		c.sidValue.Store("sid-1")
+		c.checkTurnCredentials(context.Background(), server.Client(), endpoint)
+	}
+
+	result := metrics.ToStepResult(4, 2, time.Now(), time.Now())
+	if result.TurnCheckAttempts != 4 || result.TurnCheckFailures != 2 {
+		t.Fatalf("unexpected turn check counts: %+v", result)
+	}
+	if result.TurnCheckErrorRate != 0.5 {
+		t.Fatalf("expected error rate 0.5, got %.2f", result.TurnCheckErrorRate)
+	}
+	if result.ErrorRate != 0 {
+		t.Fatalf("turn check failures must not feed the signaling error rate, got %.2f", result.ErrorRate)
+	}
+}
+
+func TestFetchTurnCredentialsRejectsIncompleteResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"username": "u", "password": "p"})
+	}))
+	defer server.Close()
+
+	if err := fetchTurnCredentials(context.Background(), server.Client(), server.URL, "token", "sid-1"); err == nil {
+		t.Fatal("expected credentials without uris to be rejected")
+	}
+}
+
+func TestEvaluateStepFailsOnTurnErrorRate(t *testing.T) {
+	cfg := Config{MaxErrorRate: 0.01, MaxJoinP95Ms: 2000, MaxTurnErrorRate: 0.1}
+	step := StepResult{TargetClients: 10, JoinSuccess: 10, TurnCheckAttempts: 5, TurnCheckErrorRate: 0.2}
+
+	if evaluated := evaluateStep(cfg, step); evaluated.Passed {
+		t.Fatal("expected step to fail on turn credential error rate")
+	}
+
+	step.TurnCheckErrorRate = 0.05
+	evaluated := evaluateStep(cfg, step)
+	if !evaluated.Passed {
+		t.Fatalf("expected step to pass, got %q", evaluated.FailReason)
+	}
+	if headroom, ok := evaluated.SLOHeadroom["turn_error_rate"]; !ok || headroom < 0.49 || headroom > 0.51 {
+		t.Fatalf("expected turn_error_rate headroom of 0.5, got %v", evaluated.SLOHeadroom)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"serenada/server/internal/fsutil"
 )
 
 type SweepReport struct {
@@ -17,6 +19,12 @@ type SweepReport struct {
 	LastPassingClients int    `json:"lastPassingClients"`
 	StoppedAtClients   int    `json:"stoppedAtClients"`
 	FinalReason        string `json:"finalReason"`
+
+	// Bisect steps run after the first failing step (--search=bisect), in
+	// order. Their results are also in Steps.
+	SearchTrajectory []SearchProbe `json:"searchTrajectory,omitempty"`
+
+	RecommendedProfile *RecommendedProfile `json:"recommendedProfile,omitempty"`
 }
 
 type StepResult struct {
@@ -41,17 +49,75 @@ type StepResult struct {
 	RelaySent            int64 `json:"relaySent"`
 	RelaySendFailures    int64 `json:"relaySendFailures"`
 	RelayReceived        int64 `json:"relayReceived"`
+	RelayInjectedLoss    int64 `json:"relayInjectedLoss,omitempty"`
+	RoomsChurned         int64 `json:"roomsChurned,omitempty"`
+	// Replacement rooms in which some client failed to connect or join; the
+	// failures themselves are in connectFailures/joinFailures.
+	ChurnJoinFailures int64 `json:"churnJoinFailures,omitempty"`
+
+	// Host departures forced by --host-transfer-percent and whether the peer
+	// saw itself promoted in room_state within the join timeout.
+	HostTransferAttempts int64   `json:"hostTransferAttempts,omitempty"`
+	HostTransferSuccess  int64   `json:"hostTransferSuccess,omitempty"`
+	HostTransferFailures int64   `json:"hostTransferFailures,omitempty"`
+	HostTransferP95Ms    float64 `json:"hostTransferP95Ms,omitempty"`
+
+	// /api/turn-credentials exchanges by --turn-check-percent of clients after
+	// their initial join, and the p95 latency of the successful ones.
+	TurnCheckAttempts  int64   `json:"turnCheckAttempts,omitempty"`
+	TurnCheckFailures  int64   `json:"turnCheckFailures,omitempty"`
+	TurnCheckP95Ms     float64 `json:"turnCheckP95Ms,omitempty"`
+	TurnCheckErrorRate float64 `json:"turnCheckErrorRate,omitempty"`
+
+	// Reconnects per second actually issued by the reconnect storm, which
+	// --reconnect-concurrency bounds.
+	ReconnectIssueRate float64 `json:"reconnectIssueRate,omitempty"`
+
+	// Malformed frames sent per category (--malformed-rate) and how many got
+	// the expected server response. Nil when none were sent.
+	Malformed map[string]MalformedOutcome `json:"malformed,omitempty"`
 
 	ClientJoinP95Ms float64 `json:"clientJoinP95Ms"`
 	ServerJoinP95Ms float64 `json:"serverJoinP95Ms"`
 	JoinErrorRate   float64 `json:"joinErrorRate"`
 	ErrorRate       float64 `json:"errorRate"`
 
-	ServerStatsAvailable bool  `json:"serverStatsAvailable"`
-	SendQueueDropDelta   int64 `json:"sendQueueDropDelta"`
+	ServerStatsAvailable bool               `json:"serverStatsAvailable"`
+	SendQueueDropDelta   int64              `json:"sendQueueDropDelta"`
+	ServerDeltas         map[string]int64   `json:"serverDeltas,omitempty"`
+	ServerGauges         *ServerGaugeSample `json:"serverGauges,omitempty"`
+
+	// Server-side path of the CPU profile captured over the steady window
+	// (--profile-steps).
+	ServerProfile string `json:"serverProfile,omitempty"`
 
 	Passed     bool   `json:"passed"`
 	FailReason string `json:"failReason,omitempty"`
+
+	// For passing steps: per-SLO headroom below its threshold as a fraction
+	// of the threshold, and the SLO with the least, which is the likely
+	// binding constraint as load grows.
+	SLOHeadroom         map[string]float64 `json:"sloHeadroom,omitempty"`
+	TightestSLO         string             `json:"tightestSlo,omitempty"`
+	TightestSLOHeadroom float64            `json:"tightestSloHeadroom,omitempty"`
+}
+
+type MalformedOutcome struct {
+	Sent       int64 `json:"sent"`
+	AsExpected int64 `json:"asExpected"`
+}
+
+// ServerGaugeSample captures server gauges at the end of a step's steady
+// window, while all step clients are still connected.
+type ServerGaugeSample struct {
+	ActiveClients int64  `json:"activeClients"`
+	ActiveRooms   int64  `json:"activeRooms"`
+	Goroutines    int    `json:"goroutines"`
+	HeapInuse     uint64 `json:"heapInuse"`
+	SendQueueSize int    `json:"sendQueueSize,omitempty"` // 0 from servers that do not report it
+	// Deepest any client's send queue has been since the server started; 0
+	// from servers that do not report it.
+	SendQueueHighWater int `json:"sendQueueHighWater,omitempty"`
 }
 
 type StepMetrics struct {
@@ -69,9 +135,50 @@ type StepMetrics struct {
 	relaySent            atomic.Int64
 	relaySendFailures    atomic.Int64
 	relayReceived        atomic.Int64
+	relayInjectedLoss    atomic.Int64
+	roomsChurned         atomic.Int64
+	churnJoinFailures    atomic.Int64
+
+	malformedSent       [malformedCategoryCount]atomic.Int64
+	malformedAsExpected [malformedCategoryCount]atomic.Int64
+
+	hostTransferAttempts atomic.Int64
+	hostTransferSuccess  atomic.Int64
+	hostTransferFailures atomic.Int64
+
+	turnCheckAttempts atomic.Int64
+	turnCheckFailures atomic.Int64
 
 	joinLatencyMu sync.Mutex
 	joinLatencies []int64
+
+	hostTransferMu        sync.Mutex
+	hostTransferLatencies []int64
+
+	turnCheckMu        sync.Mutex
+	turnCheckLatencies []int64
+
+	reconnectIssued   atomic.Int64
+	reconnectIssueDur atomic.Int64 // nanoseconds from storm start to the last reconnect issued
+}
+
+func (m *StepMetrics) recordReconnectIssuance(issued int, window time.Duration) {
+	m.reconnectIssued.Store(int64(issued))
+	m.reconnectIssueDur.Store(int64(window))
+}
+
+// ReconnectIssueRate is reconnects issued per second over the storm's
+// issuance window, floored at 1ms so an unbounded storm still reports a rate.
+func (m *StepMetrics) ReconnectIssueRate() float64 {
+	issued := m.reconnectIssued.Load()
+	if issued == 0 {
+		return 0
+	}
+	window := time.Duration(m.reconnectIssueDur.Load())
+	if window < time.Millisecond {
+		window = time.Millisecond
+	}
+	return float64(issued) / window.Seconds()
 }
 
 func (m *StepMetrics) AddJoinLatency(ms int64) {
@@ -86,10 +193,48 @@ func (m *StepMetrics) AddJoinLatency(ms int64) {
 func (m *StepMetrics) ClientJoinP95Ms() float64 {
 	m.joinLatencyMu.Lock()
 	defer m.joinLatencyMu.Unlock()
-	if len(m.joinLatencies) == 0 {
+	return p95Ms(m.joinLatencies)
+}
+
+func (m *StepMetrics) AddHostTransferLatency(ms int64) {
+	m.hostTransferMu.Lock()
+	m.hostTransferLatencies = append(m.hostTransferLatencies, ms)
+	m.hostTransferMu.Unlock()
+}
+
+func (m *StepMetrics) HostTransferP95Ms() float64 {
+	m.hostTransferMu.Lock()
+	defer m.hostTransferMu.Unlock()
+	return p95Ms(m.hostTransferLatencies)
+}
+
+func (m *StepMetrics) AddTurnCheckLatency(ms int64) {
+	m.turnCheckMu.Lock()
+	m.turnCheckLatencies = append(m.turnCheckLatencies, ms)
+	m.turnCheckMu.Unlock()
+}
+
+func (m *StepMetrics) TurnCheckP95Ms() float64 {
+	m.turnCheckMu.Lock()
+	defer m.turnCheckMu.Unlock()
+	return p95Ms(m.turnCheckLatencies)
+}
+
+// TurnCheckErrorRate is the failed share of TURN credential exchanges. It is
+// its own SLO and does not feed ErrorRate.
+func (m *StepMetrics) TurnCheckErrorRate() float64 {
+	attempts := m.turnCheckAttempts.Load()
+	if attempts <= 0 {
+		return 0
+	}
+	return float64(m.turnCheckFailures.Load()) / float64(attempts)
+}
+
+func p95Ms(values []int64) float64 {
+	if len(values) == 0 {
 		return 0
 	}
-	copySlice := append([]int64(nil), m.joinLatencies...)
+	copySlice := append([]int64(nil), values...)
 	sort.Slice(copySlice, func(i, j int) bool { return copySlice[i] < copySlice[j] })
 	idx := int(math.Ceil(0.95*float64(len(copySlice)))) - 1
 	if idx < 0 {
@@ -111,7 +256,8 @@ func (m *StepMetrics) ErrorRate() float64 {
 		m.reconnectFailures.Load() +
 		m.serverErrorMessages.Load() +
 		m.unexpectedDisconnect.Load() +
-		m.relaySendFailures.Load()
+		m.relaySendFailures.Load() +
+		m.hostTransferFailures.Load()
 	return float64(errEvents) / float64(den)
 }
 
@@ -133,21 +279,49 @@ func (m *StepMetrics) ToStepResult(targetClients, targetRooms int, started, ende
 		ReconnectAttempts:    m.reconnectAttempts.Load(),
 		ReconnectSuccess:     m.reconnectSuccess.Load(),
 		ReconnectFailures:    m.reconnectFailures.Load(),
+		ReconnectIssueRate:   m.ReconnectIssueRate(),
 		ServerErrorMessages:  m.serverErrorMessages.Load(),
 		UnexpectedDisconnect: m.unexpectedDisconnect.Load(),
 		RelaySent:            m.relaySent.Load(),
 		RelaySendFailures:    m.relaySendFailures.Load(),
 		RelayReceived:        m.relayReceived.Load(),
+		RelayInjectedLoss:    m.relayInjectedLoss.Load(),
+		RoomsChurned:         m.roomsChurned.Load(),
+		ChurnJoinFailures:    m.churnJoinFailures.Load(),
+		HostTransferAttempts: m.hostTransferAttempts.Load(),
+		HostTransferSuccess:  m.hostTransferSuccess.Load(),
+		HostTransferFailures: m.hostTransferFailures.Load(),
+		HostTransferP95Ms:    m.HostTransferP95Ms(),
+		TurnCheckAttempts:    m.turnCheckAttempts.Load(),
+		TurnCheckFailures:    m.turnCheckFailures.Load(),
+		TurnCheckP95Ms:       m.TurnCheckP95Ms(),
+		TurnCheckErrorRate:   m.TurnCheckErrorRate(),
+		Malformed:            m.malformedOutcomes(),
 
 		ClientJoinP95Ms: m.ClientJoinP95Ms(),
 		ErrorRate:       m.ErrorRate(),
 	}
 }
 
+func (m *StepMetrics) malformedOutcomes() map[string]MalformedOutcome {
+	var outcomes map[string]MalformedOutcome
+	for category := malformedCategory(0); category < malformedCategoryCount; category++ {
+		sent := m.malformedSent[category].Load()
+		if sent == 0 {
+			continue
+		}
+		if outcomes == nil {
+			outcomes = make(map[string]MalformedOutcome)
+		}
+		outcomes[category.String()] = MalformedOutcome{Sent: sent, AsExpected: m.malformedAsExpected[category].Load()}
+	}
+	return outcomes
+}
+
 func writeJSONReport(path string, report SweepReport) error {
 	data, err := json.MarshalIndent(report, "", "  ")
 	if err != nil {
 		return err
 	}
-	return atomicWriteFile(path, data)
+	return fsutil.AtomicWriteFile(path, data)
 }
@@ -2,39 +2,9 @@ package main
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 	"time"
 )
 
-func atomicWriteFile(path string, data []byte) error {
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return err
-	}
-
-	tmp, err := os.CreateTemp(dir, ".loadconduit-*.tmp")
-	if err != nil {
-		return err
-	}
-	tmpName := tmp.Name()
-	defer os.Remove(tmpName)
-
-	if _, err := tmp.Write(data); err != nil {
-		tmp.Close()
-		return err
-	}
-	if err := tmp.Sync(); err != nil {
-		tmp.Close()
-		return err
-	}
-	if err := tmp.Close(); err != nil {
-		return err
-	}
-
-	return os.Rename(tmpName, path)
-}
-
 func printStepHeader() {
 	fmt.Printf("%-8s %-6s %-10s %-10s %-10s %-12s %-8s\n", "clients", "rooms", "err_rate", "join_p95", "queue_drop", "stats_src", "result")
 }
@@ -63,6 +33,9 @@ func printStepResult(step StepResult, useServerJoinP95 bool) {
 	if step.FailReason != "" {
 		fmt.Printf("  reason: %s\n", step.FailReason)
 	}
+	if step.Passed && step.TightestSLO != "" {
+		fmt.Printf("  tightest: %s (%.0f%% headroom)\n", step.TightestSLO, step.TightestSLOHeadroom*100)
+	}
 }
 
 func nowRFC3339() string {
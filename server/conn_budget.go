@@ -0,0 +1,52 @@
+package main
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// connBudget caps the total inbound traffic of a single connection. It
+// targets slow-drip abuse that stays under the rate limits; zero disables a
+// limit. Set from CONN_MESSAGE_BUDGET / CONN_BYTE_BUDGET at startup.
+type connBudget struct {
+	messages int64
+	bytes    int64
+}
+
+var connectionBudget connBudget
+
+func parseConnBudget(rawMessages, rawBytes string) connBudget {
+	parse := func(raw string) int64 {
+		n, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+		if err != nil || n < 0 {
+			return 0
+		}
+		return n
+	}
+	return connBudget{messages: parse(rawMessages), bytes: parse(rawBytes)}
+}
+
+// chargeBudget records one inbound message of size n against c and reports
+// whether c is still within connectionBudget.
+func (c *Client) chargeBudget(n int) bool {
+	messages := atomic.AddInt64(&c.rxMessages, 1)
+	bytes := atomic.AddInt64(&c.rxBytes, int64(n))
+
+	budget := connectionBudget
+	if budget.messages > 0 && messages > budget.messages {
+		return false
+	}
+	if budget.bytes > 0 && bytes > budget.bytes {
+		return false
+	}
+	return true
+}
+
+func (h *Hub) disconnectOverBudget(c *Client) {
+	log.Printf("[BUDGET] Client %s (CID: %s) exceeded connection budget after %d messages / %d bytes",
+		c.sid, c.cid, atomic.LoadInt64(&c.rxMessages), atomic.LoadInt64(&c.rxBytes))
+	c.sendError(c.rid, "BUDGET_EXCEEDED", "Connection message budget exceeded")
+	h.disconnectClient(c, DisconnectBudgetExceeded)
+}
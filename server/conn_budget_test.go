@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"serenada/server/internal/stats"
+)
+
+func pingPayload() []byte {
+	b, _ := json.Marshal(Message{V: 1, Type: "ping"})
+	return b
+}
+
+func TestConnectionBudgetDisconnectsAfterMessageLimit(t *testing.T) {
+	original := connectionBudget
+	connectionBudget = connBudget{messages: 3}
+	defer func() { connectionBudget = original }()
+
+	hub := newHub(4)
+	c := fakeClient(hub)
+	hub.registerClient(c)
+
+	before := stats.SnapshotNow().Disconnects[string(DisconnectBudgetExceeded)]
+	for i := 0; i < 3; i++ {
+		hub.handleMessage(c, pingPayload())
+	}
+	if !hub.isClientActive(c) {
+		t.Fatal("client disconnected before exceeding budget")
+	}
+
+	hub.handleMessage(c, pingPayload())
+
+	msgs := drainMessages(c)
+	if code := errorCode(findMessage(msgs, "error")); code != "BUDGET_EXCEEDED" {
+		t.Fatalf("expected BUDGET_EXCEEDED, got %q", code)
+	}
+	if hub.isClientActive(c) {
+		t.Fatal("expected client to be disconnected")
+	}
+	if after := stats.SnapshotNow().Disconnects[string(DisconnectBudgetExceeded)]; after-before != 1 {
+		t.Fatalf("expected one budget_exceeded disconnect, got %d", after-before)
+	}
+}
+
+func TestConnectionBudgetByteLimit(t *testing.T) {
+	original := connectionBudget
+	connectionBudget = connBudget{bytes: 100}
+	defer func() { connectionBudget = original }()
+
+	c := fakeClient(newHub(4))
+	if !c.chargeBudget(60) {
+		t.Fatal("expected first message within budget")
+	}
+	if c.chargeBudget(60) {
+		t.Fatal("expected byte budget to be exceeded")
+	}
+}
+
+func TestConnectionBudgetDisabledByDefault(t *testing.T) {
+	if budget := parseConnBudget("", "bogus"); budget.messages != 0 || budget.bytes != 0 {
+		t.Fatalf("expected disabled budget, got %+v", budget)
+	}
+	c := fakeClient(newHub(4))
+	original := connectionBudget
+	connectionBudget = connBudget{}
+	defer func() { connectionBudget = original }()
+	for i := 0; i < 1000; i++ {
+		if !c.chargeBudget(maxMessageSize) {
+			t.Fatal("disabled budget must never trip")
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strings"
+)
+
+// debugConnGoroutines logs a warning from the hub loop whenever more
+// per-connection loops are running than the hub has clients for.
+var debugConnGoroutines = strings.EqualFold(strings.TrimSpace(os.Getenv("DEBUG_CONN_GOROUTINES")), "1")
+
+// goConn starts a short-lived per-connection goroutine (e.g. a delayed
+// disconnect) that tests can wait on through connWG.
+func (h *Hub) goConn(fn func()) {
+	h.connWG.Add(1)
+	go func() {
+		defer h.connWG.Done()
+		fn()
+	}()
+}
+
+// goConnLoop starts a long-lived per-connection loop (readPump, writePump)
+// and counts it while it runs.
+func (h *Hub) goConnLoop(fn func()) {
+	done := h.trackConnLoop()
+	go func() {
+		defer done()
+		fn()
+	}()
+}
+
+// runConnLoop is goConnLoop for a loop that runs on the calling goroutine,
+// such as writeSSE inside its HTTP handler.
+func (h *Hub) runConnLoop(fn func()) {
+	defer h.trackConnLoop()()
+	fn()
+}
+
+func (h *Hub) trackConnLoop() (done func()) {
+	h.connWG.Add(1)
+	h.connLoops.Add(1)
+	return func() {
+		h.connLoops.Add(-1)
+		h.connWG.Done()
+	}
+}
+
+// connGoroutineCountsLocked returns the number of loops the registered
+// clients should have (two per WebSocket, one per SSE stream) and the number
+// actually running. Expected can briefly exceed actual while a client sits
+// in its reconnect grace period; actual above expected means a loop outlived
+// its client. Callers must hold h.mu.
+func (h *Hub) connGoroutineCountsLocked() (expected, actual int64) {
+	for c := range h.clients {
+		switch c.transport {
+		case TransportWS:
+			expected += 2
+		case TransportSSE:
+			expected++
+		}
+	}
+	return expected, h.connLoops.Load()
+}
+
+func (h *Hub) checkConnGoroutines() {
+	h.mu.RLock()
+	expected, actual := h.connGoroutineCountsLocked()
+	h.mu.RUnlock()
+	if actual > expected {
+		log.Printf("[LEAK] %d connection goroutines running, expected at most %d", actual, expected)
+	}
+}
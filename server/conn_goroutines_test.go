@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// waitForConnLoops fails the test unless the hub's running per-connection
+// loops settle at want before the timeout.
+func waitForConnLoops(t *testing.T, hub *Hub, want int64) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if hub.connLoops.Load() == want {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("expected %d connection loops, got %d", want, hub.connLoops.Load())
+}
+
+func connGoroutineCounts(hub *Hub) (expected, actual int64) {
+	hub.mu.RLock()
+	defer hub.mu.RUnlock()
+	return hub.connGoroutineCountsLocked()
+}
+
+func TestWebSocketLoopsExitOnDisconnect(t *testing.T) {
+	hub := newHub(4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveWs(hub, w, r)
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+
+	waitForConnLoops(t, hub, 2)
+	if expected, actual := connGoroutineCounts(hub); expected != 2 || actual != 2 {
+		t.Fatalf("expected 2/2 connection goroutines, got expected=%d actual=%d", expected, actual)
+	}
+
+	conn.Close()
+
+	// readPump exits immediately; writePump waits for the grace period to
+	// close the send channel, which we trigger directly instead of sleeping.
+	waitForConnLoops(t, hub, 1)
+	if expected, actual := connGoroutineCounts(hub); actual > expected {
+		t.Fatalf("connection goroutines leaked during grace: expected=%d actual=%d", expected, actual)
+	}
+
+	hub.mu.RLock()
+	var client *Client
+	for c := range hub.clients {
+		client = c
+	}
+	hub.mu.RUnlock()
+	hub.disconnectClient(client, DisconnectClientClose)
+
+	waitForConnLoops(t, hub, 0)
+	if expected, actual := connGoroutineCounts(hub); expected != 0 || actual != 0 {
+		t.Fatalf("expected no connection goroutines, got expected=%d actual=%d", expected, actual)
+	}
+}
+
+func TestConnGoroutineCountsBySSEAndWSTransport(t *testing.T) {
+	hub := newHub(4)
+	ws := fakeClient(hub)
+	ws.transport = TransportWS
+	sse := fakeClient(hub)
+	sse.transport = TransportSSE
+	hub.registerClient(ws)
+	hub.registerClient(sse)
+
+	if expected, _ := connGoroutineCounts(hub); expected != 3 {
+		t.Fatalf("expected 3 connection goroutines for one WS and one SSE client, got %d", expected)
+	}
+}
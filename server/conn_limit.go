@@ -0,0 +1,124 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"serenada/server/internal/stats"
+)
+
+// connLimitRetryAfter is the Retry-After sent when an IP holds too many open
+// connections; one of them has to close first, which the server cannot
+// predict.
+const connLimitRetryAfter = 5 * time.Second
+
+// connLimiter bounds WebSocket and SSE connections per client IP: how many
+// may be open at once and how fast new ones may be opened. rateLimitMiddleware
+// counts HTTP requests, but an upgrade is a single request that then holds a
+// goroutine pair and buffers for as long as it stays open. IPs in
+// RATE_LIMIT_BYPASS_IPS are exempt.
+type connLimiter struct {
+	maxPerIP int        // 0 means no concurrent limit
+	rate     *IPLimiter // nil means no new-connection rate limit
+
+	mu   sync.Mutex
+	open map[string]int
+}
+
+// newConnLimiterFromEnv reads CONN_LIMIT_PER_IP (open connections) and
+// CONN_RATE_PER_IP (new connections per second as rate[:burst]). Both are
+// off unless set; it returns nil when neither is.
+func newConnLimiterFromEnv() *connLimiter {
+	maxPerIP := 0
+	if raw := strings.TrimSpace(os.Getenv("CONN_LIMIT_PER_IP")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			log.Printf("Ignoring invalid CONN_LIMIT_PER_IP %q", raw)
+		} else {
+			maxPerIP = n
+		}
+	}
+	rate, burst, ok := parseConnRate(os.Getenv("CONN_RATE_PER_IP"))
+	if maxPerIP == 0 && !ok {
+		return nil
+	}
+	l := &connLimiter{maxPerIP: maxPerIP, open: make(map[string]int)}
+	if ok {
+		l.rate = NewIPLimiter(rate, burst)
+	}
+	return l
+}
+
+// parseConnRate reads rate[:burst] in connections per second; the burst
+// defaults to one second's worth. ok is false when the limit is disabled:
+// unset, "off" or invalid.
+func parseConnRate(raw string) (rate, burst float64, ok bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.EqualFold(raw, "off") {
+		return 0, 0, false
+	}
+	rateRaw, burstRaw, hasBurst := strings.Cut(raw, ":")
+	rate, err := strconv.ParseFloat(strings.TrimSpace(rateRaw), 64)
+	if err != nil || rate <= 0 {
+		log.Printf("Ignoring invalid CONN_RATE_PER_IP %q", raw)
+		return 0, 0, false
+	}
+	burst = max(rate, 1)
+	if hasBurst {
+		burst, err = strconv.ParseFloat(strings.TrimSpace(burstRaw), 64)
+		if err != nil || burst < 1 {
+			log.Printf("Ignoring invalid CONN_RATE_PER_IP %q", raw)
+			return 0, 0, false
+		}
+	}
+	return rate, burst, true
+}
+
+// acquire admits a new connection from ip. On success the caller must call
+// release once the connection closes. On failure retryAfter says when to try
+// again.
+func (l *connLimiter) acquire(ip string) (release func(), retryAfter time.Duration, ok bool) {
+	if l == nil || rateLimitBypass.contains(ip) {
+		return func() {}, 0, true
+	}
+	if l.rate != nil {
+		bucket := l.rate.GetLimiter(ip)
+		if !bucket.Allow() {
+			return nil, bucket.retryAfter(), false
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.maxPerIP > 0 && l.open[ip] >= l.maxPerIP {
+		return nil, connLimitRetryAfter, false
+	}
+	l.open[ip]++
+	var once sync.Once
+	return func() { once.Do(func() { l.releaseIP(ip) }) }, 0, true
+}
+
+func (l *connLimiter) releaseIP(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.open[ip] <= 1 {
+		delete(l.open, ip)
+		return
+	}
+	l.open[ip]--
+}
+
+// rejectConnLimited answers a connection over its IP's limit with 429 and a
+// Retry-After in whole seconds.
+func rejectConnLimited(w http.ResponseWriter, kind, ip string, retryAfter time.Duration) {
+	log.Printf("[%s] Rejected connection from %s: per-IP connection limit", strings.ToUpper(kind), ip)
+	stats.IncConnLimitRejected()
+	stats.IncConnectionFailure(kind)
+	w.Header().Set("Retry-After", retryAfterHeader(retryAfter))
+	http.Error(w, "Too many connections", http.StatusTooManyRequests)
+}
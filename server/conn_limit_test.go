@@ -0,0 +1,105 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"serenada/server/internal/stats"
+)
+
+func TestParseConnRate(t *testing.T) {
+	for _, raw := range []string{"", "off", "x:y", "5:0"} {
+		if _, _, ok := parseConnRate(raw); ok {
+			t.Fatalf("expected %q to leave the rate limit disabled", raw)
+		}
+	}
+	if rate, burst, ok := parseConnRate("10:30"); !ok || rate != 10 || burst != 30 {
+		t.Fatalf("expected 10:30, got %v:%v (%v)", rate, burst, ok)
+	}
+	if rate, burst, ok := parseConnRate("2"); !ok || rate != 2 || burst != 2 {
+		t.Fatalf("expected burst to default to the rate, got %v:%v", rate, burst)
+	}
+}
+
+func TestNewConnLimiterFromEnvIsOptIn(t *testing.T) {
+	t.Setenv("CONN_LIMIT_PER_IP", "")
+	t.Setenv("CONN_RATE_PER_IP", "")
+	if l := newConnLimiterFromEnv(); l != nil {
+		t.Fatalf("expected no connection limits unless configured, got %+v", l)
+	}
+
+	t.Setenv("CONN_LIMIT_PER_IP", "8")
+	l := newConnLimiterFromEnv()
+	if l == nil || l.maxPerIP != 8 || l.rate != nil {
+		t.Fatalf("expected only the concurrent limit, got %+v", l)
+	}
+}
+
+func TestConnLimiterCapsConcurrentConnectionsPerIP(t *testing.T) {
+	l := &connLimiter{maxPerIP: 2, open: make(map[string]int)}
+
+	release1, _, ok1 := l.acquire("192.0.2.1")
+	_, _, ok2 := l.acquire("192.0.2.1")
+	if !ok1 || !ok2 {
+		t.Fatal("expected two connections within the limit")
+	}
+	if _, retryAfter, ok := l.acquire("192.0.2.1"); ok || retryAfter != connLimitRetryAfter {
+		t.Fatalf("expected the third connection rejected with Retry-After %s, got ok=%v %s", connLimitRetryAfter, ok, retryAfter)
+	}
+	if _, _, ok := l.acquire("192.0.2.2"); !ok {
+		t.Fatal("expected another IP to be unaffected")
+	}
+
+	release1()
+	release1()
+	if _, _, ok := l.acquire("192.0.2.1"); !ok {
+		t.Fatal("expected a slot to free up after release")
+	}
+	if _, _, ok := l.acquire("192.0.2.1"); ok {
+		t.Fatal("expected a double release to free only one slot")
+	}
+}
+
+func TestConnLimiterRateAndBypass(t *testing.T) {
+	l := &connLimiter{rate: NewIPLimiter(1, 2), open: make(map[string]int)}
+	for i := 0; i < 2; i++ {
+		if _, _, ok := l.acquire("192.0.2.1"); !ok {
+			t.Fatalf("expected connection %d within the burst", i+1)
+		}
+	}
+	if _, retryAfter, ok := l.acquire("192.0.2.1"); ok || retryAfter <= 0 {
+		t.Fatalf("expected the burst to be exhausted with a retry hint, got ok=%v %s", ok, retryAfter)
+	}
+
+	original := rateLimitBypass
+	rateLimitBypass = parseRateLimitBypass("192.0.2.1")
+	defer func() { rateLimitBypass = original }()
+	if _, _, ok := l.acquire("192.0.2.1"); !ok {
+		t.Fatal("expected bypassed IPs to skip the connection limit")
+	}
+}
+
+func TestServeWsRejectsOverConnLimitWith429(t *testing.T) {
+	hub := newHub(4)
+	hub.connLimit = &connLimiter{maxPerIP: 1, open: map[string]int{"192.0.2.1": 1}}
+	before := stats.SnapshotNow().Counters.ConnLimitRejected
+
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	req.RemoteAddr = "192.0.2.1:5000"
+	rec := httptest.NewRecorder()
+	serveWs(hub, rec, req)
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "5" {
+		t.Fatalf("expected Retry-After 5, got %q", got)
+	}
+	if after := stats.SnapshotNow().Counters.ConnLimitRejected; after-before != 1 {
+		t.Fatalf("expected one rejection counted, got %d", after-before)
+	}
+	if len(hub.clients) != 0 {
+		t.Fatal("expected no client registered for a rejected connection")
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxCPUProfileDuration stops a capture whose stop request never arrives, e.g.
+// because the load tool crashed mid-step.
+const maxCPUProfileDuration = 30 * time.Minute
+
+var (
+	errCPUProfileActive   = errors.New("a CPU profile is already being captured")
+	errCPUProfileInactive = errors.New("no CPU profile is being captured for this step")
+)
+
+// cpuProfiler captures at most one CPU profile at a time into dir, started and
+// stopped through /api/internal/profile/{start,stop}?step=N so load sweeps can
+// bracket each step's steady window.
+type cpuProfiler struct {
+	dir string
+
+	mu    sync.Mutex
+	file  *os.File
+	step  int
+	timer *time.Timer
+}
+
+// newCPUProfilerFromEnv returns nil unless ENABLE_INTERNAL_PROFILE=1. The
+// endpoints additionally require the internal stats gate and token.
+func newCPUProfilerFromEnv() (*cpuProfiler, error) {
+	if strings.TrimSpace(os.Getenv("ENABLE_INTERNAL_PROFILE")) != "1" {
+		return nil, nil
+	}
+	dir := strings.TrimSpace(os.Getenv("INTERNAL_PROFILE_DIR"))
+	if dir == "" {
+		return nil, errors.New("INTERNAL_PROFILE_DIR is required when ENABLE_INTERNAL_PROFILE=1")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &cpuProfiler{dir: dir}, nil
+}
+
+func (p *cpuProfiler) start(step int) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.file != nil {
+		return "", errCPUProfileActive
+	}
+
+	path := filepath.Join(p.dir, fmt.Sprintf("cpu-step-%d-%d.pprof", step, time.Now().Unix()))
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	if err := pprof.StartCPUProfile(f); err != nil {
+		f.Close()
+		os.Remove(path)
+		return "", err
+	}
+	p.file = f
+	p.step = step
+	p.timer = time.AfterFunc(maxCPUProfileDuration, func() {
+		if path, err := p.stop(step); err == nil {
+			log.Printf("[PROFILE] Step %d capture hit %s limit; wrote %s", step, maxCPUProfileDuration, path)
+		}
+	})
+	return path, nil
+}
+
+// stop ends the capture for step and returns the written profile's path.
+func (p *cpuProfiler) stop(step int) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.file == nil || p.step != step {
+		return "", errCPUProfileInactive
+	}
+
+	pprof.StopCPUProfile()
+	p.timer.Stop()
+	path := p.file.Name()
+	err := p.file.Close()
+	p.file = nil
+	p.timer = nil
+	return path, err
+}
+
+// handleInternalProfile serves POST /api/internal/profile/start and
+// /api/internal/profile/stop. A nil profiler answers 404 like a disabled
+// internal endpoint.
+func handleInternalProfile(p *cpuProfiler) http.HandlerFunc {
+	access := internalAccessFromEnv()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if p == nil {
+			http.NotFound(w, r)
+			return
+		}
+		if !access.authorize(w, r, http.MethodPost) {
+			return
+		}
+
+		step, err := strconv.Atoi(strings.TrimSpace(r.URL.Query().Get("step")))
+		if err != nil || step < 0 {
+			http.Error(w, "Invalid step", http.StatusBadRequest)
+			return
+		}
+
+		var path string
+		switch strings.TrimPrefix(r.URL.Path, "/api/internal/profile/") {
+		case "start":
+			path, err = p.start(step)
+		case "stop":
+			path, err = p.stop(step)
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		switch {
+		case errors.Is(err, errCPUProfileActive), errors.Is(err, errCPUProfileInactive):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			log.Printf("[PROFILE] Step %d: %v", step, err)
+			http.Error(w, "Profile capture failed", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"step": step, "path": path})
+	}
+}
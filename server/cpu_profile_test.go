@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func profileRequest(action, step string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/internal/profile/"+action+"?step="+step, nil)
+	req.Header.Set("X-Internal-Token", "test-token")
+	return req
+}
+
+func TestNewCPUProfilerFromEnv(t *testing.T) {
+	t.Setenv("ENABLE_INTERNAL_PROFILE", "")
+	if p, err := newCPUProfilerFromEnv(); p != nil || err != nil {
+		t.Fatalf("expected profiling disabled by default, got %v, %v", p, err)
+	}
+
+	t.Setenv("ENABLE_INTERNAL_PROFILE", "1")
+	t.Setenv("INTERNAL_PROFILE_DIR", "")
+	if _, err := newCPUProfilerFromEnv(); err == nil {
+		t.Fatal("expected an error when enabled without a directory")
+	}
+
+	t.Setenv("INTERNAL_PROFILE_DIR", t.TempDir())
+	if p, err := newCPUProfilerFromEnv(); p == nil || err != nil {
+		t.Fatalf("expected profiler, got %v, %v", p, err)
+	}
+}
+
+func TestInternalProfileDisabledReturnsNotFound(t *testing.T) {
+	t.Setenv("ENABLE_INTERNAL_STATS", "1")
+	t.Setenv("INTERNAL_STATS_TOKEN", "test-token")
+
+	rr := httptest.NewRecorder()
+	handleInternalProfile(nil).ServeHTTP(rr, profileRequest("start", "1"))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rr.Code)
+	}
+}
+
+func TestInternalProfileRequiresToken(t *testing.T) {
+	t.Setenv("ENABLE_INTERNAL_STATS", "1")
+	t.Setenv("INTERNAL_STATS_TOKEN", "test-token")
+
+	req := profileRequest("start", "1")
+	req.Header.Del("X-Internal-Token")
+	rr := httptest.NewRecorder()
+	handleInternalProfile(&cpuProfiler{dir: t.TempDir()}).ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rr.Code)
+	}
+}
+
+func TestInternalProfileCapturesStep(t *testing.T) {
+	t.Setenv("ENABLE_INTERNAL_STATS", "1")
+	t.Setenv("INTERNAL_STATS_TOKEN", "test-token")
+	handler := handleInternalProfile(&cpuProfiler{dir: t.TempDir()})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, profileRequest("start", "40"))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("start: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, profileRequest("start", "60"))
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("second start: expected 409, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, profileRequest("stop", "60"))
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("stop of other step: expected 409, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, profileRequest("stop", "40"))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("stop: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var body struct {
+		Step int    `json:"step"`
+		Path string `json:"path"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("bad response: %v", err)
+	}
+	info, err := os.Stat(body.Path)
+	if err != nil || info.Size() == 0 {
+		t.Fatalf("expected a non-empty profile at %q: %v", body.Path, err)
+	}
+	if body.Step != 40 {
+		t.Fatalf("expected step 40, got %d", body.Step)
+	}
+}
+
+func TestInternalProfileRejectsBadStep(t *testing.T) {
+	t.Setenv("ENABLE_INTERNAL_STATS", "1")
+	t.Setenv("INTERNAL_STATS_TOKEN", "test-token")
+
+	rr := httptest.NewRecorder()
+	handleInternalProfile(&cpuProfiler{dir: t.TempDir()}).ServeHTTP(rr, profileRequest("start", "abc"))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rr.Code)
+	}
+}
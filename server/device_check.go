@@ -223,6 +223,28 @@ const deviceCheckHTML = `
             </div>
         </div>
 
+        <div class="card">
+            <div class="card-title">
+                TURN Relay Self-Test
+                <div style="display: flex; gap: 0.5rem;">
+                    <button class="btn" id="relay-test-btn" onclick="runRelayTest()" style="margin: 0; padding: 0.25rem 0.5rem; font-size: 0.75rem;">Run Again</button>
+                </div>
+            </div>
+            <div class="item">
+                <span class="label">Relay Reachable</span>
+                <span id="relay-status" class="status-badge">NOT TESTED</span>
+            </div>
+            <div class="item">
+                <span class="label">Gather Time</span>
+                <span id="relay-gather-time" class="value">-</span>
+            </div>
+            <div class="item">
+                <span class="label">Relay Transports</span>
+                <span id="relay-transports" class="value">-</span>
+            </div>
+        </div>
+
+        <div class="card">
             <div class="card-title">
                 ICE Connectivity (STUN/TURN)
                 <div style="display: flex; gap: 0.5rem;">
@@ -552,6 +574,97 @@ const deviceCheckHTML = `
             }
         }
 
+        // Gathers relay-only candidates with diagnostic TURN credentials and
+        // reports whether any TURN server handed out a relay address.
+        function runRelayTest() {
+            var btn = document.getElementById('relay-test-btn');
+            if (btn) btn.disabled = true;
+            updateStatus('relay-status', 'warning', 'TESTING...');
+            document.getElementById('relay-gather-time').textContent = '-';
+            document.getElementById('relay-transports').textContent = '-';
+
+            function done(ok, text, elapsedMs, transports) {
+                updateStatus('relay-status', ok ? 'ok' : 'error', text);
+                document.getElementById('relay-gather-time').textContent = elapsedMs === null ? 'N/A' : elapsedMs + ' ms';
+                document.getElementById('relay-transports').textContent = transports.length ? transports.join(', ') : 'none';
+                if (btn) btn.disabled = false;
+            }
+
+            var RTCPeer = window.RTCPeerConnection || window.webkitRTCPeerConnection || window.mozRTCPeerConnection;
+            if (!RTCPeer) {
+                done(false, 'NOT SUPPORTED', null, []);
+                return;
+            }
+
+            xhrRequest('POST', '/api/diagnostic-ice-servers', null,
+                function(config) {
+                    var iceServers = [];
+                    var uris = config.uris || [];
+                    for (var i = 0; i < uris.length; i++) {
+                        if (uris[i].indexOf('stun:') === 0) continue;
+                        iceServers.push({ urls: uris[i], username: config.username, credential: config.password });
+                    }
+                    if (iceServers.length === 0) {
+                        done(false, 'NO TURN SERVERS', null, []);
+                        return;
+                    }
+
+                    var pc;
+                    try {
+                        pc = new RTCPeer({ iceServers: iceServers, iceTransportPolicy: 'relay' });
+                    } catch(e) {
+                        done(false, 'PC ERROR', null, []);
+                        return;
+                    }
+
+                    var startedAt = Date.now();
+                    var firstRelayMs = null;
+                    var transports = [];
+                    var finished = false;
+                    var timeout = setTimeout(finish, 15000);
+
+                    function finish() {
+                        if (finished) return;
+                        finished = true;
+                        clearTimeout(timeout);
+                        try { pc.close(); } catch(e) {}
+                        if (firstRelayMs !== null) {
+                            done(true, 'YES', firstRelayMs, transports);
+                        } else {
+                            done(false, 'NO', Date.now() - startedAt, transports);
+                        }
+                    }
+
+                    pc.onicecandidate = function(event) {
+                        if (!event.candidate || !event.candidate.candidate) {
+                            finish();
+                            return;
+                        }
+                        var type = event.candidate.type || (event.candidate.candidate.indexOf(' typ relay') !== -1 ? 'relay' : '');
+                        if (type !== 'relay') return;
+                        if (firstRelayMs === null) firstRelayMs = Date.now() - startedAt;
+                        var transport = event.candidate.relayProtocol || event.candidate.protocol || 'unknown';
+                        if (transports.indexOf(transport) === -1) transports.push(transport);
+                    };
+
+                    try {
+                        pc.createDataChannel('relay-test');
+                        pc.createOffer(
+                            function(offer) {
+                                pc.setLocalDescription(offer, function() {}, finish);
+                            },
+                            finish
+                        );
+                    } catch(e) {
+                        finish();
+                    }
+                },
+                function() {
+                    done(false, 'UNAVAILABLE', null, []);
+                }
+            );
+        }
+
         function checkBrowser() {
             document.getElementById('datetime').textContent = new Date().toISOString();
             document.getElementById('ua').textContent = navigator.userAgent;
@@ -886,6 +999,7 @@ const deviceCheckHTML = `
         checkAudioCapabilities();
         checkNetwork();
         listDevices();
+        runRelayTest();
     </script>
 </body>
 </html>
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"sync"
+
+	"serenada/server/internal/stats"
+)
+
+// DiagnosticsDocument is served by /api/diagnostics so native apps and
+// automation can show which server features are available without scraping
+// /device-check. It holds no secrets: TURN URIs are listed, credentials are
+// not.
+type DiagnosticsDocument struct {
+	Server     DiagnosticsServer     `json:"server"`
+	Transports []string              `json:"transports"`
+	TURN       DiagnosticsTURN       `json:"turn"`
+	Rooms      DiagnosticsRooms      `json:"rooms"`
+	Features   DiagnosticsFeatures   `json:"features"`
+	RateLimits DiagnosticsRateLimits `json:"rateLimits"`
+}
+
+type DiagnosticsServer struct {
+	GoVersion    string `json:"goVersion"`
+	Revision     string `json:"revision,omitempty"` // VCS commit the binary was built from, when known
+	RevisionTime string `json:"revisionTime,omitempty"`
+	Modified     bool   `json:"modified,omitempty"` // built from a dirty tree
+	DeployLabel  string `json:"deployLabel,omitempty"`
+}
+
+type DiagnosticsTURN struct {
+	Configured           bool     `json:"configured"`
+	URIs                 []string `json:"uris"`
+	CredentialTTLSeconds int      `json:"credentialTtlSeconds"`
+}
+
+type DiagnosticsRooms struct {
+	MaxParticipants      int      `json:"maxParticipants"`
+	RequiredCapabilities []string `json:"requiredCapabilities"`
+}
+
+type DiagnosticsFeatures struct {
+	InternalStats   bool `json:"internalStats"`
+	WebPush         bool `json:"webPush"`
+	FCMPush         bool `json:"fcmPush"`
+	ReconnectTokens bool `json:"reconnectTokens"`
+	SSEReplay       bool `json:"sseReplay"`
+	Draining        bool `json:"draining"`
+}
+
+type DiagnosticsRateLimits struct {
+	JoinPerMinute int                                   `json:"joinPerMinute"` // 0 when JOIN_RATE_LIMIT_PER_MINUTE is unset
+	MessageTypes  map[string]DiagnosticsMessageTypeRate `json:"messageTypes"`  // RELAY_TYPE_RATE_LIMITS
+}
+
+type DiagnosticsMessageTypeRate struct {
+	PerSecond float64 `json:"perSecond"`
+	Burst     float64 `json:"burst"`
+}
+
+// serverBuildInfo reads the Go version and VCS stamp embedded by go build.
+var serverBuildInfo = sync.OnceValue(func() DiagnosticsServer {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return DiagnosticsServer{}
+	}
+	server := DiagnosticsServer{GoVersion: info.GoVersion}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			server.Revision = setting.Value
+		case "vcs.time":
+			server.RevisionTime = setting.Value
+		case "vcs.modified":
+			server.Modified = setting.Value == "true"
+		}
+	}
+	return server
+})
+
+// diagnostics builds the document from the current configuration. turn is
+// read once by the handler, as handleTurnCredentials does.
+func (h *Hub) diagnostics(turn turnHosts) DiagnosticsDocument {
+	server := serverBuildInfo()
+	server.DeployLabel = stats.DeployLabel()
+
+	turnURIs := turn.uris(0)
+	if turnURIs == nil {
+		turnURIs = []string{}
+	}
+	required := append([]string{}, requiredCapabilities...)
+
+	features := DiagnosticsFeatures{
+		InternalStats:   internalAccessFromEnv().enabled,
+		ReconnectTokens: reconnectTokenSecret() != "",
+		SSEReplay:       sseReplayBufferSize > 0,
+		Draining:        h.isDraining(),
+	}
+	if pushService != nil {
+		features.WebPush = pushService.publicKey != ""
+		features.FCMPush = pushService.fcm != nil
+	}
+
+	limits := DiagnosticsRateLimits{MessageTypes: make(map[string]DiagnosticsMessageTypeRate, len(messageTypeRates))}
+	if h.joinLimiter != nil {
+		limits.JoinPerMinute = int(h.joinLimiter.rate*60 + 0.5)
+	}
+	for msgType, rate := range messageTypeRates {
+		limits.MessageTypes[msgType] = DiagnosticsMessageTypeRate{PerSecond: rate.rate, Burst: rate.burst}
+	}
+
+	return DiagnosticsDocument{
+		Server:     server,
+		Transports: []string{string(TransportWS), string(TransportSSE)},
+		TURN: DiagnosticsTURN{
+			Configured:           os.Getenv("TURN_SECRET") != "" && len(turn.stun) > 0,
+			URIs:                 turnURIs,
+			CredentialTTLSeconds: int(turnCredentialTTL.Seconds()),
+		},
+		Rooms: DiagnosticsRooms{
+			MaxParticipants:      h.maxParticipantsLimit,
+			RequiredCapabilities: required,
+		},
+		Features:   features,
+		RateLimits: limits,
+	}
+}
+
+func handleDiagnostics(hub *Hub) http.HandlerFunc {
+	turn := turnHostsFromEnv()
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(hub.diagnostics(turn))
+	}
+}
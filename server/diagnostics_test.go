@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDiagnosticsReportsFeaturesWithoutSecrets(t *testing.T) {
+	t.Setenv("TURN_SECRET", "turn-secret-value")
+	t.Setenv("STUN_HOST", "turn.example.com")
+	t.Setenv("TURN_HOSTS", "")
+	t.Setenv("TURN_HOST", "")
+	t.Setenv("ENABLE_INTERNAL_STATS", "")
+	prevRates := messageTypeRates
+	messageTypeRates = parseMessageTypeRates("ice=50:200")
+	defer func() { messageTypeRates = prevRates }()
+
+	hub := newHub(6)
+	hub.joinLimiter = newJoinLimiter("20")
+
+	rec := httptest.NewRecorder()
+	handleDiagnostics(hub)(rec, httptest.NewRequest(http.MethodGet, "/api/diagnostics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "turn-secret-value") {
+		t.Fatal("diagnostics must not expose the TURN secret")
+	}
+
+	var doc DiagnosticsDocument
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("bad JSON %s: %v", rec.Body.String(), err)
+	}
+	if !doc.TURN.Configured || len(doc.TURN.URIs) == 0 || doc.TURN.URIs[0] != "stun:turn.example.com" {
+		t.Fatalf("unexpected TURN section %+v", doc.TURN)
+	}
+	if doc.Rooms.MaxParticipants != 6 || doc.Features.InternalStats || doc.Server.GoVersion == "" {
+		t.Fatalf("unexpected document %+v", doc)
+	}
+	if doc.RateLimits.JoinPerMinute != 20 || doc.RateLimits.MessageTypes["ice"] != (DiagnosticsMessageTypeRate{PerSecond: 50, Burst: 200}) {
+		t.Fatalf("unexpected rate limits %+v", doc.RateLimits)
+	}
+}
+
+func TestDiagnosticsRejectsNonGet(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handleDiagnostics(newHub(4))(rec, httptest.NewRequest(http.MethodPost, "/api/diagnostics", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"net"
+
+	"github.com/gorilla/websocket"
+)
+
+// DisconnectReason is why a client was disconnected. Each disconnect is
+// counted once under its reason in the Disconnects map of internal stats.
+type DisconnectReason string
+
+const (
+	DisconnectClientClose    DisconnectReason = "client_close"    // the client closed its WebSocket or SSE stream
+	DisconnectReadError      DisconnectReason = "read_error"      // the WebSocket read failed without a close frame
+	DisconnectWriteError     DisconnectReason = "write_error"     // writing to the WebSocket or SSE stream failed
+	DisconnectIdleTimeout    DisconnectReason = "idle_timeout"    // no pong (WebSocket) or request (SSE) in time
+	DisconnectReplaced       DisconnectReason = "replaced"        // a new SSE stream took over the session
+	DisconnectKicked         DisconnectReason = "kicked"          // the host removed the participant
+	DisconnectDraining       DisconnectReason = "draining"        // join refused because the server is draining
+	DisconnectBudgetExceeded DisconnectReason = "budget_exceeded" // the connection exceeded CONN_MESSAGE_BUDGET or CONN_BYTE_BUDGET
+	DisconnectSlowConsumer   DisconnectReason = "slow_consumer"   // the send queue overflowed under the disconnect policy
+	DisconnectJoinRejected   DisconnectReason = "join_rejected"   // a permanent join rejection closed the WebSocket
+)
+
+// wsReadDisconnectReason classifies the error that ended a WebSocket read
+// loop. writeFailed means the write pump closed the connection first.
+func wsReadDisconnectReason(err error, writeFailed bool) DisconnectReason {
+	if writeFailed {
+		return DisconnectWriteError
+	}
+	if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseNoStatusReceived) {
+		return DisconnectClientClose
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return DisconnectIdleTimeout
+	}
+	return DisconnectReadError
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"serenada/server/internal/stats"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestWSReadDisconnectReason(t *testing.T) {
+	cases := []struct {
+		name        string
+		err         error
+		writeFailed bool
+		want        DisconnectReason
+	}{
+		{"normal close", &websocket.CloseError{Code: websocket.CloseNormalClosure}, false, DisconnectClientClose},
+		{"going away", &websocket.CloseError{Code: websocket.CloseGoingAway}, false, DisconnectClientClose},
+		{"pong timeout", timeoutError{}, false, DisconnectIdleTimeout},
+		{"abnormal close", &websocket.CloseError{Code: websocket.CloseAbnormalClosure}, false, DisconnectReadError},
+		{"read error", errors.New("connection reset by peer"), false, DisconnectReadError},
+		{"after write failure", errors.New("use of closed network connection"), true, DisconnectWriteError},
+	}
+	for _, tc := range cases {
+		if got := wsReadDisconnectReason(tc.err, tc.writeFailed); got != tc.want {
+			t.Fatalf("%s: expected %s, got %s", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestDisconnectClientCountsReasonOnce(t *testing.T) {
+	hub := newHub(4)
+	client := fakeClient(hub)
+	hub.registerClient(client)
+	before := stats.SnapshotNow().Disconnects[string(DisconnectKicked)]
+
+	hub.disconnectClient(client, DisconnectKicked)
+	hub.disconnectClient(client, DisconnectKicked)
+
+	if got := stats.SnapshotNow().Disconnects[string(DisconnectKicked)] - before; got != 1 {
+		t.Fatalf("expected one kicked disconnect, got %d", got)
+	}
+}
+
+func TestEvictStaleSSECountsIdleTimeout(t *testing.T) {
+	hub := newHub(4)
+	client := fakeClient(hub)
+	client.transport = TransportSSE
+	client.lastSeen = time.Now().Add(-time.Hour).UnixNano()
+	hub.registerClient(client)
+	before := stats.SnapshotNow().Disconnects[string(DisconnectIdleTimeout)]
+
+	hub.evictStaleSSE()
+
+	if got := stats.SnapshotNow().Disconnects[string(DisconnectIdleTimeout)] - before; got != 1 {
+		t.Fatalf("expected one idle_timeout disconnect, got %d", got)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"serenada/server/internal/stats"
+)
+
+// defaultDrainGrace leaves clients time to rejoin elsewhere before a drained
+// process exits.
+const defaultDrainGrace = 30 * time.Second
+
+// drainGrace is how long the server keeps running after SIGUSR1 starts a
+// drain, so participants can move to another instance at their own pace. Set
+// from DRAIN_GRACE_SECONDS at startup; zero exits right after notifying.
+var drainGrace = defaultDrainGrace
+
+func parseDrainGrace(raw string) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || seconds < 0 {
+		return defaultDrainGrace
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func (h *Hub) isDraining() bool {
+	return h.draining.Load()
+}
+
+// startDrain puts the hub into draining mode: joins are refused with
+// SERVER_DRAINING, new WebSocket and SSE connections with 503, and every
+// participant is sent server_draining so it reconnects to another instance.
+// Connections already open are left alone. It reports false if the hub was
+// already draining.
+func (h *Hub) startDrain(grace time.Duration) bool {
+	if !h.draining.CompareAndSwap(false, true) {
+		return false
+	}
+	stats.SetDraining(true)
+
+	payload, _ := json.Marshal(map[string]int64{"graceMs": grace.Milliseconds()})
+	h.mu.RLock()
+	rooms := make([]*Room, 0, len(h.rooms))
+	for _, room := range h.rooms {
+		rooms = append(rooms, room)
+	}
+	h.mu.RUnlock()
+
+	notified := 0
+	for _, room := range rooms {
+		room.mu.Lock()
+		participants := make([]*Client, 0, len(room.Participants))
+		for client := range room.Participants {
+			participants = append(participants, client)
+		}
+		rid := room.RID
+		room.mu.Unlock()
+
+		msg := Message{V: 1, Type: "server_draining", RID: rid, Payload: payload}
+		for _, client := range participants {
+			client.sendMessage(msg)
+			notified++
+		}
+	}
+	log.Printf("[DRAIN] Draining: notified %d participants in %d rooms, exiting in %s", notified, len(rooms), grace)
+	return true
+}
+
+func rejectDraining(w http.ResponseWriter, kind, ip string) {
+	log.Printf("[%s] Rejected connection from %s: server is draining", strings.ToUpper(kind), ip)
+	stats.IncConnectionFailure(kind)
+	http.Error(w, "Server is draining", http.StatusServiceUnavailable)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"serenada/server/internal/stats"
+)
+
+func TestStartDrainNotifiesParticipantsAndRejectsJoins(t *testing.T) {
+	defer stats.SetDraining(false)
+	hub, rid, host, guest, _ := joinedPair(t)
+	drainMessages(host)
+	drainMessages(guest)
+
+	if !hub.startDrain(10 * time.Second) {
+		t.Fatal("expected the first startDrain to start draining")
+	}
+	if hub.startDrain(10 * time.Second) {
+		t.Fatal("expected a second startDrain to be a no-op")
+	}
+	if !stats.SnapshotNow().Draining {
+		t.Fatal("expected the stats snapshot to report draining")
+	}
+
+	for _, c := range []*Client{host, guest} {
+		msgs := drainMessages(c)
+		notice := findMessage(msgs, "server_draining")
+		if notice == nil || notice.RID != rid {
+			t.Fatalf("expected server_draining for room %s, got %+v", rid, msgs)
+		}
+		var payload struct {
+			GraceMs int64 `json:"graceMs"`
+		}
+		_ = json.Unmarshal(notice.Payload, &payload)
+		if payload.GraceMs != 10000 {
+			t.Fatalf("expected graceMs 10000, got %d", payload.GraceMs)
+		}
+		if len(msgs) != 1 {
+			t.Fatalf("expected only server_draining, got %d messages", len(msgs))
+		}
+	}
+
+	late := fakeClient(hub)
+	hub.registerClient(late)
+	hub.handleMessage(late, joinPayload(rid, 4, 4))
+	if code := errorCode(findMessage(drainMessages(late), "error")); code != "SERVER_DRAINING" {
+		t.Fatalf("expected SERVER_DRAINING, got %q", code)
+	}
+}
+
+func TestDrainingRejectsNewConnections(t *testing.T) {
+	defer stats.SetDraining(false)
+	hub := newHub(4)
+	hub.startDrain(0)
+
+	for name, serve := range map[string]http.HandlerFunc{
+		"ws":  func(w http.ResponseWriter, r *http.Request) { serveWs(hub, w, r) },
+		"sse": handleSSE(hub),
+	} {
+		rec := httptest.NewRecorder()
+		serve(rec, httptest.NewRequest(http.MethodGet, "/"+name, nil))
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("%s: expected 503 while draining, got %d", name, rec.Code)
+		}
+	}
+}
+
+func TestParseDrainGrace(t *testing.T) {
+	for raw, want := range map[string]time.Duration{
+		"":    defaultDrainGrace,
+		"-1":  defaultDrainGrace,
+		"abc": defaultDrainGrace,
+		"0":   0,
+		"90":  90 * time.Second,
+	} {
+		if got := parseDrainGrace(raw); got != want {
+			t.Errorf("parseDrainGrace(%q) = %s, want %s", raw, got, want)
+		}
+	}
+}
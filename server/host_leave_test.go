@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func leavePayload(rid string) []byte {
+	b, _ := json.Marshal(Message{V: 1, Type: "leave", RID: rid})
+	return b
+}
+
+func hostAndGuest(t *testing.T, hub *Hub) (string, *Client, *Client) {
+	t.Helper()
+	rid := mustTestRoomID(t)
+
+	host := fakeClient(hub)
+	hub.registerClient(host)
+	hub.handleMessage(host, joinPayload(rid, 4, 4))
+
+	guest := fakeClient(hub)
+	hub.registerClient(guest)
+	hub.handleMessage(guest, joinPayload(rid, 4, 4))
+
+	drainMessages(host)
+	drainMessages(guest)
+	return rid, host, guest
+}
+
+func TestHostLeaveTransfersHostByDefault(t *testing.T) {
+	hub := newHub(4)
+	rid, host, guest := hostAndGuest(t, hub)
+	guestCID := guest.cid
+
+	hub.handleMessage(host, leavePayload(rid))
+
+	msgs := drainMessages(guest)
+	if findMessage(msgs, "room_ended") != nil {
+		t.Fatal("room must not end under the transfer policy")
+	}
+	state := findMessage(msgs, "room_state")
+	if state == nil {
+		t.Fatal("expected room_state after host left")
+	}
+	var payload struct {
+		HostCID string `json:"hostCid"`
+	}
+	_ = json.Unmarshal(state.Payload, &payload)
+	if payload.HostCID != guestCID {
+		t.Fatalf("expected host to transfer to %s, got %s", guestCID, payload.HostCID)
+	}
+}
+
+func TestHostLeaveEndsRoomUnderEndPolicy(t *testing.T) {
+	hub := newHub(4)
+	hub.hostLeavePolicy = HostLeaveEnd
+	rid, host, guest := hostAndGuest(t, hub)
+	hostCID := host.cid
+
+	hub.handleMessage(host, leavePayload(rid))
+
+	ended := findMessage(drainMessages(guest), "room_ended")
+	if ended == nil {
+		t.Fatal("expected room_ended after host left")
+	}
+	var payload map[string]string
+	_ = json.Unmarshal(ended.Payload, &payload)
+	if payload["by"] != hostCID || payload["reason"] != "host_left" {
+		t.Fatalf("unexpected room_ended payload: %v", payload)
+	}
+
+	hub.mu.RLock()
+	_, exists := hub.rooms[rid]
+	hub.mu.RUnlock()
+	if exists {
+		t.Fatal("expected room to be deleted")
+	}
+}
+
+func TestGuestLeaveDoesNotEndRoomUnderEndPolicy(t *testing.T) {
+	hub := newHub(4)
+	hub.hostLeavePolicy = HostLeaveEnd
+	rid, host, guest := hostAndGuest(t, hub)
+
+	hub.handleMessage(guest, leavePayload(rid))
+
+	if findMessage(drainMessages(host), "room_ended") != nil {
+		t.Fatal("only the host leaving should end the room")
+	}
+}
+
+func TestParseHostLeavePolicy(t *testing.T) {
+	cases := map[string]HostLeavePolicy{
+		"":         HostLeaveTransfer,
+		"transfer": HostLeaveTransfer,
+		" END ":    HostLeaveEnd,
+		"bogus":    HostLeaveTransfer,
+	}
+	for raw, want := range cases {
+		if got := parseHostLeavePolicy(raw); got != want {
+			t.Fatalf("parseHostLeavePolicy(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+)
+
+const (
+	hotRoomSampleInterval = 10 * time.Second
+	hotRoomTopN           = 20
+)
+
+// RoomRelayRate is one entry of the busiest-rooms list.
+type RoomRelayRate struct {
+	RID              string  `json:"rid"`
+	RelayRate        float64 `json:"relayRate"` // relayed messages per second over the last sample interval
+	ParticipantCount int     `json:"participantCount"`
+}
+
+// sampleRoomRelayRates reads and resets every room's relay counter and keeps
+// the top-N rooms by rate. Rooms with no relays in the interval are skipped.
+func (h *Hub) sampleRoomRelayRates(interval time.Duration) {
+	seconds := interval.Seconds()
+	if seconds <= 0 {
+		return
+	}
+
+	h.mu.RLock()
+	rates := make([]RoomRelayRate, 0)
+	for rid, room := range h.rooms {
+		room.mu.Lock()
+		count := room.relayCount
+		room.relayCount = 0
+		participants := len(room.Participants)
+		room.mu.Unlock()
+
+		if count == 0 {
+			continue
+		}
+		rates = append(rates, RoomRelayRate{
+			RID:              rid,
+			RelayRate:        float64(count) / seconds,
+			ParticipantCount: participants,
+		})
+	}
+	h.mu.RUnlock()
+
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].RelayRate != rates[j].RelayRate {
+			return rates[i].RelayRate > rates[j].RelayRate
+		}
+		return rates[i].RID < rates[j].RID
+	})
+	if len(rates) > hotRoomTopN {
+		rates = rates[:hotRoomTopN]
+	}
+
+	h.hotRoomsMu.Lock()
+	h.hotRooms = rates
+	h.hotRoomsMu.Unlock()
+}
+
+func (h *Hub) hotRoomsSnapshot() []RoomRelayRate {
+	h.hotRoomsMu.RLock()
+	defer h.hotRoomsMu.RUnlock()
+	return append([]RoomRelayRate{}, h.hotRooms...)
+}
+
+func handleInternalHotRooms(hub *Hub) http.HandlerFunc {
+	access := internalAccessFromEnv()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !access.authorize(w, r, http.MethodGet) {
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"intervalMs": hotRoomSampleInterval.Milliseconds(),
+			"rooms":      hub.hotRoomsSnapshot(),
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSampleRoomRelayRatesOrdersBusiestFirst(t *testing.T) {
+	hub := newHub(4)
+	quiet := &Room{Participants: map[*Client]string{fakeClient(hub): "C-1"}, relayCount: 5}
+	busy := &Room{Participants: map[*Client]string{fakeClient(hub): "C-1", fakeClient(hub): "C-2"}, relayCount: 30}
+	idle := &Room{Participants: map[*Client]string{fakeClient(hub): "C-1"}}
+	hub.rooms["quiet"] = quiet
+	hub.rooms["busy"] = busy
+	hub.rooms["idle"] = idle
+
+	hub.sampleRoomRelayRates(10 * time.Second)
+
+	rates := hub.hotRoomsSnapshot()
+	if len(rates) != 2 {
+		t.Fatalf("expected idle room to be skipped, got %+v", rates)
+	}
+	if rates[0].RID != "busy" || rates[0].RelayRate != 3 || rates[0].ParticipantCount != 2 {
+		t.Fatalf("unexpected top entry: %+v", rates[0])
+	}
+	if rates[1].RID != "quiet" || rates[1].RelayRate != 0.5 {
+		t.Fatalf("unexpected second entry: %+v", rates[1])
+	}
+	if busy.relayCount != 0 || quiet.relayCount != 0 {
+		t.Fatal("expected relay counters to reset after sampling")
+	}
+
+	hub.sampleRoomRelayRates(10 * time.Second)
+	if rates := hub.hotRoomsSnapshot(); len(rates) != 0 {
+		t.Fatalf("expected empty list after a quiet interval, got %+v", rates)
+	}
+}
+
+func TestInternalHotRoomsRequiresToken(t *testing.T) {
+	t.Setenv("ENABLE_INTERNAL_STATS", "1")
+	t.Setenv("INTERNAL_STATS_TOKEN", "test-token")
+
+	handler := handleInternalHotRooms(newHub(4))
+	req := httptest.NewRequest(http.MethodGet, "/api/internal/hot-rooms", nil)
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected %d, got %d", http.StatusUnauthorized, rec.Code)
+	}
+}
+
+func TestInternalHotRoomsSuccessWithToken(t *testing.T) {
+	t.Setenv("ENABLE_INTERNAL_STATS", "1")
+	t.Setenv("INTERNAL_STATS_TOKEN", "test-token")
+
+	hub := newHub(4)
+	hub.rooms["busy"] = &Room{Participants: map[*Client]string{fakeClient(hub): "C-1"}, relayCount: 20}
+	hub.sampleRoomRelayRates(10 * time.Second)
+
+	handler := handleInternalHotRooms(hub)
+	req := httptest.NewRequest(http.MethodGet, "/api/internal/hot-rooms", nil)
+	req.Header.Set("X-Internal-Token", "test-token")
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
+	}
+	var body struct {
+		Rooms []RoomRelayRate `json:"rooms"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(body.Rooms) != 1 || body.Rooms[0].RID != "busy" || body.Rooms[0].RelayRate != 2 {
+		t.Fatalf("unexpected hot rooms: %+v", body.Rooms)
+	}
+}
@@ -0,0 +1,30 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAtomicWriteFileReplacesAndLeavesNoTemp(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "nested", "report.json")
+
+	for _, content := range []string{"first", "second"} {
+		if err := AtomicWriteFile(path, []byte(content)); err != nil {
+			t.Fatalf("AtomicWriteFile: %v", err)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil || string(got) != content {
+			t.Fatalf("expected %q, got %q (%v)", content, got, err)
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected only the target file, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,86 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OpenMetricsContentType is the media type of WriteJoinLatencyOpenMetrics.
+const OpenMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// joinExemplar is the most recent join that landed in a latency bucket, so a
+// slow bucket in the histogram links to a connection that can be looked up
+// in the logs.
+type joinExemplar struct {
+	connID string
+	ms     int64
+	at     time.Time
+}
+
+var (
+	joinExemplarsMu sync.Mutex
+	joinExemplars   []joinExemplar // per bucket, parallel to joinLatencyBuckets
+)
+
+func recordJoinExemplar(bucketIndex int, connID string, ms int64) {
+	if connID == "" {
+		return
+	}
+	joinExemplarsMu.Lock()
+	if joinExemplars == nil {
+		joinExemplars = make([]joinExemplar, len(joinLatencyBuckets))
+	}
+	joinExemplars[bucketIndex] = joinExemplar{connID: connID, ms: ms, at: time.Now()}
+	joinExemplarsMu.Unlock()
+}
+
+// WriteJoinLatencyOpenMetrics writes the join-latency histogram in OpenMetrics
+// text format, in seconds. Each bucket carries the most recent join in it as
+// an exemplar labelled with its connection (session) ID.
+func WriteJoinLatencyOpenMetrics(w io.Writer) error {
+	joinExemplarsMu.Lock()
+	exemplars := append([]joinExemplar(nil), joinExemplars...)
+	joinExemplarsMu.Unlock()
+
+	var b strings.Builder
+	const name = "serenada_join_latency_seconds"
+	fmt.Fprintf(&b, "# TYPE %s histogram\n", name)
+	fmt.Fprintf(&b, "# UNIT %s seconds\n", name)
+	fmt.Fprintf(&b, "# HELP %s Time from join received to joined sent.\n", name)
+
+	var cumulative int64
+	for i := range joinLatencyBuckets {
+		cumulative += joinLatencyBuckets[i].Load()
+		le := "+Inf"
+		if i < len(joinLatencyBoundariesMs) {
+			le = formatSeconds(joinLatencyBoundariesMs[i])
+		}
+		fmt.Fprintf(&b, "%s_bucket{le=\"%s\"} %d", name, le, cumulative)
+		if i < len(exemplars) && exemplars[i].connID != "" {
+			e := exemplars[i]
+			fmt.Fprintf(&b, " # {conn_id=\"%s\"} %s %s", escapeLabelValue(e.connID), formatSeconds(e.ms),
+				strconv.FormatFloat(float64(e.at.UnixMilli())/1000, 'f', 3, 64))
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "%s_sum %s\n", name, formatSeconds(joinLatencySumMs.Load()))
+	fmt.Fprintf(&b, "%s_count %d\n", name, cumulative)
+	b.WriteString("# EOF\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func formatSeconds(ms int64) string {
+	return strconv.FormatFloat(float64(ms)/1000, 'f', -1, 64)
+}
+
+func escapeLabelValue(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `"`, `\"`)
+	return strings.ReplaceAll(v, "\n", `\n`)
+}
@@ -2,22 +2,83 @@ package stats
 
 import (
 	"runtime"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
 )
 
+// deployLabel tags every snapshot so metrics from builds running side by side
+// (A/B or canary deploys) can be told apart. Set once from DEPLOY_LABEL.
+var deployLabel atomic.Value // string
+
+// SetDeployLabel sets the label reported as deployLabel in snapshots.
+func SetDeployLabel(label string) {
+	deployLabel.Store(label)
+}
+
+func DeployLabel() string {
+	label, _ := deployLabel.Load().(string)
+	return label
+}
+
+var draining atomic.Bool
+
+// SetDraining records whether the server is draining for shutdown.
+func SetDraining(v bool) {
+	draining.Store(v)
+}
+
+// sendQueueSize is the configured per-client send buffer (SEND_QUEUE_SIZE),
+// reported so load tests can size their suggestions from the real value.
+var sendQueueSize atomic.Int64
+
+// SetSendQueueSize sets the size reported as sendQueueSize in snapshots.
+func SetSendQueueSize(n int) {
+	sendQueueSize.Store(int64(n))
+}
+
+// sendQueueHighWater is the deepest any client's send queue has been asked to
+// get since startup; a message arriving at a full queue counts as one more
+// than its capacity.
+var sendQueueHighWater atomic.Int64
+
+// ObserveSendQueueDepth raises the reported sendQueueHighWater to depth.
+func ObserveSendQueueDepth(depth int) {
+	n := int64(depth)
+	for {
+		current := sendQueueHighWater.Load()
+		if n <= current || sendQueueHighWater.CompareAndSwap(current, n) {
+			return
+		}
+	}
+}
+
 var joinLatencyBoundariesMs = []int64{5, 10, 25, 50, 100, 200, 500, 1000, 2000, 5000, 10000}
 
+// A relay is forwarded in-process, so its buckets are in microseconds; the
+// upper ones catch room lock contention under load.
+var relayLatencyBoundariesUs = []int64{10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 50000}
+
 // Snapshot is a point-in-time view of signaling stats.
 type Snapshot struct {
-	TimestampMs int64                `json:"timestampMs"`
-	Gauges      SnapshotGauges       `json:"gauges"`
-	Counters    SnapshotCounters     `json:"counters"`
-	Messages    SnapshotMessages     `json:"messages"`
-	JoinLatency SnapshotJoinLatency  `json:"joinLatency"`
-	Disconnects map[string]int64     `json:"disconnects"`
-	Runtime     SnapshotRuntimeStats `json:"runtime"`
+	TimestampMs int64               `json:"timestampMs"`
+	DeployLabel string              `json:"deployLabel,omitempty"`
+	Draining    bool                `json:"draining"` // SIGUSR1 started a graceful drain
+	Gauges      SnapshotGauges      `json:"gauges"`
+	Counters    SnapshotCounters    `json:"counters"`
+	Messages    SnapshotMessages    `json:"messages"`
+	JoinLatency SnapshotJoinLatency `json:"joinLatency"`
+	// Time from a relay message reaching handleRelay to each copy being
+	// queued for a recipient.
+	RelayLatency SnapshotRelayLatency `json:"relayLatency"`
+	Disconnects  map[string]int64     `json:"disconnects"`
+	Runtime      SnapshotRuntimeStats `json:"runtime"`
+
+	// Per-client send buffer in messages (SEND_QUEUE_SIZE), and the deepest
+	// any client's buffer has been asked to get (capacity+1 once one overflowed).
+	SendQueueSize      int64 `json:"sendQueueSize,omitempty"`
+	SendQueueHighWater int64 `json:"sendQueueHighWater,omitempty"`
 }
 
 type SnapshotGauges struct {
@@ -27,6 +88,25 @@ type SnapshotGauges struct {
 	ActiveRooms          int64 `json:"activeRooms"`
 	WatcherRooms         int64 `json:"watcherRooms"`
 	WatcherSubscriptions int64 `json:"watcherSubscriptions"`
+
+	// Per-connection goroutines (read/write loops) the hub expects for its
+	// clients vs. those actually running. Actual persistently above expected
+	// points at a leak in a disconnect path.
+	ConnGoroutinesExpected int64 `json:"connGoroutinesExpected"`
+	ConnGoroutinesActual   int64 `json:"connGoroutinesActual"`
+
+	// RoomSizes maps current participant count ("1", "2", ...) to the number
+	// of rooms of that size.
+	RoomSizes map[string]int64 `json:"roomSizes"`
+
+	// Outbound push provider sends running now vs. waiting for a free slot
+	// (PUSH_SEND_CONCURRENCY).
+	PushSendsInFlight int64 `json:"pushSendsInFlight"`
+	PushSendsQueued   int64 `json:"pushSendsQueued"`
+
+	// Rooms with 2+ participants and no signaling for ROOM_STALL_TIMEOUT_SECONDS
+	// as of the last check.
+	StalledRooms int64 `json:"stalledRooms"`
 }
 
 type SnapshotCounters struct {
@@ -37,6 +117,54 @@ type SnapshotCounters struct {
 	ConnectionSuccessSSE  int64 `json:"connectionSuccessSse"`
 	ConnectionFailuresSSE int64 `json:"connectionFailuresSse"`
 	SendQueueDropTotal    int64 `json:"sendQueueDropTotal"`
+	SendAfterCloseTotal   int64 `json:"sendAfterCloseTotal"`
+	RelayReceiptsTotal    int64 `json:"relayReceiptsTotal"`
+	RelayReceiptsWithDrop int64 `json:"relayReceiptsWithDrop"`
+	JoinShedTotal         int64 `json:"joinShedTotal"`
+	ClientCapRejected     int64 `json:"clientCapRejected"`
+	ConnLimitRejected     int64 `json:"connLimitRejected"`
+	ReconnectBlockedTotal int64 `json:"reconnectBlockedTotal"`
+	StalledRoomsClosed    int64 `json:"stalledRoomsClosed"`
+	IdleRoomsReaped       int64 `json:"idleRoomsReaped"`
+	RoomBlockedTotal      int64 `json:"roomBlockedTotal"`
+	RelayRoomGoneTotal    int64 `json:"relayRoomGoneTotal"`
+	JoinRateLimitedTotal  int64 `json:"joinRateLimitedTotal"`
+	TimeSyncTotal         int64 `json:"timeSyncTotal"`
+	TimeSyncRateLimited   int64 `json:"timeSyncRateLimited"`
+
+	// Relays addressed with toList, and the total recipients they reached.
+	PartialRelayTotal  int64 `json:"partialRelayTotal"`
+	PartialRelayFanout int64 `json:"partialRelayFanout"`
+
+	// Relay messages accepted from senders and the copies queued to
+	// recipients; out/in is the mesh fan-out amplification.
+	RelayInTotal       int64 `json:"relayInTotal"`
+	RelayOutTotal      int64 `json:"relayOutTotal"`
+	RelayTargetMissing int64 `json:"relayTargetMissing"`
+
+	// Call tokens accepted without a session in their room: tokens minted
+	// before the room binding, or clients that send no sid. Enforced with
+	// TURN_REQUIRE_SESSION once this stays at zero.
+	TurnUnboundCallTokens int64 `json:"turnUnboundCallTokens"`
+
+	MediaStateChanges int64 `json:"mediaStateChanges"`
+
+	// Presence hints accepted from senders and the copies queued to peers.
+	PresenceInTotal  int64 `json:"presenceInTotal"`
+	PresenceOutTotal int64 `json:"presenceOutTotal"`
+
+	KeepalivePings int64 `json:"keepalivePings"`
+
+	SSEMessagesCompressed int64 `json:"sseMessagesCompressed"`
+	SSEMessagesRaw        int64 `json:"sseMessagesRaw"`
+	SSESessionsRenewed    int64 `json:"sseSessionsRenewed"`
+	SSETakeoversRejected  int64 `json:"sseTakeoversRejected"`
+	SSEStaleWarnings      int64 `json:"sseStaleWarnings"`
+	SSEEventsReplayed     int64 `json:"sseEventsReplayed"`
+	SSEReplayGaps         int64 `json:"sseReplayGaps"`
+
+	// Full send buffers, by the SEND_QUEUE_POLICY that handled them.
+	SendQueueOverflowByPolicy map[string]int64 `json:"sendQueueOverflowByPolicy"`
 }
 
 type SnapshotMessages struct {
@@ -44,6 +172,12 @@ type SnapshotMessages struct {
 	TxTotal  int64            `json:"txTotal"`
 	RxByType map[string]int64 `json:"rxByType"`
 	TxByType map[string]int64 `json:"txByType"`
+
+	// Inbound messages rejected with TYPE_RATE_LIMITED, by type.
+	RateLimitedByType map[string]int64 `json:"rateLimitedByType"`
+
+	// Inbound messages rejected with PAYLOAD_TOO_LARGE, by type.
+	PayloadTooLargeByType map[string]int64 `json:"payloadTooLargeByType"`
 }
 
 type SnapshotJoinLatency struct {
@@ -51,6 +185,18 @@ type SnapshotJoinLatency struct {
 	BucketCounts []int64 `json:"bucketCounts"`
 	Total        int64   `json:"total"`
 	SumMs        int64   `json:"sumMs"`
+
+	// Percentiles from the buckets; see HistogramPercentile.
+	P50Ms float64 `json:"p50Ms"`
+	P90Ms float64 `json:"p90Ms"`
+	P99Ms float64 `json:"p99Ms"`
+}
+
+type SnapshotRelayLatency struct {
+	BoundariesUs []int64 `json:"boundariesUs"`
+	BucketCounts []int64 `json:"bucketCounts"`
+	Total        int64   `json:"total"`
+	SumUs        int64   `json:"sumUs"`
 }
 
 type SnapshotRuntimeStats struct {
@@ -119,22 +265,80 @@ var (
 	watcherRooms         atomic.Int64
 	watcherSubscriptions atomic.Int64
 
-	sendQueueDropTotal atomic.Int64
+	connGoroutinesExpected atomic.Int64
+	connGoroutinesActual   atomic.Int64
+
+	pushSendsInFlight atomic.Int64
+	pushSendsQueued   atomic.Int64
+	stalledRooms      atomic.Int64
+
+	roomSizesMu sync.Mutex
+	roomSizes   = map[string]int64{}
+
+	sendQueueDropTotal  atomic.Int64
+	sendAfterCloseTotal atomic.Int64
+
+	relayReceiptsTotal    atomic.Int64
+	relayReceiptsWithDrop atomic.Int64
+
+	joinShedTotal         atomic.Int64
+	clientCapRejected     atomic.Int64
+	connLimitRejected     atomic.Int64
+	reconnectBlockedTotal atomic.Int64
+	stalledRoomsClosed    atomic.Int64
+	idleRoomsReaped       atomic.Int64
+	roomBlockedTotal      atomic.Int64
+	relayRoomGoneTotal    atomic.Int64
+	joinRateLimitedTotal  atomic.Int64
+	timeSyncTotal         atomic.Int64
+	timeSyncRateLimited   atomic.Int64
+
+	partialRelayTotal  atomic.Int64
+	partialRelayFanout atomic.Int64
+
+	relayInTotal       atomic.Int64
+	relayOutTotal      atomic.Int64
+	relayTargetMissing atomic.Int64
+
+	turnUnboundCallTokens atomic.Int64
+
+	mediaStateChanges atomic.Int64
+	presenceInTotal   atomic.Int64
+	presenceOutTotal  atomic.Int64
+	keepalivePings    atomic.Int64
+
+	sseMessagesCompressed atomic.Int64
+	sseMessagesRaw        atomic.Int64
+	sseSessionsRenewed    atomic.Int64
+	sseTakeoversRejected  atomic.Int64
+	sseStaleWarnings      atomic.Int64
+	sseEventsReplayed     atomic.Int64
+	sseReplayGaps         atomic.Int64
 
 	messagesRXTotal  atomic.Int64
 	messagesTXTotal  atomic.Int64
 	messagesRXByType counterMap
 	messagesTXByType counterMap
 
+	messagesRateLimitedByType counterMap
+	messagesPayloadTooLarge   counterMap
+
 	disconnectsByReason counterMap
 
+	sendQueueOverflowByPolicy counterMap
+
 	joinLatencyTotal   atomic.Int64
 	joinLatencySumMs   atomic.Int64
 	joinLatencyBuckets []atomic.Int64
+
+	relayLatencyTotal   atomic.Int64
+	relayLatencySumUs   atomic.Int64
+	relayLatencyBuckets []atomic.Int64
 )
 
 func init() {
 	joinLatencyBuckets = make([]atomic.Int64, len(joinLatencyBoundariesMs)+1)
+	relayLatencyBuckets = make([]atomic.Int64, len(relayLatencyBoundariesUs)+1)
 }
 
 func IncConnectionAttempt(kind string) {
@@ -172,6 +376,30 @@ func AddActiveSSEClients(delta int64) {
 	activeSSEClients.Add(delta)
 }
 
+func AddPushSendsInFlight(delta int64) {
+	pushSendsInFlight.Add(delta)
+}
+
+func AddPushSendsQueued(delta int64) {
+	pushSendsQueued.Add(delta)
+}
+
+func SetStalledRooms(n int64) {
+	stalledRooms.Store(n)
+}
+
+// IncStalledRoomClosed counts rooms ended because ROOM_STALL_CLOSE is set and
+// they stalled.
+func IncStalledRoomClosed() {
+	stalledRoomsClosed.Add(1)
+}
+
+// IncIdleRoomReaped counts rooms ended because no participant had been seen
+// within ROOM_IDLE_TTL_SECONDS.
+func IncIdleRoomReaped() {
+	idleRoomsReaped.Add(1)
+}
+
 func SetActiveClients(value int64) {
 	activeClients.Store(value)
 }
@@ -188,10 +416,191 @@ func SetWatcherSubscriptions(value int64) {
 	watcherSubscriptions.Store(value)
 }
 
+// SetRoomSizes replaces the room size distribution with counts, indexed by
+// participant count.
+func SetRoomSizes(counts map[int]int64) {
+	sizes := make(map[string]int64, len(counts))
+	for size, n := range counts {
+		sizes[strconv.Itoa(size)] = n
+	}
+	roomSizesMu.Lock()
+	roomSizes = sizes
+	roomSizesMu.Unlock()
+}
+
+func snapshotRoomSizes() map[string]int64 {
+	roomSizesMu.Lock()
+	defer roomSizesMu.Unlock()
+	sizes := make(map[string]int64, len(roomSizes))
+	for k, v := range roomSizes {
+		sizes[k] = v
+	}
+	return sizes
+}
+
+func SetConnGoroutines(expected, actual int64) {
+	connGoroutinesExpected.Store(expected)
+	connGoroutinesActual.Store(actual)
+}
+
+// IncRelayReceipt counts a relay_receipt sent to a sender, and separately
+// those that reported at least one peer whose queue dropped the message.
+func IncRelayReceipt(hadDrop bool) {
+	relayReceiptsTotal.Add(1)
+	if hadDrop {
+		relayReceiptsWithDrop.Add(1)
+	}
+}
+
+// IncSendQueueOverflow counts a full send buffer handled by policy
+// (SEND_QUEUE_POLICY).
+func IncSendQueueOverflow(policy string) {
+	sendQueueOverflowByPolicy.Inc(policy)
+}
+
+// IncClientCapRejected counts WebSocket/SSE connections refused with 503
+// because the hub held MAX_CONCURRENT_CLIENTS clients.
+func IncClientCapRejected() {
+	clientCapRejected.Add(1)
+}
+
+// IncConnLimitRejected counts WebSocket/SSE connections refused with 429
+// because their IP was over CONN_LIMIT_PER_IP or CONN_RATE_PER_IP.
+func IncConnLimitRejected() {
+	connLimitRejected.Add(1)
+}
+
+// IncJoinShed counts joins rejected with SERVER_BUSY by the latency-based
+// load shedder.
+func IncJoinShed() {
+	joinShedTotal.Add(1)
+}
+
+// IncRelayRoomGone counts relays from clients whose room had already been
+// deleted. A spike means rooms end faster than clients tear down.
+func IncRelayRoomGone() {
+	relayRoomGoneTotal.Add(1)
+}
+
+// IncRoomBlocked counts joins rejected with ROOM_BLOCKED by the operator room
+// ID allowlist/denylist.
+func IncRoomBlocked() {
+	roomBlockedTotal.Add(1)
+}
+
+// IncTimeSync counts answered time_sync requests.
+func IncTimeSync() {
+	timeSyncTotal.Add(1)
+}
+
+// IncTimeSyncRateLimited counts time_sync requests rejected with
+// TIME_SYNC_RATE_LIMITED.
+func IncTimeSyncRateLimited() {
+	timeSyncRateLimited.Add(1)
+}
+
+// IncJoinRateLimited counts joins rejected with JOIN_RATE_LIMITED.
+func IncJoinRateLimited() {
+	joinRateLimitedTotal.Add(1)
+}
+
+// IncReconnectBlocked counts reconnect joins rejected with RECONNECT_BLOCKED
+// after repeated invalid reconnect tokens from the same IP.
+func IncReconnectBlocked() {
+	reconnectBlockedTotal.Add(1)
+}
+
+// IncPartialRelay counts one toList relay that reached fanout participants.
+func IncPartialRelay(fanout int) {
+	partialRelayTotal.Add(1)
+	partialRelayFanout.Add(int64(fanout))
+}
+
+// IncRelay counts one relay accepted from a sender and the copies of it
+// queued to other participants.
+func IncRelay(delivered int) {
+	relayInTotal.Add(1)
+	relayOutTotal.Add(int64(delivered))
+}
+
+// IncTurnUnboundCallToken counts a call token accepted without a session in
+// its room while TURN_REQUIRE_SESSION is off.
+func IncTurnUnboundCallToken() {
+	turnUnboundCallTokens.Add(1)
+}
+
+// IncRelayTargetMissing counts a relay dropped because its "to" CID was not
+// in the sender's room.
+func IncRelayTargetMissing() {
+	relayTargetMissing.Add(1)
+}
+
+func IncMediaStateChange() {
+	mediaStateChanges.Add(1)
+}
+
+// IncPresence counts one presence message relayed to recipients peers.
+func IncPresence(recipients int) {
+	presenceInTotal.Add(1)
+	presenceOutTotal.Add(int64(recipients))
+}
+
+// IncKeepalivePing counts one-way keepalive pings, which get no pong.
+func IncKeepalivePing() {
+	keepalivePings.Add(1)
+}
+
+// IncSSESessionRenewed counts SSE sids replaced for exceeding
+// SSE_SESSION_MAX_AGE_SECONDS.
+func IncSSESessionRenewed() {
+	sseSessionsRenewed.Add(1)
+}
+
+// IncSSETakeoverRejected counts SSE streams refused for presenting a live
+// in-room session's sid without its reconnectToken
+// (SSE_SID_COLLISION_POLICY=verify).
+func IncSSETakeoverRejected() {
+	sseTakeoversRejected.Add(1)
+}
+
+// IncSSEStaleWarning counts stale_warning messages sent ahead of SSE stale
+// eviction (SSE_STALE_WARNING_SECONDS).
+func IncSSEStaleWarning() {
+	sseStaleWarnings.Add(1)
+}
+
+// AddSSEEventsReplayed counts events resent to a reconnecting SSE stream from
+// its Last-Event-ID.
+func AddSSEEventsReplayed(n int) {
+	sseEventsReplayed.Add(int64(n))
+}
+
+// IncSSEReplayGap counts SSE resumptions whose Last-Event-ID was older than
+// the replay buffer, so some events could not be resent.
+func IncSSEReplayGap() {
+	sseReplayGaps.Add(1)
+}
+
+// IncSSEMessage counts a message written to an SSE stream, split by whether
+// it was sent gzip-compressed.
+func IncSSEMessage(compressed bool) {
+	if compressed {
+		sseMessagesCompressed.Add(1)
+		return
+	}
+	sseMessagesRaw.Add(1)
+}
+
 func IncSendQueueDrop() {
 	sendQueueDropTotal.Add(1)
 }
 
+// IncSendAfterClose counts messages addressed to a client whose transport had
+// already been closed. These are distinct from queue drops on a live client.
+func IncSendAfterClose() {
+	sendAfterCloseTotal.Add(1)
+}
+
 func IncMessageRX(messageType string) {
 	messagesRXTotal.Add(1)
 	messagesRXByType.Inc(messageType)
@@ -202,11 +611,59 @@ func IncMessageTX(messageType string) {
 	messagesTXByType.Inc(messageType)
 }
 
+// IncMessageRateLimited counts an inbound message dropped by its type's rate
+// limit (RELAY_TYPE_RATE_LIMITS).
+func IncMessageRateLimited(messageType string) {
+	messagesRateLimitedByType.Inc(messageType)
+}
+
+// IncMessagePayloadTooLarge counts an inbound message rejected because its
+// payload exceeded its type's limit (MESSAGE_PAYLOAD_LIMITS).
+func IncMessagePayloadTooLarge(messageType string) {
+	messagesPayloadTooLarge.Inc(messageType)
+}
+
 func IncDisconnect(reason string) {
 	disconnectsByReason.Inc(reason)
 }
 
-func RecordJoinLatency(duration time.Duration) {
+// HistogramPercentile estimates the q-th quantile (0 < q <= 1) of a bucketed
+// histogram as the upper boundary of the bucket holding the nearest-rank
+// sample. counts has one more entry than boundaries for the overflow bucket,
+// which reports the top boundary plus one. An empty histogram reports 0.
+func HistogramPercentile(boundaries, counts []int64, q float64) float64 {
+	var total int64
+	for _, c := range counts {
+		total += c
+	}
+	if total == 0 {
+		return 0
+	}
+
+	threshold := int64(float64(total)*q + 0.999999)
+	if threshold <= 0 {
+		threshold = 1
+	}
+
+	var cumulative int64
+	for i, c := range counts {
+		cumulative += c
+		if cumulative >= threshold {
+			if i < len(boundaries) {
+				return float64(boundaries[i])
+			}
+			if len(boundaries) == 0 {
+				return 0
+			}
+			return float64(boundaries[len(boundaries)-1] + 1)
+		}
+	}
+	return 0
+}
+
+// RecordJoinLatency adds a join to the latency histogram. connID, if set, is
+// kept as the exemplar for its bucket; see WriteJoinLatencyOpenMetrics.
+func RecordJoinLatency(duration time.Duration, connID string) {
 	ms := duration.Milliseconds()
 	if ms < 0 {
 		ms = 0
@@ -223,6 +680,27 @@ func RecordJoinLatency(duration time.Duration) {
 		}
 	}
 	joinLatencyBuckets[bucketIndex].Add(1)
+	recordJoinExemplar(bucketIndex, connID, ms)
+}
+
+// RecordRelayLatency adds one relayed copy to the relay latency histogram.
+func RecordRelayLatency(duration time.Duration) {
+	us := duration.Microseconds()
+	if us < 0 {
+		us = 0
+	}
+
+	relayLatencyTotal.Add(1)
+	relayLatencySumUs.Add(us)
+
+	bucketIndex := len(relayLatencyBoundariesUs)
+	for i, boundary := range relayLatencyBoundariesUs {
+		if us <= boundary {
+			bucketIndex = i
+			break
+		}
+	}
+	relayLatencyBuckets[bucketIndex].Add(1)
 }
 
 func SnapshotNow() Snapshot {
@@ -239,6 +717,10 @@ func SnapshotNow() Snapshot {
 	for i := range joinLatencyBuckets {
 		bucketCounts[i] = joinLatencyBuckets[i].Load()
 	}
+	relayBucketCounts := make([]int64, len(relayLatencyBuckets))
+	for i := range relayLatencyBuckets {
+		relayBucketCounts[i] = relayLatencyBuckets[i].Load()
+	}
 
 	rx := messagesRXByType.Snapshot()
 	tx := messagesTXByType.Snapshot()
@@ -246,6 +728,8 @@ func SnapshotNow() Snapshot {
 
 	return Snapshot{
 		TimestampMs: time.Now().UnixMilli(),
+		DeployLabel: DeployLabel(),
+		Draining:    draining.Load(),
 		Gauges: SnapshotGauges{
 			ActiveClients:        activeClients.Load(),
 			ActiveWSClients:      activeWSClients.Load(),
@@ -253,6 +737,16 @@ func SnapshotNow() Snapshot {
 			ActiveRooms:          activeRooms.Load(),
 			WatcherRooms:         watcherRooms.Load(),
 			WatcherSubscriptions: watcherSubscriptions.Load(),
+
+			ConnGoroutinesExpected: connGoroutinesExpected.Load(),
+			ConnGoroutinesActual:   connGoroutinesActual.Load(),
+
+			RoomSizes: snapshotRoomSizes(),
+
+			PushSendsInFlight: pushSendsInFlight.Load(),
+			PushSendsQueued:   pushSendsQueued.Load(),
+
+			StalledRooms: stalledRooms.Load(),
 		},
 		Counters: SnapshotCounters{
 			ConnectionAttemptsWS:  connectionAttemptsWS.Load(),
@@ -262,18 +756,63 @@ func SnapshotNow() Snapshot {
 			ConnectionSuccessSSE:  connectionSuccessSSE.Load(),
 			ConnectionFailuresSSE: connectionFailuresSSE.Load(),
 			SendQueueDropTotal:    sendQueueDropTotal.Load(),
+			SendAfterCloseTotal:   sendAfterCloseTotal.Load(),
+			RelayReceiptsTotal:    relayReceiptsTotal.Load(),
+			RelayReceiptsWithDrop: relayReceiptsWithDrop.Load(),
+			JoinShedTotal:         joinShedTotal.Load(),
+			ClientCapRejected:     clientCapRejected.Load(),
+			ConnLimitRejected:     connLimitRejected.Load(),
+			ReconnectBlockedTotal: reconnectBlockedTotal.Load(),
+			StalledRoomsClosed:    stalledRoomsClosed.Load(),
+			IdleRoomsReaped:       idleRoomsReaped.Load(),
+			RoomBlockedTotal:      roomBlockedTotal.Load(),
+			RelayRoomGoneTotal:    relayRoomGoneTotal.Load(),
+			JoinRateLimitedTotal:  joinRateLimitedTotal.Load(),
+			TimeSyncTotal:         timeSyncTotal.Load(),
+			TimeSyncRateLimited:   timeSyncRateLimited.Load(),
+			PartialRelayTotal:     partialRelayTotal.Load(),
+			PartialRelayFanout:    partialRelayFanout.Load(),
+			RelayInTotal:          relayInTotal.Load(),
+			RelayOutTotal:         relayOutTotal.Load(),
+			RelayTargetMissing:    relayTargetMissing.Load(),
+			TurnUnboundCallTokens: turnUnboundCallTokens.Load(),
+			MediaStateChanges:     mediaStateChanges.Load(),
+			PresenceInTotal:       presenceInTotal.Load(),
+			PresenceOutTotal:      presenceOutTotal.Load(),
+			KeepalivePings:        keepalivePings.Load(),
+			SSEMessagesCompressed: sseMessagesCompressed.Load(),
+			SSEMessagesRaw:        sseMessagesRaw.Load(),
+			SSESessionsRenewed:    sseSessionsRenewed.Load(),
+			SSETakeoversRejected:  sseTakeoversRejected.Load(),
+			SSEStaleWarnings:      sseStaleWarnings.Load(),
+			SSEEventsReplayed:     sseEventsReplayed.Load(),
+			SSEReplayGaps:         sseReplayGaps.Load(),
+
+			SendQueueOverflowByPolicy: sendQueueOverflowByPolicy.Snapshot(),
 		},
 		Messages: SnapshotMessages{
 			RxTotal:  messagesRXTotal.Load(),
 			TxTotal:  messagesTXTotal.Load(),
 			RxByType: rx,
 			TxByType: tx,
+
+			RateLimitedByType:     messagesRateLimitedByType.Snapshot(),
+			PayloadTooLargeByType: messagesPayloadTooLarge.Snapshot(),
 		},
 		JoinLatency: SnapshotJoinLatency{
 			BoundariesMs: append([]int64(nil), joinLatencyBoundariesMs...),
 			BucketCounts: bucketCounts,
 			Total:        joinLatencyTotal.Load(),
 			SumMs:        joinLatencySumMs.Load(),
+			P50Ms:        HistogramPercentile(joinLatencyBoundariesMs, bucketCounts, 0.50),
+			P90Ms:        HistogramPercentile(joinLatencyBoundariesMs, bucketCounts, 0.90),
+			P99Ms:        HistogramPercentile(joinLatencyBoundariesMs, bucketCounts, 0.99),
+		},
+		RelayLatency: SnapshotRelayLatency{
+			BoundariesUs: append([]int64(nil), relayLatencyBoundariesUs...),
+			BucketCounts: relayBucketCounts,
+			Total:        relayLatencyTotal.Load(),
+			SumUs:        relayLatencySumUs.Load(),
 		},
 		Disconnects: disconnects,
 		Runtime: SnapshotRuntimeStats{
@@ -285,5 +824,7 @@ func SnapshotNow() Snapshot {
 			PauseTotalNs: mem.PauseTotalNs,
 			LastPauseNs:  lastPause,
 		},
+		SendQueueSize:      sendQueueSize.Load(),
+		SendQueueHighWater: sendQueueHighWater.Load(),
 	}
 }
@@ -0,0 +1,55 @@
+package stats
+
+import "testing"
+
+func TestHistogramPercentileEmpty(t *testing.T) {
+	boundaries := []int64{10, 100}
+	if got := HistogramPercentile(boundaries, []int64{0, 0, 0}, 0.5); got != 0 {
+		t.Fatalf("expected 0 for an empty histogram, got %v", got)
+	}
+	if got := HistogramPercentile(boundaries, nil, 0.99); got != 0 {
+		t.Fatalf("expected 0 without buckets, got %v", got)
+	}
+}
+
+func TestHistogramPercentileSingleBucket(t *testing.T) {
+	boundaries := []int64{10, 100, 1000}
+	counts := []int64{0, 7, 0, 0}
+	for _, q := range []float64{0.5, 0.9, 0.99} {
+		if got := HistogramPercentile(boundaries, counts, q); got != 100 {
+			t.Fatalf("q=%v: expected the bucket's upper boundary 100, got %v", q, got)
+		}
+	}
+}
+
+func TestHistogramPercentileSpread(t *testing.T) {
+	boundaries := []int64{10, 100, 1000}
+	counts := []int64{50, 40, 9, 1}
+	cases := map[float64]float64{0.5: 10, 0.9: 100, 0.99: 1000, 1: 1001}
+	for q, want := range cases {
+		if got := HistogramPercentile(boundaries, counts, q); got != want {
+			t.Fatalf("q=%v: expected %v, got %v", q, want, got)
+		}
+	}
+}
+
+func TestHistogramPercentileSaturatedTopBucket(t *testing.T) {
+	boundaries := []int64{10, 100, 1000}
+	counts := []int64{0, 0, 0, 5}
+	if got := HistogramPercentile(boundaries, counts, 0.5); got != 1001 {
+		t.Fatalf("expected the overflow bucket to report 1001, got %v", got)
+	}
+	if got := HistogramPercentile(nil, []int64{3}, 0.99); got != 0 {
+		t.Fatalf("expected 0 without boundaries, got %v", got)
+	}
+}
+
+func TestSnapshotJoinLatencyPercentiles(t *testing.T) {
+	snapshot := SnapshotNow().JoinLatency
+	if want := HistogramPercentile(snapshot.BoundariesMs, snapshot.BucketCounts, 0.99); snapshot.P99Ms != want {
+		t.Fatalf("expected P99Ms %v from the buckets, got %v", want, snapshot.P99Ms)
+	}
+	if snapshot.P50Ms > snapshot.P90Ms || snapshot.P90Ms > snapshot.P99Ms {
+		t.Fatalf("expected ordered percentiles, got %v/%v/%v", snapshot.P50Ms, snapshot.P90Ms, snapshot.P99Ms)
+	}
+}
@@ -1,37 +1,76 @@
 package main
 
 import (
+	"compress/gzip"
 	"crypto/subtle"
 	"encoding/json"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 
 	"serenada/server/internal/stats"
 )
 
-func handleInternalStats(hub *Hub) http.HandlerFunc {
-	enabled := strings.EqualFold(strings.TrimSpace(os.Getenv("ENABLE_INTERNAL_STATS")), "1")
-	requiredToken := strings.TrimSpace(os.Getenv("INTERNAL_STATS_TOKEN"))
+// internalAccess is the ENABLE_INTERNAL_STATS / INTERNAL_STATS_TOKEN gate
+// shared by every /api/internal/* handler.
+type internalAccess struct {
+	enabled bool
+	token   string
+}
 
-	return func(w http.ResponseWriter, r *http.Request) {
-		if !enabled {
-			http.NotFound(w, r)
-			return
-		}
-		if requiredToken == "" {
-			http.Error(w, "Internal stats token is required", http.StatusServiceUnavailable)
-			return
+func internalAccessFromEnv() internalAccess {
+	return internalAccess{
+		enabled: strings.EqualFold(strings.TrimSpace(os.Getenv("ENABLE_INTERNAL_STATS")), "1"),
+		token:   strings.TrimSpace(os.Getenv("INTERNAL_STATS_TOKEN")),
+	}
+}
+
+// authorize writes an error response and returns false unless the endpoint is
+// enabled, a token is configured, the method is allowed and the request
+// carries the matching X-Internal-Token header.
+func (a internalAccess) authorize(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	if !a.enabled {
+		http.NotFound(w, r)
+		return false
+	}
+	if a.token == "" {
+		http.Error(w, "Internal stats token is required", http.StatusServiceUnavailable)
+		return false
+	}
+
+	methodAllowed := false
+	for _, method := range methods {
+		if r.Method == method {
+			methodAllowed = true
+			break
 		}
+	}
+	if !methodAllowed {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return false
+	}
 
-		if r.Method != http.MethodGet {
-			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	provided := strings.TrimSpace(r.Header.Get("X-Internal-Token"))
+	if subtle.ConstantTimeCompare([]byte(provided), []byte(a.token)) != 1 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+func handleInternalStats(hub *Hub) http.HandlerFunc {
+	access := internalAccessFromEnv()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !access.authorize(w, r, http.MethodGet) {
 			return
 		}
 
-		provided := strings.TrimSpace(r.Header.Get("X-Internal-Token"))
-		if subtle.ConstantTimeCompare([]byte(provided), []byte(requiredToken)) != 1 {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		if wantsOpenMetrics(r) {
+			w.Header().Set("Content-Type", stats.OpenMetricsContentType)
+			w.Header().Set("Cache-Control", "no-store")
+			_ = stats.WriteJoinLatencyOpenMetrics(w)
 			return
 		}
 
@@ -40,6 +79,42 @@ func handleInternalStats(hub *Hub) http.HandlerFunc {
 
 		w.Header().Set("Content-Type", "application/json")
 		w.Header().Set("Cache-Control", "no-store")
-		_ = json.NewEncoder(w).Encode(snapshot)
+		w.Header().Set("Vary", "Accept-Encoding")
+		if !acceptsGzip(r) {
+			_ = json.NewEncoder(w).Encode(snapshot)
+			return
+		}
+
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		_ = json.NewEncoder(gz).Encode(snapshot)
+		_ = gz.Close()
+	}
+}
+
+// wantsOpenMetrics reports whether the request asked for the OpenMetrics
+// join-latency histogram instead of the JSON snapshot, via ?format=openmetrics
+// or an Accept header as sent by Prometheus scrapers.
+func wantsOpenMetrics(r *http.Request) bool {
+	if strings.EqualFold(r.URL.Query().Get("format"), "openmetrics") {
+		return true
+	}
+	return strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")
+}
+
+// acceptsGzip reports whether the request's Accept-Encoding allows gzip,
+// honoring an explicit q=0 refusal.
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			weight, err := strconv.ParseFloat(q, 64)
+			return err == nil && weight > 0
+		}
+		return true
 	}
+	return false
 }
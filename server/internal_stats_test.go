@@ -1,9 +1,15 @@
 package main
 
 import (
+	"compress/gzip"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"serenada/server/internal/stats"
 )
 
 func TestInternalStatsDisabledReturnsNotFound(t *testing.T) {
@@ -69,3 +75,132 @@ func TestInternalStatsSuccessWithToken(t *testing.T) {
 		t.Fatalf("expected application/json content type, got %q", contentType)
 	}
 }
+
+func TestInternalStatsGzipWhenAccepted(t *testing.T) {
+	t.Setenv("ENABLE_INTERNAL_STATS", "1")
+	t.Setenv("INTERNAL_STATS_TOKEN", "test-token")
+
+	handler := handleInternalStats(newHub(4))
+	req := httptest.NewRequest(http.MethodGet, "/api/internal/stats", nil)
+	req.Header.Set("X-Internal-Token", "test-token")
+	req.Header.Set("Accept-Encoding", "br, gzip;q=0.8")
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	if encoding := rec.Header().Get("Content-Encoding"); encoding != "gzip" {
+		t.Fatalf("expected gzip content encoding, got %q", encoding)
+	}
+	gz, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("gzip reader: %v", err)
+	}
+	var snapshot stats.Snapshot
+	if err := json.NewDecoder(gz).Decode(&snapshot); err != nil {
+		t.Fatalf("decode gzipped snapshot: %v", err)
+	}
+	if snapshot.TimestampMs == 0 {
+		t.Fatal("expected a populated snapshot")
+	}
+}
+
+func TestInternalStatsPlainWhenGzipNotAccepted(t *testing.T) {
+	t.Setenv("ENABLE_INTERNAL_STATS", "1")
+	t.Setenv("INTERNAL_STATS_TOKEN", "test-token")
+
+	handler := handleInternalStats(newHub(4))
+	for _, acceptEncoding := range []string{"", "identity", "gzip;q=0"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/internal/stats", nil)
+		req.Header.Set("X-Internal-Token", "test-token")
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		if encoding := rec.Header().Get("Content-Encoding"); encoding != "" {
+			t.Fatalf("Accept-Encoding %q: expected no content encoding, got %q", acceptEncoding, encoding)
+		}
+		var snapshot stats.Snapshot
+		if err := json.NewDecoder(rec.Body).Decode(&snapshot); err != nil {
+			t.Fatalf("Accept-Encoding %q: decode plain snapshot: %v", acceptEncoding, err)
+		}
+	}
+}
+
+func TestRefreshStatsGaugesReportsRoomSizeDistribution(t *testing.T) {
+	hub := newHub(4)
+	hub.rooms["solo-a"] = &Room{Participants: map[*Client]string{fakeClient(hub): "C-1"}}
+	hub.rooms["solo-b"] = &Room{Participants: map[*Client]string{fakeClient(hub): "C-1"}}
+	hub.rooms["trio"] = &Room{Participants: map[*Client]string{
+		fakeClient(hub): "C-1",
+		fakeClient(hub): "C-2",
+		fakeClient(hub): "C-3",
+	}}
+
+	hub.refreshStatsGauges()
+	sizes := stats.SnapshotNow().Gauges.RoomSizes
+
+	if len(sizes) != 2 || sizes["1"] != 2 || sizes["3"] != 1 {
+		t.Fatalf("unexpected room size distribution: %v", sizes)
+	}
+}
+
+func TestInternalStatsIncludesDeployLabel(t *testing.T) {
+	t.Setenv("ENABLE_INTERNAL_STATS", "1")
+	t.Setenv("INTERNAL_STATS_TOKEN", "test-token")
+	stats.SetDeployLabel("canary")
+	defer stats.SetDeployLabel("")
+
+	handler := handleInternalStats(newHub(4))
+	req := httptest.NewRequest(http.MethodGet, "/api/internal/stats", nil)
+	req.Header.Set("X-Internal-Token", "test-token")
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	var body map[string]json.RawMessage
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode snapshot: %v", err)
+	}
+	if label := string(body["deployLabel"]); label != `"canary"` {
+		t.Fatalf("expected deployLabel \"canary\", got %s", label)
+	}
+}
+
+func TestInternalStatsOpenMetricsJoinLatencyExemplar(t *testing.T) {
+	t.Setenv("ENABLE_INTERNAL_STATS", "1")
+	t.Setenv("INTERNAL_STATS_TOKEN", "test-token")
+
+	stats.RecordJoinLatency(3*time.Second, "S-slow-join")
+
+	handler := handleInternalStats(newHub(4))
+	req := httptest.NewRequest(http.MethodGet, "/api/internal/stats", nil)
+	req.Header.Set("X-Internal-Token", "test-token")
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/openmetrics-text") {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, `serenada_join_latency_seconds_bucket{le="5"} `) {
+		t.Fatalf("expected a 5s bucket:\n%s", body)
+	}
+	var bucketLine string
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, `serenada_join_latency_seconds_bucket{le="5"} `) {
+			bucketLine = line
+		}
+	}
+	if !strings.Contains(bucketLine, `# {conn_id="S-slow-join"} 3 `) {
+		t.Fatalf("expected the slow join as the 5s bucket's exemplar, got %q", bucketLine)
+	}
+	if !strings.HasSuffix(body, "# EOF\n") {
+		t.Fatalf("expected OpenMetrics terminator:\n%s", body)
+	}
+}
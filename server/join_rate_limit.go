@@ -0,0 +1,27 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// newJoinLimiter parses JOIN_RATE_LIMIT_PER_MINUTE into a per-IP limiter for
+// join messages, which arrive over already-open WS/SSE connections and so
+// never pass through rateLimitMiddleware. The burst is one minute's worth.
+// Unset, zero or invalid values disable it (nil).
+func newJoinLimiter(raw string) *IPLimiter {
+	perMinute, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || perMinute <= 0 {
+		return nil
+	}
+	return NewIPLimiter(float64(perMinute)/60.0, float64(perMinute))
+}
+
+// allowJoin charges one join attempt to c's IP. Rate-limit bypass IPs and a
+// disabled limiter always allow.
+func (h *Hub) allowJoin(c *Client) bool {
+	if h.joinLimiter == nil || rateLimitBypass.contains(c.ip) {
+		return true
+	}
+	return h.joinLimiter.GetLimiter(c.ip).Allow()
+}
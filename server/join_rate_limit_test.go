@@ -0,0 +1,67 @@
+package main
+
+import "testing"
+
+func TestNewJoinLimiter(t *testing.T) {
+	for _, raw := range []string{"", "0", "-3", "bogus"} {
+		if newJoinLimiter(raw) != nil {
+			t.Errorf("newJoinLimiter(%q) should be disabled", raw)
+		}
+	}
+	limiter := newJoinLimiter("30")
+	if limiter == nil || limiter.burst != 30 || limiter.rate != 0.5 {
+		t.Fatalf("unexpected limiter %+v", limiter)
+	}
+}
+
+func TestJoinRateLimitRejectsJoinLeaveLoop(t *testing.T) {
+	hub := newHub(4)
+	hub.joinLimiter = newJoinLimiter("2")
+	rid := mustTestRoomID(t)
+
+	c := fakeClient(hub)
+	c.ip = "203.0.113.5"
+	hub.registerClient(c)
+
+	for i := 0; i < 2; i++ {
+		hub.handleMessage(c, joinPayload(rid, 4, 4))
+		if msg := findMessage(drainMessages(c), "joined"); msg == nil {
+			t.Fatalf("join %d: expected joined", i+1)
+		}
+		hub.handleMessage(c, []byte(`{"v":1,"type":"leave","rid":"`+rid+`"}`))
+	}
+
+	hub.handleMessage(c, joinPayload(rid, 4, 4))
+	if code := errorCode(findMessage(drainMessages(c), "error")); code != "JOIN_RATE_LIMITED" {
+		t.Fatalf("expected JOIN_RATE_LIMITED, got %q", code)
+	}
+
+	other := fakeClient(hub)
+	other.ip = "203.0.113.6"
+	hub.registerClient(other)
+	hub.handleMessage(other, joinPayload(rid, 4, 4))
+	if msg := findMessage(drainMessages(other), "joined"); msg == nil {
+		t.Fatal("expected a different IP to be unaffected")
+	}
+}
+
+func TestJoinRateLimitHonorsBypassList(t *testing.T) {
+	prev := rateLimitBypass
+	rateLimitBypass = parseRateLimitBypass("203.0.113.5")
+	t.Cleanup(func() { rateLimitBypass = prev })
+
+	hub := newHub(4)
+	hub.joinLimiter = newJoinLimiter("1")
+	rid := mustTestRoomID(t)
+	c := fakeClient(hub)
+	c.ip = "203.0.113.5"
+	hub.registerClient(c)
+
+	for i := 0; i < 3; i++ {
+		hub.handleMessage(c, joinPayload(rid, 4, 4))
+		if msg := findMessage(drainMessages(c), "joined"); msg == nil {
+			t.Fatalf("join %d: expected bypassed IP to join", i+1)
+		}
+		hub.handleMessage(c, []byte(`{"v":1,"type":"leave","rid":"`+rid+`"}`))
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	joinShedWindow     = 30 * time.Second // only joins this recent feed the p95
+	joinShedMinSamples = 20               // never shed on a handful of slow joins
+	joinShedMaxSamples = 2048             // bounds memory under a join flood
+	joinShedMinP95     = 100 * time.Millisecond
+)
+
+// joinShedder rejects fresh joins with SERVER_BUSY while the p95 of recent
+// join latencies is above threshold. The stats histogram is cumulative since
+// start, so it keeps its own sliding window. Reconnects are never shed, and
+// since shed joins are not sampled the window drains and admission resumes
+// within joinShedWindow of the last slow join. A nil *joinShedder is disabled.
+type joinShedder struct {
+	threshold time.Duration
+
+	mu      sync.Mutex
+	samples []joinSample // oldest first
+}
+
+type joinSample struct {
+	at      time.Time
+	latency time.Duration
+}
+
+// newJoinShedder parses JOIN_SHED_P95_MS. Unset, zero or invalid values
+// disable shedding; thresholds below joinShedMinP95 are raised to it.
+func newJoinShedder(raw string) *joinShedder {
+	ms, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || ms <= 0 {
+		return nil
+	}
+	threshold := time.Duration(ms) * time.Millisecond
+	if threshold < joinShedMinP95 {
+		threshold = joinShedMinP95
+	}
+	return &joinShedder{threshold: threshold}
+}
+
+func (s *joinShedder) observe(latency time.Duration, now time.Time) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked(now)
+	if len(s.samples) >= joinShedMaxSamples {
+		s.samples = s.samples[1:]
+	}
+	s.samples = append(s.samples, joinSample{at: now, latency: latency})
+}
+
+func (s *joinShedder) shouldShed(now time.Time) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked(now)
+	if len(s.samples) < joinShedMinSamples {
+		return false
+	}
+	return s.p95Locked() > s.threshold
+}
+
+func (s *joinShedder) pruneLocked(now time.Time) {
+	cutoff := now.Add(-joinShedWindow)
+	i := 0
+	for i < len(s.samples) && s.samples[i].at.Before(cutoff) {
+		i++
+	}
+	if i > 0 {
+		s.samples = append(s.samples[:0], s.samples[i:]...)
+	}
+}
+
+func (s *joinShedder) p95Locked() time.Duration {
+	latencies := make([]time.Duration, len(s.samples))
+	for i, sample := range s.samples {
+		latencies[i] = sample.latency
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	idx := (len(latencies)*95+99)/100 - 1
+	return latencies[idx]
+}
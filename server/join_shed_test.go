@@ -0,0 +1,135 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewJoinShedderConfig(t *testing.T) {
+	if s := newJoinShedder(""); s != nil {
+		t.Fatal("expected shedding disabled when unset")
+	}
+	if s := newJoinShedder("0"); s != nil {
+		t.Fatal("expected shedding disabled for 0")
+	}
+	if s := newJoinShedder("10"); s == nil || s.threshold != joinShedMinP95 {
+		t.Fatalf("expected threshold raised to %s", joinShedMinP95)
+	}
+	if s := newJoinShedder("750"); s == nil || s.threshold != 750*time.Millisecond {
+		t.Fatal("expected 750ms threshold")
+	}
+}
+
+func TestJoinShedderShedsOnSlowWindowAndRecovers(t *testing.T) {
+	s := newJoinShedder("200")
+	now := time.Now()
+
+	for i := 0; i < joinShedMinSamples-1; i++ {
+		s.observe(time.Second, now)
+	}
+	if s.shouldShed(now) {
+		t.Fatal("expected no shedding below the minimum sample count")
+	}
+
+	s.observe(time.Second, now)
+	if !s.shouldShed(now) {
+		t.Fatal("expected shedding once p95 exceeds the threshold")
+	}
+
+	if s.shouldShed(now.Add(joinShedWindow + time.Second)) {
+		t.Fatal("expected admission to resume once slow samples leave the window")
+	}
+}
+
+func TestJoinShedderIgnoresFewSlowOutliers(t *testing.T) {
+	s := newJoinShedder("200")
+	now := time.Now()
+	for i := 0; i < 99; i++ {
+		s.observe(10*time.Millisecond, now)
+	}
+	s.observe(5*time.Second, now)
+	if s.shouldShed(now) {
+		t.Fatal("expected a single outlier not to trigger shedding")
+	}
+}
+
+func TestJoinShedderBoundsSamples(t *testing.T) {
+	s := newJoinShedder("200")
+	now := time.Now()
+	for i := 0; i < joinShedMaxSamples+100; i++ {
+		s.observe(time.Millisecond, now)
+	}
+	if len(s.samples) != joinShedMaxSamples {
+		t.Fatalf("expected %d samples, got %d", joinShedMaxSamples, len(s.samples))
+	}
+}
+
+func TestHandleJoinShedsNewJoinsButNotReconnects(t *testing.T) {
+	hub := newHub(4)
+	hub.joinShed = newJoinShedder("200")
+	now := time.Now()
+	for i := 0; i < joinShedMinSamples; i++ {
+		hub.joinShed.observe(time.Second, now)
+	}
+
+	rid := mustTestRoomID(t)
+	c := fakeClient(hub)
+	hub.registerClient(c)
+	hub.handleMessage(c, joinPayload(rid, 0, 0))
+
+	if code := errorCode(findMessage(drainMessages(c), "error")); code != "SERVER_BUSY" {
+		t.Fatalf("expected SERVER_BUSY, got %q", code)
+	}
+	hub.mu.RLock()
+	_, exists := hub.rooms[rid]
+	hub.mu.RUnlock()
+	if exists {
+		t.Fatal("expected shed join not to create a room")
+	}
+
+	unproven := fakeClient(hub)
+	hub.registerClient(unproven)
+	hub.handleJoin(unproven, Message{V: 1, Type: "join", RID: rid, Payload: []byte(`{"reconnectCid":"C-gone"}`)})
+	if code := errorCode(findMessage(drainMessages(unproven), "error")); code != "SERVER_BUSY" {
+		t.Fatalf("expected an unproven reconnectCid to be shed, got %q", code)
+	}
+}
+
+func TestHandleJoinDoesNotShedVerifiedReconnects(t *testing.T) {
+	t.Setenv("TURN_TOKEN_SECRET", "test-secret-1234")
+	hub := newHub(4)
+	rid := mustTestRoomID(t)
+	ghost := fakeClient(hub)
+	hub.registerClient(ghost)
+	hub.handleMessage(ghost, joinPayload(rid, 4, 4))
+	drainMessages(ghost)
+	ghostCID := ghost.cid
+
+	hub.joinShed = newJoinShedder("200")
+	now := time.Now()
+	for i := 0; i < joinShedMinSamples; i++ {
+		hub.joinShed.observe(time.Second, now)
+	}
+
+	withToken := fakeClient(hub)
+	hub.registerClient(withToken)
+	hub.handleMessage(withToken, tokenReconnectJoinPayload(mustTestRoomID(t), "C-gone", issueReconnectToken("C-gone", "")))
+	if code := errorCode(findMessage(drainMessages(withToken), "error")); code != "SERVER_BUSY" {
+		t.Fatalf("expected a token for another room to be shed, got %q", code)
+	}
+
+	withToken = fakeClient(hub)
+	hub.registerClient(withToken)
+	goneRID := mustTestRoomID(t)
+	hub.handleMessage(withToken, tokenReconnectJoinPayload(goneRID, "C-gone", issueReconnectToken("C-gone", goneRID)))
+	if findMessage(drainMessages(withToken), "joined") == nil {
+		t.Fatal("expected a reconnect with a valid token to bypass shedding")
+	}
+
+	legacy := fakeClient(hub)
+	hub.registerClient(legacy)
+	hub.handleMessage(legacy, reconnectJoinPayload(rid, ghostCID))
+	if code := errorCode(findMessage(drainMessages(legacy), "error")); code != "SERVER_BUSY" {
+		t.Fatalf("expected a tokenless reconnect naming a ghost CID to be shed, got %q", code)
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"encoding/json"
+
+	"serenada/server/internal/stats"
+)
+
+// handlePing answers a ping with pong, unless the payload sets
+// "keepalive": true. Keepalive-only pings refresh the SSE stale timeout and get
+// no reply, halving keepalive traffic for clients that do not measure RTT.
+func (h *Hub) handlePing(c *Client, msg Message) {
+	var payload struct {
+		Keepalive bool `json:"keepalive"`
+	}
+	if len(msg.Payload) > 0 {
+		_ = json.Unmarshal(msg.Payload, &payload)
+	}
+	if payload.Keepalive {
+		h.markSSESeen(c)
+		stats.IncKeepalivePing()
+		return
+	}
+	c.sendMessage(Message{V: 1, Type: "pong"})
+}
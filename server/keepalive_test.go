@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+
+	"serenada/server/internal/stats"
+)
+
+func keepalivePingPayload() []byte {
+	b, _ := json.Marshal(Message{V: 1, Type: "ping", Payload: json.RawMessage(`{"keepalive":true}`)})
+	return b
+}
+
+func TestPingWithoutKeepaliveFlagGetsPong(t *testing.T) {
+	hub := newHub(4)
+	c := fakeClient(hub)
+	hub.registerClient(c)
+
+	hub.handleMessage(c, pingPayload())
+	if findMessage(drainMessages(c), "pong") == nil {
+		t.Fatal("expected pong for a plain ping")
+	}
+}
+
+func TestKeepalivePingRefreshesLastSeenWithoutPong(t *testing.T) {
+	hub := newHub(4)
+	c := fakeClient(hub)
+	c.transport = TransportSSE
+	hub.registerClient(c)
+	atomic.StoreInt64(&c.lastSeen, 1)
+
+	before := stats.SnapshotNow().Counters.KeepalivePings
+	hub.handleMessage(c, keepalivePingPayload())
+
+	if msgs := drainMessages(c); len(msgs) != 0 {
+		t.Fatalf("expected no reply to a keepalive ping, got %d messages", len(msgs))
+	}
+	if atomic.LoadInt64(&c.lastSeen) <= 1 {
+		t.Fatal("expected keepalive ping to refresh lastSeen")
+	}
+	if after := stats.SnapshotNow().Counters.KeepalivePings; after-before != 1 {
+		t.Fatalf("expected one keepalive ping counted, got %d", after-before)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+)
+
+// handleKick serves kick: the host evicts another participant. The target gets
+// a kicked message and its connection is closed through disconnectClient, so
+// it leaves the room (room_state goes to everyone left) and cannot keep
+// using the same session.
+func (h *Hub) handleKick(c *Client, msg Message) {
+	rid := c.rid
+	if rid == "" {
+		c.sendError(msg.RID, "NOT_IN_ROOM", "Must be in a room to kick a participant")
+		return
+	}
+
+	var payload struct {
+		TargetCID string `json:"targetCid"`
+	}
+	if len(msg.Payload) > 0 {
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			c.sendError(rid, "BAD_REQUEST", "Invalid payload")
+			return
+		}
+	}
+	if payload.TargetCID == "" {
+		c.sendError(rid, "BAD_REQUEST", "Missing targetCid")
+		return
+	}
+
+	h.mu.RLock()
+	room, exists := h.rooms[rid]
+	h.mu.RUnlock()
+	if !exists {
+		c.sendError(rid, "NOT_IN_ROOM", "Must be in a room to kick a participant")
+		return
+	}
+
+	room.mu.Lock()
+	if room.HostCID != c.cid {
+		room.mu.Unlock()
+		log.Printf("[KICK] Client %s (CID: %s) tried to kick %s from room %s but is not host", c.sid, c.cid, payload.TargetCID, rid)
+		c.sendError(rid, "NOT_HOST", "Only host can kick participants")
+		return
+	}
+	if payload.TargetCID == c.cid {
+		room.mu.Unlock()
+		c.sendError(rid, "BAD_REQUEST", "Host cannot kick itself")
+		return
+	}
+	var target *Client
+	for client, cid := range room.Participants {
+		if cid == payload.TargetCID {
+			target = client
+			break
+		}
+	}
+	if target == nil {
+		room.mu.Unlock()
+		c.sendError(rid, "NO_SUCH_PARTICIPANT", "No participant with that CID in this room")
+		return
+	}
+	room.recordEventLocked("kick", payload.TargetCID, c.cid)
+	room.mu.Unlock()
+
+	kickedPayload, _ := json.Marshal(map[string]string{"by": c.cid})
+	target.sendMessage(Message{V: 1, Type: "kicked", RID: rid, Payload: kickedPayload})
+	log.Printf("[KICK] Host %s kicked %s (SID: %s) from room %s", c.cid, payload.TargetCID, target.sid, rid)
+	if target.transport == TransportWS {
+		h.closeWS(target, wsCloseKicked, "kicked", DisconnectKicked)
+	} else {
+		h.disconnectClient(target, DisconnectKicked)
+	}
+}
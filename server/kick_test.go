@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func kickPayload(rid, targetCID string) []byte {
+	payload, _ := json.Marshal(map[string]string{"targetCid": targetCID})
+	b, _ := json.Marshal(Message{V: 1, Type: "kick", RID: rid, Payload: payload})
+	return b
+}
+
+func TestHostKicksParticipant(t *testing.T) {
+	hub, rid, host, guest, guestCID := joinedPair(t)
+	drainMessages(guest)
+
+	hub.handleMessage(host, kickPayload(rid, guestCID))
+
+	kicked := findMessage(drainMessages(guest), "kicked")
+	if kicked == nil {
+		t.Fatal("expected kicked message to the target")
+	}
+	if _, open := <-guest.send; open {
+		t.Fatal("expected the kicked client's send channel to be closed")
+	}
+	if hub.isClientActive(guest) || hub.IsClientInRoom(rid, guestCID) {
+		t.Fatal("expected the kicked client to be disconnected and out of the room")
+	}
+
+	state := findMessage(drainMessages(host), "room_state")
+	if state == nil {
+		t.Fatal("expected room_state to the host after the kick")
+	}
+	var payload struct {
+		Participants []Participant `json:"participants"`
+	}
+	_ = json.Unmarshal(state.Payload, &payload)
+	if len(payload.Participants) != 1 {
+		t.Fatalf("expected only the host left, got %+v", payload.Participants)
+	}
+
+	// The kicked session is gone; it cannot silently rejoin.
+	hub.handleMessage(guest, joinPayload(rid, 4, 4))
+	if hub.IsClientInRoom(rid, guestCID) {
+		t.Fatal("expected the kicked session to stay out of the room")
+	}
+}
+
+func TestNonHostCannotKick(t *testing.T) {
+	hub, rid, host, guest, _ := joinedPair(t)
+	drainMessages(guest)
+
+	hub.handleMessage(guest, kickPayload(rid, host.cid))
+
+	if code := errorCode(findMessage(drainMessages(guest), "error")); code != "NOT_HOST" {
+		t.Fatalf("expected NOT_HOST, got %q", code)
+	}
+	if !hub.IsClientInRoom(rid, host.cid) {
+		t.Fatal("expected the host to stay in the room")
+	}
+}
+
+func TestKickUnknownParticipant(t *testing.T) {
+	hub, rid, host, _, guestCID := joinedPair(t)
+
+	hub.handleMessage(host, kickPayload(rid, "C-not-in-room"))
+
+	if code := errorCode(findMessage(drainMessages(host), "error")); code != "NO_SUCH_PARTICIPANT" {
+		t.Fatalf("expected NO_SUCH_PARTICIPANT, got %q", code)
+	}
+	if !hub.IsClientInRoom(rid, guestCID) {
+		t.Fatal("expected the guest to stay in the room")
+	}
+}
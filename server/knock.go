@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+)
+
+// Knocks are meant to be rare: a watcher gets a small burst and then one
+// request every 15 seconds.
+const (
+	knockBurst      = 2
+	knockRefillRate = 1.0 / 15.0 // tokens per second
+)
+
+// handleKnocking relays a watcher's "about to join" signal to the room host.
+// The sender must be watching the room (via watch_rooms) and the room must
+// exist with knocks enabled by its creator. Knocking never creates a room and
+// never touches room.Participants, so it does not consume a participant slot.
+func (h *Hub) handleKnocking(c *Client, msg Message) {
+	rid := msg.RID
+	if err := validateRoomID(rid); err != nil {
+		c.sendError(rid, "INVALID_ROOM_ID", "Room ID must be a valid room token")
+		return
+	}
+	if roomIDBlocked(rid) {
+		c.sendError(rid, "ROOM_BLOCKED", "This room is not available")
+		return
+	}
+	if c.rid == rid {
+		c.sendError(rid, "ALREADY_IN_ROOM", "Participants cannot knock on their own room")
+		return
+	}
+
+	h.mu.Lock()
+	watching := h.watchers[rid][c]
+	if c.knockLimiter == nil {
+		c.knockLimiter = NewSimpleTokenBucket(knockBurst, knockRefillRate)
+	}
+	if c.watcherID == "" {
+		c.watcherID = generateID("W-")
+	}
+	limiter := c.knockLimiter
+	watcherID := c.watcherID
+	room := h.rooms[rid]
+	h.mu.Unlock()
+
+	if !watching {
+		c.sendError(rid, "NOT_WATCHING", "Must watch a room before knocking")
+		return
+	}
+	if !limiter.Allow() {
+		c.sendError(rid, "KNOCK_RATE_LIMITED", "Too many knock requests")
+		return
+	}
+
+	var host *Client
+	if room != nil {
+		room.mu.Lock()
+		if room.KnocksEnabled {
+			for client, cid := range room.Participants {
+				if cid == room.HostCID {
+					host = client
+					break
+				}
+			}
+		}
+		room.mu.Unlock()
+	}
+	if host == nil {
+		c.sendError(rid, "KNOCK_UNAVAILABLE", "Room is not accepting knocks")
+		return
+	}
+
+	payload, _ := json.Marshal(map[string]string{
+		"watcherId": watcherID,
+	})
+	host.sendMessage(Message{
+		V:       1,
+		Type:    "knock",
+		RID:     rid,
+		Payload: payload,
+	})
+	log.Printf("[KNOCK] Watcher %s (SID: %s) knocked on room %s", watcherID, c.sid, rid)
+}
+
+// handleKnockResponse lets the host pre-accept (or decline) a knocking watcher.
+// The decision is forwarded to the watcher as knock_response; admission itself
+// still goes through the normal join flow.
+func (h *Hub) handleKnockResponse(c *Client, msg Message) {
+	rid := c.rid
+	if rid == "" {
+		c.sendError(msg.RID, "NOT_IN_ROOM", "Must be in a room to answer a knock")
+		return
+	}
+
+	var payload struct {
+		WatcherID string `json:"watcherId"`
+		Accepted  bool   `json:"accepted"`
+	}
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil || payload.WatcherID == "" {
+		c.sendError(rid, "BAD_REQUEST", "Invalid payload")
+		return
+	}
+
+	h.mu.RLock()
+	room := h.rooms[rid]
+	var watcher *Client
+	for client := range h.watchers[rid] {
+		if client.watcherID == payload.WatcherID {
+			watcher = client
+			break
+		}
+	}
+	h.mu.RUnlock()
+
+	if room == nil {
+		c.sendError(rid, "NOT_IN_ROOM", "Must be in a room to answer a knock")
+		return
+	}
+	room.mu.Lock()
+	isHost := room.HostCID == c.cid
+	room.mu.Unlock()
+	if !isHost {
+		c.sendError(rid, "NOT_HOST", "Only host can answer a knock")
+		return
+	}
+	if watcher == nil {
+		c.sendError(rid, "NO_SUCH_WATCHER", "Watcher is no longer waiting")
+		return
+	}
+
+	responsePayload, _ := json.Marshal(map[string]bool{
+		"accepted": payload.Accepted,
+	})
+	watcher.sendMessage(Message{
+		V:       1,
+		Type:    "knock_response",
+		RID:     rid,
+		Payload: responsePayload,
+	})
+	log.Printf("[KNOCK] Host %s answered watcher %s in room %s (accepted=%t)", c.cid, payload.WatcherID, rid, payload.Accepted)
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func knockJoinPayload(rid string, allowKnocks bool) []byte {
+	payloadBytes, _ := json.Marshal(map[string]interface{}{
+		"allowKnocks": allowKnocks,
+	})
+	b, _ := json.Marshal(Message{V: 1, Type: "join", RID: rid, Payload: payloadBytes})
+	return b
+}
+
+func knockingPayload(rid string) []byte {
+	b, _ := json.Marshal(Message{V: 1, Type: "knocking", RID: rid})
+	return b
+}
+
+func findMessage(msgs []Message, msgType string) *Message {
+	for i := range msgs {
+		if msgs[i].Type == msgType {
+			return &msgs[i]
+		}
+	}
+	return nil
+}
+
+func errorCode(msg *Message) string {
+	if msg == nil {
+		return ""
+	}
+	var payload struct {
+		Code string `json:"code"`
+	}
+	_ = json.Unmarshal(msg.Payload, &payload)
+	return payload.Code
+}
+
+func TestKnockRelayedToHostWithoutConsumingSlot(t *testing.T) {
+	rid := mustTestRoomID(t)
+	hub := newHub(4)
+
+	host := fakeClient(hub)
+	hub.registerClient(host)
+	hub.handleMessage(host, knockJoinPayload(rid, true))
+	drainMessages(host)
+
+	watcher := fakeClient(hub)
+	hub.registerClient(watcher)
+	hub.handleMessage(watcher, watchRoomsPayload([]string{rid}))
+	drainMessages(watcher)
+
+	hub.handleMessage(watcher, knockingPayload(rid))
+
+	knock := findMessage(drainMessages(host), "knock")
+	if knock == nil {
+		t.Fatal("expected host to receive knock")
+	}
+	var payload struct {
+		WatcherID string `json:"watcherId"`
+	}
+	if err := json.Unmarshal(knock.Payload, &payload); err != nil || payload.WatcherID == "" {
+		t.Fatalf("expected opaque watcherId in knock payload, got %s", string(knock.Payload))
+	}
+	if payload.WatcherID == watcher.sid {
+		t.Fatal("knock must not expose the watcher's session ID")
+	}
+
+	hub.mu.RLock()
+	room := hub.rooms[rid]
+	hub.mu.RUnlock()
+	room.mu.Lock()
+	count := len(room.Participants)
+	room.mu.Unlock()
+	if count != 1 {
+		t.Fatalf("expected knock to leave participant count at 1, got %d", count)
+	}
+
+	responsePayload, _ := json.Marshal(map[string]interface{}{
+		"watcherId": payload.WatcherID,
+		"accepted":  true,
+	})
+	response, _ := json.Marshal(Message{V: 1, Type: "knock_response", RID: rid, Payload: responsePayload})
+	hub.handleMessage(host, response)
+
+	reply := findMessage(drainMessages(watcher), "knock_response")
+	if reply == nil {
+		t.Fatal("expected watcher to receive knock_response")
+	}
+	var replyPayload struct {
+		Accepted bool `json:"accepted"`
+	}
+	_ = json.Unmarshal(reply.Payload, &replyPayload)
+	if !replyPayload.Accepted {
+		t.Fatal("expected knock_response accepted=true")
+	}
+}
+
+func TestKnockRejectedWhenRoomDidNotOptIn(t *testing.T) {
+	rid := mustTestRoomID(t)
+	hub := newHub(4)
+
+	host := fakeClient(hub)
+	hub.registerClient(host)
+	hub.handleMessage(host, knockJoinPayload(rid, false))
+	drainMessages(host)
+
+	watcher := fakeClient(hub)
+	hub.registerClient(watcher)
+	hub.handleMessage(watcher, watchRoomsPayload([]string{rid}))
+	drainMessages(watcher)
+
+	hub.handleMessage(watcher, knockingPayload(rid))
+
+	if code := errorCode(findMessage(drainMessages(watcher), "error")); code != "KNOCK_UNAVAILABLE" {
+		t.Fatalf("expected KNOCK_UNAVAILABLE, got %q", code)
+	}
+	if findMessage(drainMessages(host), "knock") != nil {
+		t.Fatal("host must not receive knocks for rooms that did not opt in")
+	}
+}
+
+func TestKnockDoesNotCreateRoom(t *testing.T) {
+	rid := mustTestRoomID(t)
+	hub := newHub(4)
+
+	watcher := fakeClient(hub)
+	hub.registerClient(watcher)
+	hub.handleMessage(watcher, watchRoomsPayload([]string{rid}))
+	drainMessages(watcher)
+
+	hub.handleMessage(watcher, knockingPayload(rid))
+
+	hub.mu.RLock()
+	_, exists := hub.rooms[rid]
+	hub.mu.RUnlock()
+	if exists {
+		t.Fatal("knocking must not create a room")
+	}
+}
+
+func TestKnockRequiresWatchAndIsRateLimited(t *testing.T) {
+	rid := mustTestRoomID(t)
+	hub := newHub(4)
+
+	host := fakeClient(hub)
+	hub.registerClient(host)
+	hub.handleMessage(host, knockJoinPayload(rid, true))
+	drainMessages(host)
+
+	watcher := fakeClient(hub)
+	hub.registerClient(watcher)
+	hub.handleMessage(watcher, knockingPayload(rid))
+	if code := errorCode(findMessage(drainMessages(watcher), "error")); code != "NOT_WATCHING" {
+		t.Fatalf("expected NOT_WATCHING, got %q", code)
+	}
+
+	hub.handleMessage(watcher, watchRoomsPayload([]string{rid}))
+	drainMessages(watcher)
+
+	for i := 0; i < knockBurst; i++ {
+		hub.handleMessage(watcher, knockingPayload(rid))
+	}
+	if errs := findMessage(drainMessages(watcher), "error"); errs != nil {
+		t.Fatalf("expected burst knocks to succeed, got error %q", errorCode(errs))
+	}
+
+	hub.handleMessage(watcher, knockingPayload(rid))
+	if code := errorCode(findMessage(drainMessages(watcher), "error")); code != "KNOCK_RATE_LIMITED" {
+		t.Fatalf("expected KNOCK_RATE_LIMITED, got %q", code)
+	}
+}
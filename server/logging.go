@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"log/slog"
+	"strings"
+)
+
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// parseLogLevel reads LOG_LEVEL: debug, info (the default), warn or error.
+// Per-message relay and broadcast events are logged at debug.
+func parseLogLevel(raw string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// parseLogFormat reads LOG_FORMAT. text (the default) keeps human-readable
+// lines for local development; json emits one object per line for log
+// aggregators.
+func parseLogFormat(raw string) string {
+	if strings.EqualFold(strings.TrimSpace(raw), logFormatJSON) {
+		return logFormatJSON
+	}
+	return logFormatText
+}
+
+// newJSONLogHandler writes each record as a single JSON line, with the
+// message under "event" so it reads as the event name.
+func newJSONLogHandler(w io.Writer, level slog.Level) slog.Handler {
+	return slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.MessageKey {
+				a.Key = "event"
+			}
+			return a
+		},
+	})
+}
+
+// setupLogging configures slog's default logger. In text mode events keep
+// going through the standard logger, deploy label prefix included. In JSON
+// mode the deploy label becomes a field and plain log.Printf output is routed
+// through the JSON handler as info events, so every line parses.
+func setupLogging(w io.Writer, format string, level slog.Level, deployLabel string) {
+	if format != logFormatJSON {
+		slog.SetLogLoggerLevel(level)
+		return
+	}
+	logger := slog.New(newJSONLogHandler(w, level))
+	if deployLabel != "" {
+		logger = logger.With("deploy", deployLabel)
+	}
+	log.SetPrefix("")
+	slog.SetDefault(logger)
+}
+
+// logEnabled reports whether events at level are logged. Hot paths check it
+// before building attributes for a debug event.
+func logEnabled(level slog.Level) bool {
+	return slog.Default().Enabled(context.Background(), level)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestParseLogLevelAndFormat(t *testing.T) {
+	for raw, want := range map[string]slog.Level{
+		"":      slog.LevelInfo,
+		"DEBUG": slog.LevelDebug,
+		"warn":  slog.LevelWarn,
+		"error": slog.LevelError,
+		"loud":  slog.LevelInfo,
+	} {
+		if got := parseLogLevel(raw); got != want {
+			t.Errorf("parseLogLevel(%q) = %s, want %s", raw, got, want)
+		}
+	}
+	if parseLogFormat("") != logFormatText || parseLogFormat(" JSON ") != logFormatJSON {
+		t.Fatal("expected text by default and json when requested")
+	}
+}
+
+func TestJSONLogHandlerWritesEventLines(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(newJSONLogHandler(&buf, slog.LevelInfo))
+
+	logger.Debug("relay", "sid", "S-1")
+	if buf.Len() != 0 {
+		t.Fatalf("expected debug events to be dropped at info, got %s", buf.String())
+	}
+
+	logger.Info("join", "sid", "S-1", "cid", "C-1", "rid", "room")
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 1 {
+		t.Fatalf("expected one line, got %q", buf.String())
+	}
+	var entry map[string]any
+	if err := json.Unmarshal(lines[0], &entry); err != nil {
+		t.Fatalf("expected JSON, got %q: %v", lines[0], err)
+	}
+	if entry["event"] != "join" || entry["level"] != "INFO" || entry["sid"] != "S-1" || entry["cid"] != "C-1" || entry["rid"] != "room" {
+		t.Fatalf("unexpected entry %v", entry)
+	}
+	if _, ok := entry["msg"]; ok {
+		t.Fatalf("expected the message under event, not msg: %v", entry)
+	}
+}
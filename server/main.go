@@ -1,22 +1,71 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
+
+	"serenada/server/internal/stats"
 )
 
+// shutdownDrainTimeout stays well under the container stop grace period so the
+// final stats snapshot is written before a SIGKILL.
+const shutdownDrainTimeout = 5 * time.Second
+
 func main() {
 	// Load .env from current directory or parent directory (for local dev)
 	_ = godotenv.Load()
 	_ = godotenv.Load("../.env")
+	if label := strings.TrimSpace(os.Getenv("DEPLOY_LABEL")); label != "" {
+		stats.SetDeployLabel(label)
+		log.SetPrefix("[deploy=" + label + "] ")
+		log.SetFlags(log.LstdFlags | log.Lmsgprefix)
+	}
+	setupLogging(os.Stderr, parseLogFormat(os.Getenv("LOG_FORMAT")), parseLogLevel(os.Getenv("LOG_LEVEL")), stats.DeployLabel())
 	refreshAllowedOriginsFromEnv()
 	rateLimitBypass = parseRateLimitBypass(os.Getenv("RATE_LIMIT_BYPASS_IPS"))
+	ipLimiterIdleTTL = parseIPLimiterDuration(os.Getenv("RATE_LIMIT_IDLE_SECONDS"), defaultIPLimiterIdleTTL)
+	ipLimiterSweepInterval = parseIPLimiterDuration(os.Getenv("RATE_LIMIT_SWEEP_SECONDS"), defaultIPLimiterSweepInterval)
+	maxChunkedSDPBytes = parseMaxChunkedSDPBytes(os.Getenv("MAX_CHUNKED_SDP_BYTES"))
+	connectionBudget = parseConnBudget(os.Getenv("CONN_MESSAGE_BUDGET"), os.Getenv("CONN_BYTE_BUDGET"))
+	watcherTTL = parseWatcherTTL(os.Getenv("WATCHER_TTL_SECONDS"))
+	if policy, err := reloadRoomIDPolicyFromEnv(); err != nil {
+		log.Fatalf("Failed to load room ID allowlist/denylist: %v", err)
+	} else if policy != nil {
+		log.Printf("Room ID policy: %s", policy)
+	}
+	messageTypeRates = parseMessageTypeRates(os.Getenv("RELAY_TYPE_RATE_LIMITS"))
+	messagePayloadLimits = parseMessagePayloadLimits(os.Getenv("MESSAGE_PAYLOAD_LIMITS"))
+	roomReserveTTL = parseRoomReserveTTL(os.Getenv("ROOM_RESERVE_TTL_SECONDS"))
+	sseSessionMaxAge = parseSSESessionMaxAge(os.Getenv("SSE_SESSION_MAX_AGE_SECONDS"))
+	sseSIDCollisionPolicy = parseSSESIDCollisionPolicy(os.Getenv("SSE_SID_COLLISION_POLICY"))
+	sseStaleWarning = parseSSEStaleWarning(os.Getenv("SSE_STALE_WARNING_SECONDS"))
+	sseStaleGrace = parseSSEStaleGrace(os.Getenv("SSE_STALE_GRACE_SECONDS"))
+	sseReplayBufferSize = parseSSEReplayBufferSize(os.Getenv("SSE_REPLAY_BUFFER_SIZE"))
+	ssePingPeriod = parseSSEPingPeriod(os.Getenv("SSE_PING_SECONDS"))
+	sseStaleTimeoutIdle = parseSSEStaleTimeoutIdle(os.Getenv("SSE_STALE_TIMEOUT_IDLE_SECONDS"))
+	sseStaleTimeoutInRoom = parseSSEStaleTimeoutInRoom(os.Getenv("SSE_STALE_TIMEOUT_IN_ROOM_SECONDS"), sseStaleTimeoutIdle)
+	drainGrace = parseDrainGrace(os.Getenv("DRAIN_GRACE_SECONDS"))
+	reconnectTokenTTL = parseReconnectTokenTTL(os.Getenv("RECONNECT_TOKEN_TTL_SECONDS"))
+	reconnectAllowTokenless = os.Getenv("RECONNECT_ALLOW_TOKENLESS") == "1"
+	turnCredentialTTL = parseTurnCredentialTTL(os.Getenv("TURN_CREDENTIAL_TTL_SECONDS"))
+	turnRequireSession = os.Getenv("TURN_REQUIRE_SESSION") == "1"
+	roomStallTimeout = parseRoomStallTimeout(os.Getenv("ROOM_STALL_TIMEOUT_SECONDS"))
+	roomStallClose = os.Getenv("ROOM_STALL_CLOSE") == "1"
+	roomIdleTTL = parseRoomIdleTTL(os.Getenv("ROOM_IDLE_TTL_SECONDS"))
+	roomEventLogSize = parseRoomEventLogSize(os.Getenv("ROOM_EVENT_LOG_SIZE"))
+	requiredCapabilities = parseRequiredCapabilities(os.Getenv("REQUIRED_CLIENT_CAPABILITIES"))
+	if len(requiredCapabilities) > 0 {
+		log.Printf("Required client capabilities: %s", strings.Join(requiredCapabilities, ", "))
+	}
 
 	// Initialize signaling
 	maxParticipants := 4
@@ -27,6 +76,44 @@ func main() {
 	}
 	log.Printf("Max room participants limit: %d", maxParticipants)
 	hub := newHub(maxParticipants)
+	hub.maxClients = parseMaxConcurrentClients(os.Getenv("MAX_CONCURRENT_CLIENTS"))
+	hub.connLimit = newConnLimiterFromEnv()
+	hub.sendQueue = parseSendQueueConfig(os.Getenv("SEND_QUEUE_SIZE"), os.Getenv("SEND_QUEUE_POLICY"))
+	log.Printf("Send queue: %d messages per client, %s on overflow", hub.sendQueue.Size, hub.sendQueue.Policy)
+	stats.SetSendQueueSize(hub.sendQueue.Size)
+	if hub.maxClients > 0 {
+		log.Printf("Max concurrent clients: %d", hub.maxClients)
+	}
+	hub.hostLeavePolicy = parseHostLeavePolicy(os.Getenv("HOST_LEAVE_POLICY"))
+	log.Printf("Host leave policy: %s", hub.hostLeavePolicy)
+	hub.joinShed = newJoinShedder(os.Getenv("JOIN_SHED_P95_MS"))
+	if hub.joinShed != nil {
+		log.Printf("Join load shedding above p95 %s", hub.joinShed.threshold)
+	}
+	hub.usedRoomIDs = newUsedRoomIDs(os.Getenv("SINGLE_USE_ROOM_ID_TTL_SECONDS"))
+	if hub.usedRoomIDs != nil {
+		log.Printf("Single-use room IDs enforced for %s", hub.usedRoomIDs.ttl)
+	}
+	hub.statusDebounce = newRoomStatusDebouncer(parseRoomStatusDebounce(os.Getenv("ROOM_STATUS_DEBOUNCE_MS")))
+	hub.joinLimiter = newJoinLimiter(os.Getenv("JOIN_RATE_LIMIT_PER_MINUTE"))
+	if hub.joinLimiter != nil {
+		log.Printf("Join rate limit: %s per minute per IP", strings.TrimSpace(os.Getenv("JOIN_RATE_LIMIT_PER_MINUTE")))
+	}
+	profiler, err := newCPUProfilerFromEnv()
+	if err != nil {
+		log.Fatalf("CPU profile capture: %v", err)
+	}
+	hub.capture, err = newSessionCaptureFromEnv()
+	if err != nil {
+		log.Fatalf("Session capture: %v", err)
+	}
+	hub.audit, err = newAuditLogFromEnv()
+	if err != nil {
+		log.Fatalf("Audit log: %v", err)
+	}
+	if hub.audit != nil {
+		log.Printf("Audit log: writing join/leave records to %s", hub.audit.path)
+	}
 	go hub.run()
 
 	// Initialize Push Service
@@ -78,8 +165,17 @@ func main() {
 	turnCredsLimiter := NewIPLimiter(5.0/60.0, 5)
 	// Diagnostic token: 20 requests per minute per IP (bursty during device-check runs)
 	diagnosticLimiter := NewIPLimiter(20.0/60.0, 10)
-	// Room ID: 30 requests per minute per IP
-	roomIDLimiter := NewIPLimiter(30.0/60.0, 10)
+	// Room ID, room statuses, room participants and diagnostics: 30 requests
+	// per minute per IP unless RATE_LIMIT_RPS / RATE_LIMIT_BURST say otherwise
+	apiRPS := parseRateLimitValue("RATE_LIMIT_RPS", os.Getenv("RATE_LIMIT_RPS"), defaultRateLimitRPS)
+	apiBurst := parseRateLimitValue("RATE_LIMIT_BURST", os.Getenv("RATE_LIMIT_BURST"), defaultRateLimitBurst)
+	log.Printf("API rate limit: %g requests/s per IP, burst %g", apiRPS, apiBurst)
+	roomIDLimiter := NewIPLimiter(apiRPS, apiBurst)
+	roomStatusesLimiter := NewIPLimiter(apiRPS, apiBurst)
+	roomParticipantsLimiter := NewIPLimiter(apiRPS, apiBurst)
+	diagnosticsLimiter := NewIPLimiter(apiRPS, apiBurst)
+	// Room reservations: 10 requests per minute per IP
+	roomReserveLimiter := NewIPLimiter(10.0/60.0, 5)
 	// Push: 10 requests per minute
 	pushLimiter := NewIPLimiter(10.0/60.0, 5)
 
@@ -95,18 +191,49 @@ func main() {
 		serveWs(hub, w, r)
 	}))
 	http.HandleFunc("/sse", rateLimitMiddleware(sseLimiter, enableCors(handleSSE(hub))))
+	http.HandleFunc("/sse/resume", withTimeout(rateLimitMiddleware(sseLimiter, enableCors(handleSSEResume(hub))), 10*time.Second))
 
 	// ID & Credentials Routes
-	http.HandleFunc("/api/turn-credentials", withTimeout(rateLimitMiddleware(turnCredsLimiter, enableCors(handleTurnCredentials())), 15*time.Second))
+	http.HandleFunc("/api/turn-credentials", withTimeout(rateLimitMiddleware(turnCredsLimiter, enableCors(handleTurnCredentials(hub))), 15*time.Second))
 	http.HandleFunc("/api/diagnostic-token", withTimeout(rateLimitMiddleware(diagnosticLimiter, enableCors(handleDiagnosticToken())), 15*time.Second))
+	http.HandleFunc("/api/diagnostic-ice-servers", withTimeout(rateLimitMiddleware(diagnosticLimiter, enableCors(handleDiagnosticICEServers())), 15*time.Second))
 	http.HandleFunc("/api/room-id", withTimeout(rateLimitMiddleware(roomIDLimiter, enableCors(handleRoomID())), 15*time.Second))
+	http.HandleFunc("/api/room/reserve", withTimeout(rateLimitMiddleware(roomReserveLimiter, enableCors(handleRoomReserve(hub))), 10*time.Second))
+	http.HandleFunc("/api/room-statuses", withTimeout(rateLimitMiddleware(roomStatusesLimiter, enableCors(handleRoomStatuses(hub))), 10*time.Second))
+	http.HandleFunc("/api/room/participants", withTimeout(rateLimitMiddleware(roomParticipantsLimiter, enableCors(handleRoomParticipants(hub))), 5*time.Second))
+	http.HandleFunc("/api/diagnostics", withTimeout(rateLimitMiddleware(diagnosticsLimiter, enableCors(handleDiagnostics(hub))), 5*time.Second))
 	http.HandleFunc("/api/internal/stats", withTimeout(handleInternalStats(hub), 5*time.Second))
+	http.HandleFunc("/api/internal/hot-rooms", withTimeout(handleInternalHotRooms(hub), 5*time.Second))
+	http.HandleFunc("/api/internal/room", withTimeout(handleInternalRoom(hub), 5*time.Second))
+	http.HandleFunc("/api/internal/room-stats", withTimeout(handleInternalRoomStats(hub), 5*time.Second))
+	http.HandleFunc("/api/internal/profile/", withTimeout(handleInternalProfile(profiler), 5*time.Second))
+	http.HandleFunc("/api/internal/capture/", withTimeout(handleInternalCapture(hub.capture), 5*time.Second))
+	inspectableLimiters := map[string]*IPLimiter{
+		"ws":                wsLimiter,
+		"sse":               sseLimiter,
+		"turn-credentials":  turnCredsLimiter,
+		"diagnostic-token":  diagnosticLimiter,
+		"room-id":           roomIDLimiter,
+		"room-statuses":     roomStatusesLimiter,
+		"room-participants": roomParticipantsLimiter,
+		"diagnostics":       diagnosticsLimiter,
+		"room-reserve":      roomReserveLimiter,
+		"push":              pushLimiter,
+	}
+	if hub.connLimit != nil && hub.connLimit.rate != nil {
+		inspectableLimiters["connections"] = hub.connLimit.rate
+	}
+	if hub.joinLimiter != nil {
+		inspectableLimiters["join"] = hub.joinLimiter
+	}
+	http.HandleFunc("/api/internal/ratelimit", withTimeout(handleInternalRateLimit(inspectableLimiters), 5*time.Second))
 
 	// Push Routes
 	http.HandleFunc("/api/push/vapid-public-key", withTimeout(enableCors(handlePushVapidKey), 5*time.Second))
 	http.HandleFunc("/api/push/subscribe", withTimeout(rateLimitMiddleware(pushLimiter, enableCors(handlePushSubscribe)), 10*time.Second))
 	http.HandleFunc("/api/push/recipients", withTimeout(rateLimitMiddleware(pushLimiter, enableCors(handlePushRecipients)), 10*time.Second))
 	http.HandleFunc("/api/push/invite", withTimeout(rateLimitMiddleware(pushLimiter, enableCors(handlePushInvite)), 10*time.Second))
+	http.HandleFunc("/api/turn-revoke", withTimeout(rateLimitMiddleware(turnCredsLimiter, enableCors(handleTurnRevoke(hub))), 10*time.Second))
 	http.HandleFunc("/api/push/notify", withTimeout(rateLimitMiddleware(pushLimiter, enableCors(handlePushNotify(hub))), 10*time.Second))
 	http.HandleFunc("/api/push/snapshot", withTimeout(rateLimitMiddleware(pushLimiter, enableCors(handlePushSnapshot)), 10*time.Second))
 	http.HandleFunc("/api/push/snapshot/", withTimeout(enableCors(handlePushSnapshot), 10*time.Second))
@@ -126,8 +253,83 @@ func main() {
 		WriteTimeout:      0,
 		IdleTimeout:       60 * time.Second,
 	}
-	if err := server.ListenAndServe(); err != nil {
+
+	// Optional in-process TLS for deployments without a TLS-terminating proxy.
+	certFile := strings.TrimSpace(os.Getenv("TLS_CERT_FILE"))
+	keyFile := strings.TrimSpace(os.Getenv("TLS_KEY_FILE"))
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			log.Fatal("TLS_CERT_FILE and TLS_KEY_FILE must both be set")
+		}
+		tlsConfig, err := buildTLSConfig(os.Getenv("TLS_MIN_VERSION"))
+		if err != nil {
+			log.Fatal("TLS config: ", err)
+		}
+		server.TLSConfig = tlsConfig
+		log.Printf("Serving TLS (%s)", describeTLSConfig(tlsConfig))
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if server.TLSConfig != nil {
+			serveErr <- server.ListenAndServeTLS(certFile, keyFile)
+			return
+		}
+		serveErr <- server.ListenAndServe()
+	}()
+
+	// SIGHUP reloads the room ID allowlist/denylist without a restart.
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if policy, err := reloadRoomIDPolicyFromEnv(); err != nil {
+				log.Printf("Room ID policy reload failed, keeping previous lists: %v", err)
+			} else {
+				log.Printf("Reloaded room ID policy: %s", policy)
+			}
+		}
+	}()
+
+	// SIGUSR1 starts a graceful drain for zero-downtime deploys; the process
+	// exits once drainGrace has passed.
+	usr1 := make(chan os.Signal, 1)
+	signal.Notify(usr1, syscall.SIGUSR1)
+	drained := make(chan struct{})
+	go func() {
+		for range usr1 {
+			if hub.startDrain(drainGrace) {
+				time.AfterFunc(drainGrace, func() { close(drained) })
+			}
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	select {
+	case err := <-serveErr:
 		log.Fatal("ListenAndServe: ", err)
+	case <-ctx.Done():
+	case <-drained:
+		log.Printf("[DRAIN] Grace period elapsed")
+	}
+
+	// Stop accepting connections and give in-flight HTTP requests a moment to
+	// finish. Hijacked WebSockets and open SSE streams are not waited on.
+	log.Printf("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownDrainTimeout)
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Shutdown: %v", err)
+	}
+	cancel()
+	hub.audit.Close()
+
+	if finalStatsPath := strings.TrimSpace(os.Getenv("FINAL_STATS_PATH")); finalStatsPath != "" {
+		if err := hub.writeFinalStats(finalStatsPath); err != nil {
+			log.Printf("Failed to write final stats to %s: %v", finalStatsPath, err)
+		} else {
+			log.Printf("Wrote final stats to %s", finalStatsPath)
+		}
 	}
 }
 
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+
+	"serenada/server/internal/stats"
+)
+
+// Mute/camera toggles are user-driven; the bucket allows quick double-taps
+// but not scripted flapping.
+const (
+	mediaStateBurst      = 10
+	mediaStateRefillRate = 2.0 // tokens per second
+)
+
+const (
+	mediaOn  = "on"
+	mediaOff = "off"
+)
+
+// MediaState is a participant's self-reported audio/video state, relayed in
+// media_state and listed per participant in joined / room_state.
+type MediaState struct {
+	Audio string `json:"audio"`
+	Video string `json:"video"`
+}
+
+var defaultMediaState = MediaState{Audio: mediaOn, Video: mediaOn}
+
+func validMediaValue(v string) bool {
+	return v == mediaOn || v == mediaOff
+}
+
+// mediaStateLocked returns cid's last announced state, or on/on if it has not
+// announced one. Callers must hold room.mu.
+func (room *Room) mediaStateLocked(cid string) MediaState {
+	if state, ok := room.MediaStates[cid]; ok {
+		return state
+	}
+	return defaultMediaState
+}
+
+// handleMediaState records the sender's media state and relays it to the
+// other participants. Repeating the current state is a no-op.
+func (h *Hub) handleMediaState(c *Client, msg Message) {
+	var state MediaState
+	if err := json.Unmarshal(msg.Payload, &state); err != nil || !validMediaValue(state.Audio) || !validMediaValue(state.Video) {
+		c.sendError(msg.RID, "BAD_REQUEST", "media_state requires audio and video set to \"on\" or \"off\"")
+		return
+	}
+
+	h.mu.Lock()
+	room, exists := h.rooms[c.rid]
+	if c.mediaStateLimiter == nil {
+		c.mediaStateLimiter = NewSimpleTokenBucket(mediaStateBurst, mediaStateRefillRate)
+	}
+	limiter := c.mediaStateLimiter
+	h.mu.Unlock()
+
+	if c.rid == "" || !exists {
+		c.sendError(msg.RID, "NOT_IN_ROOM", "Must be in a room to send media state")
+		return
+	}
+	if !limiter.Allow() {
+		c.sendError(c.rid, "MEDIA_STATE_RATE_LIMITED", "Too many media state updates")
+		return
+	}
+
+	room.mu.Lock()
+	cid, inRoom := room.Participants[c]
+	if !inRoom {
+		room.mu.Unlock()
+		c.sendError(msg.RID, "NOT_IN_ROOM", "Must be in a room to send media state")
+		return
+	}
+	if room.mediaStateLocked(cid) == state {
+		room.mu.Unlock()
+		return
+	}
+	if room.MediaStates == nil {
+		room.MediaStates = make(map[string]MediaState)
+	}
+	room.MediaStates[cid] = state
+	peers := make([]*Client, 0, len(room.Participants))
+	for client := range room.Participants {
+		if client != c {
+			peers = append(peers, client)
+		}
+	}
+	rid := room.RID
+	room.mu.Unlock()
+
+	stats.IncMediaStateChange()
+	payload, _ := json.Marshal(map[string]string{
+		"from":  cid,
+		"audio": state.Audio,
+		"video": state.Video,
+	})
+	relay := Message{
+		V:       1,
+		Type:    "media_state",
+		RID:     rid,
+		Payload: payload,
+	}
+	for _, peer := range peers {
+		peer.sendMessage(relay)
+	}
+	log.Printf("[MEDIA_STATE] Client %s (CID: %s) in room %s: audio=%s video=%s", c.sid, cid, rid, state.Audio, state.Video)
+}
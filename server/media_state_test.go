@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"serenada/server/internal/stats"
+)
+
+func mediaStatePayload(rid, audio, video string) []byte {
+	payloadBytes, _ := json.Marshal(map[string]string{"audio": audio, "video": video})
+	b, _ := json.Marshal(Message{V: 1, Type: "media_state", RID: rid, Payload: payloadBytes})
+	return b
+}
+
+func participantMedia(t *testing.T, msg *Message, cid string) MediaState {
+	t.Helper()
+	if msg == nil {
+		t.Fatal("expected message with participants")
+	}
+	var payload struct {
+		Participants []Participant `json:"participants"`
+	}
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		t.Fatalf("decode participants: %v", err)
+	}
+	for _, p := range payload.Participants {
+		if p.CID == cid {
+			return p.Media
+		}
+	}
+	t.Fatalf("participant %s not listed", cid)
+	return MediaState{}
+}
+
+func TestMediaStateDefaultsToOnAndIsRelayed(t *testing.T) {
+	hub, rid, sender, peer, _ := joinedPair(t)
+
+	late := fakeClient(hub)
+	hub.registerClient(late)
+	hub.handleMessage(late, joinPayload(rid, 4, 4))
+	joined := findMessage(drainMessages(late), "joined")
+	if got := participantMedia(t, joined, sender.cid); got != defaultMediaState {
+		t.Fatalf("expected on/on at join, got %+v", got)
+	}
+	drainMessages(sender)
+	drainMessages(peer)
+
+	before := stats.SnapshotNow().Counters.MediaStateChanges
+	hub.handleMessage(sender, mediaStatePayload(rid, "off", "on"))
+
+	relayed := findMessage(drainMessages(peer), "media_state")
+	if relayed == nil {
+		t.Fatal("expected media_state relay to peer")
+	}
+	var payload map[string]string
+	_ = json.Unmarshal(relayed.Payload, &payload)
+	if payload["from"] != sender.cid || payload["audio"] != "off" || payload["video"] != "on" {
+		t.Fatalf("unexpected relay payload: %v", payload)
+	}
+	if findMessage(drainMessages(sender), "media_state") != nil {
+		t.Fatal("sender must not receive its own media_state")
+	}
+	if got := stats.SnapshotNow().Counters.MediaStateChanges - before; got != 1 {
+		t.Fatalf("expected one media state change, got %d", got)
+	}
+
+	// Repeating the same state is a no-op.
+	hub.handleMessage(sender, mediaStatePayload(rid, "off", "on"))
+	if findMessage(drainMessages(peer), "media_state") != nil {
+		t.Fatal("expected unchanged media state not to be relayed")
+	}
+
+	hub.broadcastRoomState(hub.rooms[rid])
+	state := findMessage(drainMessages(peer), "room_state")
+	if got := participantMedia(t, state, sender.cid); got != (MediaState{Audio: "off", Video: "on"}) {
+		t.Fatalf("expected room_state to carry the announced state, got %+v", got)
+	}
+}
+
+func TestMediaStateValidatesValues(t *testing.T) {
+	hub, rid, sender, _, _ := joinedPair(t)
+
+	hub.handleMessage(sender, mediaStatePayload(rid, "muted", "on"))
+	if code := errorCode(findMessage(drainMessages(sender), "error")); code != "BAD_REQUEST" {
+		t.Fatalf("expected BAD_REQUEST, got %q", code)
+	}
+}
+
+func TestMediaStateRequiresRoom(t *testing.T) {
+	hub := newHub(4)
+	c := fakeClient(hub)
+	hub.registerClient(c)
+
+	hub.handleMessage(c, mediaStatePayload("", "off", "off"))
+	if code := errorCode(findMessage(drainMessages(c), "error")); code != "NOT_IN_ROOM" {
+		t.Fatalf("expected NOT_IN_ROOM, got %q", code)
+	}
+}
+
+func TestMediaStateRateLimited(t *testing.T) {
+	hub, rid, sender, _, _ := joinedPair(t)
+
+	values := []string{"off", "on"}
+	for i := 0; i < mediaStateBurst; i++ {
+		hub.handleMessage(sender, mediaStatePayload(rid, values[i%2], "on"))
+	}
+	if findMessage(drainMessages(sender), "error") != nil {
+		t.Fatal("expected burst of toggles to be allowed")
+	}
+
+	hub.handleMessage(sender, mediaStatePayload(rid, "off", "off"))
+	if code := errorCode(findMessage(drainMessages(sender), "error")); code != "MEDIA_STATE_RATE_LIMITED" {
+		t.Fatalf("expected MEDIA_STATE_RATE_LIMITED, got %q", code)
+	}
+}
@@ -34,7 +34,10 @@ func drainMessages(c *Client) []Message {
 	var msgs []Message
 	for {
 		select {
-		case raw := <-c.send:
+		case raw, ok := <-c.send:
+			if !ok {
+				return msgs
+			}
 			var msg Message
 			if err := json.Unmarshal(raw, &msg); err == nil {
 				msgs = append(msgs, msg)
@@ -454,6 +457,31 @@ func TestJoinedPayloadIncludesMaxParticipantsAndJoinedAt(t *testing.T) {
 	t.Fatal("did not receive joined message")
 }
 
+func TestJoinedPayloadIncludesServerTime(t *testing.T) {
+	rid := mustTestRoomID(t)
+	hub := newHub(4)
+
+	c := fakeClient(hub)
+	hub.registerClient(c)
+	before := time.Now().UnixMilli()
+	hub.handleMessage(c, joinPayload(rid, 4, 4))
+	after := time.Now().UnixMilli()
+
+	joined := findMessage(drainMessages(c), "joined")
+	if joined == nil {
+		t.Fatal("did not receive joined message")
+	}
+	var payload struct {
+		ServerTimeMs int64 `json:"serverTimeMs"`
+	}
+	if err := json.Unmarshal(joined.Payload, &payload); err != nil {
+		t.Fatalf("failed to parse joined payload: %v", err)
+	}
+	if payload.ServerTimeMs < before || payload.ServerTimeMs > after {
+		t.Fatalf("serverTimeMs %d outside [%d, %d]", payload.ServerTimeMs, before, after)
+	}
+}
+
 func TestCreateMaxParticipantsClampedToServerCeiling(t *testing.T) {
 	t.Setenv("ROOM_ID_SECRET", "test-room-id-secret")
 	rid := mustTestRoomID(t)
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"serenada/server/internal/stats"
+)
+
+func TestRelayToListReachesOnlyListedParticipants(t *testing.T) {
+	rid := mustTestRoomID(t)
+	hub := newHub(4)
+
+	clients := make([]*Client, 4)
+	for i := range clients {
+		clients[i] = fakeClient(hub)
+		hub.registerClient(clients[i])
+		hub.handleMessage(clients[i], joinPayload(rid, 4, 4))
+	}
+	for _, c := range clients {
+		drainMessages(c)
+	}
+	sender, first, skipped, second := clients[0], clients[1], clients[2], clients[3]
+
+	payloadBytes, _ := json.Marshal(map[string]interface{}{"sdp": "v=0"})
+	raw, _ := json.Marshal(Message{
+		V:       1,
+		Type:    "offer",
+		RID:     rid,
+		To:      skipped.cid, // ignored when toList is present
+		ToList:  []string{first.cid, second.cid, "C-not-in-room"},
+		Payload: payloadBytes,
+	})
+
+	before := stats.SnapshotNow().Counters
+	hub.handleMessage(sender, raw)
+
+	for _, c := range []*Client{first, second} {
+		if findMessage(drainMessages(c), "offer") == nil {
+			t.Fatalf("expected listed participant %s to receive the offer", c.cid)
+		}
+	}
+	if msgs := drainMessages(skipped); findMessage(msgs, "offer") != nil {
+		t.Fatal("expected unlisted participant not to receive the offer")
+	}
+
+	after := stats.SnapshotNow().Counters
+	if after.PartialRelayTotal-before.PartialRelayTotal != 1 {
+		t.Fatalf("expected one partial relay, got %d", after.PartialRelayTotal-before.PartialRelayTotal)
+	}
+	if after.PartialRelayFanout-before.PartialRelayFanout != 2 {
+		t.Fatalf("expected partial relay fan-out of 2, got %d", after.PartialRelayFanout-before.PartialRelayFanout)
+	}
+}
+
+func TestRelayWithoutToListIsNotCountedAsPartial(t *testing.T) {
+	rid := mustTestRoomID(t)
+	hub := newHub(4)
+
+	sender := fakeClient(hub)
+	peer := fakeClient(hub)
+	for _, c := range []*Client{sender, peer} {
+		hub.registerClient(c)
+		hub.handleMessage(c, joinPayload(rid, 4, 4))
+	}
+
+	before := stats.SnapshotNow().Counters.PartialRelayTotal
+	hub.handleMessage(sender, iceMessage(rid))
+
+	if findMessage(drainMessages(peer), "ice") == nil {
+		t.Fatal("expected broadcast relay to reach the peer")
+	}
+	if got := stats.SnapshotNow().Counters.PartialRelayTotal - before; got != 0 {
+		t.Fatalf("expected broadcast relay not to count as partial, got %d", got)
+	}
+}
+
+func TestRelayToOwnCIDReturnsSelfRelay(t *testing.T) {
+	hub, rid, sender, peer, _ := joinedPair(t)
+
+	payloadBytes, _ := json.Marshal(map[string]interface{}{"sdp": "v=0"})
+	raw, _ := json.Marshal(Message{V: 1, Type: "offer", RID: rid, To: sender.cid, Payload: payloadBytes})
+	hub.handleMessage(sender, raw)
+
+	if code := errorCode(findMessage(drainMessages(sender), "error")); code != "SELF_RELAY" {
+		t.Fatalf("expected SELF_RELAY, got %q", code)
+	}
+	if findMessage(drainMessages(peer), "offer") != nil {
+		t.Fatal("expected self-addressed relay not to reach other participants")
+	}
+}
+
+func TestRelayAfterRoomEndedReturnsRoomGone(t *testing.T) {
+	hub, rid, sender, peer, _ := joinedPair(t)
+
+	hub.mu.RLock()
+	room := hub.rooms[rid]
+	hub.mu.RUnlock()
+	hub.endRoom(room, rid, "", "host_ended", nil)
+	drainMessages(sender)
+
+	before := stats.SnapshotNow().Counters.RelayRoomGoneTotal
+	payloadBytes, _ := json.Marshal(map[string]interface{}{"candidate": "candidate:0"})
+	raw, _ := json.Marshal(Message{V: 1, Type: "ice", RID: rid, To: peer.cid, Payload: payloadBytes})
+	hub.handleMessage(sender, raw)
+
+	if code := errorCode(findMessage(drainMessages(sender), "error")); code != "ROOM_GONE" {
+		t.Fatalf("expected ROOM_GONE, got %q", code)
+	}
+	if after := stats.SnapshotNow().Counters.RelayRoomGoneTotal; after-before != 1 {
+		t.Fatalf("expected one room-gone relay counted, got %d", after-before)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"serenada/server/internal/stats"
+)
+
+// defaultMessagePayloadLimits applies when MESSAGE_PAYLOAD_LIMITS is unset.
+// An ICE candidate is a few hundred bytes and a presence hint smaller still;
+// offers and answers leave room for a large multi-track SDP, and anything
+// bigger can use offer-chunk.
+const defaultMessagePayloadLimits = "offer=32768,answer=32768,ice=2048,presence=512"
+
+// messagePayloadLimits caps the payload size in bytes of inbound messages by
+// type, below the maxMessageSize frame limit, so a relayed message cannot be
+// used to fan large blobs out to every peer. Set from MESSAGE_PAYLOAD_LIMITS
+// (e.g. "ice=4096,offer=65536") at startup; "off" disables it.
+var messagePayloadLimits map[string]int
+
+// parseMessagePayloadLimits reads type=bytes entries.
+func parseMessagePayloadLimits(raw string) map[string]int {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		raw = defaultMessagePayloadLimits
+	}
+	if strings.EqualFold(raw, "off") {
+		return nil
+	}
+	limits := make(map[string]int)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		msgType, value, ok := strings.Cut(part, "=")
+		msgType = strings.TrimSpace(msgType)
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || msgType == "" || err != nil || n <= 0 {
+			log.Printf("Ignoring invalid MESSAGE_PAYLOAD_LIMITS entry %q", part)
+			continue
+		}
+		limits[msgType] = n
+	}
+	if len(limits) == 0 {
+		return nil
+	}
+	return limits
+}
+
+// checkPayloadSize reports whether msg's payload fits its type's limit in
+// messagePayloadLimits. Oversized messages are counted and answered with
+// PAYLOAD_TOO_LARGE; types without a limit always pass.
+func (c *Client) checkPayloadSize(msg Message) bool {
+	limit, limited := messagePayloadLimits[msg.Type]
+	if !limited || len(msg.Payload) <= limit {
+		return true
+	}
+	stats.IncMessagePayloadTooLarge(msg.Type)
+	c.sendError(msg.RID, "PAYLOAD_TOO_LARGE", msg.Type+" payload exceeds "+strconv.Itoa(limit)+" bytes")
+	return false
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"serenada/server/internal/stats"
+)
+
+func TestParseMessagePayloadLimits(t *testing.T) {
+	if limits := parseMessagePayloadLimits(""); limits["ice"] != 2048 || limits["offer"] != 32768 || limits["presence"] != 512 {
+		t.Fatalf("expected default payload limits, got %v", limits)
+	}
+	if limits := parseMessagePayloadLimits("off"); limits != nil {
+		t.Fatalf("expected off to disable limits, got %v", limits)
+	}
+	limits := parseMessagePayloadLimits(" ice=4096, offer=65536,bogus,=3,answer=0,pong=x ")
+	if len(limits) != 2 || limits["ice"] != 4096 || limits["offer"] != 65536 {
+		t.Fatalf("unexpected limits: %v", limits)
+	}
+}
+
+func TestOversizedPayloadRejectedPerType(t *testing.T) {
+	original := messagePayloadLimits
+	messagePayloadLimits = map[string]int{"ice": 64}
+	defer func() { messagePayloadLimits = original }()
+
+	hub, rid, sender, peer, _ := joinedPair(t)
+	relay := func(msgType, candidate string) []byte {
+		payload, _ := json.Marshal(map[string]string{"candidate": candidate})
+		b, _ := json.Marshal(Message{V: 1, Type: msgType, RID: rid, To: peer.cid, Payload: payload})
+		return b
+	}
+
+	before := stats.SnapshotNow().Messages.PayloadTooLargeByType["ice"]
+	hub.handleMessage(sender, relay("ice", strings.Repeat("x", 100)))
+	if code := errorCode(findMessage(drainMessages(sender), "error")); code != "PAYLOAD_TOO_LARGE" {
+		t.Fatalf("expected PAYLOAD_TOO_LARGE, got %q", code)
+	}
+	if findMessage(drainMessages(peer), "ice") != nil {
+		t.Fatal("expected the oversized ice not to be relayed")
+	}
+	if after := stats.SnapshotNow().Messages.PayloadTooLargeByType["ice"]; after-before != 1 {
+		t.Fatalf("expected one oversized ice counted, got %d", after-before)
+	}
+
+	hub.handleMessage(sender, relay("ice", "candidate:1"))
+	if findMessage(drainMessages(peer), "ice") == nil {
+		t.Fatal("expected a small ice to be relayed")
+	}
+	hub.handleMessage(sender, relay("offer", strings.Repeat("x", 100)))
+	if findMessage(drainMessages(peer), "offer") == nil {
+		t.Fatal("expected types without a limit to be relayed")
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"serenada/server/internal/stats"
+)
+
+// maxPresencePayloadBytes caps a presence hint. Presence is for small UI
+// state ("connecting", "camera off"), not a side channel for bulk data.
+const maxPresencePayloadBytes = 512
+
+// handlePresence relays an ephemeral presence hint to the other participants
+// with the sender's CID set as "from". Unlike offer/answer/ice it is neither
+// logged per message nor counted as room relay activity, and nothing is
+// stored: a participant that joins later only sees the next hint.
+func (h *Hub) handlePresence(c *Client, msg Message) {
+	body := bytes.TrimSpace(msg.Payload)
+	if len(body) > maxPresencePayloadBytes {
+		c.sendError(msg.RID, "BAD_REQUEST", "Presence payload is too large")
+		return
+	}
+	if len(body) == 0 || body[0] != '{' || !json.Valid(body) {
+		c.sendError(msg.RID, "BAD_REQUEST", "presence requires a JSON object payload")
+		return
+	}
+
+	h.mu.RLock()
+	room, exists := h.rooms[c.rid]
+	h.mu.RUnlock()
+	if c.rid == "" || !exists {
+		c.sendError(msg.RID, "NOT_IN_ROOM", "Must be in a room to send presence")
+		return
+	}
+
+	room.mu.Lock()
+	if _, inRoom := room.Participants[c]; !inRoom {
+		room.mu.Unlock()
+		c.sendError(msg.RID, "NOT_IN_ROOM", "Must be in a room to send presence")
+		return
+	}
+	peers := make([]*Client, 0, len(room.Participants))
+	for client := range room.Participants {
+		if client != c {
+			peers = append(peers, client)
+		}
+	}
+	rid := room.RID
+	room.mu.Unlock()
+
+	relay := Message{
+		V:       1,
+		Type:    "presence",
+		RID:     rid,
+		Payload: c.relayPayloadWithFrom(msg.Type, body),
+	}
+	delivered := 0
+	for _, peer := range peers {
+		if peer.sendMessage(relay) {
+			delivered++
+		}
+	}
+	stats.IncPresence(delivered)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"serenada/server/internal/stats"
+)
+
+func presencePayload(rid string, payload string) []byte {
+	b, _ := json.Marshal(Message{V: 1, Type: "presence", RID: rid, Payload: json.RawMessage(payload)})
+	return b
+}
+
+func TestPresenceIsRelayedToPeersWithFrom(t *testing.T) {
+	hub, rid, sender, peer, _ := joinedPair(t)
+	drainMessages(sender)
+	drainMessages(peer)
+
+	before := stats.SnapshotNow()
+	hub.handleMessage(sender, presencePayload(rid, `{"state":"connecting"}`))
+
+	relayed := findMessage(drainMessages(peer), "presence")
+	if relayed == nil {
+		t.Fatal("expected presence relay to peer")
+	}
+	var payload map[string]string
+	_ = json.Unmarshal(relayed.Payload, &payload)
+	if payload["from"] != sender.cid || payload["state"] != "connecting" {
+		t.Fatalf("unexpected presence payload: %v", payload)
+	}
+	if findMessage(drainMessages(sender), "presence") != nil {
+		t.Fatal("sender must not receive its own presence")
+	}
+
+	after := stats.SnapshotNow()
+	if got := after.Counters.PresenceInTotal - before.Counters.PresenceInTotal; got != 1 {
+		t.Fatalf("expected one presence message counted, got %d", got)
+	}
+	if got := after.Counters.RelayInTotal - before.Counters.RelayInTotal; got != 0 {
+		t.Fatalf("presence must not count as a relay, got %d", got)
+	}
+}
+
+func TestPresenceRejectsOversizedAndNonObjectPayloads(t *testing.T) {
+	hub, rid, sender, peer, _ := joinedPair(t)
+	drainMessages(sender)
+	drainMessages(peer)
+
+	for _, payload := range []string{
+		`{"state":"` + strings.Repeat("x", maxPresencePayloadBytes) + `"}`,
+		`"connecting"`,
+		`[1,2]`,
+	} {
+		hub.handleMessage(sender, presencePayload(rid, payload))
+		if code := errorCode(findMessage(drainMessages(sender), "error")); code != "BAD_REQUEST" {
+			t.Fatalf("expected BAD_REQUEST for %.20s, got %q", payload, code)
+		}
+	}
+	if findMessage(drainMessages(peer), "presence") != nil {
+		t.Fatal("rejected presence must not be relayed")
+	}
+
+	outsider := fakeClient(hub)
+	hub.registerClient(outsider)
+	hub.handleMessage(outsider, presencePayload(rid, `{"state":"connecting"}`))
+	if code := errorCode(findMessage(drainMessages(outsider), "error")); code != "NOT_IN_ROOM" {
+		t.Fatalf("expected NOT_IN_ROOM, got %q", code)
+	}
+}
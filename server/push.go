@@ -19,14 +19,20 @@ import (
 
 	"github.com/SherClockHolmes/webpush-go"
 	_ "modernc.org/sqlite"
+	"serenada/server/internal/stats"
 )
 
+// defaultPushSendConcurrency bounds simultaneous provider (FCM/Web Push)
+// requests so a room-wide invite cannot burst outbound connections.
+const defaultPushSendConcurrency = 16
+
 type PushService struct {
 	db         *sql.DB
 	privateKey string
 	publicKey  string
 	fcm        *FCMService
 	mu         sync.RWMutex
+	sendSlots  chan struct{} // semaphore for provider sends; nil means unbounded
 }
 
 type VAPIDKeys struct {
@@ -190,6 +196,7 @@ func InitPushService() error {
 		privateKey: keys.PrivateKey,
 		publicKey:  keys.PublicKey,
 		fcm:        fcmService,
+		sendSlots:  make(chan struct{}, parsePushSendConcurrency(os.Getenv("PUSH_SEND_CONCURRENCY"))),
 	}
 
 	log.Printf("[PUSH] PushService initialized with SQLite persistence at %s", dbPath)
@@ -347,8 +354,36 @@ func (s *PushService) sendNotificationToRoom(roomID string, excludeEndpoint stri
 	}
 
 	for _, target := range targets {
-		go s.sendOne(roomID, target, snapshotID, snapshotMeta, kind)
+		s.goSend(func() { s.sendOne(roomID, target, snapshotID, snapshotMeta, kind) })
+	}
+}
+
+func parsePushSendConcurrency(raw string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || n <= 0 {
+		return defaultPushSendConcurrency
+	}
+	return n
+}
+
+// goSend runs send in the background once a send slot is free. Waiting sends
+// hold no connection, only a goroutine, and are reported as queued.
+func (s *PushService) goSend(send func()) {
+	if s.sendSlots == nil {
+		go send()
+		return
 	}
+	stats.AddPushSendsQueued(1)
+	go func() {
+		s.sendSlots <- struct{}{}
+		stats.AddPushSendsQueued(-1)
+		stats.AddPushSendsInFlight(1)
+		defer func() {
+			stats.AddPushSendsInFlight(-1)
+			<-s.sendSlots
+		}()
+		send()
+	}()
 }
 
 func getLocalizedMessage(locale string, kind string) (string, string) {
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"serenada/server/internal/stats"
+)
+
+func TestParsePushSendConcurrency(t *testing.T) {
+	cases := map[string]int{
+		"":     defaultPushSendConcurrency,
+		"0":    defaultPushSendConcurrency,
+		"-3":   defaultPushSendConcurrency,
+		"nope": defaultPushSendConcurrency,
+		"4":    4,
+	}
+	for raw, want := range cases {
+		if got := parsePushSendConcurrency(raw); got != want {
+			t.Errorf("parsePushSendConcurrency(%q) = %d, want %d", raw, got, want)
+		}
+	}
+}
+
+func TestPushGoSendBoundsConcurrency(t *testing.T) {
+	const limit = 2
+	const sends = 6
+	service := &PushService{sendSlots: make(chan struct{}, limit)}
+
+	release := make(chan struct{})
+	var running, peak atomic.Int64
+	var wg sync.WaitGroup
+	wg.Add(sends)
+	for i := 0; i < sends; i++ {
+		service.goSend(func() {
+			defer wg.Done()
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			<-release
+			running.Add(-1)
+		})
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for running.Load() < limit && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	gauges := stats.SnapshotNow().Gauges
+	if gauges.PushSendsInFlight != limit || gauges.PushSendsQueued != sends-limit {
+		t.Fatalf("expected %d in flight and %d queued, got %d and %d", limit, sends-limit, gauges.PushSendsInFlight, gauges.PushSendsQueued)
+	}
+
+	close(release)
+	wg.Wait()
+	if peak.Load() > limit {
+		t.Fatalf("expected at most %d concurrent sends, saw %d", limit, peak.Load())
+	}
+}
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
 	"log"
+	"math"
 	"net"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -13,10 +17,50 @@ import (
 var rateLimitBypass = parseRateLimitBypass(os.Getenv("RATE_LIMIT_BYPASS_IPS"))
 
 const (
-	ipLimiterEntryTTL      = 30 * time.Minute
-	ipLimiterPruneInterval = 10 * time.Minute
+	defaultIPLimiterIdleTTL       = 30 * time.Minute
+	defaultIPLimiterSweepInterval = 10 * time.Minute
 )
 
+// A bucket unused for ipLimiterIdleTTL that has refilled to capacity is
+// dropped, at the latest ipLimiterSweepInterval later. Set from
+// RATE_LIMIT_IDLE_SECONDS and RATE_LIMIT_SWEEP_SECONDS before limiters are
+// created; each limiter copies them when it is built.
+var (
+	ipLimiterIdleTTL       = defaultIPLimiterIdleTTL
+	ipLimiterSweepInterval = defaultIPLimiterSweepInterval
+)
+
+// The general HTTP API limit (room IDs, room statuses and participants,
+// diagnostics), overridable with RATE_LIMIT_RPS and RATE_LIMIT_BURST.
+// Connection and credential endpoints keep their own limits.
+const (
+	defaultRateLimitRPS   = 30.0 / 60.0
+	defaultRateLimitBurst = 10
+)
+
+// parseRateLimitValue reads a positive float from the environment variable
+// name, falling back with a warning when it is set but invalid.
+func parseRateLimitValue(name, raw string, fallback float64) float64 {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return fallback
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || value <= 0 || math.IsInf(value, 0) || math.IsNaN(value) {
+		log.Printf("Ignoring invalid %s %q; using %g", name, raw, fallback)
+		return fallback
+	}
+	return value
+}
+
+func parseIPLimiterDuration(raw string, fallback time.Duration) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || seconds <= 0 {
+		return fallback
+	}
+	return time.Duration(seconds) * time.Second
+}
+
 // SimpleTokenBucket implements a token bucket rate limiter.
 type SimpleTokenBucket struct {
 	tokens         float64
@@ -59,6 +103,42 @@ func (tb *SimpleTokenBucket) Allow() bool {
 	return false
 }
 
+// SecondsUntilToken reports how long, as of now, until the bucket holds a
+// whole token again. It does not consume a token.
+func (tb *SimpleTokenBucket) SecondsUntilToken() float64 {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	elapsed := time.Since(tb.lastRefillTime).Seconds()
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	tokens := tb.tokens + elapsed*tb.refillRate
+	if tokens >= 1 || tb.refillRate <= 0 {
+		return 0
+	}
+	return (1 - tokens) / tb.refillRate
+}
+
+// retryAfter is SecondsUntilToken as a duration of at least a millisecond
+// while the bucket is empty.
+func (tb *SimpleTokenBucket) retryAfter() time.Duration {
+	seconds := tb.SecondsUntilToken()
+	if seconds <= 0 {
+		return 0
+	}
+	wait := time.Duration(seconds * float64(time.Second))
+	if wait < time.Millisecond {
+		return time.Millisecond
+	}
+	return wait
+}
+
+// retryAfterHeader formats d for a Retry-After header: whole seconds,
+// rounded up, and never less than one.
+func retryAfterHeader(d time.Duration) string {
+	return strconv.Itoa(max(1, int(math.Ceil(d.Seconds()))))
+}
+
 // Global Rate Limiter Manager
 type IPLimiter struct {
 	ips          map[string]*SimpleTokenBucket
@@ -67,6 +147,11 @@ type IPLimiter struct {
 	burst        float64
 	lastPrunedAt time.Time
 	now          func() time.Time
+
+	idleTTL       time.Duration
+	sweepInterval time.Duration
+	stop          chan struct{}
+	stopOnce      sync.Once
 }
 
 type rateLimitBypassList struct {
@@ -129,12 +214,45 @@ func (l rateLimitBypassList) contains(rawIP string) bool {
 	return false
 }
 
+// NewIPLimiter also starts a goroutine that sweeps idle buckets every
+// ipLimiterSweepInterval, so the map shrinks even when no requests arrive.
+// Limiters built by main live for the whole process; tests call Stop.
 func NewIPLimiter(r float64, b float64) *IPLimiter {
-	return &IPLimiter{
-		ips:   make(map[string]*SimpleTokenBucket),
-		rate:  r,
-		burst: b,
-		now:   time.Now,
+	return newIPLimiter(r, b, ipLimiterIdleTTL, ipLimiterSweepInterval)
+}
+
+func newIPLimiter(r, b float64, idleTTL, sweepInterval time.Duration) *IPLimiter {
+	limiter := &IPLimiter{
+		ips:           make(map[string]*SimpleTokenBucket),
+		rate:          r,
+		burst:         b,
+		now:           time.Now,
+		idleTTL:       idleTTL,
+		sweepInterval: sweepInterval,
+		stop:          make(chan struct{}),
+	}
+	go limiter.sweepLoop()
+	return limiter
+}
+
+// Stop ends the background sweep. The limiter keeps working, pruning only
+// when buckets are requested.
+func (i *IPLimiter) Stop() {
+	i.stopOnce.Do(func() { close(i.stop) })
+}
+
+func (i *IPLimiter) sweepLoop() {
+	ticker := time.NewTicker(i.sweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-i.stop:
+			return
+		case <-ticker.C:
+			i.mu.Lock()
+			i.pruneStaleEntries(i.now())
+			i.mu.Unlock()
+		}
 	}
 }
 
@@ -155,20 +273,76 @@ func (i *IPLimiter) GetLimiter(ip string) *SimpleTokenBucket {
 	return limiter
 }
 
+// RateLimitBucketState is a read-only view of one IP's token bucket.
+type RateLimitBucketState struct {
+	Tokens     float64 `json:"tokens"`
+	Capacity   float64 `json:"capacity"`
+	RefillRate float64 `json:"refillRate"` // tokens per second
+}
+
+// State reports the bucket for ip as of now without consuming a token or
+// refreshing its last-seen time. ok is false when no bucket exists.
+func (i *IPLimiter) State(ip string) (state RateLimitBucketState, ok bool) {
+	i.mu.Lock()
+	limiter, exists := i.ips[ip]
+	i.mu.Unlock()
+	if !exists {
+		return RateLimitBucketState{}, false
+	}
+
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	tokens := limiter.tokens + time.Since(limiter.lastRefillTime).Seconds()*limiter.refillRate
+	if tokens > limiter.capacity {
+		tokens = limiter.capacity
+	}
+	return RateLimitBucketState{
+		Tokens:     tokens,
+		Capacity:   limiter.capacity,
+		RefillRate: limiter.refillRate,
+	}, true
+}
+
+// Clear drops the bucket for ip so its next request starts with a full
+// bucket. It reports whether a bucket existed.
+func (i *IPLimiter) Clear(ip string) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	_, exists := i.ips[ip]
+	delete(i.ips, ip)
+	return exists
+}
+
+// pruneStaleEntries drops idle, full buckets at most once per sweep interval.
+// Called with i.mu held. GetLimiter refreshes lastSeen under i.mu before
+// handing a bucket out, so a bucket in use by a concurrent request is never
+// idle here.
 func (i *IPLimiter) pruneStaleEntries(now time.Time) {
-	if !i.lastPrunedAt.IsZero() && now.Sub(i.lastPrunedAt) < ipLimiterPruneInterval {
+	if !i.lastPrunedAt.IsZero() && now.Sub(i.lastPrunedAt) < i.sweepInterval {
 		return
 	}
 
-	cutoff := now.Add(-ipLimiterEntryTTL)
+	cutoff := now.Add(-i.idleTTL)
 	for ip, limiter := range i.ips {
-		if limiter.lastSeen.Before(cutoff) {
+		if limiter.lastSeen.Before(cutoff) && limiter.fullAt(now) {
 			delete(i.ips, ip)
 		}
 	}
 	i.lastPrunedAt = now
 }
 
+// fullAt reports whether the bucket will have refilled to capacity by now, so
+// dropping it loses no rate-limit state.
+func (tb *SimpleTokenBucket) fullAt(now time.Time) bool {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	elapsed := now.Sub(tb.lastRefillTime).Seconds()
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return tb.tokens+elapsed*tb.refillRate >= tb.capacity
+}
+
 // Middleware
 func rateLimitMiddleware(limiter *IPLimiter, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -177,8 +351,9 @@ func rateLimitMiddleware(limiter *IPLimiter, next http.HandlerFunc) http.Handler
 			next(w, r)
 			return
 		}
-		if !limiter.GetLimiter(ip).Allow() {
-			http.Error(w, "429 Too Many Requests", http.StatusTooManyRequests)
+		bucket := limiter.GetLimiter(ip)
+		if !bucket.Allow() {
+			writeRateLimited(w, bucket.retryAfter())
 			log.Printf("Rate limit exceeded for IP: %s", ip)
 			return
 		}
@@ -186,6 +361,74 @@ func rateLimitMiddleware(limiter *IPLimiter, next http.HandlerFunc) http.Handler
 	}
 }
 
+// writeRateLimited answers 429 with a Retry-After header and a JSON body
+// carrying the same wait, so clients back off instead of retrying at once.
+func writeRateLimited(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := retryAfterHeader(retryAfter)
+	w.Header().Set("Retry-After", seconds)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusTooManyRequests)
+	_, _ = fmt.Fprintf(w, "{\"error\":\"RATE_LIMITED\",\"message\":\"Too many requests\",\"retryAfter\":%s}\n", seconds)
+}
+
+// handleInternalRateLimit reports (GET) or clears (DELETE) the buckets held
+// for ?ip= across the named limiters. ?limiter= narrows it to one limiter.
+func handleInternalRateLimit(limiters map[string]*IPLimiter) http.HandlerFunc {
+	access := internalAccessFromEnv()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !access.authorize(w, r, http.MethodGet, http.MethodDelete) {
+			return
+		}
+
+		ip := strings.TrimSpace(r.URL.Query().Get("ip"))
+		if parsed := parseIP(ip); parsed != nil {
+			ip = parsed.String()
+		} else {
+			http.Error(w, "Invalid ip", http.StatusBadRequest)
+			return
+		}
+
+		selected := limiters
+		if name := strings.TrimSpace(r.URL.Query().Get("limiter")); name != "" {
+			limiter, ok := limiters[name]
+			if !ok {
+				http.Error(w, "Unknown limiter", http.StatusBadRequest)
+				return
+			}
+			selected = map[string]*IPLimiter{name: limiter}
+		}
+
+		type bucketResult struct {
+			Exists bool `json:"exists"`
+			*RateLimitBucketState
+		}
+		results := make(map[string]bucketResult, len(selected))
+		for name, limiter := range selected {
+			if r.Method == http.MethodDelete {
+				results[name] = bucketResult{Exists: limiter.Clear(ip)}
+				continue
+			}
+			if state, ok := limiter.State(ip); ok {
+				results[name] = bucketResult{Exists: true, RateLimitBucketState: &state}
+			} else {
+				results[name] = bucketResult{}
+			}
+		}
+		if r.Method == http.MethodDelete {
+			log.Printf("[RATE_LIMIT] Cleared buckets for IP %s", ip)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"ip":       ip,
+			"limiters": results,
+		})
+	}
+}
+
 func getClientIP(r *http.Request) string {
 	trustProxy := strings.EqualFold(os.Getenv("TRUST_PROXY"), "1")
 	if trustProxy {
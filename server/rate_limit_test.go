@@ -1,8 +1,11 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 )
@@ -53,9 +56,12 @@ func TestRateLimitMiddlewareBypass(t *testing.T) {
 
 func TestIPLimiterPrunesIdleEntries(t *testing.T) {
 	base := time.Date(2026, time.March, 25, 12, 0, 0, 0, time.UTC)
-	limiter := NewIPLimiter(1, 1)
+	limiter := newIPLimiter(1, 1, defaultIPLimiterIdleTTL, defaultIPLimiterSweepInterval)
+	defer limiter.Stop()
+	limiter.mu.Lock()
 	limiter.now = func() time.Time { return base }
 	limiter.lastPrunedAt = base.Add(-11 * time.Minute)
+	limiter.mu.Unlock()
 
 	stale := NewSimpleTokenBucket(1, 1)
 	stale.lastSeen = base.Add(-31 * time.Minute)
@@ -79,3 +85,212 @@ func TestIPLimiterPrunesIdleEntries(t *testing.T) {
 		t.Fatalf("expected fresh limiter lastSeen to refresh to %v, got %v", base, fresh.lastSeen)
 	}
 }
+
+func TestIPLimiterStateDoesNotConsumeTokens(t *testing.T) {
+	limiter := NewIPLimiter(0, 3)
+	if _, ok := limiter.State("1.2.3.4"); ok {
+		t.Fatalf("expected no bucket before first request")
+	}
+
+	limiter.GetLimiter("1.2.3.4").Allow()
+	for i := 0; i < 3; i++ {
+		state, ok := limiter.State("1.2.3.4")
+		if !ok {
+			t.Fatalf("expected bucket to exist")
+		}
+		if state.Tokens != 2 || state.Capacity != 3 || state.RefillRate != 0 {
+			t.Fatalf("unexpected state: %+v", state)
+		}
+	}
+
+	if !limiter.Clear("1.2.3.4") {
+		t.Fatalf("expected Clear to report an existing bucket")
+	}
+	if _, ok := limiter.State("1.2.3.4"); ok {
+		t.Fatalf("expected bucket to be gone after Clear")
+	}
+}
+
+func TestInternalRateLimitInspectAndClear(t *testing.T) {
+	t.Setenv("ENABLE_INTERNAL_STATS", "1")
+	t.Setenv("INTERNAL_STATS_TOKEN", "test-token")
+
+	ws := NewIPLimiter(0, 5)
+	ws.GetLimiter("1.2.3.4").Allow()
+	handler := handleInternalRateLimit(map[string]*IPLimiter{"ws": ws, "push": NewIPLimiter(0, 5)})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/internal/ratelimit?ip=1.2.3.4", nil)
+	req.Header.Set("X-Internal-Token", "test-token")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
+	}
+	var body struct {
+		Limiters map[string]struct {
+			Exists bool    `json:"exists"`
+			Tokens float64 `json:"tokens"`
+		} `json:"limiters"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if !body.Limiters["ws"].Exists || body.Limiters["ws"].Tokens != 4 {
+		t.Fatalf("unexpected ws bucket: %+v", body.Limiters["ws"])
+	}
+	if body.Limiters["push"].Exists {
+		t.Fatalf("expected no push bucket: %+v", body.Limiters["push"])
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/internal/ratelimit?ip=1.2.3.4&limiter=ws", nil)
+	req.Header.Set("X-Internal-Token", "test-token")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
+	}
+	if _, ok := ws.State("1.2.3.4"); ok {
+		t.Fatalf("expected bucket to be cleared")
+	}
+}
+
+func TestInternalRateLimitRejectsBadIP(t *testing.T) {
+	t.Setenv("ENABLE_INTERNAL_STATS", "1")
+	t.Setenv("INTERNAL_STATS_TOKEN", "test-token")
+
+	handler := handleInternalRateLimit(map[string]*IPLimiter{"ws": NewIPLimiter(0, 5)})
+	req := httptest.NewRequest(http.MethodGet, "/api/internal/ratelimit?ip=not-an-ip", nil)
+	req.Header.Set("X-Internal-Token", "test-token")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestIPLimiterBackgroundSweepShrinksMap(t *testing.T) {
+	base := time.Now()
+	limiter := newIPLimiter(1, 2, defaultIPLimiterIdleTTL, 10*time.Millisecond)
+	defer limiter.Stop()
+	limiter.mu.Lock()
+	limiter.now = func() time.Time { return base }
+	limiter.mu.Unlock()
+
+	for n := 0; n < 500; n++ {
+		limiter.GetLimiter(fmt.Sprintf("10.0.%d.%d", n/256, n%256)).Allow()
+	}
+
+	limiter.mu.Lock()
+	limiter.now = func() time.Time { return base.Add(defaultIPLimiterIdleTTL + time.Minute) }
+	limiter.mu.Unlock()
+	// Used again after the clock moves: kept.
+	limiter.GetLimiter("192.0.2.1")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		limiter.mu.Lock()
+		size := len(limiter.ips)
+		limiter.mu.Unlock()
+		if size == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected idle buckets swept without further requests, %d remain", size)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, ok := limiter.State("192.0.2.1"); !ok {
+		t.Fatal("expected the recently used bucket to remain")
+	}
+}
+
+func TestIPLimiterKeepsIdleBucketsThatAreNotFull(t *testing.T) {
+	base := time.Date(2026, time.March, 25, 12, 0, 0, 0, time.UTC)
+	limiter := newIPLimiter(0, 2, defaultIPLimiterIdleTTL, defaultIPLimiterSweepInterval)
+	defer limiter.Stop()
+	limited := NewSimpleTokenBucket(2, 0)
+	limited.tokens = 0
+	limited.lastSeen = base.Add(-defaultIPLimiterIdleTTL - time.Minute)
+
+	limiter.mu.Lock()
+	limiter.now = func() time.Time { return base }
+	limiter.lastPrunedAt = base.Add(-defaultIPLimiterSweepInterval)
+	limiter.ips["limited"] = limited
+	limiter.mu.Unlock()
+
+	limiter.GetLimiter("other")
+	if _, ok := limiter.ips["limited"]; !ok {
+		t.Fatal("expected a bucket below capacity to survive the sweep")
+	}
+}
+
+func TestRateLimitMiddlewareSetsRetryAfter(t *testing.T) {
+	limiter := NewIPLimiter(0.5, 1)
+	handler := rateLimitMiddleware(limiter, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	var w *httptest.ResponseRecorder
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com/api/room-id", nil)
+		req.RemoteAddr = "192.0.2.10:12345"
+		w = httptest.NewRecorder()
+		handler(w, req)
+	}
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 once the burst is spent, got %d", w.Code)
+	}
+	seconds, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	if err != nil || seconds < 1 || seconds > 2 {
+		t.Fatalf("expected a numeric Retry-After of 1-2s, got %q", w.Header().Get("Retry-After"))
+	}
+	var body struct {
+		Error      string `json:"error"`
+		RetryAfter int    `json:"retryAfter"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("expected a JSON body: %v", err)
+	}
+	if body.Error != "RATE_LIMITED" || body.RetryAfter != seconds {
+		t.Fatalf("unexpected body %+v", body)
+	}
+}
+
+func TestSecondsUntilTokenDoesNotConsume(t *testing.T) {
+	bucket := NewSimpleTokenBucket(1, 2)
+	if got := bucket.SecondsUntilToken(); got != 0 {
+		t.Fatalf("expected a full bucket to need no wait, got %v", got)
+	}
+	if !bucket.Allow() {
+		t.Fatal("expected the first token to be granted")
+	}
+	got := bucket.SecondsUntilToken()
+	if got <= 0 || got > 0.5 {
+		t.Fatalf("expected up to 0.5s until the next token, got %v", got)
+	}
+	if again := bucket.SecondsUntilToken(); again > got {
+		t.Fatalf("expected SecondsUntilToken not to consume, got %v then %v", got, again)
+	}
+}
+
+func TestParseRateLimitValue(t *testing.T) {
+	cases := []struct {
+		raw  string
+		want float64
+	}{
+		{"", defaultRateLimitRPS},
+		{"2.5", 2.5},
+		{" 4 ", 4},
+		{"0", defaultRateLimitRPS},
+		{"-1", defaultRateLimitRPS},
+		{"fast", defaultRateLimitRPS},
+		{"NaN", defaultRateLimitRPS},
+		{"+Inf", defaultRateLimitRPS},
+	}
+	for _, tc := range cases {
+		if got := parseRateLimitValue("RATE_LIMIT_RPS", tc.raw, defaultRateLimitRPS); got != tc.want {
+			t.Fatalf("parseRateLimitValue(%q) = %v, want %v", tc.raw, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	reconnectFailureLimit  = 5                // invalid reconnect tokens allowed per IP within the window
+	reconnectFailureWindow = 10 * time.Minute // failures older than this are forgotten
+	reconnectBlockDuration = 10 * time.Minute
+)
+
+// reconnectGuard throttles reconnect-token guessing. Each invalid
+// reconnectToken counts against the client IP; after reconnectFailureLimit
+// failures within reconnectFailureWindow every reconnect join from that IP is
+// rejected with RECONNECT_BLOCKED for reconnectBlockDuration. A client
+// presenting its own valid token never fails, so it never blocks itself.
+type reconnectGuard struct {
+	mu      sync.Mutex
+	entries map[string]*reconnectFailures
+}
+
+type reconnectFailures struct {
+	count        int
+	windowStart  time.Time
+	blockedUntil time.Time
+}
+
+func newReconnectGuard() *reconnectGuard {
+	return &reconnectGuard{entries: make(map[string]*reconnectFailures)}
+}
+
+func (g *reconnectGuard) blocked(ip string, now time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	entry, ok := g.entries[ip]
+	return ok && now.Before(entry.blockedUntil)
+}
+
+// recordFailure counts an invalid reconnect token from ip and reports whether
+// that failure started a block.
+func (g *reconnectGuard) recordFailure(ip string, now time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	entry, ok := g.entries[ip]
+	if !ok || now.Sub(entry.windowStart) > reconnectFailureWindow {
+		entry = &reconnectFailures{windowStart: now}
+		g.entries[ip] = entry
+	}
+	entry.count++
+	if entry.count < reconnectFailureLimit {
+		return false
+	}
+	entry.count = 0
+	entry.windowStart = now
+	entry.blockedUntil = now.Add(reconnectBlockDuration)
+	return true
+}
+
+// prune drops entries whose window and block have both lapsed.
+func (g *reconnectGuard) prune(now time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for ip, entry := range g.entries {
+		if now.Sub(entry.windowStart) > reconnectFailureWindow && !now.Before(entry.blockedUntil) {
+			delete(g.entries, ip)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"serenada/server/internal/stats"
+)
+
+func tokenReconnectJoinPayload(rid, cid, token string) []byte {
+	payloadBytes, _ := json.Marshal(map[string]interface{}{
+		"reconnectCid":   cid,
+		"reconnectToken": token,
+		"capabilities":   map[string]int{"maxParticipants": 4},
+	})
+	b, _ := json.Marshal(Message{V: 1, Type: "join", RID: rid, Payload: payloadBytes})
+	return b
+}
+
+func TestReconnectGuardBlocksAfterRepeatedFailures(t *testing.T) {
+	g := newReconnectGuard()
+	now := time.Now()
+	for i := 0; i < reconnectFailureLimit-1; i++ {
+		if g.recordFailure("203.0.113.7", now) {
+			t.Fatalf("failure %d should not block yet", i+1)
+		}
+	}
+	if g.blocked("203.0.113.7", now) {
+		t.Fatal("expected IP not blocked below the limit")
+	}
+	if !g.recordFailure("203.0.113.7", now) {
+		t.Fatal("expected the limit-th failure to start a block")
+	}
+	if !g.blocked("203.0.113.7", now) {
+		t.Fatal("expected IP blocked")
+	}
+	if g.blocked("198.51.100.1", now) {
+		t.Fatal("expected other IPs unaffected")
+	}
+	if g.blocked("203.0.113.7", now.Add(reconnectBlockDuration+time.Second)) {
+		t.Fatal("expected block to lapse")
+	}
+
+	g.prune(now.Add(reconnectFailureWindow + reconnectBlockDuration + time.Second))
+	if len(g.entries) != 0 {
+		t.Fatalf("expected prune to drop lapsed entries, got %d", len(g.entries))
+	}
+}
+
+func TestReconnectGuardForgetsOldFailures(t *testing.T) {
+	g := newReconnectGuard()
+	now := time.Now()
+	for i := 0; i < reconnectFailureLimit-1; i++ {
+		g.recordFailure("203.0.113.7", now)
+	}
+	if g.recordFailure("203.0.113.7", now.Add(reconnectFailureWindow+time.Second)) {
+		t.Fatal("expected failures outside the window not to count")
+	}
+}
+
+func TestJoinBlocksReconnectsAfterInvalidTokens(t *testing.T) {
+	t.Setenv("TURN_TOKEN_SECRET", "reconnect-guard-secret")
+	hub := newHub(4)
+	rid := mustTestRoomID(t)
+
+	host := fakeClient(hub)
+	hub.registerClient(host)
+	hub.handleMessage(host, joinPayload(rid, 4, 4))
+	hostCID := host.cid
+	validToken := issueReconnectToken(hostCID, rid)
+
+	// A legitimate reconnect from another IP presents its valid token once.
+	owner := fakeClient(hub)
+	owner.ip = "198.51.100.1"
+	hub.registerClient(owner)
+
+	for i := 0; i < reconnectFailureLimit; i++ {
+		attacker := fakeClient(hub)
+		attacker.ip = "203.0.113.7"
+		hub.registerClient(attacker)
+		hub.handleMessage(attacker, tokenReconnectJoinPayload(rid, hostCID, "deadbeef"))
+		if code := errorCode(findMessage(drainMessages(attacker), "error")); code != "INVALID_RECONNECT_TOKEN" {
+			t.Fatalf("attempt %d: expected INVALID_RECONNECT_TOKEN, got %q", i+1, code)
+		}
+	}
+
+	before := stats.SnapshotNow().Counters.ReconnectBlockedTotal
+	attacker := fakeClient(hub)
+	attacker.ip = "203.0.113.7"
+	hub.registerClient(attacker)
+	hub.handleMessage(attacker, tokenReconnectJoinPayload(rid, hostCID, validToken))
+	if code := errorCode(findMessage(drainMessages(attacker), "error")); code != "RECONNECT_BLOCKED" {
+		t.Fatalf("expected RECONNECT_BLOCKED, got %q", code)
+	}
+	if after := stats.SnapshotNow().Counters.ReconnectBlockedTotal; after-before != 1 {
+		t.Fatalf("expected one blocked reconnect counted, got %d", after-before)
+	}
+
+	hub.handleMessage(owner, tokenReconnectJoinPayload(rid, hostCID, validToken))
+	if findMessage(drainMessages(owner), "joined") == nil {
+		t.Fatal("expected valid reconnect from another IP to succeed")
+	}
+}
+
+func TestTokenlessReconnectDoesNotEvictGhostWhenTokensIssued(t *testing.T) {
+	t.Setenv("TURN_TOKEN_SECRET", "reconnect-guard-secret")
+	hub := newHub(4)
+	rid := mustTestRoomID(t)
+
+	host := fakeClient(hub)
+	hub.registerClient(host)
+	hub.handleMessage(host, joinPayload(rid, 4, 4))
+	hostCID := host.cid
+
+	hijacker := fakeClient(hub)
+	hub.registerClient(hijacker)
+	hub.handleMessage(hijacker, reconnectJoinPayload(rid, hostCID))
+	if findMessage(drainMessages(hijacker), "joined") == nil {
+		t.Fatal("expected tokenless reconnect to join as a new participant")
+	}
+	if hijacker.cid == hostCID {
+		t.Fatal("expected tokenless reconnect not to take over the host CID")
+	}
+	hub.mu.RLock()
+	room := hub.rooms[rid]
+	hub.mu.RUnlock()
+	room.mu.Lock()
+	_, hostStillIn := room.Participants[host]
+	hostCIDKept := room.HostCID == hostCID
+	room.mu.Unlock()
+	if !hostStillIn || !hostCIDKept {
+		t.Fatal("expected the host to keep its place and role")
+	}
+
+	prev := reconnectAllowTokenless
+	reconnectAllowTokenless = true
+	t.Cleanup(func() { reconnectAllowTokenless = prev })
+	legacy := fakeClient(hub)
+	hub.registerClient(legacy)
+	hub.handleMessage(legacy, reconnectJoinPayload(rid, hostCID))
+	drainMessages(legacy)
+	if legacy.cid != hostCID {
+		t.Fatalf("expected RECONNECT_ALLOW_TOKENLESS to allow a tokenless reclaim, got cid %q", legacy.cid)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+)
+
+func reconnectJoinPayload(rid, reconnectCID string) []byte {
+	payloadBytes, _ := json.Marshal(map[string]interface{}{
+		"reconnectCid": reconnectCID,
+		"capabilities": map[string]int{"maxParticipants": 4},
+	})
+	b, _ := json.Marshal(Message{V: 1, Type: "join", RID: rid, Payload: payloadBytes})
+	return b
+}
+
+func TestJoinRejectsReconnectWhileCIDIsBeingReclaimed(t *testing.T) {
+	hub := newHub(4)
+	rid := mustTestRoomID(t)
+	host := fakeClient(hub)
+	hub.registerClient(host)
+	hub.handleMessage(host, joinPayload(rid, 4, 4))
+	drainMessages(host)
+
+	hub.mu.RLock()
+	room := hub.rooms[rid]
+	hub.mu.RUnlock()
+
+	claimant := fakeClient(hub)
+	room.mu.Lock()
+	room.reconnectClaims = map[string]*Client{host.cid: claimant}
+	room.mu.Unlock()
+
+	late := fakeClient(hub)
+	hub.registerClient(late)
+	hub.handleMessage(late, reconnectJoinPayload(rid, host.cid))
+
+	if code := errorCode(findMessage(drainMessages(late), "error")); code != "CID_IN_USE" {
+		t.Fatalf("expected CID_IN_USE, got %q", code)
+	}
+	room.mu.Lock()
+	_, hostStillIn := room.Participants[host]
+	room.mu.Unlock()
+	if !hostStillIn {
+		t.Fatal("rejected reconnect must not evict the current participant")
+	}
+}
+
+func TestConcurrentReconnectsToSameCIDStayConsistent(t *testing.T) {
+	for iteration := 0; iteration < 50; iteration++ {
+		hub := newHub(4)
+		rid := mustTestRoomID(t)
+
+		host := fakeClient(hub)
+		hub.registerClient(host)
+		hub.handleMessage(host, joinPayload(rid, 4, 4))
+		ghost := fakeClient(hub)
+		hub.registerClient(ghost)
+		hub.handleMessage(ghost, joinPayload(rid, 4, 4))
+		reclaimedCID := ghost.cid
+
+		const racers = 8
+		clients := make([]*Client, racers)
+		for i := range clients {
+			clients[i] = fakeClient(hub)
+			hub.registerClient(clients[i])
+		}
+
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		for _, c := range clients {
+			wg.Add(1)
+			go func(c *Client) {
+				defer wg.Done()
+				<-start
+				hub.handleMessage(c, reconnectJoinPayload(rid, reclaimedCID))
+			}(c)
+		}
+		close(start)
+		wg.Wait()
+
+		hub.mu.RLock()
+		room := hub.rooms[rid]
+		hub.mu.RUnlock()
+		room.mu.Lock()
+		holders := 0
+		for _, cid := range room.Participants {
+			if cid == reclaimedCID {
+				holders++
+			}
+		}
+		size := len(room.Participants)
+		claims := len(room.reconnectClaims)
+		room.mu.Unlock()
+
+		if holders != 1 {
+			t.Fatalf("iteration %d: expected exactly one participant with CID %s, got %d", iteration, reclaimedCID, holders)
+		}
+		if size != 2 {
+			t.Fatalf("iteration %d: expected host and one reconnected participant, got %d participants", iteration, size)
+		}
+		if claims != 0 {
+			t.Fatalf("iteration %d: expected no leftover reconnect claims, got %d", iteration, claims)
+		}
+		for _, c := range clients {
+			if c.cid != "" && c.cid != reclaimedCID {
+				t.Fatalf("iteration %d: racer %s joined with a fresh CID %s", iteration, c.sid, c.cid)
+			}
+		}
+	}
+}
+
+func TestJoinTreatsReclaimedSlotAsTaken(t *testing.T) {
+	hub := newHub(2)
+	rid := mustTestRoomID(t)
+	host := fakeClient(hub)
+	hub.registerClient(host)
+	hub.handleMessage(host, joinPayload(rid, 2, 2))
+	drainMessages(host)
+
+	// Mid-reconnect state: the ghost is gone from Participants but its
+	// reconnecting client has not been inserted yet.
+	hub.mu.RLock()
+	room := hub.rooms[rid]
+	hub.mu.RUnlock()
+	reconnecting := fakeClient(hub)
+	room.mu.Lock()
+	room.reconnectClaims = map[string]*Client{"C-reclaimed": reconnecting}
+	room.mu.Unlock()
+
+	intruder := fakeClient(hub)
+	hub.registerClient(intruder)
+	hub.handleMessage(intruder, joinPayload(rid, 2, 2))
+	if code := errorCode(findMessage(drainMessages(intruder), "error")); code != "ROOM_FULL" {
+		t.Fatalf("expected ROOM_FULL while a slot is being reclaimed, got %q", code)
+	}
+}
+
+func TestReconnectReclaimsSlotWhileRoomFills(t *testing.T) {
+	for iteration := 0; iteration < 100; iteration++ {
+		hub := newHub(2)
+		rid := mustTestRoomID(t)
+
+		host := fakeClient(hub)
+		hub.registerClient(host)
+		hub.handleMessage(host, joinPayload(rid, 2, 2))
+		ghost := fakeClient(hub)
+		hub.registerClient(ghost)
+		hub.handleMessage(ghost, joinPayload(rid, 2, 2))
+		reclaimedCID := ghost.cid
+
+		reconnecting := fakeClient(hub)
+		intruder := fakeClient(hub)
+		hub.registerClient(reconnecting)
+		hub.registerClient(intruder)
+
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			<-start
+			hub.handleMessage(reconnecting, reconnectJoinPayload(rid, reclaimedCID))
+		}()
+		go func() {
+			defer wg.Done()
+			<-start
+			hub.handleMessage(intruder, joinPayload(rid, 2, 2))
+		}()
+		close(start)
+		wg.Wait()
+
+		if reconnecting.cid != reclaimedCID {
+			msgs := drainMessages(reconnecting)
+			t.Fatalf("iteration %d: reconnecting client did not reclaim %s (error %q)", iteration, reclaimedCID, errorCode(findMessage(msgs, "error")))
+		}
+		if code := errorCode(findMessage(drainMessages(intruder), "error")); code != "ROOM_FULL" {
+			t.Fatalf("iteration %d: expected intruder to get ROOM_FULL, got %q", iteration, code)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReconnectTokenRoundTripAndExpiry(t *testing.T) {
+	t.Setenv("TURN_TOKEN_SECRET", "test-reconnect-token-secret")
+	now := time.Now()
+	token := issueReconnectTokenAt("C-1", "room", now)
+
+	if err := checkReconnectToken(token, "C-1", "room", now); err != nil {
+		t.Fatalf("expected fresh token to validate, got %v", err)
+	}
+	if err := checkReconnectToken(token, "C-2", "room", now); !errors.Is(err, errReconnectTokenInvalid) {
+		t.Fatalf("expected token for another CID to be invalid, got %v", err)
+	}
+	if err := checkReconnectToken(token, "C-1", "room", now.Add(reconnectTokenTTL)); !errors.Is(err, errReconnectTokenExpired) {
+		t.Fatalf("expected token to expire after the TTL, got %v", err)
+	}
+
+	// Tampering with the expiry breaks the MAC.
+	parts := strings.Split(token, ".")
+	tampered := strings.Join([]string{parts[0], "99999999999", parts[2]}, ".")
+	if err := checkReconnectToken(tampered, "C-1", "room", now); !errors.Is(err, errReconnectTokenInvalid) {
+		t.Fatalf("expected tampered expiry to be invalid, got %v", err)
+	}
+	// Unversioned tokens from before expiry was added fail cleanly.
+	legacy := reconnectTokenMAC("test-reconnect-token-secret", "C-1", "room", 0)
+	if err := checkReconnectToken(legacy, "C-1", "room", now); !errors.Is(err, errReconnectTokenInvalid) {
+		t.Fatalf("expected unversioned token to be invalid, got %v", err)
+	}
+}
+
+func TestReconnectTokenWithoutSecretAllowsAll(t *testing.T) {
+	t.Setenv("TURN_TOKEN_SECRET", "")
+	t.Setenv("TURN_SECRET", "")
+	if token := issueReconnectToken("C-1", "room"); token != "" {
+		t.Fatalf("expected no token without a secret, got %q", token)
+	}
+	if !validateReconnectToken("anything", "C-1", "room") {
+		t.Fatal("expected any token to validate without a secret")
+	}
+}
+
+func TestParseReconnectTokenTTL(t *testing.T) {
+	if got := parseReconnectTokenTTL(""); got != defaultReconnectTokenTTL {
+		t.Fatalf("expected default TTL, got %s", got)
+	}
+	if got := parseReconnectTokenTTL("120"); got != 2*time.Minute {
+		t.Fatalf("expected 2m, got %s", got)
+	}
+}
+
+func TestJoinWithExpiredReconnectTokenIsRejected(t *testing.T) {
+	t.Setenv("TURN_TOKEN_SECRET", "test-reconnect-token-secret")
+	hub, rid, _, guest, guestCID := joinedPair(t)
+
+	payloadBytes, _ := json.Marshal(map[string]interface{}{
+		"reconnectCid":   guestCID,
+		"reconnectToken": issueReconnectTokenAt(guestCID, rid, time.Now().Add(-2*reconnectTokenTTL)),
+		"capabilities":   map[string]int{"maxParticipants": 4},
+	})
+	raw, _ := json.Marshal(Message{V: 1, Type: "join", RID: rid, Payload: payloadBytes})
+	returning := fakeClient(hub)
+	hub.registerClient(returning)
+	hub.handleMessage(returning, raw)
+
+	if code := errorCode(findMessage(drainMessages(returning), "error")); code != "RECONNECT_TOKEN_EXPIRED" {
+		t.Fatalf("expected RECONNECT_TOKEN_EXPIRED, got %q", code)
+	}
+	if guest.cid != guestCID {
+		t.Fatal("expected the expired reclaim to leave the participant in place")
+	}
+}
+
+func TestTurnRefreshRenewsReconnectToken(t *testing.T) {
+	t.Setenv("TURN_TOKEN_SECRET", "test-reconnect-token-secret")
+	hub, rid, host, _, _ := joinedPair(t)
+
+	raw, _ := json.Marshal(Message{V: 1, Type: "turn-refresh", RID: rid})
+	hub.handleMessage(host, raw)
+
+	refreshed := findMessage(drainMessages(host), "turn-refreshed")
+	if refreshed == nil {
+		t.Fatal("expected turn-refreshed")
+	}
+	var payload struct {
+		ReconnectToken string `json:"reconnectToken"`
+	}
+	_ = json.Unmarshal(refreshed.Payload, &payload)
+	if !validateReconnectToken(payload.ReconnectToken, host.cid, rid) {
+		t.Fatalf("expected a valid renewed reconnectToken, got %q", payload.ReconnectToken)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"serenada/server/internal/stats"
+)
+
+func joinedMeshRoom(t *testing.T, size int) (*Hub, string, []*Client) {
+	t.Helper()
+	rid := mustTestRoomID(t)
+	hub := newHub(4)
+	clients := make([]*Client, size)
+	for i := range clients {
+		clients[i] = fakeClient(hub)
+		hub.registerClient(clients[i])
+		hub.handleMessage(clients[i], joinPayload(rid, 4, 4))
+	}
+	for _, c := range clients {
+		drainMessages(c)
+	}
+	return hub, rid, clients
+}
+
+func TestRelayBroadcastFansOutToEveryOtherParticipant(t *testing.T) {
+	hub, rid, clients := joinedMeshRoom(t, 3)
+
+	before := stats.SnapshotNow().Counters
+	hub.handleMessage(clients[0], iceMessage(rid))
+
+	for _, c := range clients[1:] {
+		if findMessage(drainMessages(c), "ice") == nil {
+			t.Fatalf("expected %s to receive the broadcast relay", c.cid)
+		}
+	}
+	if findMessage(drainMessages(clients[0]), "ice") != nil {
+		t.Fatal("expected sender not to receive its own relay")
+	}
+
+	after := stats.SnapshotNow().Counters
+	if in, out := after.RelayInTotal-before.RelayInTotal, after.RelayOutTotal-before.RelayOutTotal; in != 1 || out != 2 {
+		t.Fatalf("expected 1 relay in and 2 out, got %d/%d", in, out)
+	}
+}
+
+func TestRelayToReachesOnlyTarget(t *testing.T) {
+	hub, rid, clients := joinedMeshRoom(t, 3)
+	sender, target, other := clients[0], clients[1], clients[2]
+
+	raw, _ := json.Marshal(Message{V: 1, Type: "answer", RID: rid, To: target.cid, Payload: json.RawMessage(`{"sdp":"v=0"}`)})
+	hub.handleMessage(sender, raw)
+
+	if findMessage(drainMessages(target), "answer") == nil {
+		t.Fatal("expected the addressed participant to receive the relay")
+	}
+	if findMessage(drainMessages(other), "answer") != nil {
+		t.Fatal("expected other participants not to receive an addressed relay")
+	}
+}
+
+func TestRelayToMissingParticipantIsDropped(t *testing.T) {
+	hub, rid, clients := joinedMeshRoom(t, 3)
+
+	raw, _ := json.Marshal(Message{V: 1, Type: "offer", RID: rid, To: "C-not-in-room", Payload: json.RawMessage(`{"sdp":"v=0"}`)})
+	before := stats.SnapshotNow().Counters
+	hub.handleMessage(clients[0], raw)
+
+	for _, c := range clients[1:] {
+		if findMessage(drainMessages(c), "offer") != nil {
+			t.Fatal("expected relay to an unknown CID to reach nobody")
+		}
+	}
+	after := stats.SnapshotNow().Counters
+	if got := after.RelayTargetMissing - before.RelayTargetMissing; got != 1 {
+		t.Fatalf("expected one missing-target relay, got %d", got)
+	}
+	if got := after.RelayOutTotal - before.RelayOutTotal; got != 0 {
+		t.Fatalf("expected no relay copies, got %d", got)
+	}
+
+	errMsg := findMessage(drainMessages(clients[0]), "error")
+	if errMsg == nil {
+		t.Fatal("expected the sender to be told its target is gone")
+	}
+	var payload struct {
+		Code string `json:"code"`
+		CID  string `json:"cid"`
+	}
+	if err := json.Unmarshal(errMsg.Payload, &payload); err != nil {
+		t.Fatalf("unmarshal error payload: %v", err)
+	}
+	if payload.Code != "PEER_GONE" || payload.CID != "C-not-in-room" {
+		t.Fatalf("expected PEER_GONE for C-not-in-room, got %+v", payload)
+	}
+}
+
+func TestRelayBroadcastSendsNoPeerGone(t *testing.T) {
+	hub, rid, clients := joinedMeshRoom(t, 2)
+
+	raw, _ := json.Marshal(Message{V: 1, Type: "ice", RID: rid, Payload: json.RawMessage(`{"candidate":"c"}`)})
+	hub.handleMessage(clients[0], raw)
+
+	if findMessage(drainMessages(clients[0]), "error") != nil {
+		t.Fatal("expected a broadcast relay not to produce an error")
+	}
+}
+
+func TestRelayRecordsLatencyPerDeliveredCopy(t *testing.T) {
+	hub, rid, clients := joinedMeshRoom(t, 3)
+
+	before := stats.SnapshotNow().RelayLatency
+	hub.handleMessage(clients[0], iceMessage(rid))
+	after := stats.SnapshotNow().RelayLatency
+
+	if after.Total-before.Total != 2 {
+		t.Fatalf("expected 2 relay latency samples, got %d", after.Total-before.Total)
+	}
+	if len(after.BucketCounts) != len(after.BoundariesUs)+1 {
+		t.Fatalf("expected one bucket per boundary plus overflow, got %d buckets for %d boundaries", len(after.BucketCounts), len(after.BoundariesUs))
+	}
+	var counted int64
+	for i := range after.BucketCounts {
+		counted += after.BucketCounts[i] - before.BucketCounts[i]
+	}
+	if counted != 2 {
+		t.Fatalf("expected 2 bucketed samples, got %d", counted)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+)
+
+// relayPayloadWithFrom returns payload with the sender's CID set as "from",
+// as relayed to the other participants. The common case, a JSON object that
+// does not mention "from", is handled by splicing the field in before the
+// closing brace, which avoids decoding the payload (SDP or an ICE candidate)
+// into a map and encoding it again on every relayed message. Anything else
+// takes the map path, which also overwrites a client-supplied "from".
+func (c *Client) relayPayloadWithFrom(msgType string, payload json.RawMessage) []byte {
+	if out, ok := spliceRelayFrom(payload, c.cid); ok {
+		return out
+	}
+
+	var rawPayload map[string]interface{}
+	if err := json.Unmarshal(payload, &rawPayload); err != nil {
+		log.Printf("[RELAY] Client %s (CID: %s) sent invalid payload for type %s: %v", c.sid, c.cid, msgType, err)
+	}
+	if rawPayload == nil {
+		rawPayload = make(map[string]interface{})
+	}
+	rawPayload["from"] = c.cid
+
+	out, _ := json.Marshal(rawPayload)
+	return out
+}
+
+// spliceRelayFrom appends "from" to a JSON object payload without decoding
+// it. It reports false when the payload is not an object or could already
+// carry a "from" key, including one spelled with \u escapes.
+func spliceRelayFrom(payload json.RawMessage, from string) ([]byte, bool) {
+	body := bytes.TrimSpace(payload)
+	if len(body) < 2 || body[0] != '{' || body[len(body)-1] != '}' {
+		return nil, false
+	}
+	if bytes.Contains(body, []byte(`"from"`)) || bytes.Contains(body, []byte(`\u`)) {
+		return nil, false
+	}
+	if !json.Valid(body) {
+		return nil, false
+	}
+	quoted, err := json.Marshal(from)
+	if err != nil {
+		return nil, false
+	}
+
+	inner := bytes.TrimSpace(body[1 : len(body)-1])
+	out := make([]byte, 0, len(inner)+len(quoted)+10)
+	out = append(out, '{')
+	out = append(out, inner...)
+	if len(inner) > 0 {
+		out = append(out, ',')
+	}
+	out = append(out, `"from":`...)
+	out = append(out, quoted...)
+	out = append(out, '}')
+	return out, true
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const benchICEPayload = `{"candidate":{"candidate":"candidate:842163049 1 udp 1677729535 203.0.113.7 54321 typ srflx raddr 192.168.1.20 rport 54321 generation 0 ufrag EsP3 network-cost 999","sdpMid":"0","sdpMLineIndex":0,"usernameFragment":"EsP3"}}`
+
+// legacyRelayPayload is the decode/re-encode rewrite the fast path replaces.
+func legacyRelayPayload(payload json.RawMessage, from string) []byte {
+	var rawPayload map[string]interface{}
+	if err := json.Unmarshal(payload, &rawPayload); err != nil || rawPayload == nil {
+		rawPayload = make(map[string]interface{})
+	}
+	rawPayload["from"] = from
+	out, _ := json.Marshal(rawPayload)
+	return out
+}
+
+func TestRelayPayloadWithFromMatchesMapRewrite(t *testing.T) {
+	c := &Client{sid: "S-test", cid: "C-sender"}
+	payloads := []string{
+		benchICEPayload,
+		`{"sdp":"v=0\r\no=- 1 2 IN IP4 127.0.0.1\r\n","type":"offer"}`,
+		`{}`,
+		` { "a" : [1, 2, {"b": null}] } `,
+		`{"from":"C-spoofed","sdp":"x"}`,
+		`{"\u0066rom":"C-spoofed"}`,
+		`{"nested":{"from":"kept"}}`,
+		`null`,
+		`[1,2]`,
+		`"text"`,
+		``,
+	}
+	for _, raw := range payloads {
+		got := c.relayPayloadWithFrom("ice", json.RawMessage(raw))
+		var gotMap, wantMap map[string]interface{}
+		if err := json.Unmarshal(got, &gotMap); err != nil {
+			t.Fatalf("payload %q: output %q is not a JSON object: %v", raw, got, err)
+		}
+		_ = json.Unmarshal(legacyRelayPayload(json.RawMessage(raw), c.cid), &wantMap)
+		if !reflect.DeepEqual(gotMap, wantMap) {
+			t.Errorf("payload %q: got %s, want %v", raw, got, wantMap)
+		}
+	}
+}
+
+func TestSpliceRelayFromSkipsPayloadsThatMentionFrom(t *testing.T) {
+	for _, raw := range []string{
+		`{"from":"C-spoofed"}`,
+		`{"\u0066rom":"C-spoofed"}`,
+		`[1]`,
+		`null`,
+		`{"a":1`,
+	} {
+		if _, ok := spliceRelayFrom(json.RawMessage(raw), "C-x"); ok {
+			t.Errorf("expected %q to take the map path", raw)
+		}
+	}
+	out, ok := spliceRelayFrom(json.RawMessage(`{"type":"answer"}`), "C-x")
+	if !ok || string(out) != `{"type":"answer","from":"C-x"}` {
+		t.Fatalf("unexpected splice result %q, %v", out, ok)
+	}
+}
+
+func BenchmarkRelayPayloadWithFrom(b *testing.B) {
+	c := &Client{sid: "S-bench", cid: "C-bench"}
+	payload := json.RawMessage(benchICEPayload)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.relayPayloadWithFrom("ice", payload)
+	}
+}
+
+func BenchmarkRelayPayloadWithFromMap(b *testing.B) {
+	payload := json.RawMessage(benchICEPayload)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		legacyRelayPayload(payload, "C-bench")
+	}
+}
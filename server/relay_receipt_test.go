@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"serenada/server/internal/stats"
+)
+
+func receiptJoinPayload(rid string) []byte {
+	payloadBytes, _ := json.Marshal(map[string]interface{}{
+		"capabilities":          map[string]interface{}{"maxParticipants": 4, "relayReceipts": true},
+		"createMaxParticipants": 4,
+	})
+	b, _ := json.Marshal(Message{V: 1, Type: "join", RID: rid, Payload: payloadBytes})
+	return b
+}
+
+func iceMessage(rid string) []byte {
+	payloadBytes, _ := json.Marshal(map[string]interface{}{"candidate": "candidate:1"})
+	b, _ := json.Marshal(Message{V: 1, Type: "ice", RID: rid, Payload: payloadBytes})
+	return b
+}
+
+func TestRelayReceiptListsDeliveredAndDroppedPeers(t *testing.T) {
+	rid := mustTestRoomID(t)
+	hub := newHub(4)
+
+	sender := fakeClient(hub)
+	hub.registerClient(sender)
+	hub.handleMessage(sender, receiptJoinPayload(rid))
+
+	peers := make([]*Client, 2)
+	for i := range peers {
+		peers[i] = fakeClient(hub)
+		hub.registerClient(peers[i])
+		hub.handleMessage(peers[i], joinPayload(rid, 4, 4))
+	}
+	drainMessages(sender)
+	drainMessages(peers[0])
+	drainMessages(peers[1])
+
+	// Fill the second peer's queue so the relay is dropped.
+	for len(peers[1].send) < cap(peers[1].send) {
+		peers[1].send <- []byte("{}")
+	}
+
+	before := stats.SnapshotNow().Counters
+	hub.handleMessage(sender, iceMessage(rid))
+
+	receipt := findMessage(drainMessages(sender), "relay_receipt")
+	if receipt == nil {
+		t.Fatal("expected relay_receipt for sender with relayReceipts capability")
+	}
+	var payload struct {
+		Type      string   `json:"type"`
+		Delivered []string `json:"delivered"`
+		Dropped   []string `json:"dropped"`
+	}
+	if err := json.Unmarshal(receipt.Payload, &payload); err != nil {
+		t.Fatalf("decode receipt: %v", err)
+	}
+	if payload.Type != "ice" {
+		t.Fatalf("expected receipt for ice, got %q", payload.Type)
+	}
+	if len(payload.Delivered) != 1 || payload.Delivered[0] != peers[0].cid {
+		t.Fatalf("unexpected delivered list: %v", payload.Delivered)
+	}
+	if len(payload.Dropped) != 1 || payload.Dropped[0] != peers[1].cid {
+		t.Fatalf("unexpected dropped list: %v", payload.Dropped)
+	}
+
+	after := stats.SnapshotNow().Counters
+	if after.RelayReceiptsTotal-before.RelayReceiptsTotal != 1 || after.RelayReceiptsWithDrop-before.RelayReceiptsWithDrop != 1 {
+		t.Fatalf("expected receipt counters to advance: before=%+v after=%+v", before, after)
+	}
+}
+
+func TestNoRelayReceiptWithoutCapability(t *testing.T) {
+	rid := mustTestRoomID(t)
+	hub := newHub(4)
+
+	sender := fakeClient(hub)
+	hub.registerClient(sender)
+	hub.handleMessage(sender, joinPayload(rid, 4, 4))
+	peer := fakeClient(hub)
+	hub.registerClient(peer)
+	hub.handleMessage(peer, joinPayload(rid, 4, 4))
+	drainMessages(sender)
+
+	hub.handleMessage(sender, iceMessage(rid))
+
+	if findMessage(drainMessages(sender), "relay_receipt") != nil {
+		t.Fatal("relay_receipt must be opt-in")
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRoomEventLogSize = 32
+	maxRoomEventLogSize     = 1024
+)
+
+// roomEventLogSize is how many recent events each room keeps for
+// /api/internal/room. The log lives on the Room, so it is scoped to the room's
+// lifetime and a busy room cannot push out another room's history. Zero
+// disables it. Set from ROOM_EVENT_LOG_SIZE at startup.
+var roomEventLogSize = defaultRoomEventLogSize
+
+func parseRoomEventLogSize(raw string) int {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return defaultRoomEventLogSize
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return defaultRoomEventLogSize
+	}
+	return min(n, maxRoomEventLogSize)
+}
+
+// RoomEvent is one entry in a room's event log.
+type RoomEvent struct {
+	AtMs   int64  `json:"at"` // unix ms
+	Type   string `json:"type"`
+	CID    string `json:"cid,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// roomEventLog is a fixed-size ring of the most recent events.
+type roomEventLog struct {
+	events []RoomEvent
+	next   int // slot the next event overwrites once the ring is full
+}
+
+// recordEventLocked appends an event to the room's log, overwriting the oldest
+// once the log holds roomEventLogSize events. Caller must hold room.mu.
+func (room *Room) recordEventLocked(eventType, cid, detail string) {
+	size := roomEventLogSize
+	if size <= 0 {
+		return
+	}
+	event := RoomEvent{AtMs: time.Now().UnixMilli(), Type: eventType, CID: cid, Detail: detail}
+	ring := &room.events
+	if len(ring.events) < size {
+		ring.events = append(ring.events, event)
+		return
+	}
+	ring.events[ring.next] = event
+	ring.next = (ring.next + 1) % len(ring.events)
+}
+
+// eventsLocked returns a copy of the room's log, oldest first. Caller must
+// hold room.mu.
+func (room *Room) eventsLocked() []RoomEvent {
+	ring := room.events
+	out := make([]RoomEvent, 0, len(ring.events))
+	out = append(out, ring.events[ring.next:]...)
+	return append(out, ring.events[:ring.next]...)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseRoomEventLogSize(t *testing.T) {
+	cases := map[string]int{
+		"":      defaultRoomEventLogSize,
+		"bogus": defaultRoomEventLogSize,
+		"-1":    defaultRoomEventLogSize,
+		"0":     0,
+		"100":   100,
+		"99999": maxRoomEventLogSize,
+	}
+	for raw, want := range cases {
+		if got := parseRoomEventLogSize(raw); got != want {
+			t.Errorf("parseRoomEventLogSize(%q) = %d, want %d", raw, got, want)
+		}
+	}
+}
+
+func TestRoomEventLogKeepsMostRecent(t *testing.T) {
+	prev := roomEventLogSize
+	roomEventLogSize = 3
+	defer func() { roomEventLogSize = prev }()
+
+	room := newRoom("R", 2, false)
+	for _, cid := range []string{"C-1", "C-2", "C-3", "C-4", "C-5"} {
+		room.recordEventLocked("join", cid, "")
+	}
+
+	events := room.eventsLocked()
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	for i, want := range []string{"C-3", "C-4", "C-5"} {
+		if events[i].CID != want {
+			t.Fatalf("event %d: expected %s, got %+v", i, want, events)
+		}
+	}
+}
+
+func TestRoomEventLogRecordsRoomLifetime(t *testing.T) {
+	hub := newHub(4)
+	rid := mustTestRoomID(t)
+	host := fakeClient(hub)
+	hub.registerClient(host)
+	hub.handleMessage(host, joinPayload(rid, 4, 4))
+	guest := fakeClient(hub)
+	hub.registerClient(guest)
+	hub.handleMessage(guest, joinPayload(rid, 4, 4))
+	hostCID, guestCID := host.cid, guest.cid
+	hub.handleMessage(host, []byte(`{"v":1,"type":"ice","rid":"`+rid+`","payload":{"candidate":{}}}`))
+	hub.handleMessage(host, []byte(`{"v":1,"type":"leave","rid":"`+rid+`"}`))
+
+	topology, ok := hub.roomTopology(rid)
+	if !ok {
+		t.Fatal("expected room to exist")
+	}
+	want := []RoomEvent{
+		{Type: "join", CID: hostCID},
+		{Type: "join", CID: guestCID},
+		{Type: "relay", CID: hostCID, Detail: "ice"},
+		{Type: "leave", CID: hostCID},
+		{Type: "host_change", CID: guestCID},
+	}
+	if len(topology.Events) != len(want) {
+		t.Fatalf("expected %d events, got %+v", len(want), topology.Events)
+	}
+	for i, w := range want {
+		got := topology.Events[i]
+		if got.Type != w.Type || got.CID != w.CID || got.Detail != w.Detail || got.AtMs == 0 {
+			t.Fatalf("event %d: expected %+v, got %+v", i, w, got)
+		}
+	}
+}
@@ -23,6 +23,10 @@ var (
 	ErrRoomIDSecretMissing = errors.New("room id secret not configured")
 )
 
+// roomIDRandRead is the entropy source for generateRoomID; tests replace it
+// to simulate a failing source.
+var roomIDRandRead = rand.Read
+
 func roomIDContext() string {
 	env := os.Getenv("ROOM_ID_ENV")
 	if env == "" {
@@ -46,8 +50,8 @@ func generateRoomID() (string, error) {
 	}
 
 	random := make([]byte, roomIDRandomBytes)
-	if _, err := rand.Read(random); err != nil {
-		return "", err
+	if _, err := roomIDRandRead(random); err != nil {
+		return "", fmt.Errorf("read random bytes: %w", err)
 	}
 
 	mac := hmac.New(sha256.New, []byte(secret))
@@ -2,10 +2,18 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 )
 
+// roomIDGenerateAttempts bounds how often handleRoomID retries a transient
+// generation failure before answering 500.
+const roomIDGenerateAttempts = 3
+
+// handleRoomID answers 503 when no room ID secret is configured, which will
+// not fix itself, and 500 with Retry-After when generation failed for another
+// reason, so callers know whether retrying can help.
 func handleRoomID() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost && r.Method != http.MethodGet {
@@ -13,10 +21,23 @@ func handleRoomID() http.HandlerFunc {
 			return
 		}
 
-		roomID, err := generateRoomID()
-		if err != nil {
+		var roomID string
+		var err error
+		for attempt := 0; attempt < roomIDGenerateAttempts; attempt++ {
+			roomID, err = generateRoomID()
+			if err == nil || errors.Is(err, ErrRoomIDSecretMissing) {
+				break
+			}
+		}
+		if errors.Is(err, ErrRoomIDSecretMissing) {
 			log.Printf("room id generation failed: %v", err)
-			http.Error(w, "Room ID service unavailable", http.StatusServiceUnavailable)
+			http.Error(w, "Room ID service not configured", http.StatusServiceUnavailable)
+			return
+		}
+		if err != nil {
+			log.Printf("room id generation failed after %d attempts: %v", roomIDGenerateAttempts, err)
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "Room ID generation failed", http.StatusInternalServerError)
 			return
 		}
 
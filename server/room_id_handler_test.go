@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -64,6 +65,55 @@ func TestHandleRoomIDMissingSecret(t *testing.T) {
 	}
 }
 
+func TestHandleRoomIDTransientFailureIsRetryable(t *testing.T) {
+	t.Setenv("ROOM_ID_SECRET", "test-room-secret-1234")
+	prev := roomIDRandRead
+	t.Cleanup(func() { roomIDRandRead = prev })
+	calls := 0
+	roomIDRandRead = func(b []byte) (int, error) {
+		calls++
+		return 0, errors.New("entropy unavailable")
+	}
+
+	handler := handleRoomID()
+	req := httptest.NewRequest(http.MethodPost, "/api/room-id", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Fatal("expected Retry-After on a transient failure")
+	}
+	if calls != roomIDGenerateAttempts {
+		t.Fatalf("expected %d generation attempts, got %d", roomIDGenerateAttempts, calls)
+	}
+}
+
+func TestHandleRoomIDRecoversFromOneTransientFailure(t *testing.T) {
+	t.Setenv("ROOM_ID_SECRET", "test-room-secret-1234")
+	prev := roomIDRandRead
+	t.Cleanup(func() { roomIDRandRead = prev })
+	failed := false
+	roomIDRandRead = func(b []byte) (int, error) {
+		if !failed {
+			failed = true
+			return 0, errors.New("entropy unavailable")
+		}
+		return prev(b)
+	}
+
+	handler := handleRoomID()
+	req := httptest.NewRequest(http.MethodPost, "/api/room-id", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 after retry, got %d", w.Code)
+	}
+}
+
 func TestHandleRoomIDValidatesGenerated(t *testing.T) {
 	t.Setenv("ROOM_ID_SECRET", "test-room-secret-1234")
 
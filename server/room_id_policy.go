@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// roomIDPolicy is an operator moderation list applied on top of room ID
+// validation. A denied room ID is always blocked; when an allowlist is
+// configured, every room ID not on it is blocked too.
+type roomIDPolicy struct {
+	allow map[string]struct{} // nil when no allowlist is configured
+	deny  map[string]struct{}
+}
+
+// currentRoomIDPolicy is loaded from ROOM_ID_ALLOWLIST_FILE and
+// ROOM_ID_DENYLIST_FILE at startup and reloaded on SIGHUP. Nil means no
+// policy, which blocks nothing.
+var currentRoomIDPolicy atomic.Pointer[roomIDPolicy]
+
+func roomIDBlocked(rid string) bool {
+	policy := currentRoomIDPolicy.Load()
+	if policy == nil {
+		return false
+	}
+	if _, denied := policy.deny[rid]; denied {
+		return true
+	}
+	if policy.allow != nil {
+		_, allowed := policy.allow[rid]
+		return !allowed
+	}
+	return false
+}
+
+// loadRoomIDPolicy reads the configured list files. Empty paths mean no list;
+// if both are empty the result is nil.
+func loadRoomIDPolicy(allowPath, denyPath string) (*roomIDPolicy, error) {
+	if allowPath == "" && denyPath == "" {
+		return nil, nil
+	}
+	policy := &roomIDPolicy{}
+	var err error
+	if allowPath != "" {
+		if policy.allow, err = readRoomIDList(allowPath); err != nil {
+			return nil, err
+		}
+	}
+	if denyPath != "" {
+		if policy.deny, err = readRoomIDList(denyPath); err != nil {
+			return nil, err
+		}
+	}
+	return policy, nil
+}
+
+// readRoomIDList reads one room ID per line. Blank lines and lines starting
+// with # are ignored.
+func readRoomIDList(path string) (map[string]struct{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ids := make(map[string]struct{})
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ids[line] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	return ids, nil
+}
+
+// reloadRoomIDPolicyFromEnv replaces the current policy. On error the previous
+// policy stays in effect.
+func reloadRoomIDPolicyFromEnv() (*roomIDPolicy, error) {
+	policy, err := loadRoomIDPolicy(
+		strings.TrimSpace(os.Getenv("ROOM_ID_ALLOWLIST_FILE")),
+		strings.TrimSpace(os.Getenv("ROOM_ID_DENYLIST_FILE")),
+	)
+	if err != nil {
+		return nil, err
+	}
+	currentRoomIDPolicy.Store(policy)
+	return policy, nil
+}
+
+func (p *roomIDPolicy) String() string {
+	if p == nil {
+		return "none"
+	}
+	if p.allow != nil {
+		return fmt.Sprintf("%d allowed, %d denied", len(p.allow), len(p.deny))
+	}
+	return fmt.Sprintf("%d denied", len(p.deny))
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"serenada/server/internal/stats"
+)
+
+func writeRoomIDList(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rooms.txt")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRoomIDPolicyDenyAndAllow(t *testing.T) {
+	defer currentRoomIDPolicy.Store(nil)
+
+	if roomIDBlocked("anything") {
+		t.Fatal("expected no policy to block nothing")
+	}
+
+	t.Setenv("ROOM_ID_DENYLIST_FILE", writeRoomIDList(t, "# abusive\nbad-room\n\n"))
+	if _, err := reloadRoomIDPolicyFromEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if !roomIDBlocked("bad-room") || roomIDBlocked("good-room") {
+		t.Fatal("expected only the denied room to be blocked")
+	}
+
+	t.Setenv("ROOM_ID_ALLOWLIST_FILE", writeRoomIDList(t, "good-room\nbad-room\n"))
+	if _, err := reloadRoomIDPolicyFromEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if roomIDBlocked("good-room") {
+		t.Fatal("expected allowlisted room to pass")
+	}
+	if !roomIDBlocked("bad-room") {
+		t.Fatal("expected denylist to win over allowlist")
+	}
+	if !roomIDBlocked("other-room") {
+		t.Fatal("expected rooms missing from the allowlist to be blocked")
+	}
+}
+
+func TestRoomIDPolicyReloadFailureKeepsPrevious(t *testing.T) {
+	defer currentRoomIDPolicy.Store(nil)
+
+	t.Setenv("ROOM_ID_DENYLIST_FILE", writeRoomIDList(t, "bad-room\n"))
+	if _, err := reloadRoomIDPolicyFromEnv(); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ROOM_ID_DENYLIST_FILE", filepath.Join(t.TempDir(), "missing.txt"))
+	if _, err := reloadRoomIDPolicyFromEnv(); err == nil {
+		t.Fatal("expected reload of a missing file to fail")
+	}
+	if !roomIDBlocked("bad-room") {
+		t.Fatal("expected previous policy to stay in effect")
+	}
+}
+
+func TestJoinRejectsBlockedRoom(t *testing.T) {
+	defer currentRoomIDPolicy.Store(nil)
+	rid := mustTestRoomID(t)
+	t.Setenv("ROOM_ID_DENYLIST_FILE", writeRoomIDList(t, rid+"\n"))
+	if _, err := reloadRoomIDPolicyFromEnv(); err != nil {
+		t.Fatal(err)
+	}
+
+	hub := newHub(4)
+	c := fakeClient(hub)
+	hub.registerClient(c)
+	before := stats.SnapshotNow().Counters.RoomBlockedTotal
+	hub.handleMessage(c, joinPayload(rid, 0, 0))
+
+	if code := errorCode(findMessage(drainMessages(c), "error")); code != "ROOM_BLOCKED" {
+		t.Fatalf("expected ROOM_BLOCKED, got %q", code)
+	}
+	if after := stats.SnapshotNow().Counters.RoomBlockedTotal; after-before != 1 {
+		t.Fatalf("expected one blocked join counted, got %d", after-before)
+	}
+	hub.mu.RLock()
+	_, exists := hub.rooms[rid]
+	hub.mu.RUnlock()
+	if exists {
+		t.Fatal("expected blocked join not to create a room")
+	}
+}
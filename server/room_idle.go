@@ -0,0 +1,88 @@
+package main
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"serenada/server/internal/stats"
+)
+
+const (
+	defaultRoomIdleTTL = 10 * time.Minute
+	minRoomIdleTTL     = time.Minute
+)
+
+// roomIdleTTL is how long every participant of a room may go unseen (no
+// message, WebSocket pong or SSE post) before reapIdleRooms tears the room
+// down. It catches rooms whose clients all became ghosts before their
+// transport reaper noticed. Zero disables it. Set from ROOM_IDLE_TTL_SECONDS
+// at startup.
+var roomIdleTTL = defaultRoomIdleTTL
+
+func parseRoomIdleTTL(raw string) time.Duration {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return defaultRoomIdleTTL
+	}
+	seconds, err := strconv.Atoi(raw)
+	if err != nil || seconds < 0 {
+		return defaultRoomIdleTTL
+	}
+	if seconds == 0 {
+		return 0
+	}
+	return max(time.Duration(seconds)*time.Second, minRoomIdleTTL)
+}
+
+// reapIdleRooms ends every room none of whose participants has been seen
+// within ttl, with room_ended reason "idle", and notifies watchers. A
+// participant's join time counts as being seen, so a client that has not yet
+// sent anything is not mistaken for a ghost.
+func (h *Hub) reapIdleRooms(now time.Time, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	cutoff := now.Add(-ttl).UnixNano()
+
+	var idle []stalledRoom
+	h.mu.RLock()
+	for rid, room := range h.rooms {
+		room.mu.Lock()
+		if len(room.Participants) == 0 {
+			room.mu.Unlock()
+			continue
+		}
+		newest := newestSeenLocked(room)
+		participants := len(room.Participants)
+		room.mu.Unlock()
+		if newest < cutoff {
+			idle = append(idle, stalledRoom{room: room, rid: rid, participants: participants})
+		}
+	}
+	h.mu.RUnlock()
+
+	// A participant may be seen, or the room may end, between the sweep and
+	// here, so endRoom re-checks the room under its lock.
+	stillIdle := func(r *Room) bool {
+		return len(r.Participants) > 0 && newestSeenLocked(r) < cutoff
+	}
+	for _, r := range idle {
+		if h.endRoom(r.room, r.rid, "", "idle", stillIdle) {
+			log.Printf("[IDLE] Reaped room %s: none of its %d participants seen for %s", r.rid, r.participants, ttl)
+			stats.IncIdleRoomReaped()
+		}
+	}
+}
+
+// newestSeenLocked returns the most recent time any of room's participants was
+// seen or joined, in Unix nanoseconds. room.mu must be held.
+func newestSeenLocked(room *Room) int64 {
+	var newest int64
+	for client, cid := range room.Participants {
+		newest = max(newest, atomic.LoadInt64(&client.lastSeen), room.JoinedAt[cid]*int64(time.Millisecond))
+	}
+	return newest
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"serenada/server/internal/stats"
+)
+
+func TestParseRoomIdleTTL(t *testing.T) {
+	for raw, want := range map[string]time.Duration{
+		"":    defaultRoomIdleTTL,
+		"abc": defaultRoomIdleTTL,
+		"0":   0,
+		"10":  minRoomIdleTTL,
+		"900": 15 * time.Minute,
+	} {
+		if got := parseRoomIdleTTL(raw); got != want {
+			t.Errorf("parseRoomIdleTTL(%q) = %s, want %s", raw, got, want)
+		}
+	}
+}
+
+func TestReapIdleRoomsEndsRoomsWithOnlyGhosts(t *testing.T) {
+	hub, rid, sender, peer, _ := joinedPair(t)
+	watcher := fakeClient(hub)
+	hub.registerClient(watcher)
+	hub.handleMessage(watcher, watchRoomsPayload([]string{rid}))
+	drainMessages(sender)
+	drainMessages(peer)
+	drainMessages(watcher)
+
+	later := time.Now().Add(time.Hour)
+	atomic.StoreInt64(&sender.lastSeen, later.UnixNano())
+	hub.reapIdleRooms(later.Add(30*time.Minute), time.Hour)
+	hub.mu.RLock()
+	_, exists := hub.rooms[rid]
+	hub.mu.RUnlock()
+	if !exists {
+		t.Fatal("expected a room with one recently seen participant to stay open")
+	}
+
+	before := stats.SnapshotNow().Counters.IdleRoomsReaped
+	hub.reapIdleRooms(later.Add(2*time.Hour), time.Hour)
+	hub.mu.RLock()
+	_, exists = hub.rooms[rid]
+	hub.mu.RUnlock()
+	if exists {
+		t.Fatal("expected the idle room to be reaped")
+	}
+	if findMessage(drainMessages(peer), "room_ended") == nil {
+		t.Fatal("expected participants to receive room_ended")
+	}
+	if findMessage(drainMessages(watcher), "room_status_update") == nil {
+		t.Fatal("expected watchers to be notified of the reaped room")
+	}
+	if got := stats.SnapshotNow().Counters.IdleRoomsReaped - before; got != 1 {
+		t.Fatalf("expected one idle room reaped, got %d", got)
+	}
+}
+
+func TestReapIdleRoomsCountsJoinTimeAsSeen(t *testing.T) {
+	hub := newHub(4)
+	c := fakeClient(hub)
+	hub.registerClient(c)
+	rid := mustTestRoomID(t)
+	hub.handleMessage(c, joinPayload(rid, 4, 4))
+
+	hub.reapIdleRooms(time.Now().Add(time.Minute), time.Hour)
+	hub.mu.RLock()
+	_, exists := hub.rooms[rid]
+	hub.mu.RUnlock()
+	if !exists {
+		t.Fatal("expected a freshly joined room not to be reaped")
+	}
+
+	hub.reapIdleRooms(time.Now(), 0)
+	hub.mu.RLock()
+	_, exists = hub.rooms[rid]
+	hub.mu.RUnlock()
+	if !exists {
+		t.Fatal("expected a zero TTL to disable the reaper")
+	}
+}
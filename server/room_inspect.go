@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// RoomTopology is a point-in-time view of one room for debugging multi-party
+// issues such as one peer not seeing another.
+type RoomTopology struct {
+	RID             string                `json:"rid"`
+	HostCID         string                `json:"hostCid"`
+	MaxParticipants int                   `json:"maxParticipants"`
+	CapacityLocked  bool                  `json:"capacityLocked"`
+	Participants    []ParticipantTopology `json:"participants"` // in join order
+	Events          []RoomEvent           `json:"events"`       // oldest first; see roomEventLogSize
+}
+
+type ParticipantTopology struct {
+	CID            string        `json:"cid"`
+	SID            string        `json:"sid"`
+	Transport      TransportKind `json:"transport"`
+	IsHost         bool          `json:"isHost"`
+	JoinedAt       int64         `json:"joinedAt"`
+	SendQueueDepth int           `json:"sendQueueDepth"`
+	SendQueueCap   int           `json:"sendQueueCap"`
+	LastSeenMs     int64         `json:"lastSeenMs,omitempty"` // last inbound message, keepalive or WebSocket pong, unix ms
+	Media          MediaState    `json:"media"`
+}
+
+// roomTopology snapshots rid's participants under the room lock and then reads
+// per-client details without it. Reports false if the room does not exist.
+func (h *Hub) roomTopology(rid string) (RoomTopology, bool) {
+	h.mu.RLock()
+	room, exists := h.rooms[rid]
+	h.mu.RUnlock()
+	if !exists {
+		return RoomTopology{}, false
+	}
+
+	room.mu.Lock()
+	topology := RoomTopology{
+		RID:             rid,
+		HostCID:         room.HostCID,
+		MaxParticipants: room.MaxParticipants,
+		CapacityLocked:  room.CapacityLocked,
+		Events:          room.eventsLocked(),
+	}
+	clients := make([]*Client, 0, len(room.Participants))
+	participants := make([]ParticipantTopology, 0, len(room.Participants))
+	for client, cid := range room.Participants {
+		clients = append(clients, client)
+		participants = append(participants, ParticipantTopology{
+			CID:      cid,
+			IsHost:   cid == room.HostCID,
+			JoinedAt: room.JoinedAt[cid],
+			Media:    room.mediaStateLocked(cid),
+		})
+	}
+	room.mu.Unlock()
+
+	for i, client := range clients {
+		p := &participants[i]
+		p.SID = client.sid
+		p.Transport = client.transport
+		p.SendQueueDepth = len(client.send)
+		p.SendQueueCap = cap(client.send)
+		if lastSeen := atomic.LoadInt64(&client.lastSeen); lastSeen > 0 {
+			p.LastSeenMs = lastSeen / 1e6
+		}
+	}
+	sort.Slice(participants, func(i, j int) bool {
+		if participants[i].JoinedAt != participants[j].JoinedAt {
+			return participants[i].JoinedAt < participants[j].JoinedAt
+		}
+		return participants[i].CID < participants[j].CID
+	})
+	topology.Participants = participants
+	return topology, true
+}
+
+func handleInternalRoom(hub *Hub) http.HandlerFunc {
+	access := internalAccessFromEnv()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !access.authorize(w, r, http.MethodGet) {
+			return
+		}
+
+		rid := strings.TrimSpace(r.URL.Query().Get("rid"))
+		if rid == "" {
+			http.Error(w, "Missing rid", http.StatusBadRequest)
+			return
+		}
+		topology, ok := hub.roomTopology(rid)
+		if !ok {
+			http.Error(w, "Room not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(topology)
+	}
+}
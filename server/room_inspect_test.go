@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestInternalRoomRequiresToken(t *testing.T) {
+	t.Setenv("ENABLE_INTERNAL_STATS", "1")
+	t.Setenv("INTERNAL_STATS_TOKEN", "test-token")
+
+	handler := handleInternalRoom(newHub(4))
+	req := httptest.NewRequest(http.MethodGet, "/api/internal/room?rid=any", nil)
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected %d, got %d", http.StatusUnauthorized, rec.Code)
+	}
+}
+
+func TestInternalRoomUnknownRoom(t *testing.T) {
+	t.Setenv("ENABLE_INTERNAL_STATS", "1")
+	t.Setenv("INTERNAL_STATS_TOKEN", "test-token")
+
+	handler := handleInternalRoom(newHub(4))
+	req := httptest.NewRequest(http.MethodGet, "/api/internal/room?rid=missing", nil)
+	req.Header.Set("X-Internal-Token", "test-token")
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected %d, got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestInternalRoomReturnsTopology(t *testing.T) {
+	t.Setenv("ENABLE_INTERNAL_STATS", "1")
+	t.Setenv("INTERNAL_STATS_TOKEN", "test-token")
+
+	hub := newHub(4)
+	rid := mustTestRoomID(t)
+	host := fakeClient(hub)
+	host.transport = TransportWS
+	hub.registerClient(host)
+	hub.handleMessage(host, joinPayload(rid, 4, 4))
+	guest := fakeClient(hub)
+	guest.transport = TransportSSE
+	hub.registerClient(guest)
+	hub.markSSESeen(guest)
+	hub.handleMessage(guest, joinPayload(rid, 4, 4))
+	drainMessages(guest)
+
+	handler := handleInternalRoom(hub)
+	req := httptest.NewRequest(http.MethodGet, "/api/internal/room?rid="+rid, nil)
+	req.Header.Set("X-Internal-Token", "test-token")
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
+	}
+	var topology RoomTopology
+	if err := json.Unmarshal(rec.Body.Bytes(), &topology); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if topology.HostCID != host.cid || len(topology.Participants) != 2 {
+		t.Fatalf("unexpected topology: %+v", topology)
+	}
+	byCID := make(map[string]ParticipantTopology)
+	for _, p := range topology.Participants {
+		byCID[p.CID] = p
+	}
+	first, second := byCID[host.cid], byCID[guest.cid]
+	if first.CID != host.cid || !first.IsHost || first.SID != host.sid || first.Transport != TransportWS {
+		t.Fatalf("unexpected host entry: %+v", first)
+	}
+	if first.SendQueueDepth == 0 || first.SendQueueCap != cap(host.send) {
+		t.Fatalf("expected host send queue to hold undrained messages: %+v", first)
+	}
+	if second.CID != guest.cid || second.IsHost || second.Transport != TransportSSE || second.LastSeenMs == 0 {
+		t.Fatalf("unexpected guest entry: %+v", second)
+	}
+	if second.SendQueueDepth != 0 {
+		t.Fatalf("expected drained guest queue, got %d", second.SendQueueDepth)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"log"
+)
+
+// lockedRoomReconnectAllowed reports whether a join reclaiming cid may enter
+// a locked room: it must carry a valid reconnectToken. Without a token secret
+// no tokens are issued, so the reclaim is trusted as for unlocked rooms.
+func lockedRoomReconnectAllowed(token, cid, rid string) bool {
+	if reconnectTokenSecret() == "" {
+		return true
+	}
+	return validateReconnectToken(token, cid, rid)
+}
+
+// handleRoomLock serves lock_room and unlock_room. Only the host may change
+// the lock; while locked, handleJoin admits only reconnects reclaiming a CID
+// still present in the room. Changes are announced through room_state.
+func (h *Hub) handleRoomLock(c *Client, msg Message, locked bool) {
+	rid := c.rid
+	if rid == "" {
+		c.sendError(msg.RID, "NOT_IN_ROOM", "Must be in a room to change its lock")
+		return
+	}
+
+	h.mu.RLock()
+	room, exists := h.rooms[rid]
+	h.mu.RUnlock()
+	if !exists {
+		c.sendError(rid, "NOT_IN_ROOM", "Must be in a room to change its lock")
+		return
+	}
+
+	room.mu.Lock()
+	if room.HostCID != c.cid {
+		room.mu.Unlock()
+		log.Printf("[LOCK] Client %s (CID: %s) tried to set lock=%t on room %s but is not host", c.sid, c.cid, locked, rid)
+		c.sendError(rid, "NOT_HOST", "Only host can lock or unlock the room")
+		return
+	}
+	changed := room.Locked != locked
+	room.Locked = locked
+	if changed && locked {
+		room.recordEventLocked("lock", c.cid, "")
+	} else if changed {
+		room.recordEventLocked("unlock", c.cid, "")
+	}
+	room.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	log.Printf("[LOCK] Host %s set lock=%t on room %s", c.cid, locked, rid)
+	h.broadcastRoomState(room)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func lockRoomPayload(msgType, rid string) []byte {
+	b, _ := json.Marshal(Message{V: 1, Type: msgType, RID: rid})
+	return b
+}
+
+func TestNonHostCannotLockRoom(t *testing.T) {
+	hub := newHub(4)
+	rid := mustTestRoomID(t)
+	host := fakeClient(hub)
+	hub.registerClient(host)
+	hub.handleMessage(host, joinPayload(rid, 4, 4))
+	guest := fakeClient(hub)
+	hub.registerClient(guest)
+	hub.handleMessage(guest, joinPayload(rid, 4, 4))
+	drainMessages(guest)
+
+	hub.handleMessage(guest, lockRoomPayload("lock_room", rid))
+
+	if code := errorCode(findMessage(drainMessages(guest), "error")); code != "NOT_HOST" {
+		t.Fatalf("expected NOT_HOST, got %q", code)
+	}
+	hub.mu.RLock()
+	room := hub.rooms[rid]
+	hub.mu.RUnlock()
+	room.mu.Lock()
+	locked := room.Locked
+	room.mu.Unlock()
+	if locked {
+		t.Fatal("non-host must not be able to lock the room")
+	}
+}
+
+func TestLockedRoomRejectsNewJoins(t *testing.T) {
+	hub := newHub(4)
+	rid := mustTestRoomID(t)
+	host := fakeClient(hub)
+	hub.registerClient(host)
+	hub.handleMessage(host, joinPayload(rid, 4, 4))
+	drainMessages(host)
+
+	hub.handleMessage(host, lockRoomPayload("lock_room", rid))
+	state := findMessage(drainMessages(host), "room_state")
+	if state == nil {
+		t.Fatal("expected room_state after locking")
+	}
+	var payload struct {
+		Locked bool `json:"locked"`
+	}
+	if err := json.Unmarshal(state.Payload, &payload); err != nil || !payload.Locked {
+		t.Fatalf("expected room_state with locked=true, got %s", state.Payload)
+	}
+
+	joiner := fakeClient(hub)
+	hub.registerClient(joiner)
+	hub.handleMessage(joiner, joinPayload(rid, 4, 4))
+	if code := errorCode(findMessage(drainMessages(joiner), "error")); code != "ROOM_LOCKED" {
+		t.Fatalf("expected ROOM_LOCKED, got %q", code)
+	}
+	if joiner.rid != "" {
+		t.Fatal("rejected joiner must not be placed in the room")
+	}
+
+	hub.handleMessage(host, lockRoomPayload("unlock_room", rid))
+	drainMessages(host)
+	hub.handleMessage(joiner, joinPayload(rid, 4, 4))
+	if findMessage(drainMessages(joiner), "joined") == nil {
+		t.Fatal("expected join to succeed after unlock_room")
+	}
+}
+
+func TestLockedRoomRejectionLeavesCapacityUnlocked(t *testing.T) {
+	hub := newHub(4)
+	rid := mustTestRoomID(t)
+	host := fakeClient(hub)
+	hub.registerClient(host)
+	hub.handleMessage(host, joinPayload(rid, 4, 4))
+	hub.handleMessage(host, lockRoomPayload("lock_room", rid))
+	drainMessages(host)
+
+	joiner := fakeClient(hub)
+	hub.registerClient(joiner)
+	hub.handleMessage(joiner, joinPayload(rid, 2, 2))
+	if code := errorCode(findMessage(drainMessages(joiner), "error")); code != "ROOM_LOCKED" {
+		t.Fatalf("expected ROOM_LOCKED, got %q", code)
+	}
+
+	hub.mu.RLock()
+	room := hub.rooms[rid]
+	hub.mu.RUnlock()
+	room.mu.Lock()
+	capacityLocked := room.CapacityLocked
+	room.mu.Unlock()
+	if capacityLocked {
+		t.Fatal("expected a rejected join not to lock the room's capacity")
+	}
+}
+
+func TestLockedRoomAllowsReconnect(t *testing.T) {
+	hub := newHub(4)
+	rid := mustTestRoomID(t)
+	host := fakeClient(hub)
+	hub.registerClient(host)
+	hub.handleMessage(host, joinPayload(rid, 4, 4))
+	ghost := fakeClient(hub)
+	hub.registerClient(ghost)
+	hub.handleMessage(ghost, joinPayload(rid, 4, 4))
+	ghostCID := ghost.cid
+	hub.handleMessage(host, lockRoomPayload("lock_room", rid))
+
+	returning := fakeClient(hub)
+	hub.registerClient(returning)
+	hub.handleMessage(returning, reconnectJoinPayload(rid, ghostCID))
+
+	joined := findMessage(drainMessages(returning), "joined")
+	if joined == nil {
+		t.Fatal("expected reconnect to a locked room to succeed")
+	}
+	if returning.cid != ghostCID {
+		t.Fatalf("expected reconnect to reclaim %s, got %s", ghostCID, returning.cid)
+	}
+}
+
+func TestLockedRoomReconnectNeedsValidToken(t *testing.T) {
+	t.Setenv("TURN_TOKEN_SECRET", "test-lock-reconnect-secret")
+	hub := newHub(4)
+	rid := mustTestRoomID(t)
+	host := fakeClient(hub)
+	hub.registerClient(host)
+	hub.handleMessage(host, joinPayload(rid, 4, 4))
+	ghost := fakeClient(hub)
+	hub.registerClient(ghost)
+	hub.handleMessage(ghost, joinPayload(rid, 4, 4))
+	ghostCID := ghost.cid
+	hub.handleMessage(host, lockRoomPayload("lock_room", rid))
+
+	intruder := fakeClient(hub)
+	hub.registerClient(intruder)
+	hub.handleMessage(intruder, reconnectJoinPayload(rid, ghostCID))
+	if code := errorCode(findMessage(drainMessages(intruder), "error")); code != "ROOM_LOCKED" {
+		t.Fatalf("expected ROOM_LOCKED for a tokenless reclaim, got %q", code)
+	}
+	if ghost.cid != ghostCID {
+		t.Fatal("expected the refused reclaim to leave the original participant in place")
+	}
+
+	payloadBytes, _ := json.Marshal(map[string]interface{}{
+		"reconnectCid":   ghostCID,
+		"reconnectToken": issueReconnectToken(ghostCID, rid),
+		"capabilities":   map[string]int{"maxParticipants": 4},
+	})
+	raw, _ := json.Marshal(Message{V: 1, Type: "join", RID: rid, Payload: payloadBytes})
+	returning := fakeClient(hub)
+	hub.registerClient(returning)
+	hub.handleMessage(returning, raw)
+	if findMessage(drainMessages(returning), "joined") == nil || returning.cid != ghostCID {
+		t.Fatal("expected a reclaim with a valid token to rejoin the locked room")
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Limits for set_room_meta. Lengths are in characters (runes); the payload
+// cap bounds the JSON as sent, escapes included.
+const (
+	maxRoomMetaPayloadBytes = 4096
+	maxRoomTitleLen         = 120
+	maxRoomTopicLen         = 1000
+)
+
+// RoomMetadata is the host-set, human-readable description of a room, listed
+// as metadata in joined and room_state. It lives on the Room, so it carries
+// over host changes and is gone once the room is deleted.
+type RoomMetadata struct {
+	Title string `json:"title,omitempty"`
+	Topic string `json:"topic,omitempty"`
+}
+
+// sanitizeRoomMetaText trims s and drops control characters, keeping line
+// breaks only where allowed (the topic).
+func sanitizeRoomMetaText(s string, keepNewlines bool) string {
+	s = strings.Map(func(r rune) rune {
+		if r == '\n' && keepNewlines {
+			return r
+		}
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+	return strings.TrimSpace(s)
+}
+
+// parseRoomMetadata validates a set_room_meta payload, returning a message
+// for BAD_REQUEST if it is invalid. Empty title and topic give nil metadata,
+// which clears it.
+func parseRoomMetadata(raw json.RawMessage) (meta *RoomMetadata, errMsg string) {
+	if len(raw) > maxRoomMetaPayloadBytes {
+		return nil, "Room metadata is too large"
+	}
+	var payload RoomMetadata
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, "set_room_meta requires a payload with string title and topic"
+	}
+	if !utf8.ValidString(payload.Title) || !utf8.ValidString(payload.Topic) {
+		return nil, "Room metadata must be valid UTF-8"
+	}
+	payload.Title = sanitizeRoomMetaText(payload.Title, false)
+	payload.Topic = sanitizeRoomMetaText(payload.Topic, true)
+	if utf8.RuneCountInString(payload.Title) > maxRoomTitleLen || utf8.RuneCountInString(payload.Topic) > maxRoomTopicLen {
+		return nil, "Room title or topic is too long"
+	}
+	if payload.Title == "" && payload.Topic == "" {
+		return nil, ""
+	}
+	return &payload, ""
+}
+
+func equalRoomMetadata(a, b *RoomMetadata) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// handleSetRoomMeta serves set_room_meta. Only the host may change the
+// metadata; changes are announced through room_state.
+func (h *Hub) handleSetRoomMeta(c *Client, msg Message) {
+	rid := c.rid
+	if rid == "" {
+		c.sendError(msg.RID, "NOT_IN_ROOM", "Must be in a room to set its metadata")
+		return
+	}
+
+	meta, errMsg := parseRoomMetadata(msg.Payload)
+	if errMsg != "" {
+		c.sendError(rid, "BAD_REQUEST", errMsg)
+		return
+	}
+
+	h.mu.RLock()
+	room, exists := h.rooms[rid]
+	h.mu.RUnlock()
+	if !exists {
+		c.sendError(rid, "NOT_IN_ROOM", "Must be in a room to set its metadata")
+		return
+	}
+
+	room.mu.Lock()
+	if room.HostCID != c.cid {
+		room.mu.Unlock()
+		log.Printf("[ROOM_META] Client %s (CID: %s) tried to set metadata on room %s but is not host", c.sid, c.cid, rid)
+		c.sendError(rid, "NOT_HOST", "Only host can set room metadata")
+		return
+	}
+	changed := !equalRoomMetadata(room.Metadata, meta)
+	room.Metadata = meta
+	if changed {
+		room.recordEventLocked("meta", c.cid, "")
+	}
+	room.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	log.Printf("[ROOM_META] Host %s updated metadata on room %s", c.cid, rid)
+	h.broadcastRoomState(room)
+}
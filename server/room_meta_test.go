@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func setRoomMetaPayload(rid string, meta any) []byte {
+	payload, _ := json.Marshal(meta)
+	b, _ := json.Marshal(Message{V: 1, Type: "set_room_meta", RID: rid, Payload: payload})
+	return b
+}
+
+func roomStateMetadata(t *testing.T, msg *Message) *RoomMetadata {
+	t.Helper()
+	if msg == nil {
+		t.Fatal("expected a room state message")
+	}
+	var payload struct {
+		Metadata *RoomMetadata `json:"metadata"`
+	}
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		t.Fatalf("bad payload %s: %v", msg.Payload, err)
+	}
+	return payload.Metadata
+}
+
+func TestHostSetsRoomMetadataForEveryone(t *testing.T) {
+	hub := newHub(4)
+	rid := mustTestRoomID(t)
+	host := fakeClient(hub)
+	hub.registerClient(host)
+	hub.handleMessage(host, joinPayload(rid, 4, 4))
+	guest := fakeClient(hub)
+	hub.registerClient(guest)
+	hub.handleMessage(guest, joinPayload(rid, 4, 4))
+	drainMessages(host)
+	drainMessages(guest)
+
+	hub.handleMessage(host, setRoomMetaPayload(rid, map[string]string{"title": "  Weekly\tsync\x00 ", "topic": "Agenda:\n- budget"}))
+
+	meta := roomStateMetadata(t, findMessage(drainMessages(guest), "room_state"))
+	if meta == nil || meta.Title != "Weeklysync" || meta.Topic != "Agenda:\n- budget" {
+		t.Fatalf("expected sanitized metadata in room_state, got %+v", meta)
+	}
+	drainMessages(host)
+
+	hub.handleMessage(host, setRoomMetaPayload(rid, map[string]string{"title": "Weekly\tsync", "topic": "Agenda:\n- budget"}))
+	if msg := findMessage(drainMessages(guest), "room_state"); msg != nil {
+		t.Fatal("expected an unchanged update not to broadcast room_state")
+	}
+
+	late := fakeClient(hub)
+	hub.registerClient(late)
+	hub.handleMessage(late, joinPayload(rid, 4, 4))
+	if meta := roomStateMetadata(t, findMessage(drainMessages(late), "joined")); meta == nil || meta.Title != "Weeklysync" {
+		t.Fatalf("expected joined to carry the metadata, got %+v", meta)
+	}
+
+	drainMessages(guest)
+	hub.handleMessage(host, setRoomMetaPayload(rid, map[string]string{}))
+	if meta := roomStateMetadata(t, findMessage(drainMessages(guest), "room_state")); meta != nil {
+		t.Fatalf("expected empty title and topic to clear the metadata, got %+v", meta)
+	}
+}
+
+func TestSetRoomMetaRejectsNonHostAndInvalidPayloads(t *testing.T) {
+	hub := newHub(4)
+	rid := mustTestRoomID(t)
+	host := fakeClient(hub)
+	hub.registerClient(host)
+	hub.handleMessage(host, joinPayload(rid, 4, 4))
+	guest := fakeClient(hub)
+	hub.registerClient(guest)
+	hub.handleMessage(guest, joinPayload(rid, 4, 4))
+	drainMessages(host)
+	drainMessages(guest)
+
+	hub.handleMessage(guest, setRoomMetaPayload(rid, map[string]string{"title": "Mine now"}))
+	if code := errorCode(findMessage(drainMessages(guest), "error")); code != "NOT_HOST" {
+		t.Fatalf("expected NOT_HOST, got %q", code)
+	}
+
+	for _, meta := range []any{
+		map[string]string{"title": strings.Repeat("t", maxRoomTitleLen+1)},
+		map[string]string{"topic": strings.Repeat("t", maxRoomMetaPayloadBytes)},
+		map[string]int{"title": 5},
+	} {
+		hub.handleMessage(host, setRoomMetaPayload(rid, meta))
+		if code := errorCode(findMessage(drainMessages(host), "error")); code != "BAD_REQUEST" {
+			t.Fatalf("expected BAD_REQUEST for %v, got %q", meta, code)
+		}
+	}
+
+	hub.mu.RLock()
+	room := hub.rooms[rid]
+	hub.mu.RUnlock()
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	if room.Metadata != nil {
+		t.Fatalf("rejected updates must not change the metadata, got %+v", room.Metadata)
+	}
+}
+
+func TestRoomMetadataSurvivesHostHandoffAndRoomDeletion(t *testing.T) {
+	hub := newHub(4)
+	rid := mustTestRoomID(t)
+	host := fakeClient(hub)
+	hub.registerClient(host)
+	hub.handleMessage(host, joinPayload(rid, 4, 4))
+	guest := fakeClient(hub)
+	hub.registerClient(guest)
+	hub.handleMessage(guest, joinPayload(rid, 4, 4))
+	hub.handleMessage(host, setRoomMetaPayload(rid, map[string]string{"title": "Standup"}))
+	drainMessages(guest)
+
+	hub.handleMessage(host, []byte(`{"v":1,"type":"leave","rid":"`+rid+`"}`))
+	meta := roomStateMetadata(t, findMessage(drainMessages(guest), "room_state"))
+	if meta == nil || meta.Title != "Standup" {
+		t.Fatalf("expected metadata to survive the host leaving, got %+v", meta)
+	}
+
+	hub.handleMessage(guest, setRoomMetaPayload(rid, map[string]string{"title": "Standup (new host)"}))
+	if meta := roomStateMetadata(t, findMessage(drainMessages(guest), "room_state")); meta == nil || meta.Title != "Standup (new host)" {
+		t.Fatalf("expected the promoted host to update the metadata, got %+v", meta)
+	}
+
+	hub.handleMessage(guest, []byte(`{"v":1,"type":"leave","rid":"`+rid+`"}`))
+	fresh := fakeClient(hub)
+	hub.registerClient(fresh)
+	hub.handleMessage(fresh, joinPayload(rid, 4, 4))
+	if meta := roomStateMetadata(t, findMessage(drainMessages(fresh), "joined")); meta != nil {
+		t.Fatalf("expected a recreated room to start without metadata, got %+v", meta)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// RoomRoster is the /api/room/participants response.
+type RoomRoster struct {
+	RoomID       string   `json:"roomId"`
+	HostCID      string   `json:"hostCid"`
+	Participants []string `json:"participants"` // CIDs, earliest joiner first
+}
+
+// roomRoster returns the room's host and participant CIDs, or false if the
+// room does not exist.
+func (h *Hub) roomRoster(rid string) (RoomRoster, bool) {
+	h.mu.RLock()
+	room, exists := h.rooms[rid]
+	h.mu.RUnlock()
+	if !exists {
+		return RoomRoster{}, false
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	roster := RoomRoster{RoomID: rid, HostCID: room.HostCID, Participants: make([]string, 0, len(room.Participants))}
+	for _, cid := range room.Participants {
+		roster.Participants = append(roster.Participants, cid)
+	}
+	sort.Slice(roster.Participants, func(i, j int) bool {
+		a, b := roster.Participants[i], roster.Participants[j]
+		if room.JoinedAt[a] != room.JoinedAt[b] {
+			return room.JoinedAt[a] < room.JoinedAt[b]
+		}
+		return a < b
+	})
+	return roster, true
+}
+
+// handleRoomParticipants serves GET /api/room/participants?roomId=<rid>&cid=<cid>.
+// Like /api/push/notify, only a current participant of the room may call it,
+// so the roster is never exposed to outsiders.
+func handleRoomParticipants(hub *Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			return
+		}
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		roomID := strings.TrimSpace(r.URL.Query().Get("roomId"))
+		if writeRoomIDValidationError(w, roomID) {
+			return
+		}
+
+		cid := strings.TrimSpace(r.URL.Query().Get("cid"))
+		if cid == "" {
+			http.Error(w, "Missing cid", http.StatusBadRequest)
+			return
+		}
+
+		if !hub.IsClientInRoom(roomID, cid) {
+			http.Error(w, "Not a room participant", http.StatusForbidden)
+			return
+		}
+		roster, ok := hub.roomRoster(roomID)
+		if !ok {
+			// The room ended between the two lookups.
+			http.Error(w, "Not a room participant", http.StatusForbidden)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(roster)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func roomParticipantsRequest(roomID, cid string) *http.Request {
+	return httptest.NewRequest(http.MethodGet, "/api/room/participants?roomId="+roomID+"&cid="+cid, nil)
+}
+
+func TestHandleRoomParticipantsListsRoster(t *testing.T) {
+	hub, rid, host, _, guestCID := joinedPair(t)
+	hostCID := host.cid
+	// Both joined within the same millisecond; order them explicitly.
+	room := hub.rooms[rid]
+	room.mu.Lock()
+	room.JoinedAt[guestCID] = room.JoinedAt[hostCID] + 1
+	room.mu.Unlock()
+
+	rec := httptest.NewRecorder()
+	handleRoomParticipants(hub)(rec, roomParticipantsRequest(rid, guestCID))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var roster RoomRoster
+	if err := json.Unmarshal(rec.Body.Bytes(), &roster); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if roster.RoomID != rid || roster.HostCID != hostCID {
+		t.Fatalf("unexpected roster %+v", roster)
+	}
+	if len(roster.Participants) != 2 || roster.Participants[0] != hostCID || roster.Participants[1] != guestCID {
+		t.Fatalf("expected host then guest, got %v", roster.Participants)
+	}
+}
+
+func TestHandleRoomParticipantsRequiresRoomParticipant(t *testing.T) {
+	roomID := mustTestRoomID(t)
+	handler := handleRoomParticipants(makeTestHubWithParticipant(roomID, "cid-1"))
+
+	for name, tc := range map[string]struct {
+		roomID, cid string
+		want        int
+	}{
+		"outsider":        {roomID, "cid-2", http.StatusForbidden},
+		"missing cid":     {roomID, "", http.StatusBadRequest},
+		"invalid room ID": {"bad", "cid-1", http.StatusBadRequest},
+	} {
+		rec := httptest.NewRecorder()
+		handler(rec, roomParticipantsRequest(tc.roomID, tc.cid))
+		if rec.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d", name, tc.want, rec.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodPost, "/api/room/participants?roomId="+roomID+"&cid=cid-1", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405 for POST, got %d", rec.Code)
+	}
+}
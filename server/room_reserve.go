@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"serenada/server/internal/stats"
+)
+
+const defaultRoomReserveTTL = 10 * time.Minute
+
+// roomReserveTTL is how long a reserved room waits for its first join before
+// the reaper drops it. Set from ROOM_RESERVE_TTL_SECONDS at startup.
+var roomReserveTTL = defaultRoomReserveTTL
+
+func parseRoomReserveTTL(raw string) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || seconds <= 0 {
+		return defaultRoomReserveTTL
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+var (
+	errRoomExists = errors.New("room already exists")
+	errRoomIDUsed = errors.New("room ID has already been used")
+)
+
+// RoomReservation is the /api/room/reserve response: the settings the first
+// join will inherit, and the token that join must present.
+type RoomReservation struct {
+	RoomID          string `json:"roomId"`
+	MaxParticipants int    `json:"maxParticipants"`
+	AllowKnocks     bool   `json:"allowKnocks"`
+	ReservedUntil   int64  `json:"reservedUntil"` // unix ms
+	ReserveToken    string `json:"reserveToken"`
+}
+
+// reserveRoom registers an empty room for rid with the given settings. Only a
+// join carrying the returned reserveToken is admitted until someone claims
+// it; that join becomes host and inherits the settings. If nobody joins
+// before roomReserveTTL, pruneReservedRooms removes it.
+func (h *Hub) reserveRoom(rid string, maxParticipants int, allowKnocks bool, now time.Time) (RoomReservation, error) {
+	if maxParticipants < 2 {
+		maxParticipants = 2
+	}
+	if maxParticipants > h.maxParticipantsLimit {
+		maxParticipants = h.maxParticipantsLimit
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, exists := h.rooms[rid]; exists {
+		return RoomReservation{}, errRoomExists
+	}
+	if !h.usedRoomIDs.claim(rid, now) {
+		return RoomReservation{}, errRoomIDUsed
+	}
+	room := newRoom(rid, maxParticipants, allowKnocks)
+	room.reservedUntil = now.Add(roomReserveTTL).UnixMilli()
+	room.reserveToken = randomToken()
+	h.rooms[rid] = room
+
+	return RoomReservation{
+		RoomID:          rid,
+		MaxParticipants: maxParticipants,
+		AllowKnocks:     allowKnocks,
+		ReservedUntil:   room.reservedUntil,
+		ReserveToken:    room.reserveToken,
+	}, nil
+}
+
+// pruneReservedRooms deletes reserved rooms whose reservation expired before
+// anyone joined.
+func (h *Hub) pruneReservedRooms(now time.Time) {
+	nowMs := now.UnixMilli()
+	var expired []string
+
+	h.mu.Lock()
+	for rid, room := range h.rooms {
+		room.mu.Lock()
+		if room.reservedUntil != 0 && room.reservedUntil <= nowMs && len(room.Participants) == 0 {
+			delete(h.rooms, rid)
+			expired = append(expired, rid)
+		}
+		room.mu.Unlock()
+	}
+	h.mu.Unlock()
+
+	for _, rid := range expired {
+		log.Printf("[RESERVE] Reservation for room %s expired without a join", rid)
+		h.broadcastRoomStatusUpdate(rid)
+	}
+}
+
+// handleRoomReserve serves POST /api/room/reserve with a JSON body of
+// {"roomId": "...", "maxParticipants": 4, "allowKnocks": true}.
+func handleRoomReserve(hub *Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var body struct {
+			RoomID          string `json:"roomId"`
+			MaxParticipants int    `json:"maxParticipants"`
+			AllowKnocks     bool   `json:"allowKnocks"`
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxMessageSize)
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		rid := strings.TrimSpace(body.RoomID)
+		if err := validateRoomID(rid); err != nil {
+			if errors.Is(err, ErrRoomIDSecretMissing) {
+				http.Error(w, "Room ID service unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			http.Error(w, "Invalid room ID", http.StatusBadRequest)
+			return
+		}
+		if roomIDBlocked(rid) {
+			stats.IncRoomBlocked()
+			http.Error(w, "Room is not available", http.StatusForbidden)
+			return
+		}
+
+		reservation, err := hub.reserveRoom(rid, body.MaxParticipants, body.AllowKnocks, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		log.Printf("[RESERVE] Reserved room %s (maxParticipants=%d allowKnocks=%t)", rid, reservation.MaxParticipants, reservation.AllowKnocks)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(reservation)
+	}
+}
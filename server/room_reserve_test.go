@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func postRoomReserve(t *testing.T, hub *Hub, body map[string]interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	raw, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/api/room/reserve", bytes.NewReader(raw))
+	w := httptest.NewRecorder()
+	handleRoomReserve(hub).ServeHTTP(w, req)
+	return w
+}
+
+// reservedJoinPayload is a join into rid presenting a reservation's token.
+func reservedJoinPayload(rid, reserveToken string) []byte {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"capabilities":          map[string]int{"maxParticipants": 4},
+		"createMaxParticipants": 2,
+		"reserveToken":          reserveToken,
+	})
+	b, _ := json.Marshal(Message{V: 1, Type: "join", RID: rid, Payload: payload})
+	return b
+}
+
+func TestRoomReserveFirstJoinInheritsSettings(t *testing.T) {
+	hub := newHub(4)
+	rid := mustTestRoomID(t)
+
+	w := postRoomReserve(t, hub, map[string]interface{}{"roomId": rid, "maxParticipants": 8, "allowKnocks": true})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var reservation RoomReservation
+	if err := json.NewDecoder(w.Body).Decode(&reservation); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if reservation.RoomID != rid || reservation.MaxParticipants != 4 || !reservation.AllowKnocks || reservation.ReservedUntil == 0 || reservation.ReserveToken == "" {
+		t.Fatalf("unexpected reservation %+v", reservation)
+	}
+
+	host := fakeClient(hub)
+	hub.registerClient(host)
+	// createMaxParticipants is ignored for a reserved room.
+	hub.handleMessage(host, reservedJoinPayload(rid, reservation.ReserveToken))
+	joined := findMessage(drainMessages(host), "joined")
+	if joined == nil {
+		t.Fatal("expected creator to join the reserved room")
+	}
+	var payload struct {
+		HostCID string `json:"hostCid"`
+	}
+	_ = json.Unmarshal(joined.Payload, &payload)
+	if payload.HostCID != host.cid {
+		t.Fatalf("expected first joiner %s to be host, got %s", host.cid, payload.HostCID)
+	}
+
+	guest := fakeClient(hub)
+	hub.registerClient(guest)
+	hub.handleMessage(guest, joinPayload(rid, 4, 2))
+
+	hub.mu.RLock()
+	room := hub.rooms[rid]
+	hub.mu.RUnlock()
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	if room.MaxParticipants != 4 || !room.KnocksEnabled || room.reservedUntil != 0 || room.reserveToken != "" {
+		t.Fatalf("expected reserved settings to apply, got max=%d knocks=%t reservedUntil=%d", room.MaxParticipants, room.KnocksEnabled, room.reservedUntil)
+	}
+}
+
+func TestRoomReserveOnlyAdmitsCreatorFirst(t *testing.T) {
+	hub := newHub(4)
+	rid := mustTestRoomID(t)
+	reservation, err := hub.reserveRoom(rid, 4, false, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, token := range []string{"", "wrong-token"} {
+		intruder := fakeClient(hub)
+		hub.registerClient(intruder)
+		hub.handleMessage(intruder, reservedJoinPayload(rid, token))
+		if code := errorCode(findMessage(drainMessages(intruder), "error")); code != "ROOM_RESERVED" {
+			t.Fatalf("token %q: expected ROOM_RESERVED, got %q", token, code)
+		}
+	}
+
+	hub.mu.RLock()
+	room := hub.rooms[rid]
+	hub.mu.RUnlock()
+	room.mu.Lock()
+	reservedUntil, participants := room.reservedUntil, len(room.Participants)
+	room.mu.Unlock()
+	if reservedUntil == 0 || participants != 0 {
+		t.Fatalf("expected rejected joins to leave the reservation in place, got reservedUntil=%d participants=%d", reservedUntil, participants)
+	}
+
+	creator := fakeClient(hub)
+	hub.registerClient(creator)
+	hub.handleMessage(creator, reservedJoinPayload(rid, reservation.ReserveToken))
+	if findMessage(drainMessages(creator), "joined") == nil {
+		t.Fatal("expected the creator's token to claim the reservation")
+	}
+	guest := fakeClient(hub)
+	hub.registerClient(guest)
+	hub.handleMessage(guest, joinPayload(rid, 4, 4))
+	if findMessage(drainMessages(guest), "joined") == nil {
+		t.Fatal("expected joins without a token once the reservation is claimed")
+	}
+}
+
+func TestRoomReserveRejectsExistingRoomAndBadIDs(t *testing.T) {
+	hub := newHub(4)
+	rid := mustTestRoomID(t)
+
+	if w := postRoomReserve(t, hub, map[string]interface{}{"roomId": rid}); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if w := postRoomReserve(t, hub, map[string]interface{}{"roomId": rid}); w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for an existing room, got %d", w.Code)
+	}
+	if w := postRoomReserve(t, hub, map[string]interface{}{"roomId": "not-a-room-id"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid room ID, got %d", w.Code)
+	}
+}
+
+func TestPruneReservedRoomsDropsUnclaimedReservations(t *testing.T) {
+	hub := newHub(4)
+	now := time.Now()
+	unclaimed := mustTestRoomID(t)
+	claimed := mustTestRoomID(t)
+	if _, err := hub.reserveRoom(unclaimed, 2, false, now); err != nil {
+		t.Fatal(err)
+	}
+	reservation, err := hub.reserveRoom(claimed, 2, false, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := fakeClient(hub)
+	hub.registerClient(c)
+	hub.handleMessage(c, reservedJoinPayload(claimed, reservation.ReserveToken))
+
+	hub.pruneReservedRooms(now.Add(roomReserveTTL - time.Second))
+	hub.mu.RLock()
+	_, stillReserved := hub.rooms[unclaimed]
+	hub.mu.RUnlock()
+	if !stillReserved {
+		t.Fatal("expected reservation to survive until its TTL")
+	}
+
+	hub.pruneReservedRooms(now.Add(roomReserveTTL))
+	hub.mu.RLock()
+	_, unclaimedExists := hub.rooms[unclaimed]
+	_, claimedExists := hub.rooms[claimed]
+	hub.mu.RUnlock()
+	if unclaimedExists {
+		t.Fatal("expected unclaimed reservation to be pruned")
+	}
+	if !claimedExists {
+		t.Fatal("expected joined room to be kept")
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"serenada/server/internal/stats"
+)
+
+// minRoomStallTimeout keeps a misconfigured ROOM_STALL_TIMEOUT_SECONDS from
+// flagging calls that are merely settled: once ICE completes, a healthy call
+// can go minutes without signaling.
+const minRoomStallTimeout = 5 * time.Minute
+
+// roomStallTimeout is how long a room with two or more participants may go
+// without signaling activity from any of them before it counts as stalled.
+// Transport keepalive cannot catch clients that are connected but frozen.
+// Zero disables detection. Set from ROOM_STALL_TIMEOUT_SECONDS at startup;
+// roomStallClose (ROOM_STALL_CLOSE=1) additionally ends stalled rooms.
+var (
+	roomStallTimeout time.Duration
+	roomStallClose   bool
+)
+
+func parseRoomStallTimeout(raw string) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	timeout := time.Duration(seconds) * time.Second
+	if timeout < minRoomStallTimeout {
+		return minRoomStallTimeout
+	}
+	return timeout
+}
+
+// touchActivity records signaling activity from c. Keepalives do not count,
+// since frozen clients may keep sending them.
+func (c *Client) touchActivity(msgType string) {
+	if msgType == "ping" || msgType == "watch_keepalive" || msgType == "time_sync" {
+		return
+	}
+	atomic.StoreInt64(&c.lastActivity, time.Now().UnixNano())
+}
+
+type stalledRoom struct {
+	room         *Room
+	rid          string
+	participants int
+}
+
+// newestSignalLocked returns the most recent signaling activity or join time
+// across room's participants, in Unix nanoseconds. room.mu must be held.
+func newestSignalLocked(room *Room) int64 {
+	var newest int64
+	for client, cid := range room.Participants {
+		newest = max(newest, atomic.LoadInt64(&client.lastActivity), room.JoinedAt[cid]*int64(time.Millisecond))
+	}
+	return newest
+}
+
+// checkStalledRooms updates the stalled-rooms gauge and, if close is set, ends
+// every stalled room with room_ended reason "stalled". A participant's join
+// time counts as activity so a freshly reconnected client is not flagged.
+func (h *Hub) checkStalledRooms(now time.Time, timeout time.Duration, close bool) {
+	if timeout <= 0 {
+		return
+	}
+	cutoff := now.Add(-timeout).UnixNano()
+
+	var stalled []stalledRoom
+	h.mu.RLock()
+	for rid, room := range h.rooms {
+		room.mu.Lock()
+		if len(room.Participants) < 2 {
+			room.stalled = false
+			room.mu.Unlock()
+			continue
+		}
+		newest := newestSignalLocked(room)
+		isStalled := newest < cutoff
+		if isStalled && !room.stalled {
+			log.Printf("[STALL] Room %s has had no signaling from its %d participants for %s", rid, len(room.Participants), now.Sub(time.Unix(0, newest)).Round(time.Second))
+		}
+		room.stalled = isStalled
+		participants := len(room.Participants)
+		room.mu.Unlock()
+		if isStalled {
+			stalled = append(stalled, stalledRoom{room: room, rid: rid, participants: participants})
+		}
+	}
+	h.mu.RUnlock()
+
+	if !close {
+		stats.SetStalledRooms(int64(len(stalled)))
+		return
+	}
+	stats.SetStalledRooms(0)
+	// A participant may signal, or the room may end, between the sweep and
+	// here, so endRoom re-checks the room under its lock.
+	stillStalled := func(r *Room) bool {
+		return len(r.Participants) >= 2 && newestSignalLocked(r) < cutoff
+	}
+	for _, s := range stalled {
+		if h.endRoom(s.room, s.rid, "", "stalled", stillStalled) {
+			log.Printf("[STALL] Ended stalled room %s", s.rid)
+			stats.IncStalledRoomClosed()
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"serenada/server/internal/stats"
+)
+
+func TestParseRoomStallTimeout(t *testing.T) {
+	if got := parseRoomStallTimeout(""); got != 0 {
+		t.Fatalf("expected disabled by default, got %s", got)
+	}
+	if got := parseRoomStallTimeout("60"); got != minRoomStallTimeout {
+		t.Fatalf("expected timeout raised to %s, got %s", minRoomStallTimeout, got)
+	}
+	if got := parseRoomStallTimeout("900"); got != 15*time.Minute {
+		t.Fatalf("expected 15m, got %s", got)
+	}
+}
+
+func TestKeepalivesDoNotCountAsActivity(t *testing.T) {
+	hub := newHub(4)
+	c := fakeClient(hub)
+	hub.registerClient(c)
+
+	hub.handleMessage(c, pingPayload())
+	if atomic.LoadInt64(&c.lastActivity) != 0 {
+		t.Fatal("expected ping not to count as signaling activity")
+	}
+	hub.handleMessage(c, joinPayload(mustTestRoomID(t), 4, 4))
+	if atomic.LoadInt64(&c.lastActivity) == 0 {
+		t.Fatal("expected join to count as signaling activity")
+	}
+}
+
+func TestCheckStalledRoomsFlagsAndCloses(t *testing.T) {
+	hub, rid, sender, peer, _ := joinedPair(t)
+	drainMessages(sender)
+	drainMessages(peer)
+
+	later := time.Now().Add(time.Hour)
+	hub.checkStalledRooms(time.Now(), time.Hour, false)
+	if got := stats.SnapshotNow().Gauges.StalledRooms; got != 0 {
+		t.Fatalf("expected no stalled rooms yet, got %d", got)
+	}
+
+	hub.checkStalledRooms(later.Add(time.Second), time.Hour, false)
+	if got := stats.SnapshotNow().Gauges.StalledRooms; got != 1 {
+		t.Fatalf("expected one stalled room, got %d", got)
+	}
+	hub.mu.RLock()
+	_, exists := hub.rooms[rid]
+	hub.mu.RUnlock()
+	if !exists {
+		t.Fatal("expected stalled room to stay open without ROOM_STALL_CLOSE")
+	}
+
+	before := stats.SnapshotNow().Counters.StalledRoomsClosed
+	hub.checkStalledRooms(later.Add(time.Second), time.Hour, true)
+	for _, c := range []*Client{sender, peer} {
+		if findMessage(drainMessages(c), "room_ended") == nil {
+			t.Fatalf("expected %s to receive room_ended", c.cid)
+		}
+	}
+	hub.mu.RLock()
+	_, exists = hub.rooms[rid]
+	hub.mu.RUnlock()
+	if exists {
+		t.Fatal("expected stalled room to be ended")
+	}
+	if after := stats.SnapshotNow().Counters.StalledRoomsClosed; after-before != 1 {
+		t.Fatalf("expected one stalled room closed, got %d", after-before)
+	}
+}
+
+func TestCheckStalledRoomsIgnoresSoloRooms(t *testing.T) {
+	hub := newHub(4)
+	c := fakeClient(hub)
+	hub.registerClient(c)
+	hub.handleMessage(c, joinPayload(mustTestRoomID(t), 4, 4))
+
+	hub.checkStalledRooms(time.Now().Add(2*time.Hour), time.Hour, false)
+	if got := stats.SnapshotNow().Gauges.StalledRooms; got != 0 {
+		t.Fatalf("expected a participant waiting alone not to count as stalled, got %d", got)
+	}
+}
+
+func TestEndRoomSkipsRoomThatChangedSinceSnapshot(t *testing.T) {
+	hub, rid, sender, peer, _ := joinedPair(t)
+	drainMessages(sender)
+	drainMessages(peer)
+
+	hub.mu.RLock()
+	room := hub.rooms[rid]
+	hub.mu.RUnlock()
+
+	// The sweep saw the room stalled, but a participant signalled since.
+	if hub.endRoom(room, rid, "", "stalled", func(*Room) bool { return false }) {
+		t.Fatal("expected endRoom to skip a room that no longer meets its condition")
+	}
+
+	// The room ended and a new one took its ID since the snapshot.
+	replacement := newRoom(rid, 4, false)
+	hub.mu.Lock()
+	hub.rooms[rid] = replacement
+	hub.mu.Unlock()
+	if hub.endRoom(room, rid, "", "stalled", nil) {
+		t.Fatal("expected endRoom to skip a room that is no longer live")
+	}
+	hub.mu.RLock()
+	live := hub.rooms[rid]
+	hub.mu.RUnlock()
+	if live != replacement {
+		t.Fatal("expected the replacement room to stay open")
+	}
+	for _, c := range []*Client{sender, peer} {
+		if findMessage(drainMessages(c), "room_ended") != nil {
+			t.Fatalf("expected %s not to receive room_ended", c.cid)
+		}
+	}
+}
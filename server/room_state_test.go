@@ -0,0 +1,79 @@
+package main
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestBroadcastRoomStateSkipsUnregisteredRoom(t *testing.T) {
+	hub := newHub(4)
+	rid := mustTestRoomID(t)
+	a := fakeClient(hub)
+	b := fakeClient(hub)
+	hub.registerClient(a)
+	hub.registerClient(b)
+	hub.handleMessage(a, joinPayload(rid, 4, 4))
+	hub.handleMessage(b, joinPayload(rid, 4, 4))
+	drainMessages(a)
+	drainMessages(b)
+
+	hub.mu.Lock()
+	room := hub.rooms[rid]
+	delete(hub.rooms, rid)
+	hub.mu.Unlock()
+
+	hub.broadcastRoomState(room)
+
+	for _, c := range []*Client{a, b} {
+		if msg := findMessage(drainMessages(c), "room_state"); msg != nil {
+			t.Fatalf("expected no room_state for a deleted room, got %+v", msg)
+		}
+	}
+}
+
+func TestRoomStateNeverFollowsRoomEnded(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		hub := newHub(4)
+		rid := mustTestRoomID(t)
+		host := fakeClient(hub)
+		peers := []*Client{fakeClient(hub), fakeClient(hub)}
+		hub.registerClient(host)
+		hub.handleMessage(host, joinPayload(rid, 4, 4))
+		for _, p := range peers {
+			hub.registerClient(p)
+			hub.handleMessage(p, joinPayload(rid, 4, 4))
+		}
+		drainMessages(host)
+		for _, p := range peers {
+			drainMessages(p)
+		}
+
+		// A peer leaving (which broadcasts room_state to the rest) races the
+		// host ending the room.
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			hub.handleMessage(peers[0], []byte(`{"v":1,"type":"leave","rid":"`+rid+`"}`))
+		}()
+		go func() {
+			defer wg.Done()
+			hub.handleMessage(host, []byte(`{"v":1,"type":"end_room","rid":"`+rid+`"}`))
+		}()
+		wg.Wait()
+
+		for _, c := range []*Client{host, peers[1]} {
+			ended := false
+			for _, msg := range drainMessages(c) {
+				switch msg.Type {
+				case "room_ended":
+					ended = true
+				case "room_state":
+					if ended {
+						t.Fatalf("iteration %d: room_state delivered after room_ended", i)
+					}
+				}
+			}
+		}
+	}
+}
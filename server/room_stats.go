@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RoomStats is one room's counters, for debugging a single call without the
+// noise of the server-wide stats.
+type RoomStats struct {
+	RID              string                 `json:"roomId"`
+	HostCID          string                 `json:"hostCid"`
+	ParticipantCount int                    `json:"participantCount"`
+	AgeMs            int64                  `json:"ageMs"`        // since the room was created
+	RelayedTotal     int64                  `json:"relayedTotal"` // including senders who have since left
+	Participants     []ParticipantRoomStats `json:"participants"` // current participants, in join order
+}
+
+type ParticipantRoomStats struct {
+	CID      string `json:"cid"`
+	JoinedAt int64  `json:"joinedAt"`
+	Relayed  int64  `json:"relayed"` // messages this CID sent for relay while in the room
+}
+
+// roomStats reads rid's counters under the room lock. Reports false if the
+// room does not exist.
+func (h *Hub) roomStats(rid string, now time.Time) (RoomStats, bool) {
+	h.mu.RLock()
+	room, exists := h.rooms[rid]
+	h.mu.RUnlock()
+	if !exists {
+		return RoomStats{}, false
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	snapshot := RoomStats{
+		RID:              rid,
+		HostCID:          room.HostCID,
+		ParticipantCount: len(room.Participants),
+		RelayedTotal:     room.relayedTotal,
+		Participants:     make([]ParticipantRoomStats, 0, len(room.Participants)),
+	}
+	if room.createdAt > 0 {
+		snapshot.AgeMs = now.UnixMilli() - room.createdAt
+	}
+	for _, cid := range room.Participants {
+		snapshot.Participants = append(snapshot.Participants, ParticipantRoomStats{
+			CID:      cid,
+			JoinedAt: room.JoinedAt[cid],
+			Relayed:  room.relayedByCID[cid],
+		})
+	}
+	sort.Slice(snapshot.Participants, func(i, j int) bool {
+		a, b := snapshot.Participants[i], snapshot.Participants[j]
+		if a.JoinedAt != b.JoinedAt {
+			return a.JoinedAt < b.JoinedAt
+		}
+		return a.CID < b.CID
+	})
+	return snapshot, true
+}
+
+func handleInternalRoomStats(hub *Hub) http.HandlerFunc {
+	access := internalAccessFromEnv()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !access.authorize(w, r, http.MethodGet) {
+			return
+		}
+
+		rid := strings.TrimSpace(r.URL.Query().Get("roomId"))
+		if rid == "" {
+			http.Error(w, "Missing roomId", http.StatusBadRequest)
+			return
+		}
+		snapshot, ok := hub.roomStats(rid, time.Now())
+		if !ok {
+			http.Error(w, "Room not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(snapshot)
+	}
+}
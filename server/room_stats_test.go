@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestInternalRoomStatsRequiresToken(t *testing.T) {
+	t.Setenv("ENABLE_INTERNAL_STATS", "1")
+	t.Setenv("INTERNAL_STATS_TOKEN", "test-token")
+
+	rec := httptest.NewRecorder()
+	handleInternalRoomStats(newHub(4)).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/internal/room-stats?roomId=any", nil))
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected %d, got %d", http.StatusUnauthorized, rec.Code)
+	}
+}
+
+func TestInternalRoomStatsUnknownRoom(t *testing.T) {
+	t.Setenv("ENABLE_INTERNAL_STATS", "1")
+	t.Setenv("INTERNAL_STATS_TOKEN", "test-token")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/internal/room-stats?roomId=missing", nil)
+	req.Header.Set("X-Internal-Token", "test-token")
+	rec := httptest.NewRecorder()
+	handleInternalRoomStats(newHub(4)).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected %d, got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestInternalRoomStatsCountsRelays(t *testing.T) {
+	t.Setenv("ENABLE_INTERNAL_STATS", "1")
+	t.Setenv("INTERNAL_STATS_TOKEN", "test-token")
+
+	hub, rid, clients := joinedMeshRoom(t, 2)
+	hub.handleMessage(clients[0], iceMessage(rid))
+	hub.handleMessage(clients[0], iceMessage(rid))
+	hub.handleMessage(clients[1], iceMessage(rid))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/internal/room-stats?roomId="+rid, nil)
+	req.Header.Set("X-Internal-Token", "test-token")
+	rec := httptest.NewRecorder()
+	handleInternalRoomStats(hub).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var got RoomStats
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.RID != rid || got.ParticipantCount != 2 || got.HostCID != clients[0].cid {
+		t.Fatalf("unexpected room stats %+v", got)
+	}
+	if got.RelayedTotal != 3 || got.AgeMs < 0 {
+		t.Fatalf("expected 3 relays and a non-negative age, got %+v", got)
+	}
+	relayed := map[string]int64{}
+	for _, p := range got.Participants {
+		relayed[p.CID] = p.Relayed
+	}
+	if relayed[clients[0].cid] != 2 || relayed[clients[1].cid] != 1 {
+		t.Fatalf("expected per-participant relay counts 2 and 1, got %v", relayed)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultRoomStatusDebounce is short enough that the lobby still feels live
+// but long enough to absorb a quick leave/rejoin after a network blip.
+const defaultRoomStatusDebounce = 250 * time.Millisecond
+
+// parseRoomStatusDebounce reads ROOM_STATUS_DEBOUNCE_MS. Unset or invalid
+// values use the default; 0 sends every change immediately.
+func parseRoomStatusDebounce(raw string) time.Duration {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return defaultRoomStatusDebounce
+	}
+	ms, err := strconv.Atoi(raw)
+	if err != nil || ms < 0 {
+		return defaultRoomStatusDebounce
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// roomStatusDebouncer holds at most one pending room_status_update per room.
+// The first change in a window schedules it; later changes in the same window
+// are folded in, since the update reads the room's count when it fires.
+type roomStatusDebouncer struct {
+	window time.Duration
+
+	mu      sync.Mutex
+	pending map[string]*time.Timer
+}
+
+func newRoomStatusDebouncer(window time.Duration) *roomStatusDebouncer {
+	if window <= 0 {
+		return nil
+	}
+	return &roomStatusDebouncer{window: window, pending: make(map[string]*time.Timer)}
+}
+
+// schedule arranges for send(rid) to run once after the window, unless one is
+// already pending for rid.
+func (d *roomStatusDebouncer) schedule(rid string, send func(string)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.pending[rid]; ok {
+		return
+	}
+	d.pending[rid] = time.AfterFunc(d.window, func() {
+		d.mu.Lock()
+		delete(d.pending, rid)
+		d.mu.Unlock()
+		send(rid)
+	})
+}
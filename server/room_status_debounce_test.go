@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseRoomStatusDebounce(t *testing.T) {
+	cases := map[string]time.Duration{
+		"":      defaultRoomStatusDebounce,
+		"bogus": defaultRoomStatusDebounce,
+		"-1":    defaultRoomStatusDebounce,
+		"0":     0,
+		"1000":  time.Second,
+	}
+	for raw, want := range cases {
+		if got := parseRoomStatusDebounce(raw); got != want {
+			t.Errorf("parseRoomStatusDebounce(%q) = %s, want %s", raw, got, want)
+		}
+	}
+	if newRoomStatusDebouncer(0) != nil {
+		t.Error("expected a zero window to disable debouncing")
+	}
+}
+
+func TestRoomStatusUpdatesCoalesceReconnectChurn(t *testing.T) {
+	hub := newHub(4)
+	hub.statusDebounce = newRoomStatusDebouncer(50 * time.Millisecond)
+	rid := mustTestRoomID(t)
+
+	host := fakeClient(hub)
+	hub.registerClient(host)
+	hub.handleMessage(host, joinPayload(rid, 4, 4))
+
+	watcher := fakeClient(hub)
+	hub.registerClient(watcher)
+	hub.handleMessage(watcher, watchRoomsPayload([]string{rid}))
+	drainMessages(watcher)
+
+	// A peer blips: join, drop, rejoin within the window.
+	peer := fakeClient(hub)
+	hub.registerClient(peer)
+	hub.handleMessage(peer, joinPayload(rid, 4, 4))
+	hub.handleMessage(peer, []byte(`{"v":1,"type":"leave","rid":"`+rid+`"}`))
+	hub.handleMessage(peer, joinPayload(rid, 4, 4))
+
+	if msg := findMessage(drainMessages(watcher), "room_status_update"); msg != nil {
+		t.Fatal("expected updates to be held for the debounce window")
+	}
+
+	var updates []Message
+	deadline := time.After(time.Second)
+	for len(updates) == 0 {
+		select {
+		case raw := <-watcher.send:
+			var msg Message
+			if err := json.Unmarshal(raw, &msg); err == nil && msg.Type == "room_status_update" {
+				updates = append(updates, msg)
+			}
+		case <-deadline:
+			t.Fatal("expected a settled room_status_update")
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+	if msg := findMessage(drainMessages(watcher), "room_status_update"); msg != nil {
+		t.Fatal("expected churn to coalesce into a single update")
+	}
+
+	var payload struct {
+		Count int `json:"count"`
+	}
+	if err := json.Unmarshal(updates[0].Payload, &payload); err != nil {
+		t.Fatalf("bad payload: %v", err)
+	}
+	if payload.Count != 2 {
+		t.Fatalf("expected settled count 2, got %d", payload.Count)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+const maxRoomStatusesPerRequest = 50
+
+// handleRoomStatuses is the one-shot HTTP counterpart of watch_rooms: it
+// returns the same room_statuses payload for the requested rooms without a
+// signaling connection. Room IDs that fail validation are omitted, so only
+// holders of valid room tokens learn anything.
+func handleRoomStatuses(hub *Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var body struct {
+			RIDs []string `json:"rids"`
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxMessageSize)
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if len(body.RIDs) > maxRoomStatusesPerRequest {
+			http.Error(w, "Too many room IDs", http.StatusBadRequest)
+			return
+		}
+
+		valid := make([]string, 0, len(body.RIDs))
+		for _, rid := range body.RIDs {
+			if err := validateRoomID(rid); err != nil {
+				if errors.Is(err, ErrRoomIDSecretMissing) {
+					http.Error(w, "Room ID service unavailable", http.StatusServiceUnavailable)
+					return
+				}
+				continue
+			}
+			valid = append(valid, rid)
+		}
+
+		status := make(map[string]map[string]int, len(valid))
+		hub.mu.RLock()
+		for _, rid := range valid {
+			status[rid] = hub.roomStatusLocked(rid)
+		}
+		hub.mu.RUnlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(status)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func postRoomStatuses(t *testing.T, hub *Hub, rids []string) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(map[string]interface{}{"rids": rids})
+	req := httptest.NewRequest(http.MethodPost, "/api/room-statuses", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	handleRoomStatuses(hub).ServeHTTP(w, req)
+	return w
+}
+
+func TestHandleRoomStatusesReturnsCountsForValidRooms(t *testing.T) {
+	active := mustTestRoomID(t)
+	empty := mustTestRoomID(t)
+	hub := newHub(4)
+
+	host := fakeClient(hub)
+	hub.registerClient(host)
+	hub.handleMessage(host, joinPayload(active, 4, 4))
+
+	w := postRoomStatuses(t, hub, []string{active, empty, "not-a-room-id"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	var status map[string]map[string]int
+	if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(status) != 2 {
+		t.Fatalf("expected invalid room ID to be omitted, got %v", status)
+	}
+	if status[active]["count"] != 1 || status[active]["maxParticipants"] != 2 {
+		t.Fatalf("unexpected status for active room: %v", status[active])
+	}
+	if status[empty]["count"] != 0 {
+		t.Fatalf("unexpected status for empty room: %v", status[empty])
+	}
+
+	hub.mu.RLock()
+	defer hub.mu.RUnlock()
+	if len(hub.watchers) != 0 {
+		t.Fatalf("HTTP status query must not register watchers")
+	}
+}
+
+func TestHandleRoomStatusesRejectsTooManyRooms(t *testing.T) {
+	rids := make([]string, maxRoomStatusesPerRequest+1)
+	for i := range rids {
+		rids[i] = "x"
+	}
+
+	w := postRoomStatuses(t, newHub(4), rids)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestHandleRoomStatusesWrongMethod(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/room-statuses", nil)
+	w := httptest.NewRecorder()
+	handleRoomStatuses(newHub(4)).ServeHTTP(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", w.Code)
+	}
+}
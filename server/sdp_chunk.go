@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultMaxChunkedSDPBytes = 256 * 1024
+	maxSDPChunks              = 64
+)
+
+// maxChunkedSDPBytes caps the reassembled size of one offer-chunk stream.
+// Overridden from MAX_CHUNKED_SDP_BYTES at startup.
+var maxChunkedSDPBytes = defaultMaxChunkedSDPBytes
+
+func parseMaxChunkedSDPBytes(raw string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || n < maxMessageSize {
+		return defaultMaxChunkedSDPBytes
+	}
+	return n
+}
+
+// sdpChunkStream tracks the in-progress offer-chunk sequence from one sender.
+type sdpChunkStream struct {
+	// target identifies the stream's recipients (see chunkTarget).
+	target string
+	total  int
+	next   int
+	bytes  int
+}
+
+var (
+	errChunkTotal    = errors.New("invalid chunk total")
+	errChunkOrder    = errors.New("chunk out of order")
+	errChunkTooLarge = errors.New("chunked SDP too large")
+)
+
+// chunkTarget keys a chunk's recipients: the sorted toList when one is given,
+// else to ("" for every other participant).
+func chunkTarget(msg Message) string {
+	if len(msg.ToList) == 0 {
+		return msg.To
+	}
+	cids := append([]string(nil), msg.ToList...)
+	sort.Strings(cids)
+	return strings.Join(cids, ",")
+}
+
+// nextSDPChunkStream validates the next chunk against current and returns the
+// stream state once it is relayed: nil when it completes the offer. A chunk
+// with index 0 starts a new stream. It does not modify current.
+func nextSDPChunkStream(current *sdpChunkStream, target string, index, total, size int) (*sdpChunkStream, error) {
+	if total < 1 || total > maxSDPChunks {
+		return nil, errChunkTotal
+	}
+
+	stream := current
+	if index == 0 {
+		stream = &sdpChunkStream{target: target, total: total}
+	}
+	if stream == nil || index != stream.next || total != stream.total || target != stream.target {
+		return nil, errChunkOrder
+	}
+
+	next := *stream
+	next.bytes += size
+	if next.bytes > maxChunkedSDPBytes {
+		return nil, errChunkTooLarge
+	}
+
+	next.next++
+	if next.next == next.total {
+		return nil, nil
+	}
+	return &next, nil
+}
+
+// handleOfferChunk relays one piece of an offer too large for a single
+// message. Receivers concatenate data in index order once total chunks arrive.
+func (h *Hub) handleOfferChunk(c *Client, msg Message) {
+	var payload struct {
+		Index int    `json:"index"`
+		Total int    `json:"total"`
+		Data  string `json:"data"`
+	}
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		c.sendError(msg.RID, "BAD_REQUEST", "Invalid payload")
+		return
+	}
+
+	c.chunkMu.Lock()
+	defer c.chunkMu.Unlock()
+	next, err := nextSDPChunkStream(c.chunkStream, chunkTarget(msg), payload.Index, payload.Total, len(payload.Data))
+	if err != nil {
+		// The receiver cannot reassemble a broken sequence, so drop it.
+		c.chunkStream = nil
+		c.sendError(msg.RID, "CHUNK_INVALID", err.Error())
+		return
+	}
+
+	// A chunk the relay rejects, or that every recipient's queue dropped, was
+	// never seen, so the stream only advances once some copy is enqueued.
+	if h.handleRelay(c, msg) > 0 {
+		c.chunkStream = next
+	}
+}
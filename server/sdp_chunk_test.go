@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func offerChunkPayload(rid, to string, index, total int, data string) []byte {
+	payloadBytes, _ := json.Marshal(map[string]interface{}{
+		"index": index,
+		"total": total,
+		"data":  data,
+	})
+	b, _ := json.Marshal(Message{V: 1, Type: "offer-chunk", RID: rid, To: to, Payload: payloadBytes})
+	return b
+}
+
+func joinedPair(t *testing.T) (*Hub, string, *Client, *Client, string) {
+	t.Helper()
+	rid := mustTestRoomID(t)
+	hub := newHub(4)
+
+	sender := fakeClient(hub)
+	hub.registerClient(sender)
+	hub.handleMessage(sender, joinPayload(rid, 4, 4))
+
+	receiver := fakeClient(hub)
+	hub.registerClient(receiver)
+	hub.handleMessage(receiver, joinPayload(rid, 4, 4))
+
+	joined := findMessage(drainMessages(receiver), "joined")
+	if joined == nil {
+		t.Fatal("expected receiver to join")
+	}
+	drainMessages(sender)
+	return hub, rid, sender, receiver, joined.CID
+}
+
+func TestOfferChunksRelayedInOrderAndReassemble(t *testing.T) {
+	hub, rid, sender, receiver, receiverCID := joinedPair(t)
+
+	parts := []string{"v=0\r\n", "o=- 1 2 IN IP4 0.0.0.0\r\n", "s=-\r\n"}
+	for i, part := range parts {
+		hub.handleMessage(sender, offerChunkPayload(rid, receiverCID, i, len(parts), part))
+	}
+	if msg := findMessage(drainMessages(sender), "error"); msg != nil {
+		t.Fatalf("unexpected error: %s", errorCode(msg))
+	}
+
+	var assembled strings.Builder
+	for i, msg := range drainMessages(receiver) {
+		var payload struct {
+			Index int    `json:"index"`
+			Data  string `json:"data"`
+			From  string `json:"from"`
+		}
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			t.Fatalf("decode chunk: %v", err)
+		}
+		if msg.Type != "offer-chunk" || payload.Index != i || payload.From == "" {
+			t.Fatalf("unexpected chunk %d: %+v", i, payload)
+		}
+		assembled.WriteString(payload.Data)
+	}
+	if assembled.String() != strings.Join(parts, "") {
+		t.Fatalf("reassembled SDP mismatch: %q", assembled.String())
+	}
+}
+
+func TestOfferChunkOutOfOrderRejected(t *testing.T) {
+	hub, rid, sender, receiver, receiverCID := joinedPair(t)
+
+	hub.handleMessage(sender, offerChunkPayload(rid, receiverCID, 0, 3, "a"))
+	hub.handleMessage(sender, offerChunkPayload(rid, receiverCID, 2, 3, "c"))
+
+	if code := errorCode(findMessage(drainMessages(sender), "error")); code != "CHUNK_INVALID" {
+		t.Fatalf("expected CHUNK_INVALID, got %q", code)
+	}
+	if n := len(drainMessages(receiver)); n != 1 {
+		t.Fatalf("expected only the first chunk relayed, got %d", n)
+	}
+
+	// The stream was discarded, so continuing it is rejected too.
+	hub.handleMessage(sender, offerChunkPayload(rid, receiverCID, 1, 3, "b"))
+	if code := errorCode(findMessage(drainMessages(sender), "error")); code != "CHUNK_INVALID" {
+		t.Fatalf("expected CHUNK_INVALID after reset, got %q", code)
+	}
+}
+
+func TestOfferChunkTotalSizeCapped(t *testing.T) {
+	original := maxChunkedSDPBytes
+	maxChunkedSDPBytes = 10
+	defer func() { maxChunkedSDPBytes = original }()
+
+	hub, rid, sender, receiver, receiverCID := joinedPair(t)
+
+	hub.handleMessage(sender, offerChunkPayload(rid, receiverCID, 0, 2, "123456"))
+	hub.handleMessage(sender, offerChunkPayload(rid, receiverCID, 1, 2, "789012"))
+
+	if code := errorCode(findMessage(drainMessages(sender), "error")); code != "CHUNK_INVALID" {
+		t.Fatalf("expected CHUNK_INVALID, got %q", code)
+	}
+	if n := len(drainMessages(receiver)); n != 1 {
+		t.Fatalf("expected oversized chunk not to be relayed, got %d messages", n)
+	}
+}
+
+func TestRejectedOfferChunkDoesNotAdvanceStream(t *testing.T) {
+	hub, rid, sender, receiver, receiverCID := joinedPair(t)
+
+	hub.handleMessage(sender, offerChunkPayload(rid, receiverCID, 0, 2, "a"))
+	// Restarting the stream at the sender's own CID fails in the relay, so
+	// the stream to the receiver must stay where it was.
+	hub.handleMessage(sender, offerChunkPayload(rid, sender.cid, 0, 2, "x"))
+	if code := errorCode(findMessage(drainMessages(sender), "error")); code != "SELF_RELAY" {
+		t.Fatalf("expected SELF_RELAY, got %q", code)
+	}
+
+	hub.handleMessage(sender, offerChunkPayload(rid, receiverCID, 1, 2, "b"))
+	if msg := findMessage(drainMessages(sender), "error"); msg != nil {
+		t.Fatalf("expected the stream to continue, got %s", errorCode(msg))
+	}
+	if n := len(drainMessages(receiver)); n != 2 {
+		t.Fatalf("expected both chunks relayed, got %d", n)
+	}
+}
+
+func TestDroppedOfferChunkDoesNotAdvanceStream(t *testing.T) {
+	hub, rid, sender, receiver, receiverCID := joinedPair(t)
+
+	// Every copy of the first chunk is dropped, so the receiver never sees
+	// the stream start and the next chunk cannot continue it.
+	receiver.closeSend()
+	hub.handleMessage(sender, offerChunkPayload(rid, receiverCID, 0, 2, "a"))
+	hub.handleMessage(sender, offerChunkPayload(rid, receiverCID, 1, 2, "b"))
+	if code := errorCode(findMessage(drainMessages(sender), "error")); code != "CHUNK_INVALID" {
+		t.Fatalf("expected CHUNK_INVALID, got %q", code)
+	}
+}
+
+func TestOfferChunkStreamKeyedOnToList(t *testing.T) {
+	hub, rid, sender, receiver, receiverCID := joinedPair(t)
+	third := fakeClient(hub)
+	hub.registerClient(third)
+	hub.handleMessage(third, joinPayload(rid, 4, 4))
+	drainMessages(sender)
+	drainMessages(receiver)
+
+	chunk := func(index int, toList []string) []byte {
+		payloadBytes, _ := json.Marshal(map[string]interface{}{"index": index, "total": 2, "data": "x"})
+		b, _ := json.Marshal(Message{V: 1, Type: "offer-chunk", RID: rid, ToList: toList, Payload: payloadBytes})
+		return b
+	}
+	hub.handleMessage(sender, chunk(0, []string{third.cid, receiverCID}))
+	// The same recipients in another order continue the stream.
+	hub.handleMessage(sender, chunk(1, []string{receiverCID, third.cid}))
+	if msg := findMessage(drainMessages(sender), "error"); msg != nil {
+		t.Fatalf("expected the stream to continue, got %s", errorCode(msg))
+	}
+
+	hub.handleMessage(sender, chunk(0, []string{receiverCID, third.cid}))
+	hub.handleMessage(sender, chunk(1, []string{receiverCID}))
+	if code := errorCode(findMessage(drainMessages(sender), "error")); code != "CHUNK_INVALID" {
+		t.Fatalf("expected CHUNK_INVALID when the toList changes mid-stream, got %q", code)
+	}
+}
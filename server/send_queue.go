@@ -0,0 +1,97 @@
+package main
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"serenada/server/internal/stats"
+)
+
+// SendQueuePolicy selects what sendMessage does when a client's send buffer
+// is full.
+type SendQueuePolicy string
+
+const (
+	SendQueueDropNewest SendQueuePolicy = "drop-newest" // drop the message being sent (default)
+	SendQueueDropOldest SendQueuePolicy = "drop-oldest" // drop the oldest queued message to make room
+	SendQueueDisconnect SendQueuePolicy = "disconnect"  // disconnect the slow consumer
+)
+
+const defaultSendQueueSize = 256
+
+// SendQueueConfig sizes each client's outbound buffer and picks its overflow
+// policy. Set from SEND_QUEUE_SIZE and SEND_QUEUE_POLICY at startup.
+type SendQueueConfig struct {
+	Size   int
+	Policy SendQueuePolicy
+}
+
+var defaultSendQueueConfig = SendQueueConfig{Size: defaultSendQueueSize, Policy: SendQueueDropNewest}
+
+func parseSendQueueConfig(sizeRaw, policyRaw string) SendQueueConfig {
+	cfg := defaultSendQueueConfig
+	if sizeRaw = strings.TrimSpace(sizeRaw); sizeRaw != "" {
+		if n, err := strconv.Atoi(sizeRaw); err == nil && n > 0 {
+			cfg.Size = n
+		} else {
+			log.Printf("Ignoring invalid SEND_QUEUE_SIZE %q", sizeRaw)
+		}
+	}
+	switch policy := SendQueuePolicy(strings.ToLower(strings.TrimSpace(policyRaw))); policy {
+	case "":
+	case SendQueueDropNewest, SendQueueDropOldest, SendQueueDisconnect:
+		cfg.Policy = policy
+	default:
+		log.Printf("Ignoring invalid SEND_QUEUE_POLICY %q", policyRaw)
+	}
+	return cfg
+}
+
+// newClient builds a client whose send buffer follows the hub's
+// SendQueueConfig.
+func (h *Hub) newClient(sid, ip string, transport TransportKind) *Client {
+	return &Client{
+		hub:        h,
+		send:       make(chan []byte, h.sendQueue.Size),
+		sendPolicy: h.sendQueue.Policy,
+		sid:        sid,
+		ip:         ip,
+		transport:  transport,
+	}
+}
+
+// overflowSend handles a full send buffer for b under c.sendPolicy. It
+// reports whether b was queued. Called with c.sendMu read-locked, and possibly
+// with hub and room locks held, so a disconnect runs on its own goroutine.
+func (c *Client) overflowSend(b []byte) bool {
+	stats.IncSendQueueDrop()
+	stats.ObserveSendQueueDepth(cap(c.send) + 1)
+	switch c.sendPolicy {
+	case SendQueueDropOldest:
+		stats.IncSendQueueOverflow(string(SendQueueDropOldest))
+		select {
+		case <-c.send:
+		default:
+		}
+		select {
+		case c.send <- b:
+			return true
+		default:
+			// Another sender took the freed slot.
+			return false
+		}
+	case SendQueueDisconnect:
+		stats.IncSendQueueOverflow(string(SendQueueDisconnect))
+		if c.hub != nil && c.slowConsumer.CompareAndSwap(false, true) {
+			log.Printf("[SEND] Client %s (CID: %s) send buffer full; disconnecting slow consumer", c.sid, c.cid)
+			c.hub.goConn(func() {
+				c.hub.disconnectClient(c, DisconnectSlowConsumer)
+			})
+		}
+		return false
+	default:
+		stats.IncSendQueueOverflow(string(SendQueueDropNewest))
+		return false
+	}
+}
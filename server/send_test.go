@@ -0,0 +1,158 @@
+package main
+
+import (
+	"sync"
+	"testing"
+
+	"serenada/server/internal/stats"
+)
+
+func TestSendMessageAfterCloseIsCountedNotDropped(t *testing.T) {
+	hub := newHub(4)
+	c := fakeClient(hub)
+
+	before := stats.SnapshotNow().Counters
+	c.closeSend()
+	c.closeSend() // idempotent
+
+	c.sendMessage(Message{V: 1, Type: "pong"})
+
+	after := stats.SnapshotNow().Counters
+	if after.SendAfterCloseTotal-before.SendAfterCloseTotal != 1 {
+		t.Fatalf("expected one send-after-close, got %d", after.SendAfterCloseTotal-before.SendAfterCloseTotal)
+	}
+	if after.SendQueueDropTotal != before.SendQueueDropTotal {
+		t.Fatalf("send after close must not be counted as a queue drop")
+	}
+}
+
+func TestSendMessageConcurrentWithClose(t *testing.T) {
+	hub := newHub(4)
+	c := fakeClient(hub)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.sendMessage(Message{V: 1, Type: "pong"})
+			}
+		}()
+	}
+	c.closeSend()
+	wg.Wait()
+}
+
+func TestParseSendQueueConfig(t *testing.T) {
+	if cfg := parseSendQueueConfig("", ""); cfg != defaultSendQueueConfig {
+		t.Fatalf("expected defaults, got %+v", cfg)
+	}
+	if cfg := parseSendQueueConfig(" 32 ", "Drop-Oldest"); cfg.Size != 32 || cfg.Policy != SendQueueDropOldest {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+	if cfg := parseSendQueueConfig("-1", "bogus"); cfg != defaultSendQueueConfig {
+		t.Fatalf("expected invalid values to fall back to defaults, got %+v", cfg)
+	}
+}
+
+func fullSendQueueClient(hub *Hub, policy SendQueuePolicy) *Client {
+	hub.sendQueue = SendQueueConfig{Size: 2, Policy: policy}
+	c := hub.newClient(generateID("S-"), "", TransportWS)
+	hub.registerClient(c)
+	c.sendMessage(Message{V: 1, Type: "first"})
+	c.sendMessage(Message{V: 1, Type: "second"})
+	return c
+}
+
+func TestSendQueueDropNewest(t *testing.T) {
+	c := fullSendQueueClient(newHub(4), SendQueueDropNewest)
+	before := stats.SnapshotNow().Counters
+
+	if c.sendMessage(Message{V: 1, Type: "third"}) {
+		t.Fatal("expected the newest message to be dropped")
+	}
+	msgs := drainMessages(c)
+	if len(msgs) != 2 || msgs[0].Type != "first" || msgs[1].Type != "second" {
+		t.Fatalf("expected the queued messages kept, got %+v", msgs)
+	}
+	after := stats.SnapshotNow().Counters
+	if after.SendQueueOverflowByPolicy["drop-newest"]-before.SendQueueOverflowByPolicy["drop-newest"] != 1 ||
+		after.SendQueueDropTotal-before.SendQueueDropTotal != 1 {
+		t.Fatalf("expected one drop-newest overflow counted")
+	}
+}
+
+func TestSendQueueDropOldest(t *testing.T) {
+	c := fullSendQueueClient(newHub(4), SendQueueDropOldest)
+	before := stats.SnapshotNow().Counters
+
+	if !c.sendMessage(Message{V: 1, Type: "third"}) {
+		t.Fatal("expected the newest message to be queued")
+	}
+	msgs := drainMessages(c)
+	if len(msgs) != 2 || msgs[0].Type != "second" || msgs[1].Type != "third" {
+		t.Fatalf("expected the oldest message dropped, got %+v", msgs)
+	}
+	after := stats.SnapshotNow().Counters
+	if after.SendQueueOverflowByPolicy["drop-oldest"]-before.SendQueueOverflowByPolicy["drop-oldest"] != 1 {
+		t.Fatalf("expected one drop-oldest overflow counted")
+	}
+}
+
+func TestSendQueueHighWaterCountsOverflow(t *testing.T) {
+	c := fullSendQueueClient(newHub(4), SendQueueDropNewest)
+	c.sendMessage(Message{V: 1, Type: "third"})
+
+	// The high-water mark is process-wide, so other tests may have raised it further.
+	if got := stats.SnapshotNow().SendQueueHighWater; got < 3 {
+		t.Fatalf("expected a full 2-message queue to report a high-water of at least 3, got %d", got)
+	}
+}
+
+func TestSendQueueDisconnectsSlowConsumer(t *testing.T) {
+	hub := newHub(4)
+	c := fullSendQueueClient(hub, SendQueueDisconnect)
+	before := stats.SnapshotNow().Counters
+
+	for i := 0; i < 3; i++ {
+		if c.sendMessage(Message{V: 1, Type: "overflow"}) {
+			t.Fatal("expected overflowing messages to be dropped")
+		}
+	}
+	hub.connWG.Wait()
+
+	if hub.isClientActive(c) {
+		t.Fatal("expected the slow consumer to be disconnected")
+	}
+	after := stats.SnapshotNow()
+	if after.Counters.SendQueueOverflowByPolicy["disconnect"]-before.SendQueueOverflowByPolicy["disconnect"] != 3 {
+		t.Fatalf("expected 3 disconnect overflows counted")
+	}
+	if after.Disconnects["slow_consumer"] < 1 {
+		t.Fatal("expected a slow_consumer disconnect counted")
+	}
+}
+
+func BenchmarkClientSendMessage(b *testing.B) {
+	hub := newHub(4)
+	c := &Client{hub: hub, send: make(chan []byte, 256), sid: generateID("S-")}
+	done := make(chan struct{})
+	go func() {
+		for range c.send {
+		}
+		close(done)
+	}()
+
+	msg := Message{V: 1, Type: "ice"}
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c.sendMessage(msg)
+		}
+	})
+	b.StopTimer()
+	c.closeSend()
+	<-done
+}
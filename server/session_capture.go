@@ -0,0 +1,367 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// maxSessionCaptureDuration stops a capture whose stop request never
+	// arrives, like maxCPUProfileDuration.
+	maxSessionCaptureDuration = 30 * time.Minute
+	// maxActiveSessionCaptures bounds how many rooms are captured at once.
+	maxActiveSessionCaptures = 8
+	// defaultSessionCaptureMaxBytes caps one capture file when
+	// INTERNAL_CAPTURE_MAX_BYTES is unset.
+	defaultSessionCaptureMaxBytes = 16 << 20
+	// sessionCaptureQueueSize bounds the lines waiting for a room's capture
+	// writer; record drops lines rather than wait for the disk.
+	sessionCaptureQueueSize = 1024
+)
+
+var (
+	errSessionCaptureActive   = errors.New("this room is already being captured")
+	errSessionCaptureInactive = errors.New("this room is not being captured")
+	errSessionCaptureLimit    = errors.New("too many rooms are being captured")
+)
+
+// sessionCaptureHeader is the first line of a capture file.
+type sessionCaptureHeader struct {
+	Capture   int    `json:"capture"` // file format version
+	RID       string `json:"rid"`
+	StartedAt int64  `json:"startedAt"` // unix ms
+	Scrubbed  bool   `json:"scrubbed"`
+}
+
+// sessionCaptureRecord is one signaling message in a capture file. Msg is the
+// envelope exactly as received from or queued to the client, unless scrubbed.
+type sessionCaptureRecord struct {
+	AtMs int64           `json:"at"`
+	Dir  string          `json:"dir"` // "in" (client to server) or "out"
+	SID  string          `json:"sid"`
+	CID  string          `json:"cid,omitempty"`
+	Msg  json.RawMessage `json:"msg"`
+}
+
+// SessionCaptureSummary describes a finished capture.
+type SessionCaptureSummary struct {
+	RID       string `json:"rid"`
+	Path      string `json:"path"`
+	Records   int64  `json:"records"`
+	Bytes     int64  `json:"bytes"`
+	Truncated bool   `json:"truncated"` // INTERNAL_CAPTURE_MAX_BYTES was hit and later messages were dropped
+	Dropped   int64  `json:"dropped"`   // messages dropped because the writer fell behind
+}
+
+// sessionCapture writes every signaling message of selected rooms to JSON
+// Lines files in dir, started and stopped through
+// /api/internal/capture/{start,stop}?rid=R so reported bugs can be replayed
+// with loadconduit --replay. Each room's file is written by its own
+// goroutine, so recording a message never waits for the disk.
+type sessionCapture struct {
+	dir      string
+	maxBytes int64
+
+	active atomic.Int32 // len(rooms), read without mu on the message path
+
+	mu    sync.RWMutex
+	rooms map[string]*roomCapture
+}
+
+type roomCapture struct {
+	scrub   bool
+	timer   *time.Timer
+	lines   chan []byte   // closed by stop, with s.mu held
+	done    chan struct{} // closed once the writer has closed the file
+	dropped atomic.Int64
+
+	// Owned by the writer goroutine until done is closed.
+	file      *os.File
+	w         *bufio.Writer
+	records   int64
+	bytes     int64
+	truncated bool
+	err       error
+}
+
+// newSessionCaptureFromEnv returns nil unless ENABLE_INTERNAL_CAPTURE=1. The
+// endpoints additionally require the internal stats gate and token.
+func newSessionCaptureFromEnv() (*sessionCapture, error) {
+	if strings.TrimSpace(os.Getenv("ENABLE_INTERNAL_CAPTURE")) != "1" {
+		return nil, nil
+	}
+	dir := strings.TrimSpace(os.Getenv("INTERNAL_CAPTURE_DIR"))
+	if dir == "" {
+		return nil, errors.New("INTERNAL_CAPTURE_DIR is required when ENABLE_INTERNAL_CAPTURE=1")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	maxBytes := int64(defaultSessionCaptureMaxBytes)
+	if raw := strings.TrimSpace(os.Getenv("INTERNAL_CAPTURE_MAX_BYTES")); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid INTERNAL_CAPTURE_MAX_BYTES %q", raw)
+		}
+		maxBytes = parsed
+	}
+	return newSessionCapture(dir, maxBytes), nil
+}
+
+func newSessionCapture(dir string, maxBytes int64) *sessionCapture {
+	return &sessionCapture{dir: dir, maxBytes: maxBytes, rooms: make(map[string]*roomCapture)}
+}
+
+func (s *sessionCapture) start(rid string, scrub bool) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rooms[rid] != nil {
+		return "", errSessionCaptureActive
+	}
+	if len(s.rooms) >= maxActiveSessionCaptures {
+		return "", errSessionCaptureLimit
+	}
+
+	now := time.Now()
+	path := filepath.Join(s.dir, fmt.Sprintf("capture-%s-%d.jsonl", rid, now.Unix()))
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	rc := &roomCapture{
+		file:  f,
+		w:     bufio.NewWriter(f),
+		scrub: scrub,
+		lines: make(chan []byte, sessionCaptureQueueSize),
+		done:  make(chan struct{}),
+	}
+	header, _ := json.Marshal(sessionCaptureHeader{Capture: 1, RID: rid, StartedAt: now.UnixMilli(), Scrubbed: scrub})
+	rc.write(append(header, '\n'))
+	go rc.run(rid, s.maxBytes)
+	rc.timer = time.AfterFunc(maxSessionCaptureDuration, func() {
+		if summary, err := s.stop(rid); err == nil {
+			log.Printf("[CAPTURE] Room %s capture hit %s limit; wrote %s", rid, maxSessionCaptureDuration, summary.Path)
+		}
+	})
+	s.rooms[rid] = rc
+	s.active.Store(int32(len(s.rooms)))
+	return path, nil
+}
+
+// stop ends the capture for rid and waits for its writer to flush the file.
+func (s *sessionCapture) stop(rid string) (SessionCaptureSummary, error) {
+	s.mu.Lock()
+	rc := s.rooms[rid]
+	if rc == nil {
+		s.mu.Unlock()
+		return SessionCaptureSummary{}, errSessionCaptureInactive
+	}
+	delete(s.rooms, rid)
+	s.active.Store(int32(len(s.rooms)))
+	close(rc.lines)
+	s.mu.Unlock()
+
+	rc.timer.Stop()
+	<-rc.done
+	return SessionCaptureSummary{
+		RID:       rid,
+		Path:      rc.file.Name(),
+		Records:   rc.records,
+		Bytes:     rc.bytes,
+		Truncated: rc.truncated,
+		Dropped:   rc.dropped.Load(),
+	}, rc.err
+}
+
+// record queues raw, a message sent ("out") or received ("in") by c, for the
+// capture of rid if there is one. It never blocks: the line is built before
+// s.mu is taken, and is dropped if the room's writer is behind. Safe to call
+// with hub and room locks held; s.mu is never held while taking them.
+func (s *sessionCapture) record(rid, dir string, c *Client, raw []byte) {
+	if s == nil || rid == "" || s.active.Load() == 0 {
+		return
+	}
+	now := time.Now()
+
+	s.mu.RLock()
+	rc := s.rooms[rid]
+	s.mu.RUnlock()
+	if rc == nil {
+		return
+	}
+	msg := json.RawMessage(raw)
+	if rc.scrub {
+		msg = scrubCapturedMessage(raw)
+	}
+	line, err := json.Marshal(sessionCaptureRecord{AtMs: now.UnixMilli(), Dir: dir, SID: c.sid, CID: c.cid, Msg: msg})
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+
+	// Held across the send so stop cannot close lines under it.
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.rooms[rid] != rc {
+		return
+	}
+	select {
+	case rc.lines <- line:
+	default:
+		rc.dropped.Add(1)
+	}
+}
+
+// run writes queued lines to the capture file until stop closes lines, then
+// flushes and closes the file.
+func (rc *roomCapture) run(rid string, maxBytes int64) {
+	defer close(rc.done)
+	for line := range rc.lines {
+		if rc.truncated {
+			continue
+		}
+		if rc.bytes+int64(len(line)) > maxBytes {
+			rc.truncated = true
+			log.Printf("[CAPTURE] Room %s capture reached %d bytes; dropping later messages", rid, maxBytes)
+			continue
+		}
+		rc.write(line)
+		rc.records++
+	}
+	rc.err = rc.w.Flush()
+	if closeErr := rc.file.Close(); rc.err == nil {
+		rc.err = closeErr
+	}
+}
+
+// outboundCaptureRID is the room a message queued to c belongs to: the
+// message's own rid (e.g. an error for a rejected join), else c's room.
+func outboundCaptureRID(c *Client, msg interface{}) string {
+	switch v := msg.(type) {
+	case Message:
+		if v.RID != "" {
+			return v.RID
+		}
+	case *Message:
+		if v != nil && v.RID != "" {
+			return v.RID
+		}
+	}
+	return c.rid
+}
+
+func (rc *roomCapture) write(line []byte) {
+	n, _ := rc.w.Write(line)
+	rc.bytes += int64(n)
+}
+
+// capturedPayloadKeys are replaced by scrubbedValue wherever they hold a
+// string: SDP and ICE candidates carry addresses, the rest are credentials.
+var capturedPayloadKeys = map[string]bool{
+	"sdp":              true,
+	"candidate":        true,
+	"usernameFragment": true,
+	"reconnectToken":   true,
+	"turnToken":        true,
+	"token":            true,
+	"username":         true,
+	"password":         true,
+	"endpoint":         true,
+}
+
+const scrubbedValue = "[scrubbed]"
+
+// scrubCapturedMessage returns raw with sensitive string values replaced,
+// keeping the message shape so it still replays. Invalid JSON is kept as a
+// JSON string.
+func scrubCapturedMessage(raw []byte) json.RawMessage {
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		quoted, _ := json.Marshal(string(raw))
+		return quoted
+	}
+	scrubbed, err := json.Marshal(scrubCapturedValue(value))
+	if err != nil {
+		return json.RawMessage(`null`)
+	}
+	return scrubbed
+}
+
+func scrubCapturedValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if _, isString := field.(string); isString && capturedPayloadKeys[key] {
+				v[key] = scrubbedValue
+				continue
+			}
+			v[key] = scrubCapturedValue(field)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = scrubCapturedValue(v[i])
+		}
+	}
+	return value
+}
+
+// handleInternalCapture serves POST /api/internal/capture/start and
+// /api/internal/capture/stop with ?rid=<rid>; start also takes &scrub=1. A nil
+// capture answers 404 like a disabled internal endpoint.
+func handleInternalCapture(s *sessionCapture) http.HandlerFunc {
+	access := internalAccessFromEnv()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s == nil {
+			http.NotFound(w, r)
+			return
+		}
+		if !access.authorize(w, r, http.MethodPost) {
+			return
+		}
+
+		rid := strings.TrimSpace(r.URL.Query().Get("rid"))
+		if err := validateRoomID(rid); err != nil {
+			http.Error(w, "Invalid rid", http.StatusBadRequest)
+			return
+		}
+
+		var response interface{}
+		var err error
+		switch strings.TrimPrefix(r.URL.Path, "/api/internal/capture/") {
+		case "start":
+			var path string
+			path, err = s.start(rid, r.URL.Query().Get("scrub") == "1")
+			response = map[string]string{"rid": rid, "path": path}
+		case "stop":
+			response, err = s.stop(rid)
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		switch {
+		case errors.Is(err, errSessionCaptureActive), errors.Is(err, errSessionCaptureInactive), errors.Is(err, errSessionCaptureLimit):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			log.Printf("[CAPTURE] Room %s: %v", rid, err)
+			http.Error(w, "Session capture failed", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(response)
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func captureRequest(action, rid string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/internal/capture/"+action+"?rid="+rid, nil)
+	req.Header.Set("X-Internal-Token", "test-token")
+	return req
+}
+
+func readCaptureFile(t *testing.T, path string) (sessionCaptureHeader, []sessionCaptureRecord) {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open capture: %v", err)
+	}
+	defer f.Close()
+
+	var header sessionCaptureHeader
+	var records []sessionCaptureRecord
+	scanner := bufio.NewScanner(f)
+	for first := true; scanner.Scan(); first = false {
+		if first {
+			if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+				t.Fatalf("decode header: %v", err)
+			}
+			continue
+		}
+		var record sessionCaptureRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("decode record: %v", err)
+		}
+		records = append(records, record)
+	}
+	return header, records
+}
+
+func TestNewSessionCaptureFromEnv(t *testing.T) {
+	t.Setenv("ENABLE_INTERNAL_CAPTURE", "")
+	if s, err := newSessionCaptureFromEnv(); s != nil || err != nil {
+		t.Fatalf("expected capture disabled by default, got %v, %v", s, err)
+	}
+
+	t.Setenv("ENABLE_INTERNAL_CAPTURE", "1")
+	t.Setenv("INTERNAL_CAPTURE_DIR", "")
+	if _, err := newSessionCaptureFromEnv(); err == nil {
+		t.Fatal("expected an error when enabled without a directory")
+	}
+
+	t.Setenv("INTERNAL_CAPTURE_DIR", t.TempDir())
+	t.Setenv("INTERNAL_CAPTURE_MAX_BYTES", "nope")
+	if _, err := newSessionCaptureFromEnv(); err == nil {
+		t.Fatal("expected an error for an invalid byte limit")
+	}
+
+	t.Setenv("INTERNAL_CAPTURE_MAX_BYTES", "4096")
+	s, err := newSessionCaptureFromEnv()
+	if s == nil || err != nil || s.maxBytes != 4096 {
+		t.Fatalf("expected capture with a 4096 byte limit, got %v, %v", s, err)
+	}
+}
+
+func TestInternalCaptureDisabledReturnsNotFound(t *testing.T) {
+	t.Setenv("ENABLE_INTERNAL_STATS", "1")
+	t.Setenv("INTERNAL_STATS_TOKEN", "test-token")
+
+	rr := httptest.NewRecorder()
+	handleInternalCapture(nil).ServeHTTP(rr, captureRequest("start", mustTestRoomID(t)))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rr.Code)
+	}
+}
+
+func TestInternalCaptureRecordsRoomTraffic(t *testing.T) {
+	t.Setenv("ENABLE_INTERNAL_STATS", "1")
+	t.Setenv("INTERNAL_STATS_TOKEN", "test-token")
+	rid := mustTestRoomID(t)
+	otherRID := mustTestRoomID(t)
+
+	hub := newHub(4)
+	hub.capture = newSessionCapture(t.TempDir(), defaultSessionCaptureMaxBytes)
+	handler := handleInternalCapture(hub.capture)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, captureRequest("start", rid))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("start: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, captureRequest("start", rid))
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("second start: expected 409, got %d", rr.Code)
+	}
+
+	host := fakeClient(hub)
+	peer := fakeClient(hub)
+	outsider := fakeClient(hub)
+	for _, c := range []*Client{host, peer, outsider} {
+		hub.registerClient(c)
+	}
+	hub.handleMessage(host, joinPayload(rid, 4, 4))
+	hub.handleMessage(peer, joinPayload(rid, 4, 4))
+	hub.handleMessage(outsider, joinPayload(otherRID, 4, 4))
+	offer, _ := json.Marshal(Message{V: 1, Type: "offer", RID: rid, Payload: json.RawMessage(`{"sdp":"v=0 secret"}`)})
+	hub.handleMessage(host, offer)
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, captureRequest("stop", rid))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("stop: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var summary SessionCaptureSummary
+	if err := json.Unmarshal(rr.Body.Bytes(), &summary); err != nil {
+		t.Fatalf("decode summary: %v", err)
+	}
+
+	header, records := readCaptureFile(t, summary.Path)
+	if header.RID != rid || header.Scrubbed {
+		t.Fatalf("unexpected header %+v", header)
+	}
+	if int64(len(records)) != summary.Records || summary.Truncated {
+		t.Fatalf("summary %+v does not match %d records", summary, len(records))
+	}
+
+	var joinsIn, joinedOut, offersOut int
+	for _, record := range records {
+		if record.SID == outsider.sid {
+			t.Fatalf("captured traffic from another room: %s", record.Msg)
+		}
+		var msg Message
+		_ = json.Unmarshal(record.Msg, &msg)
+		switch {
+		case record.Dir == "in" && msg.Type == "join":
+			joinsIn++
+		case record.Dir == "out" && msg.Type == "joined":
+			joinedOut++
+		case record.Dir == "out" && msg.Type == "offer":
+			offersOut++
+			if record.SID != peer.sid || !strings.Contains(string(record.Msg), "v=0 secret") {
+				t.Fatalf("expected unscrubbed offer delivered to the peer, got %+v", record)
+			}
+		}
+	}
+	if joinsIn != 2 || joinedOut != 2 || offersOut != 1 {
+		t.Fatalf("expected 2 joins in, 2 joined out and 1 offer out, got %d/%d/%d", joinsIn, joinedOut, offersOut)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, captureRequest("stop", rid))
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("second stop: expected 409, got %d", rr.Code)
+	}
+}
+
+func TestSessionCaptureScrubsAndTruncates(t *testing.T) {
+	t.Setenv("TURN_TOKEN_SECRET", "test-turn-token-secret")
+	rid := mustTestRoomID(t)
+	hub := newHub(4)
+	hub.capture = newSessionCapture(t.TempDir(), 2048)
+	if _, err := hub.capture.start(rid, true); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	c := fakeClient(hub)
+	hub.registerClient(c)
+	hub.handleMessage(c, joinPayload(rid, 2, 2))
+	drainMessages(c)
+	ice, _ := json.Marshal(Message{V: 1, Type: "ice", RID: rid, Payload: json.RawMessage(`{"candidate":{"candidate":"candidate:1 1 udp 1 10.0.0.1 5000 typ host","sdpMid":"0"}}`)})
+	for i := 0; i < 50; i++ {
+		hub.handleMessage(c, ice)
+	}
+
+	summary, err := hub.capture.stop(rid)
+	if err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	if !summary.Truncated || summary.Bytes > 2048 {
+		t.Fatalf("expected a truncated capture within 2048 bytes, got %+v", summary)
+	}
+	header, records := readCaptureFile(t, summary.Path)
+	if !header.Scrubbed {
+		t.Fatal("expected scrubbed header")
+	}
+	for _, record := range records {
+		raw := string(record.Msg)
+		if strings.Contains(raw, "10.0.0.1") || (strings.Contains(raw, `"reconnectToken"`) && !strings.Contains(raw, `"reconnectToken":"[scrubbed]"`)) {
+			t.Fatalf("expected sensitive values scrubbed, got %s", record.Msg)
+		}
+	}
+	if !strings.Contains(string(records[len(records)-1].Msg), `"sdpMid":"0"`) {
+		t.Fatalf("expected non-sensitive fields kept, got %s", records[len(records)-1].Msg)
+	}
+}
+
+func TestSessionCaptureRecordDropsWhenWriterBehind(t *testing.T) {
+	rid := mustTestRoomID(t)
+	s := newSessionCapture(t.TempDir(), defaultSessionCaptureMaxBytes)
+	// No writer drains this queue, as if the disk had stalled.
+	rc := &roomCapture{lines: make(chan []byte, 1), done: make(chan struct{})}
+	s.rooms[rid] = rc
+	s.active.Store(1)
+
+	c := fakeClient(newHub(4))
+	for i := 0; i < 3; i++ {
+		s.record(rid, "in", c, []byte(`{"v":1,"type":"ping"}`))
+	}
+	if got := rc.dropped.Load(); got != 2 {
+		t.Fatalf("expected 2 messages dropped once the queue was full, got %d", got)
+	}
+}
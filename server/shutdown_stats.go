@@ -0,0 +1,37 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+
+	"serenada/server/internal/fsutil"
+	"serenada/server/internal/stats"
+)
+
+// processStartedAt anchors the uptime reported in the final stats snapshot.
+var processStartedAt = time.Now()
+
+// finalStatsReport is written to FINAL_STATS_PATH on graceful shutdown so
+// short-lived (e.g. load-test) instances leave a post-mortem of their lifetime.
+type finalStatsReport struct {
+	StartedAtRFC3339 string         `json:"startedAt"`
+	StoppedAtRFC3339 string         `json:"stoppedAt"`
+	UptimeSeconds    float64        `json:"uptimeSeconds"`
+	Stats            stats.Snapshot `json:"stats"`
+}
+
+func (h *Hub) writeFinalStats(path string) error {
+	h.refreshStatsGauges()
+	now := time.Now()
+	report := finalStatsReport{
+		StartedAtRFC3339: processStartedAt.UTC().Format(time.RFC3339),
+		StoppedAtRFC3339: now.UTC().Format(time.RFC3339),
+		UptimeSeconds:    now.Sub(processStartedAt).Seconds(),
+		Stats:            stats.SnapshotNow(),
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.AtomicWriteFile(path, append(data, '\n'))
+}
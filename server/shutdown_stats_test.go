@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFinalStatsWritesSnapshotWithUptime(t *testing.T) {
+	hub := newHub(4)
+	rid := mustTestRoomID(t)
+	c := fakeClient(hub)
+	hub.registerClient(c)
+	hub.handleMessage(c, joinPayload(rid, 4, 4))
+
+	path := filepath.Join(t.TempDir(), "nested", "final-stats.json")
+	if err := hub.writeFinalStats(path); err != nil {
+		t.Fatalf("writeFinalStats: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read final stats: %v", err)
+	}
+	var report finalStatsReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("decode final stats: %v", err)
+	}
+	if report.UptimeSeconds <= 0 || report.StartedAtRFC3339 == "" || report.StoppedAtRFC3339 == "" {
+		t.Fatalf("expected uptime and timestamps, got %+v", report)
+	}
+	if report.Stats.Gauges.ActiveRooms != 1 {
+		t.Fatalf("expected refreshed gauges with 1 active room, got %d", report.Stats.Gauges.ActiveRooms)
+	}
+	if report.Stats.JoinLatency.Total == 0 {
+		t.Fatal("expected join latency histogram in the final snapshot")
+	}
+
+	leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".serenada-*.tmp"))
+	if len(leftovers) != 0 {
+		t.Fatalf("expected no temp files left behind, got %v", leftovers)
+	}
+}
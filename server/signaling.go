@@ -4,12 +4,17 @@ import (
 	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
-	"log"
+	"log/slog"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"serenada/server/internal/stats"
@@ -20,34 +25,107 @@ const maxMessageSize = 65536 // 64KB
 // TURN token TTL: 30 minutes. Clients proactively refresh at 80% of TTL.
 const turnTokenTTL = 30 * time.Minute
 
+// Reconnect tokens default to one hour; RECONNECT_TOKEN_TTL_SECONDS overrides.
+// Clients in longer calls get a fresh token with every turn-refreshed.
+const defaultReconnectTokenTTL = time.Hour
+
+// reconnectTokenVersion prefixes the current token format. Tokens without it
+// (the unversioned cid|rid HMAC) are rejected as invalid.
+const reconnectTokenVersion = "v2"
+
+var reconnectTokenTTL = defaultReconnectTokenTTL
+
+var (
+	errReconnectTokenInvalid = errors.New("invalid reconnect token")
+	errReconnectTokenExpired = errors.New("reconnect token expired")
+)
+
+func parseReconnectTokenTTL(raw string) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || seconds <= 0 {
+		return defaultReconnectTokenTTL
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// reconnectAllowTokenless lets a join that sends reconnectCid without a
+// reconnectToken evict that CID's ghost even when tokens are issued. It is
+// for clients too old to send the token (RECONNECT_ALLOW_TOKENLESS=1); without
+// it such a join gets a fresh CID and the ghost is left in place.
+var reconnectAllowTokenless = false
+
+func reconnectTokenSecret() string {
+	secret := os.Getenv("TURN_TOKEN_SECRET")
+	if secret == "" {
+		secret = os.Getenv("TURN_SECRET")
+	}
+	return secret
+}
+
+func reconnectTokenMAC(secret, cid, rid string, expiresUnix int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(reconnectTokenVersion + "|" + cid + "|" + rid + "|" + strconv.FormatInt(expiresUnix, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
 // issueReconnectToken generates an HMAC proof that allows a client to reclaim
-// its CID on reconnect. Format: hex(HMAC-SHA256(secret, cid|rid)).
+// its CID on reconnect until reconnectTokenTTL from now. Format:
+// v2.<expiresUnix>.hex(HMAC-SHA256(secret, v2|cid|rid|expiresUnix)).
 // The token is bound to (cid, rid) — NOT session id — because the session id
 // changes on every reconnect.
 func issueReconnectToken(cid, rid string) string {
-	secret := os.Getenv("TURN_TOKEN_SECRET")
+	return issueReconnectTokenAt(cid, rid, time.Now())
+}
+
+func issueReconnectTokenAt(cid, rid string, now time.Time) string {
+	secret := reconnectTokenSecret()
 	if secret == "" {
-		secret = os.Getenv("TURN_SECRET")
+		return ""
 	}
+	expiresUnix := now.Add(reconnectTokenTTL).Unix()
+	return reconnectTokenVersion + "." + strconv.FormatInt(expiresUnix, 10) + "." + reconnectTokenMAC(secret, cid, rid, expiresUnix)
+}
+
+// checkReconnectToken reports why token cannot reclaim (cid, rid) at now, or
+// nil if it can. Without a secret configured every token is accepted
+// (backwards compatible).
+func checkReconnectToken(token, cid, rid string, now time.Time) error {
+	secret := reconnectTokenSecret()
 	if secret == "" {
-		return ""
+		return nil
 	}
-	mac := hmac.New(sha256.New, []byte(secret))
-	mac.Write([]byte(cid + "|" + rid))
-	return hex.EncodeToString(mac.Sum(nil))
+	version, rest, _ := strings.Cut(token, ".")
+	expiresRaw, mac, ok := strings.Cut(rest, ".")
+	if version != reconnectTokenVersion || !ok {
+		return errReconnectTokenInvalid
+	}
+	expiresUnix, err := strconv.ParseInt(expiresRaw, 10, 64)
+	if err != nil || !hmac.Equal([]byte(reconnectTokenMAC(secret, cid, rid, expiresUnix)), []byte(mac)) {
+		return errReconnectTokenInvalid
+	}
+	if now.Unix() >= expiresUnix {
+		return errReconnectTokenExpired
+	}
+	return nil
 }
 
-// validateReconnectToken checks that the provided token matches the expected HMAC.
+// validateReconnectToken reports whether token currently proves ownership of
+// (cid, rid).
 func validateReconnectToken(token, cid, rid string) bool {
 	if token == "" {
 		return false
 	}
-	expected := issueReconnectToken(cid, rid)
-	if expected == "" {
-		// No secret configured — allow legacy clients (backwards compatible)
-		return true
+	return checkReconnectToken(token, cid, rid, time.Now()) == nil
+}
+
+// reconnectTokenVerified reports whether token proves ownership of (cid,
+// rid) on a server that issues reconnect tokens. Unlike
+// validateReconnectToken it never passes when no secret is configured.
+func reconnectTokenVerified(token, cid, rid string, now time.Time) bool {
+	if cid == "" || token == "" || reconnectTokenSecret() == "" {
+		return false
 	}
-	return hmac.Equal([]byte(expected), []byte(token))
+	return checkReconnectToken(token, cid, rid, now) == nil
 }
 
 type TransportKind string
@@ -65,12 +143,14 @@ type Message struct {
 	SID     string          `json:"sid,omitempty"`
 	CID     string          `json:"cid,omitempty"`
 	To      string          `json:"to,omitempty"`
+	ToList  []string        `json:"toList,omitempty"` // relay targets for a subset of peers; overrides To
 	Payload json.RawMessage `json:"payload,omitempty"`
 }
 
 type Participant struct {
-	CID      string `json:"cid"`
-	JoinedAt int64  `json:"joinedAt,omitempty"`
+	CID      string     `json:"cid"`
+	JoinedAt int64      `json:"joinedAt,omitempty"`
+	Media    MediaState `json:"media"`
 }
 
 type Hub struct {
@@ -79,30 +159,141 @@ type Hub struct {
 	mu                   sync.RWMutex
 	clients              map[*Client]bool
 	clientsBySID         map[string]*Client
-	maxParticipantsLimit int // server-wide ceiling for room capacity
+	maxParticipantsLimit int             // server-wide ceiling for room capacity
+	maxClients           int             // MAX_CONCURRENT_CLIENTS; 0 means unlimited
+	connLimit            *connLimiter    // per-IP WebSocket/SSE limits; nil means unlimited
+	sendQueue            SendQueueConfig // per-client send buffer size and overflow policy
+	hostLeavePolicy      HostLeavePolicy // what happens to a room when its host leaves
+
+	hotRooms   []RoomRelayRate // busiest rooms from the last relay-rate sample
+	hotRoomsMu sync.RWMutex
+
+	connWG    sync.WaitGroup // every per-connection goroutine, including grace-period disconnects
+	connLoops atomic.Int64   // running read/write loops
+
+	joinShed    *joinShedder // nil unless JOIN_SHED_P95_MS is set
+	usedRoomIDs *usedRoomIDs // nil unless SINGLE_USE_ROOM_ID_TTL_SECONDS is set
+	joinLimiter *IPLimiter   // nil unless JOIN_RATE_LIMIT_PER_MINUTE is set
+
+	reconnectGuard *reconnectGuard   // per-IP invalid reconnectToken tracking
+	sseResumes     *sseResumeTickets // one-time tickets for in-room SSE takeovers; see handleSSEResume
+
+	statusDebounce *roomStatusDebouncer // nil sends room_status_update on every change
+
+	capture *sessionCapture // nil unless ENABLE_INTERNAL_CAPTURE=1
+	audit   *auditLog       // nil unless AUDIT_LOG_FILE is set
+
+	draining atomic.Bool // set by startDrain; never cleared
+}
+
+// HostLeavePolicy selects what removeClientFromRoom does when the host leaves
+// a room that still has other participants.
+type HostLeavePolicy string
+
+const (
+	HostLeaveTransfer HostLeavePolicy = "transfer" // hand host to a remaining participant (default)
+	HostLeaveEnd      HostLeavePolicy = "end"      // end the room for everyone
+)
+
+func parseHostLeavePolicy(raw string) HostLeavePolicy {
+	if HostLeavePolicy(strings.ToLower(strings.TrimSpace(raw))) == HostLeaveEnd {
+		return HostLeaveEnd
+	}
+	return HostLeaveTransfer
 }
 
 type Room struct {
 	RID                      string
 	Participants             map[*Client]string // client -> cid
 	HostCID                  string
-	MaxParticipants          int              // effective room capacity; group-capable rooms stay provisional at 2 until participant #2 joins
-	RequestedMaxParticipants int              // creator's requested ceiling, clamped by creator capability and server ceiling
-	CapacityLocked           bool             // once true, MaxParticipants is final for the room lifetime
-	JoinedAt                 map[string]int64 // cid -> join timestamp (ms)
+	MaxParticipants          int                   // effective room capacity; group-capable rooms stay provisional at 2 until participant #2 joins
+	RequestedMaxParticipants int                   // creator's requested ceiling, clamped by creator capability and server ceiling
+	CapacityLocked           bool                  // once true, MaxParticipants is final for the room lifetime
+	JoinedAt                 map[string]int64      // cid -> join timestamp (ms)
+	KnocksEnabled            bool                  // creator opted in to knock requests from watchers
+	Locked                   bool                  // host closed the room to new joins via lock_room
+	Metadata                 *RoomMetadata         // host-set title/topic via set_room_meta; nil when unset
+	relayCount               int64                 // relays since the last hot-room sample
+	relayedTotal             int64                 // relays over the room's lifetime; see handleInternalRoomStats
+	relayedByCID             map[string]int64      // cid -> relays sent over the room's lifetime
+	createdAt                int64                 // unix ms
+	reconnectClaims          map[string]*Client    // cid -> join currently reclaiming it; see handleJoin
+	MediaStates              map[string]MediaState // cid -> last media_state; absent means on/on
+	stalled                  bool                  // flagged by the last checkStalledRooms pass
+	reservedUntil            int64                 // unix ms; nonzero while reserved by /api/room/reserve and not yet joined
+	reserveToken             string                // the first join must present it while reservedUntil is set
+	events                   roomEventLog          // recent joins, leaves, host changes and relays; see roomEventLogSize
 	mu                       sync.Mutex
 }
 
 type Client struct {
-	hub       *Hub
-	send      chan []byte
-	sid       string
-	cid       string // assigned on join
-	rid       string // current room
-	ip        string
-	replaced  bool
-	lastSeen  int64
-	transport TransportKind
+	hub        *Hub
+	send       chan []byte
+	sid        string
+	cid        string // assigned on join
+	rid        string // current room
+	ip         string
+	replaced   bool
+	lastSeen   int64
+	rxMessages int64 // inbound messages over the connection's lifetime; see connectionBudget
+	rxBytes    int64
+	transport  TransportKind
+
+	sseSessionStartedAt int64            // unix nanos the SSE sid was first used; see sseSessionMaxAge
+	sseReplay           *sseReplayBuffer // shared by every stream of the SSE session; nil when disabled
+	staleWarnedAt       int64            // unix nanos of the pending stale_warning; see sseStaleWarning
+
+	sseCompress bool // SSE stream opened with ?compress=gzip; see writeSSEPayload
+
+	sseStop       chan struct{} // closed when another stream takes over the sid; see stopSSEWriter
+	sseStopOnce   sync.Once
+	sseWriterDone chan struct{} // closed once this stream no longer reads send
+
+	watcherID         string             // opaque ID exposed to hosts instead of sid; assigned on first knock
+	knockLimiter      *SimpleTokenBucket // lazily created on first knock
+	mediaStateLimiter *SimpleTokenBucket // lazily created on first media_state
+	timeSyncLimiter   *SimpleTokenBucket // lazily created on first time_sync
+	typeLimiters      typeLimiters       // per-type limits from messageTypeRates
+	relayReceipts     bool               // client asked for relay_receipt after each relay (join capability)
+
+	watchRefreshedAt int64 // unix nanos of the last watch_rooms / watch_keepalive; see watcherTTL
+	lastActivity     int64 // unix nanos of the last non-keepalive message; see roomStallTimeout
+
+	chunkMu     sync.Mutex
+	chunkStream *sdpChunkStream // unfinished offer-chunk sequence, if any
+
+	// sendMu guards sendClosed so a send can never race with close(send).
+	// Senders hold the read lock only for a non-blocking channel send.
+	sendMu     sync.RWMutex
+	sendClosed bool
+	closeFrame []byte // WebSocket close payload set by closeWS; see ws_close.go
+
+	sendPolicy   SendQueuePolicy // what to do when send is full; see SendQueueConfig
+	slowConsumer atomic.Bool     // a disconnect was scheduled by SendQueueDisconnect
+}
+
+// newRoom builds an empty room with the creator's requested capacity, which
+// must already be clamped to [2, server ceiling].
+func newRoom(rid string, requestedMax int, allowKnocks bool) *Room {
+	roomMaxParticipants := requestedMax
+	capacityLocked := true
+	if roomMaxParticipants > 2 {
+		// Keep group-capable rooms joinable by legacy clients until the second
+		// distinct participant locks the final room capacity.
+		roomMaxParticipants = 2
+		capacityLocked = false
+	}
+	return &Room{
+		RID:                      rid,
+		Participants:             make(map[*Client]string),
+		MaxParticipants:          roomMaxParticipants,
+		RequestedMaxParticipants: requestedMax,
+		CapacityLocked:           capacityLocked,
+		JoinedAt:                 make(map[string]int64),
+		KnocksEnabled:            allowKnocks,
+		relayedByCID:             make(map[string]int64),
+		createdAt:                time.Now().UnixMilli(),
+	}
 }
 
 func newHub(maxParticipantsLimit int) *Hub {
@@ -115,6 +306,10 @@ func newHub(maxParticipantsLimit int) *Hub {
 		clients:              make(map[*Client]bool),
 		clientsBySID:         make(map[string]*Client),
 		maxParticipantsLimit: maxParticipantsLimit,
+		hostLeavePolicy:      HostLeaveTransfer,
+		sendQueue:            defaultSendQueueConfig,
+		reconnectGuard:       newReconnectGuard(),
+		sseResumes:           newSSEResumeTickets(),
 	}
 }
 
@@ -158,17 +353,55 @@ func (h *Hub) IsClientInRoom(roomID, cid string) bool {
 	return false
 }
 
+// sessionRoomID returns the room the live session sid is a participant of,
+// or "" when there is no such session or it is not in a room. Thread-safe
+// for use from HTTP handlers.
+func (h *Hub) sessionRoomID(sid string) string {
+	if sid == "" {
+		return ""
+	}
+	h.mu.RLock()
+	client := h.clientsBySID[sid]
+	var room *Room
+	if client != nil {
+		room = h.rooms[client.rid]
+	}
+	h.mu.RUnlock()
+	if room == nil {
+		return ""
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	if _, ok := room.Participants[client]; !ok {
+		return ""
+	}
+	return room.RID
+}
+
 func (h *Hub) replaceClient(oldClient, newClient *Client) {
+	// Set before newClient is reachable by sid, so its first relay already
+	// sees the capability negotiated on the original join.
+	newClient.relayReceipts = oldClient.relayReceipts
+	// Without this the stall sweep falls back to the join time and can close
+	// an active room right after an SSE reconnect.
+	atomic.StoreInt64(&newClient.lastActivity, atomic.LoadInt64(&oldClient.lastActivity))
+
 	h.mu.Lock()
 	delete(h.clients, oldClient)
 	h.clients[newClient] = true
 	h.clientsBySID[newClient.sid] = newClient
+	if newClient.sid != oldClient.sid && h.clientsBySID[oldClient.sid] == oldClient {
+		delete(h.clientsBySID, oldClient.sid)
+	}
 	for _, clientSet := range h.watchers {
 		if clientSet[oldClient] {
 			delete(clientSet, oldClient)
 			clientSet[newClient] = true
 		}
 	}
+	// The subscriptions moved over, so their refresh time must too or the
+	// next watcher sweep drops them.
+	atomic.StoreInt64(&newClient.watchRefreshedAt, atomic.LoadInt64(&oldClient.watchRefreshedAt))
 	h.mu.Unlock()
 
 	if oldClient.rid != "" {
@@ -190,27 +423,37 @@ func (h *Hub) replaceClient(oldClient, newClient *Client) {
 	oldClient.replaced = true
 }
 
-func (c *Client) sendMessage(msg interface{}) {
+// sendMessage queues msg for the client and reports whether it was enqueued.
+func (c *Client) sendMessage(msg interface{}) bool {
 	b, err := json.Marshal(msg)
 	if err != nil {
-		log.Printf("json error: %v", err)
-		return
+		slog.Error("marshal_failed", "sid", c.sid, "error", err)
+		return false
 	}
 
-	defer func() {
-		if r := recover(); r != nil {
-			// Transport send channel may be closed during forced cleanup.
-			stats.IncSendQueueDrop()
-		}
-	}()
+	c.sendMu.RLock()
+	defer c.sendMu.RUnlock()
+	if c.sendClosed {
+		// Transport was torn down (disconnect or forced cleanup) before this send.
+		stats.IncSendAfterClose()
+		return false
+	}
 
+	var queued bool
 	select {
 	case c.send <- b:
-		stats.IncMessageTX(extractMessageType(msg))
+		queued = true
+		stats.ObserveSendQueueDepth(len(c.send))
 	default:
-		// Buffer full. We keep current behavior (drop), but account for it.
-		stats.IncSendQueueDrop()
+		queued = c.overflowSend(b)
+	}
+	if queued {
+		stats.IncMessageTX(extractMessageType(msg))
+		if c.hub != nil {
+			c.hub.capture.record(outboundCaptureRID(c, msg), "out", c, b)
+		}
 	}
+	return queued
 }
 
 // Logic
@@ -219,6 +462,10 @@ func (h *Hub) handleMessage(c *Client, msgBytes []byte) {
 	if !h.isClientActive(c) {
 		return
 	}
+	if !c.chargeBudget(len(msgBytes)) {
+		h.disconnectOverBudget(c)
+		return
+	}
 
 	var msg Message
 	if err := json.Unmarshal(msgBytes, &msg); err != nil {
@@ -228,43 +475,93 @@ func (h *Hub) handleMessage(c *Client, msgBytes []byte) {
 	}
 
 	stats.IncMessageRX(msg.Type)
+	c.touchActivity(msg.Type)
+	if msg.RID != "" {
+		h.capture.record(msg.RID, "in", c, msgBytes)
+	} else {
+		h.capture.record(c.rid, "in", c, msgBytes)
+	}
 
 	if msg.V != 1 {
 		c.sendError(msg.RID, "UNSUPPORTED_VERSION", "Only version 1 is supported")
 		return
 	}
 
+	if !c.checkPayloadSize(msg) {
+		return
+	}
+
+	if allowed, retryAfter := c.allowMessageType(msg.Type); !allowed {
+		c.sendTypeRateLimited(msg.RID, msg.Type, retryAfter)
+		return
+	}
+
 	switch msg.Type {
 	case "ping":
-		c.sendMessage(Message{V: 1, Type: "pong"})
+		h.handlePing(c, msg)
 		return
 	case "join":
-		log.Printf("[JOIN] Client %s joining room %s", c.sid, msg.RID)
+		slog.Debug("join_requested", "sid", c.sid, "rid", msg.RID)
 		if c.rid != "" {
 			h.removeClientFromRoom(c)
 		}
 		h.handleJoin(c, msg)
 	case "leave":
-		log.Printf("[LEAVE] Client %s leaving", c.cid)
+		slog.Info("leave", "sid", c.sid, "cid", c.cid, "rid", c.rid)
 		h.handleLeave(c, msg)
 	case "end_room":
-		log.Printf("[END_ROOM] Client %s ending room %s", c.cid, c.rid)
+		slog.Debug("end_room_requested", "sid", c.sid, "cid", c.cid, "rid", c.rid)
 		h.handleEndRoom(c, msg)
 	case "watch_rooms":
 		h.handleWatchRooms(c, msg)
+	case "watch_keepalive":
+		h.handleWatchKeepalive(c)
+	case "knocking":
+		h.handleKnocking(c, msg)
+	case "media_state":
+		h.handleMediaState(c, msg)
+	case "presence":
+		h.handlePresence(c, msg)
+	case "time_sync":
+		h.handleTimeSync(c, msg)
+	case "lock_room":
+		h.handleRoomLock(c, msg, true)
+	case "unlock_room":
+		h.handleRoomLock(c, msg, false)
+	case "set_room_meta":
+		h.handleSetRoomMeta(c, msg)
+	case "transfer_host":
+		h.handleTransferHost(c, msg)
+	case "kick":
+		h.handleKick(c, msg)
+	case "knock_response":
+		h.handleKnockResponse(c, msg)
 	case "turn-refresh":
 		h.handleTurnRefresh(c, msg)
 	case "offer", "answer", "ice", "content_state":
-		// log.Printf("[%s] Relay from %s to room %s", msg.Type, c.cid, c.rid) // verbose
 		h.handleRelay(c, msg)
+	case "offer-chunk":
+		h.handleOfferChunk(c, msg)
 	default:
-		log.Printf("[UNKNOWN] Unknown message type: %s", msg.Type)
+		slog.Warn("unknown_message_type", "sid", c.sid, "type", msg.Type)
 	}
 }
 
 func (h *Hub) handleJoin(c *Client, msg Message) {
 	joinStartedAt := time.Now()
 
+	if h.isDraining() {
+		h.rejectJoin(c, msg.RID, "SERVER_DRAINING", "Server is shutting down, reconnect to join")
+		return
+	}
+
+	if !h.allowJoin(c) {
+		stats.IncJoinRateLimited()
+		slog.Warn("join_rejected", "reason", "rate_limited", "sid", c.sid, "ip", c.ip)
+		c.sendError(msg.RID, "JOIN_RATE_LIMITED", "Too many join attempts, slow down")
+		return
+	}
+
 	rid := msg.RID
 	if rid == "" {
 		c.sendError("", "BAD_REQUEST", "Missing roomId")
@@ -276,7 +573,13 @@ func (h *Hub) handleJoin(c *Client, msg Message) {
 			c.sendError(rid, "SERVER_NOT_CONFIGURED", "Room ID service is not configured")
 			return
 		}
-		c.sendError(rid, "INVALID_ROOM_ID", "Room ID must be a valid room token")
+		h.rejectJoin(c, rid, "INVALID_ROOM_ID", "Room ID must be a valid room token")
+		return
+	}
+	if roomIDBlocked(rid) {
+		stats.IncRoomBlocked()
+		slog.Info("join_rejected", "reason", "room_blocked", "sid", c.sid, "rid", rid)
+		h.rejectJoin(c, rid, "ROOM_BLOCKED", "This room is not available")
 		return
 	}
 
@@ -284,16 +587,25 @@ func (h *Hub) handleJoin(c *Client, msg Message) {
 	var joinPayload struct {
 		ReconnectCID          string `json:"reconnectCid"`
 		ReconnectToken        string `json:"reconnectToken"`
+		ReserveToken          string `json:"reserveToken"`
 		CreateMaxParticipants int    `json:"createMaxParticipants"`
+		AllowKnocks           bool   `json:"allowKnocks"`
 		Capabilities          struct {
-			MaxParticipants int `json:"maxParticipants"`
+			MaxParticipants int  `json:"maxParticipants"`
+			RelayReceipts   bool `json:"relayReceipts"`
 		} `json:"capabilities"`
 	}
 	if len(msg.Payload) > 0 {
 		if err := json.Unmarshal(msg.Payload, &joinPayload); err != nil {
-			log.Printf("[JOIN] Failed to parse payload: %v", err)
+			slog.Warn("join_payload_invalid", "sid", c.sid, "rid", rid, "error", err)
 		}
 	}
+	if missing := missingCapabilities(msg.Payload, requiredCapabilities); len(missing) > 0 {
+		slog.Info("join_rejected", "reason", "capability_required", "sid", c.sid, "rid", rid, "missing", missing)
+		h.rejectJoin(c, rid, "CAPABILITY_REQUIRED", "Client must support: "+strings.Join(missing, ", "))
+		return
+	}
+	c.relayReceipts = joinPayload.Capabilities.RelayReceipts
 
 	// Client capability: largest room size this client supports (default 2 for legacy)
 	clientMaxParticipants := joinPayload.Capabilities.MaxParticipants
@@ -316,41 +628,89 @@ func (h *Hub) handleJoin(c *Client, msg Message) {
 	reconnectCID := joinPayload.ReconnectCID
 	reconnectToken := joinPayload.ReconnectToken
 
+	// Only reconnects that prove themselves skip shedding; a bare
+	// reconnectCid would otherwise let any join through.
+	if h.joinShed.shouldShed(joinStartedAt) && !reconnectTokenVerified(reconnectToken, reconnectCID, rid, joinStartedAt) {
+		stats.IncJoinShed()
+		c.sendError(rid, "SERVER_BUSY", "Server is busy, please retry shortly")
+		return
+	}
+
+	if reconnectCID != "" && h.reconnectGuard.blocked(c.ip, joinStartedAt) {
+		stats.IncReconnectBlocked()
+		slog.Warn("join_rejected", "reason", "reconnect_blocked", "sid", c.sid, "rid", rid, "reconnectCid", reconnectCID, "ip", c.ip)
+		h.rejectJoin(c, rid, "RECONNECT_BLOCKED", "Too many invalid reconnect attempts, try again later")
+		return
+	}
+
 	h.mu.Lock()
 	room, exists := h.rooms[rid]
 	if !exists {
-		roomMaxParticipants := createMax
-		capacityLocked := true
-		if roomMaxParticipants > 2 {
-			// Keep group-capable rooms joinable by legacy clients until the second
-			// distinct participant locks the final room capacity.
-			roomMaxParticipants = 2
-			capacityLocked = false
-		}
-		log.Printf("[JOIN] Creating new room %s (maxParticipants=%d requestedMaxParticipants=%d locked=%t)", rid, roomMaxParticipants, createMax, capacityLocked)
-		room = &Room{
-			RID:                      rid,
-			Participants:             make(map[*Client]string),
-			MaxParticipants:          roomMaxParticipants,
-			RequestedMaxParticipants: createMax,
-			CapacityLocked:           capacityLocked,
-			JoinedAt:                 make(map[string]int64),
+		// A room that no longer exists has no ghost to reclaim, so only a
+		// valid reconnect token may recreate a used room ID.
+		if reconnectTokenVerified(reconnectToken, reconnectCID, rid, joinStartedAt) {
+			h.usedRoomIDs.record(rid, joinStartedAt)
+		} else if !h.usedRoomIDs.claim(rid, joinStartedAt) {
+			h.mu.Unlock()
+			slog.Info("join_rejected", "reason", "room_id_used", "sid", c.sid, "rid", rid)
+			h.rejectJoin(c, rid, "ROOM_ID_IN_USE", "Room ID has already been used")
+			return
 		}
+		room = newRoom(rid, createMax, joinPayload.AllowKnocks)
+		slog.Info("room_created", "sid", c.sid, "rid", rid, "maxParticipants", room.MaxParticipants, "requestedMaxParticipants", createMax, "capacityLocked", room.CapacityLocked)
 		h.rooms[rid] = room
 	}
 	h.mu.Unlock()
 
 	room.mu.Lock()
+	// Until its creator joins, a reserved room only admits the join that
+	// presents the token /api/room/reserve returned.
+	if room.reservedUntil != 0 && subtle.ConstantTimeCompare([]byte(joinPayload.ReserveToken), []byte(room.reserveToken)) != 1 {
+		room.mu.Unlock()
+		slog.Info("join_rejected", "reason", "room_reserved", "sid", c.sid, "rid", rid)
+		h.rejectJoin(c, rid, "ROOM_RESERVED", "Room is reserved for its creator")
+		return
+	}
 	reusedCID := false
 
 	// Single-pass ghost eviction: find ghost client with reconnectCID, mark for removal under room lock
 	var ghostToEvict *Client
 	if reconnectCID != "" {
-		// Validate reconnectToken if provided (backwards compatible: legacy clients without token still allowed)
-		if reconnectToken != "" && !validateReconnectToken(reconnectToken, reconnectCID, rid) {
+		// Validate reconnectToken if provided. A join without one is checked
+		// below, before it may evict a ghost.
+		var tokenErr error
+		if reconnectToken != "" {
+			tokenErr = checkReconnectToken(reconnectToken, reconnectCID, rid, time.Now())
+		}
+		if errors.Is(tokenErr, errReconnectTokenExpired) {
+			room.mu.Unlock()
+			slog.Info("reconnect_token_expired", "sid", c.sid, "rid", rid, "reconnectCid", reconnectCID)
+			h.rejectJoin(c, rid, "RECONNECT_TOKEN_EXPIRED", "Reconnect token has expired; join without reconnectCid")
+			return
+		}
+		if tokenErr != nil {
 			room.mu.Unlock()
-			log.Printf("[JOIN] Invalid reconnectToken for CID %s from client %s", reconnectCID, c.sid)
-			c.sendError(rid, "INVALID_RECONNECT_TOKEN", "Reconnect token validation failed")
+			slog.Warn("reconnect_token_invalid", "sid", c.sid, "rid", rid, "reconnectCid", reconnectCID, "ip", c.ip)
+			if h.reconnectGuard.recordFailure(c.ip, time.Now()) {
+				slog.Warn("reconnect_blocked", "ip", c.ip, "duration", reconnectBlockDuration)
+			}
+			h.rejectJoin(c, rid, "INVALID_RECONNECT_TOKEN", "Reconnect token validation failed")
+			return
+		}
+
+		// Another join already evicted this CID's ghost and is finishing hub
+		// cleanup with the room lock dropped. First reclaim wins.
+		if claimant := room.reconnectClaims[reconnectCID]; claimant != nil && claimant != c {
+			room.mu.Unlock()
+			slog.Info("join_rejected", "reason", "reclaim_in_progress", "sid", c.sid, "rid", rid, "reconnectCid", reconnectCID, "claimantSid", claimant.sid)
+			c.sendError(rid, "CID_IN_USE", "This participant is already reconnecting")
+			return
+		}
+		// Checked before the ghost is evicted so a refused reclaim leaves it in place.
+		if room.Locked && !lockedRoomReconnectAllowed(reconnectToken, reconnectCID, rid) {
+			room.mu.Unlock()
+			slog.Info("join_rejected", "reason", "room_locked_no_token", "sid", c.sid, "rid", rid, "reconnectCid", reconnectCID)
+			h.rejectJoin(c, rid, "ROOM_LOCKED", "Room is locked by the host")
 			return
 		}
 
@@ -360,19 +720,37 @@ func (h *Hub) handleJoin(c *Client, msg Message) {
 				break
 			}
 		}
+		// A bare reconnectCid proves nothing once tokens are issued, so it
+		// must not take over the CID (and with it, possibly the host role).
+		if ghostToEvict != nil && reconnectToken == "" && reconnectTokenSecret() != "" && !reconnectAllowTokenless {
+			slog.Info("reconnect_ghost_kept", "reason", "token_required", "sid", c.sid, "rid", rid, "reconnectCid", reconnectCID, "ghostSid", ghostToEvict.sid)
+			ghostToEvict = nil
+		}
 		if ghostToEvict != nil {
-			log.Printf("[JOIN] Reconnection detected for CID %s. Evicting ghost client %s", reconnectCID, ghostToEvict.sid)
+			slog.Info("reconnect_ghost_evicted", "sid", c.sid, "rid", rid, "cid", reconnectCID, "ghostSid", ghostToEvict.sid)
 			// Remove ghost from room under room lock (atomic)
 			delete(room.Participants, ghostToEvict)
 			ghostToEvict.cid = ""
 			ghostToEvict.rid = ""
 			reusedCID = true
+			if room.reconnectClaims == nil {
+				room.reconnectClaims = make(map[string]*Client)
+			}
+			room.reconnectClaims[reconnectCID] = c
 			// Note: room.HostCID is intentionally left unchanged so that
 			// the host assignment is preserved across reconnects via the
 			// reused client ID (reconnectCID).
 		}
 	}
 
+	// A locked room only takes back participants reclaiming their own CID.
+	if room.Locked && !reusedCID {
+		room.mu.Unlock()
+		slog.Info("join_rejected", "reason", "room_locked", "sid", c.sid, "rid", rid)
+		h.rejectJoin(c, rid, "ROOM_LOCKED", "Room is locked by the host")
+		return
+	}
+
 	if !room.CapacityLocked && len(room.Participants) == 1 {
 		lockedMaxParticipants := room.RequestedMaxParticipants
 		if lockedMaxParticipants < 2 {
@@ -383,34 +761,40 @@ func (h *Hub) handleJoin(c *Client, msg Message) {
 		}
 		room.MaxParticipants = lockedMaxParticipants
 		room.CapacityLocked = true
-		log.Printf("[JOIN] Room %s capacity locked at %d after second participant %s (client cap=%d, requested=%d)", rid, room.MaxParticipants, c.sid, clientMaxParticipants, room.RequestedMaxParticipants)
+		slog.Info("room_capacity_locked", "sid", c.sid, "rid", rid, "maxParticipants", room.MaxParticipants, "clientMaxParticipants", clientMaxParticipants, "requestedMaxParticipants", room.RequestedMaxParticipants)
 	}
 
 	// Reject clients that don't support this room's capacity once it's finalized.
 	if clientMaxParticipants < room.MaxParticipants {
+		room.releaseReconnectClaim(reconnectCID, c)
 		room.mu.Unlock()
-		log.Printf("[JOIN] Client %s (cap=%d) cannot join room %s (maxParticipants=%d)", c.sid, clientMaxParticipants, rid, room.MaxParticipants)
-		c.sendError(rid, "ROOM_CAPACITY_UNSUPPORTED", "This client does not support group calls")
+		slog.Info("join_rejected", "reason", "capacity_unsupported", "sid", c.sid, "rid", rid, "clientMaxParticipants", clientMaxParticipants, "maxParticipants", room.MaxParticipants)
+		h.rejectJoin(c, rid, "ROOM_CAPACITY_UNSUPPORTED", "This client does not support group calls")
 		return
 	}
 
-	// Room full check (after ghost eviction / capacity negotiation)
-	if len(room.Participants) >= room.MaxParticipants {
+	// Room full check (after ghost eviction / capacity negotiation). Slots
+	// whose ghost another join is still reclaiming count as taken.
+	if occupied, maxParticipants := room.occupiedSlotsLocked(c), room.MaxParticipants; occupied >= maxParticipants {
+		room.releaseReconnectClaim(reconnectCID, c)
 		room.mu.Unlock()
-		log.Printf("[JOIN] Room %s is full (%d/%d)", rid, len(room.Participants), room.MaxParticipants)
-		c.sendError(rid, "ROOM_FULL", "Room is full")
+		slog.Info("join_rejected", "reason", "room_full", "sid", c.sid, "rid", rid, "occupied", occupied, "maxParticipants", maxParticipants)
+		h.rejectJoin(c, rid, "ROOM_FULL", "Room is full")
 		return
 	}
 
-	// Deferred hub-level cleanup of ghost outside room lock to avoid deadlock
+	// Deferred hub-level cleanup of ghost outside room lock to avoid deadlock.
+	// Our reconnect claim reserves the evicted slot meanwhile, so joins racing
+	// into this window see the room as full rather than taking it.
 	if ghostToEvict != nil {
 		room.mu.Unlock()
 		h.cleanupEvictedClient(ghostToEvict)
 		room.mu.Lock()
-		if len(room.Participants) >= room.MaxParticipants {
+		if occupied, maxParticipants := room.occupiedSlotsLocked(c), room.MaxParticipants; occupied >= maxParticipants {
+			room.releaseReconnectClaim(reconnectCID, c)
 			room.mu.Unlock()
-			log.Printf("[JOIN] Room %s is full after ghost cleanup (%d/%d)", rid, len(room.Participants), room.MaxParticipants)
-			c.sendError(rid, "ROOM_FULL", "Room is full")
+			slog.Info("join_rejected", "reason", "room_full_after_ghost_cleanup", "sid", c.sid, "rid", rid, "occupied", occupied, "maxParticipants", maxParticipants)
+			h.rejectJoin(c, rid, "ROOM_FULL", "Room is full")
 			return
 		}
 	}
@@ -422,6 +806,18 @@ func (h *Hub) handleJoin(c *Client, msg Message) {
 	c.cid = cid
 	c.rid = rid
 	room.Participants[c] = cid
+	room.releaseReconnectClaim(reconnectCID, c)
+
+	if room.reservedUntil != 0 {
+		// First join into a reserved room: it keeps the reserved settings,
+		// clamped to this client's capability as if it had created the room.
+		room.reservedUntil = 0
+		room.reserveToken = ""
+		if room.RequestedMaxParticipants > clientMaxParticipants {
+			room.RequestedMaxParticipants = clientMaxParticipants
+		}
+		slog.Info("room_reservation_claimed", "sid", c.sid, "rid", rid, "requestedMaxParticipants", room.RequestedMaxParticipants)
+	}
 
 	// Track stable join time (preserve on reconnect)
 	if _, hasJoinTime := room.JoinedAt[cid]; !hasJoinTime {
@@ -431,28 +827,46 @@ func (h *Hub) handleJoin(c *Client, msg Message) {
 	if room.HostCID == "" {
 		room.HostCID = cid
 	}
+	if reusedCID {
+		room.recordEventLocked("join", cid, "reconnect")
+	} else {
+		room.recordEventLocked("join", cid, "")
+	}
 
-	log.Printf("[JOIN] Client %s assigned CID %s in room %s (maxParticipants=%d). Host: %s", c.sid, cid, rid, room.MaxParticipants, room.HostCID)
+	slog.Info("join", "sid", c.sid, "cid", cid, "rid", rid, "maxParticipants", room.MaxParticipants, "hostCid", room.HostCID)
 
 	// Send 'joined'
 	participants := []Participant{}
 	for _, id := range room.Participants {
-		participants = append(participants, Participant{CID: id, JoinedAt: room.JoinedAt[id]})
+		participants = append(participants, Participant{CID: id, JoinedAt: room.JoinedAt[id], Media: room.mediaStateLocked(id)})
 	}
 	roomMaxParticipants := room.MaxParticipants
+	roomLocked := room.Locked
+	roomMetadata := room.Metadata
 
 	room.mu.Unlock() // <--- CRITICAL FIX: Unlock before broadcast/send to avoid deadlock/blocking
 
+	if reusedCID {
+		h.audit.record("reconnect", c, cid, rid, "")
+	} else {
+		h.audit.record("join", c, cid, rid, "")
+	}
+
 	payload := map[string]interface{}{
 		"hostCid":         room.HostCID,
 		"participants":    participants,
 		"maxParticipants": roomMaxParticipants,
+		"locked":          roomLocked,
+		"serverTimeMs":    time.Now().UnixMilli(), // lets clients correct for clock skew when scheduling TURN refresh
+	}
+	if roomMetadata != nil {
+		payload["metadata"] = roomMetadata
 	}
 
 	// Include TURN token in joined response (gated by valid room ID)
-	token, expiresAt, err := issueTurnToken(turnTokenTTL, turnTokenKindCall)
+	token, expiresAt, err := issueTurnToken(turnTokenTTL, turnTokenKindCall, rid)
 	if err != nil {
-		log.Printf("[TURN] Failed to issue token: %v", err)
+		slog.Error("turn_token_failed", "sid", c.sid, "rid", rid, "error", err)
 	} else {
 		payload["turnToken"] = token
 		payload["turnTokenExpiresAt"] = expiresAt.Unix()
@@ -474,7 +888,9 @@ func (h *Hub) handleJoin(c *Client, msg Message) {
 		CID:     cid,
 		Payload: payloadBytes,
 	})
-	stats.RecordJoinLatency(time.Since(joinStartedAt))
+	joinLatency := time.Since(joinStartedAt)
+	stats.RecordJoinLatency(joinLatency, c.sid)
+	h.joinShed.observe(joinLatency, time.Now())
 
 	// Broadcast room_state to others
 	h.broadcastRoomState(room)
@@ -489,9 +905,9 @@ func (h *Hub) handleTurnRefresh(c *Client, msg Message) {
 		return
 	}
 
-	token, expiresAt, err := issueTurnToken(turnTokenTTL, turnTokenKindCall)
+	token, expiresAt, err := issueTurnToken(turnTokenTTL, turnTokenKindCall, c.rid)
 	if err != nil {
-		log.Printf("[TURN-REFRESH] Failed to issue token for %s: %v", c.cid, err)
+		slog.Error("turn_refresh_failed", "sid", c.sid, "cid", c.cid, "rid", c.rid, "error", err)
 		c.sendError(msg.RID, "TURN_REFRESH_FAILED", "Failed to refresh TURN credentials")
 		return
 	}
@@ -500,6 +916,11 @@ func (h *Hub) handleTurnRefresh(c *Client, msg Message) {
 		"turnToken":          token,
 		"turnTokenExpiresAt": expiresAt.Unix(),
 		"turnTokenTTLMs":     int64(turnTokenTTL / time.Millisecond),
+		"serverTimeMs":       time.Now().UnixMilli(),
+	}
+	// Renew the reconnect token too, so calls outlasting reconnectTokenTTL can still reconnect.
+	if rt := issueReconnectToken(c.cid, c.rid); rt != "" {
+		payload["reconnectToken"] = rt
 	}
 	payloadBytes, _ := json.Marshal(payload)
 
@@ -509,7 +930,7 @@ func (h *Hub) handleTurnRefresh(c *Client, msg Message) {
 		RID:     c.rid,
 		Payload: payloadBytes,
 	})
-	log.Printf("[TURN-REFRESH] Refreshed TURN credentials for client %s (CID: %s) in room %s", c.sid, c.cid, c.rid)
+	slog.Info("turn_refreshed", "sid", c.sid, "cid", c.cid, "rid", c.rid)
 }
 
 func (h *Hub) handleLeave(c *Client, msg Message) {
@@ -530,7 +951,7 @@ func (h *Hub) handleEndRoom(c *Client, msg Message) {
 	h.mu.RUnlock()
 
 	if !exists {
-		log.Printf("[END_ROOM] Client %s tried to end non-existent room %s", c.sid, rid)
+		slog.Info("end_room_rejected", "reason", "room_not_found", "sid", c.sid, "rid", rid)
 		return
 	}
 
@@ -539,24 +960,47 @@ func (h *Hub) handleEndRoom(c *Client, msg Message) {
 	if room.HostCID != c.cid {
 		room.mu.Unlock()
 		c.sendError(rid, "NOT_HOST", "Only host can end room")
-		log.Printf("[END_ROOM] Client %s (CID: %s) tried to end room %s but is not host (Host: %s)", c.sid, c.cid, rid, room.HostCID)
+		slog.Info("end_room_rejected", "reason", "not_host", "sid", c.sid, "cid", c.cid, "rid", rid, "hostCid", room.HostCID)
 		return
 	}
 
-	// Collect clients to notify
+	participants := len(room.Participants)
+	room.mu.Unlock() // Unlock before sending
+
+	slog.Info("end_room", "sid", c.sid, "cid", c.cid, "rid", rid, "participants", participants)
+	h.endRoom(room, rid, c.cid, "host_ended", func(r *Room) bool { return r.HostCID == c.cid })
+}
+
+// endRoom sends room_ended to the room's participants, removes the room from
+// the hub and notifies watchers. room.mu must not be held. Callers decide to
+// end a room from a snapshot taken without the hub lock, so endRoom only acts
+// if room is still the live room for rid and still (when non-nil) holds under
+// room.mu; it returns whether the room was ended.
+func (h *Hub) endRoom(room *Room, rid string, by string, reason string, still func(*Room) bool) bool {
+	// Remove room from hub first so no room_state is queued after room_ended
+	h.mu.Lock()
+	room.mu.Lock()
+	if h.rooms[rid] != room || (still != nil && !still(room)) {
+		room.mu.Unlock()
+		h.mu.Unlock()
+		return false
+	}
+	delete(h.rooms, rid)
 	clients := make([]*Client, 0, len(room.Participants))
 	for client := range room.Participants {
 		clients = append(clients, client)
 	}
-
-	room.mu.Unlock() // Unlock before sending
-
-	log.Printf("[END_ROOM] Host %s ending room %s. Notifying %d clients", c.cid, rid, len(clients))
+	// Also clear participants in room to help GC
+	room.Participants = make(map[*Client]string)
+	room.HostCID = ""
+	room.events = roomEventLog{}
+	room.mu.Unlock()
+	h.mu.Unlock()
 
 	// Broadcast room_ended
 	endPayload, _ := json.Marshal(map[string]string{
-		"by":     c.cid,
-		"reason": "host_ended",
+		"by":     by,
+		"reason": reason,
 	})
 	endMsg := Message{
 		V:       1,
@@ -567,6 +1011,7 @@ func (h *Hub) handleEndRoom(c *Client, msg Message) {
 
 	for _, client := range clients {
 		client.sendMessage(endMsg)
+		h.audit.record("room_ended", client, client.cid, rid, reason)
 		// Reset client state
 		// Note: modifying client struct is dangerous if read concurrently.
 		// Client struct fields `rid`/`cid` are read in readPump/handle handlers.
@@ -576,29 +1021,20 @@ func (h *Hub) handleEndRoom(c *Client, msg Message) {
 		// Let's just leave them stale, it's fine.
 	}
 
-	// Clear room
-	// Re-acquire lock to clear participants? Or just delete room.
-	// If we delete room from hub, existing clients can't find it.
-
-	// Remove room from hub
-	h.mu.Lock()
-	delete(h.rooms, rid)
-	h.mu.Unlock()
-
-	// Also clear participants in room to help GC?
-	room.mu.Lock()
-	room.Participants = make(map[*Client]string)
-	room.HostCID = ""
-	room.mu.Unlock()
-
 	// Notify watchers
 	h.broadcastRoomStatusUpdate(rid)
+	return true
 }
 
-func (h *Hub) handleRelay(c *Client, msg Message) {
+// handleRelay forwards msg to its targets in c's room. It returns how many
+// copies were enqueued: 0 when the relay was rejected or every target
+// dropped it.
+func (h *Hub) handleRelay(c *Client, msg Message) int {
+	// Monotonic ingress stamp for the relay latency histogram.
+	receivedAt := time.Now()
 	if c.rid == "" {
-		log.Printf("[RELAY] Client %s (CID: %s) tried to relay but not in a room", c.sid, c.cid)
-		return
+		slog.Debug("relay_rejected", "reason", "not_in_room", "sid", c.sid, "cid", c.cid, "type", msg.Type)
+		return 0
 	}
 
 	h.mu.RLock()
@@ -606,8 +1042,12 @@ func (h *Hub) handleRelay(c *Client, msg Message) {
 	h.mu.RUnlock()
 
 	if !exists {
-		log.Printf("[RELAY] Client %s (CID: %s) tried to relay in non-existent room %s", c.sid, c.cid, c.rid)
-		return
+		// Usually the end-of-call race: the relay was sent just as end_room or
+		// the last leave deleted the room.
+		slog.Debug("relay_rejected", "reason", "room_gone", "sid", c.sid, "cid", c.cid, "rid", c.rid, "type", msg.Type)
+		stats.IncRelayRoomGone()
+		c.sendError(msg.RID, "ROOM_GONE", "Room has ended")
+		return 0
 	}
 
 	room.mu.Lock()
@@ -615,27 +1055,29 @@ func (h *Hub) handleRelay(c *Client, msg Message) {
 
 	// Check if sender is in room
 	if _, ok := room.Participants[c]; !ok {
-		log.Printf("[RELAY] Client %s (CID: %s) tried to relay in room %s but is not a participant", c.sid, c.cid, c.rid)
-		return
+		slog.Warn("relay_rejected", "reason", "not_participant", "sid", c.sid, "cid", c.cid, "rid", c.rid, "type", msg.Type)
+		return 0
+	}
+	// A relay addressed to the sender would silently reach nobody; surface the
+	// client's misrouting instead.
+	if len(msg.ToList) == 0 && msg.To != "" && msg.To == c.cid {
+		slog.Warn("relay_rejected", "reason", "self_relay", "sid", c.sid, "cid", c.cid, "rid", c.rid, "type", msg.Type)
+		c.sendError(msg.RID, "SELF_RELAY", "Relay target is the sender's own CID")
+		return 0
 	}
+	room.relayCount++
+	room.relayedTotal++
+	if room.relayedByCID == nil {
+		room.relayedByCID = make(map[string]int64)
+	}
+	room.relayedByCID[c.cid]++
+	room.recordEventLocked("relay", c.cid, msg.Type)
 
-	// Relay to other participant(s). Protocol says "to" is optional or required.
-	// MVP: Relay to all OTHER participants.
+	// Relay to the "to" participant, the toList subset, or else every other
+	// participant (full mesh).
 
-	// We need to wrap payload with "from"
-	// But Message.Payload is RawMessage.
 	// The protocol says: Server -> client (relay): { payload: { from: "...", ...original_payload... } }
-	// This implies we need to unmarshal payload, add from, and marshal back.
-	// Or more simply: construct a new map.
-
-	var rawPayload map[string]interface{}
-	if err := json.Unmarshal(msg.Payload, &rawPayload); err != nil {
-		rawPayload = make(map[string]interface{})
-		log.Printf("[RELAY] Client %s (CID: %s) sent invalid payload for type %s: %v", c.sid, c.cid, msg.Type, err)
-	}
-	rawPayload["from"] = c.cid
-
-	newPayload, _ := json.Marshal(rawPayload)
+	newPayload := c.relayPayloadWithFrom(msg.Type, msg.Payload)
 
 	relayMsg := Message{
 		V:       1,
@@ -644,29 +1086,82 @@ func (h *Hub) handleRelay(c *Client, msg Message) {
 		Payload: newPayload,
 	}
 
-	relayedCount := 0
+	// Partial relay: only the listed CIDs that are actually in the room.
+	var targets map[string]bool
+	if len(msg.ToList) > 0 {
+		targets = make(map[string]bool, len(msg.ToList))
+		for _, cid := range msg.ToList {
+			targets[cid] = true
+		}
+	}
+
+	delivered := []string{}
+	dropped := []string{}
 	for client, cid := range room.Participants {
 		if cid != c.cid {
-			// Check 'to' if present? Protocol says "to" is optional/recommended.
-			// Implementing direct targeting if "to" is present
-			if msg.To != "" && msg.To != cid {
+			if targets != nil {
+				if !targets[cid] {
+					continue
+				}
+			} else if msg.To != "" && msg.To != cid {
 				continue
 			}
-			client.sendMessage(relayMsg)
-			relayedCount++
+			if client.sendMessage(relayMsg) {
+				stats.RecordRelayLatency(time.Since(receivedAt))
+				delivered = append(delivered, cid)
+			} else {
+				dropped = append(dropped, cid)
+			}
 		}
 	}
-	log.Printf("[RELAY] Client %s (CID: %s) relayed %s message to %d participants in room %s", c.sid, c.cid, msg.Type, relayedCount, c.rid)
+	if logEnabled(slog.LevelDebug) {
+		slog.Debug("relay", "sid", c.sid, "cid", c.cid, "rid", c.rid, "type", msg.Type, "delivered", len(delivered), "dropped", len(dropped))
+	}
+	stats.IncRelay(len(delivered))
+	// Recipients found in the room, whether or not their queue took the copy.
+	addressed := len(delivered) + len(dropped)
+	if targets == nil && msg.To != "" && addressed == 0 {
+		slog.Info("relay_target_missing", "sid", c.sid, "cid", c.cid, "rid", c.rid, "type", msg.Type, "to", msg.To)
+		stats.IncRelayTargetMissing()
+		c.sendPeerGone(msg.RID, msg.To)
+	}
+	if targets != nil {
+		if addressed < len(targets) {
+			slog.Debug("relay_targets_missing", "sid", c.sid, "cid", c.cid, "rid", c.rid, "type", msg.Type, "targets", len(targets), "delivered", len(delivered), "dropped", len(dropped))
+		}
+		stats.IncPartialRelay(len(delivered))
+	}
+
+	if c.relayReceipts {
+		sort.Strings(delivered)
+		sort.Strings(dropped)
+		receiptPayload, _ := json.Marshal(map[string]interface{}{
+			"type":      msg.Type,
+			"delivered": delivered,
+			"dropped":   dropped,
+		})
+		c.sendMessage(Message{
+			V:       1,
+			Type:    "relay_receipt",
+			RID:     msg.RID,
+			Payload: receiptPayload,
+		})
+		stats.IncRelayReceipt(len(dropped) > 0)
+	}
+	return len(delivered)
 }
 
-func (h *Hub) disconnectClient(c *Client) {
-	log.Printf("[DISCONNECT] Client %s disconnected", c.sid)
+// disconnectClient removes c from the hub and its room, counting the
+// disconnect under reason. Clients already gone are not counted again.
+func (h *Hub) disconnectClient(c *Client, reason DisconnectReason) {
+	slog.Info("disconnect", "sid", c.sid, "cid", c.cid, "rid", c.rid, "transport", c.transport, "reason", reason)
 	h.mu.Lock()
 	_, existed := h.clients[c]
 	if !existed {
 		h.mu.Unlock()
 		return
 	}
+	stats.IncDisconnect(string(reason))
 
 	delete(h.clients, c)
 	delete(h.clientsBySID, c.sid)
@@ -689,27 +1184,55 @@ func (h *Hub) disconnectClient(c *Client) {
 	if c.rid != "" {
 		h.removeClientFromRoom(c)
 	}
-	closeClientSend(c.send)
+	c.closeSend()
 }
 
 func (h *Hub) removeClientFromRoom(c *Client) {
-	log.Printf("[REMOVE_FROM_ROOM] Client %s (CID: %s) being removed from room %s", c.sid, c.cid, c.rid)
+	slog.Debug("remove_from_room", "sid", c.sid, "cid", c.cid, "rid", c.rid)
 	h.mu.Lock()
 	room, exists := h.rooms[c.rid]
 	h.mu.Unlock()
 
 	if !exists {
-		log.Printf("[REMOVE_FROM_ROOM] Room %s not found for client %s", c.rid, c.sid)
+		slog.Debug("remove_from_room_skipped", "reason", "room_not_found", "sid", c.sid, "rid", c.rid)
 		return
 	}
 
 	rid := c.rid // Store RID for broadcast
+	h.audit.record("leave", c, c.cid, rid, "")
 	room.mu.Lock()
 	delete(room.Participants, c)
 	delete(room.JoinedAt, c.cid)
-	log.Printf("[REMOVE_FROM_ROOM] Client %s (CID: %s) removed from room %s. Remaining participants: %d", c.sid, c.cid, c.rid, len(room.Participants))
+	delete(room.MediaStates, c.cid)
+	room.recordEventLocked("leave", c.cid, "")
+	slog.Info("removed_from_room", "sid", c.sid, "cid", c.cid, "rid", c.rid, "remaining", len(room.Participants))
 
 	// Manage Host
+	if room.HostCID == c.cid && len(room.Participants) > 0 && h.hostLeavePolicy == HostLeaveEnd {
+		remaining := len(room.Participants)
+		room.mu.Unlock()
+
+		hostCID := c.cid
+		c.rid = ""
+		c.cid = ""
+		// The host may reconnect under its CID, or the room may end, before
+		// endRoom takes the locks again.
+		hostStillGone := func(r *Room) bool {
+			if r.HostCID != hostCID || len(r.Participants) == 0 {
+				return false
+			}
+			for _, cid := range r.Participants {
+				if cid == hostCID {
+					return false
+				}
+			}
+			return true
+		}
+		if h.endRoom(room, rid, hostCID, "host_left", hostStillGone) {
+			slog.Info("host_left_room_ended", "cid", hostCID, "rid", rid, "participants", remaining)
+		}
+		return
+	}
 	if room.HostCID == c.cid {
 		// Transfer host to next available
 		newHost := ""
@@ -719,7 +1242,8 @@ func (h *Hub) removeClientFromRoom(c *Client) {
 		}
 		room.HostCID = newHost
 		if newHost != "" {
-			log.Printf("[REMOVE_FROM_ROOM] Host %s left room %s. New host: %s", c.cid, c.rid, newHost)
+			room.recordEventLocked("host_change", newHost, "")
+			slog.Info("host_transferred", "reason", "host_left", "cid", c.cid, "rid", c.rid, "hostCid", newHost)
 		} else {
 			// No participants left, host is empty
 		}
@@ -732,7 +1256,7 @@ func (h *Hub) removeClientFromRoom(c *Client) {
 	c.cid = ""
 
 	if isEmpty {
-		log.Printf("[REMOVE_FROM_ROOM] Room %s is now empty. Deleting room.", rid)
+		slog.Info("room_deleted", "rid", rid)
 		h.mu.Lock()
 		delete(h.rooms, rid)
 		h.mu.Unlock()
@@ -747,29 +1271,47 @@ func (h *Hub) removeClientFromRoom(c *Client) {
 func (h *Hub) broadcastRoomState(room *Room) {
 	// Must be called without room lock!
 
+	// Hold the hub lock until the sends are queued: endRoom unregisters the
+	// room before sending room_ended, so a room_state can never trail it.
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.rooms[room.RID] != room {
+		slog.Debug("room_state_skipped", "reason", "room_gone", "rid", room.RID)
+		return
+	}
+
 	room.mu.Lock()
 	participants := []Participant{}
 	for _, cid := range room.Participants {
-		participants = append(participants, Participant{CID: cid, JoinedAt: room.JoinedAt[cid]})
+		participants = append(participants, Participant{CID: cid, JoinedAt: room.JoinedAt[cid], Media: room.mediaStateLocked(cid)})
 	}
 	hostCid := room.HostCID
 	rid := room.RID
 	roomMaxParticipants := room.MaxParticipants
+	roomLocked := room.Locked
+	roomMetadata := room.Metadata
 	// Collect clients
 	clients := make([]*Client, 0, len(room.Participants))
 	for client := range room.Participants {
 		clients = append(clients, client)
 	}
 	room.mu.Unlock()
+	if len(clients) == 0 {
+		return
+	}
 
 	payload := map[string]interface{}{
 		"hostCid":         hostCid,
 		"participants":    participants,
 		"maxParticipants": roomMaxParticipants,
+		"locked":          roomLocked,
+	}
+	if roomMetadata != nil {
+		payload["metadata"] = roomMetadata
 	}
 	payloadBytes, _ := json.Marshal(payload)
 
-	log.Printf("[BROADCAST] Room State for %s: %d participants", rid, len(participants))
+	slog.Debug("room_state", "rid", rid, "participants", len(participants))
 
 	msg := Message{
 		V:       1,
@@ -796,12 +1338,56 @@ func (c *Client) sendError(rid, code, message string) {
 	})
 }
 
+// sendPeerGone tells c that the CID it addressed is no longer in the room,
+// so it can tear down that peer connection without waiting for ICE to fail.
+func (c *Client) sendPeerGone(rid, cid string) {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"code":    "PEER_GONE",
+		"message": "Relay target is not in the room",
+		"cid":     cid,
+	})
+	c.sendMessage(Message{
+		V:       1,
+		Type:    "error",
+		RID:     rid,
+		Payload: payload,
+	})
+}
+
 func generateID(prefix string) string {
 	b := make([]byte, 8)
 	rand.Read(b)
 	return prefix + hex.EncodeToString(b)
 }
 
+// randomToken returns 128 random bits in hex, for bearer secrets such as
+// reservation tokens that are longer-lived or more sensitive than an ID.
+func randomToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// occupiedSlotsLocked counts participants plus slots reserved by other
+// clients' in-flight reconnects. Callers must hold room.mu.
+func (room *Room) occupiedSlotsLocked(c *Client) int {
+	occupied := len(room.Participants)
+	for _, claimant := range room.reconnectClaims {
+		if claimant != c {
+			occupied++
+		}
+	}
+	return occupied
+}
+
+// releaseReconnectClaim drops c's in-flight claim on cid, if it holds one.
+// Callers must hold room.mu.
+func (room *Room) releaseReconnectClaim(cid string, c *Client) {
+	if cid != "" && room.reconnectClaims[cid] == c {
+		delete(room.reconnectClaims, cid)
+	}
+}
+
 // cleanupEvictedClient performs hub-level cleanup for a ghost client that was already
 // removed from its room's Participants map. This must be called outside the room lock.
 func (h *Hub) cleanupEvictedClient(ghost *Client) {
@@ -823,14 +1409,19 @@ func (h *Hub) cleanupEvictedClient(ghost *Client) {
 		stats.AddActiveSSEClients(-1)
 	}
 
-	closeClientSend(ghost.send)
+	ghost.closeSend()
 }
 
-func closeClientSend(ch chan []byte) {
-	defer func() {
-		_ = recover()
-	}()
-	close(ch)
+// closeSend closes the client's send channel exactly once. Later calls to
+// sendMessage observe sendClosed and are counted instead of panicking.
+func (c *Client) closeSend() {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if c.sendClosed {
+		return
+	}
+	c.sendClosed = true
+	close(c.send)
 }
 
 func extractMessageType(msg interface{}) string {
@@ -861,6 +1452,34 @@ func (h *Hub) refreshStatsGauges() {
 		subscriptions += int64(len(clientSet))
 	}
 	stats.SetWatcherSubscriptions(subscriptions)
+
+	roomSizes := make(map[int]int64)
+	for _, room := range h.rooms {
+		room.mu.Lock()
+		size := len(room.Participants)
+		room.mu.Unlock()
+		roomSizes[size]++
+	}
+	stats.SetRoomSizes(roomSizes)
+
+	expected, actual := h.connGoroutineCountsLocked()
+	stats.SetConnGoroutines(expected, actual)
+}
+
+// roomStatusLocked returns the room_statuses entry for rid. Callers must hold h.mu.
+func (h *Hub) roomStatusLocked(rid string) map[string]int {
+	room, ok := h.rooms[rid]
+	if !ok {
+		return map[string]int{
+			"count": 0,
+		}
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	return map[string]int{
+		"count":           len(room.Participants),
+		"maxParticipants": room.MaxParticipants,
+	}
 }
 
 func (h *Hub) handleWatchRooms(c *Client, msg Message) {
@@ -872,6 +1491,7 @@ func (h *Hub) handleWatchRooms(c *Client, msg Message) {
 		return
 	}
 
+	c.touchWatch()
 	h.mu.Lock()
 	status := make(map[string]map[string]int)
 	for rid, clientSet := range h.watchers {
@@ -890,19 +1510,7 @@ func (h *Hub) handleWatchRooms(c *Client, msg Message) {
 		}
 		h.watchers[rid][c] = true
 
-		// Get current count
-		if room, ok := h.rooms[rid]; ok {
-			room.mu.Lock()
-			status[rid] = map[string]int{
-				"count":           len(room.Participants),
-				"maxParticipants": room.MaxParticipants,
-			}
-			room.mu.Unlock()
-		} else {
-			status[rid] = map[string]int{
-				"count": 0,
-			}
-		}
+		status[rid] = h.roomStatusLocked(rid)
 	}
 	h.mu.Unlock()
 
@@ -914,7 +1522,17 @@ func (h *Hub) handleWatchRooms(c *Client, msg Message) {
 	})
 }
 
+// broadcastRoomStatusUpdate tells rid's watchers its participant count,
+// coalescing changes within ROOM_STATUS_DEBOUNCE_MS into one update.
 func (h *Hub) broadcastRoomStatusUpdate(rid string) {
+	if h.statusDebounce != nil {
+		h.statusDebounce.schedule(rid, h.sendRoomStatusUpdate)
+		return
+	}
+	h.sendRoomStatusUpdate(rid)
+}
+
+func (h *Hub) sendRoomStatusUpdate(rid string) {
 	h.mu.RLock()
 	clients, exists := h.watchers[rid]
 	if !exists {
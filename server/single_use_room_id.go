@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// usedRoomIDs enforces single-use room IDs: once a room ID has created a room,
+// it cannot create another one until ttl after that creation, even if the
+// first room has since been deleted. Reconnects whose reconnectToken verifies
+// for that room may still recreate it so an in-progress call can recover. A
+// nil *usedRoomIDs is disabled.
+type usedRoomIDs struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	expires map[string]time.Time
+}
+
+// newUsedRoomIDs parses SINGLE_USE_ROOM_ID_TTL_SECONDS. Unset, zero or invalid
+// values disable single-use enforcement.
+func newUsedRoomIDs(raw string) *usedRoomIDs {
+	seconds, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || seconds <= 0 {
+		return nil
+	}
+	return &usedRoomIDs{
+		ttl:     time.Duration(seconds) * time.Second,
+		expires: make(map[string]time.Time),
+	}
+}
+
+// claim records rid as used and reports true, or reports false if rid already
+// created a room within the ttl.
+func (u *usedRoomIDs) claim(rid string, now time.Time) bool {
+	if u == nil {
+		return true
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if expiry, ok := u.expires[rid]; ok && now.Before(expiry) {
+		return false
+	}
+	u.expires[rid] = now.Add(u.ttl)
+	return true
+}
+
+// record marks rid as used without checking, for rooms recreated by a
+// reconnect.
+func (u *usedRoomIDs) record(rid string, now time.Time) {
+	if u == nil {
+		return
+	}
+	u.mu.Lock()
+	u.expires[rid] = now.Add(u.ttl)
+	u.mu.Unlock()
+}
+
+// prune drops expired entries so the tracker stays bounded by the number of
+// rooms created within the ttl.
+func (u *usedRoomIDs) prune(now time.Time) {
+	if u == nil {
+		return
+	}
+	u.mu.Lock()
+	for rid, expiry := range u.expires {
+		if !now.Before(expiry) {
+			delete(u.expires, rid)
+		}
+	}
+	u.mu.Unlock()
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewUsedRoomIDsConfig(t *testing.T) {
+	if u := newUsedRoomIDs(""); u != nil {
+		t.Fatal("expected single-use room IDs disabled when unset")
+	}
+	if u := newUsedRoomIDs("0"); u != nil {
+		t.Fatal("expected single-use room IDs disabled for 0")
+	}
+	if u := newUsedRoomIDs("60"); u == nil || u.ttl != time.Minute {
+		t.Fatal("expected 60s ttl")
+	}
+}
+
+func TestUsedRoomIDsExpireAfterTTL(t *testing.T) {
+	u := newUsedRoomIDs("60")
+	now := time.Now()
+	if !u.claim("room", now) {
+		t.Fatal("expected first claim to succeed")
+	}
+	if u.claim("room", now.Add(30*time.Second)) {
+		t.Fatal("expected reuse within ttl to be rejected")
+	}
+	if !u.claim("room", now.Add(61*time.Second)) {
+		t.Fatal("expected claim to succeed once the ttl has passed")
+	}
+
+	u.prune(now.Add(3 * time.Minute))
+	if len(u.expires) != 0 {
+		t.Fatalf("expected prune to drop expired entries, got %d", len(u.expires))
+	}
+}
+
+func TestJoinRejectsRecreatingUsedRoomID(t *testing.T) {
+	t.Setenv("TURN_TOKEN_SECRET", "test-secret-1234")
+	hub := newHub(4)
+	hub.usedRoomIDs = newUsedRoomIDs("3600")
+	rid := mustTestRoomID(t)
+
+	first := fakeClient(hub)
+	hub.registerClient(first)
+	hub.handleMessage(first, joinPayload(rid, 0, 0))
+	if findMessage(drainMessages(first), "joined") == nil {
+		t.Fatal("expected first join to create the room")
+	}
+	firstCID := first.cid
+	hub.removeClientFromRoom(first)
+
+	hub.mu.RLock()
+	_, exists := hub.rooms[rid]
+	hub.mu.RUnlock()
+	if exists {
+		t.Fatal("expected room to be deleted once empty")
+	}
+
+	second := fakeClient(hub)
+	hub.registerClient(second)
+	hub.handleMessage(second, joinPayload(rid, 0, 0))
+	if code := errorCode(findMessage(drainMessages(second), "error")); code != "ROOM_ID_IN_USE" {
+		t.Fatalf("expected ROOM_ID_IN_USE, got %q", code)
+	}
+
+	unproven := fakeClient(hub)
+	hub.registerClient(unproven)
+	hub.handleMessage(unproven, reconnectJoinPayload(rid, firstCID))
+	if code := errorCode(findMessage(drainMessages(unproven), "error")); code != "ROOM_ID_IN_USE" {
+		t.Fatalf("expected a reconnectCid without a token to get ROOM_ID_IN_USE, got %q", code)
+	}
+
+	reconnecting := fakeClient(hub)
+	hub.registerClient(reconnecting)
+	hub.handleMessage(reconnecting, tokenReconnectJoinPayload(rid, firstCID, issueReconnectToken(firstCID, rid)))
+	if findMessage(drainMessages(reconnecting), "joined") == nil {
+		t.Fatal("expected reconnect join to recreate the room")
+	}
+}
@@ -3,7 +3,7 @@ package main
 import (
 	"bytes"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 	"sync/atomic"
@@ -13,18 +13,32 @@ import (
 )
 
 const (
-	ssePingPeriod            = 12 * time.Second
 	sseGracePeriod           = 5 * time.Second
-	sseStaleTimeoutIdle      = 60 * time.Second  // clients not in a room
-	sseStaleTimeoutInRoom    = 5 * time.Minute    // clients currently in a room
 	sseReaperInterval        = 15 * time.Second
+	sseTakeoverWriterWait    = 2 * time.Second
 )
 
 func (h *Hub) run() {
-	ticker := time.NewTicker(sseReaperInterval)
-	defer ticker.Stop()
-	for range ticker.C {
-		h.evictStaleSSE()
+	reaper := time.NewTicker(sseReaperInterval)
+	defer reaper.Stop()
+	sampler := time.NewTicker(hotRoomSampleInterval)
+	defer sampler.Stop()
+	for {
+		select {
+		case <-reaper.C:
+			h.evictStaleSSE()
+			if debugConnGoroutines {
+				h.checkConnGoroutines()
+			}
+			h.expireStaleWatchers(watcherTTL)
+			h.usedRoomIDs.prune(time.Now())
+			h.reconnectGuard.prune(time.Now())
+			h.checkStalledRooms(time.Now(), roomStallTimeout, roomStallClose)
+			h.reapIdleRooms(time.Now(), roomIdleTTL)
+			h.pruneReservedRooms(time.Now())
+		case <-sampler.C:
+			h.sampleRoomRelayRates(hotRoomSampleInterval)
+		}
 	}
 }
 
@@ -43,6 +57,10 @@ func handleSSE(hub *Hub) http.HandlerFunc {
 
 func serveSSE(hub *Hub, w http.ResponseWriter, r *http.Request) {
 	stats.IncConnectionAttempt("sse")
+	if hub.isDraining() {
+		rejectDraining(w, "sse", getClientIP(r))
+		return
+	}
 
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
@@ -62,30 +80,86 @@ func serveSSE(hub *Hub, w http.ResponseWriter, r *http.Request) {
 	}
 
 	ip := getClientIP(r)
-	client := &Client{hub: hub, send: make(chan []byte, 256), sid: sid, ip: ip, transport: TransportSSE}
+	release, retryAfter, ok := hub.connLimit.acquire(ip)
+	if !ok {
+		rejectConnLimited(w, "sse", ip, retryAfter)
+		return
+	}
+	defer release()
+
+	now := time.Now()
 	existing := hub.getClientBySID(sid)
+	if existing != nil && !sseTakeoverAllowed(existing, hub.sseResumes.redeem(r.URL.Query().Get("resumeTicket"), sid, now)) {
+		slog.Warn("sse_takeover_rejected", "sid", sid, "ip", ip)
+		stats.IncSSETakeoverRejected()
+		stats.IncConnectionFailure("sse")
+		http.Error(w, "SSE session in use", http.StatusConflict)
+		return
+	}
+	renewedFrom := ""
+	if sseSessionExpired(existing, now) {
+		renewedFrom = sid
+		sid = generateID("S-")
+	}
+
+	client := hub.newClient(sid, ip, TransportSSE)
+	client.sseCompress = r.URL.Query().Get("compress") == "gzip"
+	client.sseStop = make(chan struct{})
+	client.sseWriterDone = make(chan struct{})
+	defer close(client.sseWriterDone)
+	client.sseSessionStartedAt = now.UnixNano()
 	if existing != nil {
+		if renewedFrom == "" {
+			client.sseSessionStartedAt = existing.sseSessionStartedAt
+		}
+		client.sseReplay = existing.sseReplay
 		hub.replaceClient(existing, client)
+		if existing.stopSSEWriter(sseTakeoverWriterWait) {
+			existing.moveUndeliveredToReplay()
+		} else {
+			slog.Warn("sse_takeover_writer_stuck", "sid", existing.sid)
+		}
 	} else {
-		hub.registerClient(client)
+		client.sseReplay = newSSEReplayBuffer(sseReplayBufferSize)
+		if !hub.tryRegisterClient(client) {
+			rejectAtClientCap(w, "sse", ip)
+			return
+		}
 		stats.AddActiveSSEClients(1)
 	}
 	stats.IncConnectionSuccess("sse")
 	hub.markSSESeen(client)
+	if renewedFrom != "" {
+		stats.IncSSESessionRenewed()
+		slog.Info("sse_session_renewed", "sid", client.sid, "previousSid", renewedFrom, "maxAge", sseSessionMaxAge)
+		client.sendMessage(sessionRenewedMessage(client.sid, renewedFrom))
+	}
 
-	log.Printf("[SSE] Client %s connected", client.sid)
+	slog.Info("sse_connected", "sid", client.sid, "ip", ip)
 
 	if _, err := w.Write([]byte(": ready\n\n")); err != nil {
-		hub.handleDisconnectSSE(client)
+		hub.handleDisconnectSSE(client, DisconnectWriteError)
 		return
 	}
 	flusher.Flush()
 
+	if lastID, ok := sseLastEventID(r); ok && existing != nil {
+		replayed, err := client.replaySSE(w, flusher, lastID)
+		if err != nil {
+			hub.handleDisconnectSSE(client, DisconnectWriteError)
+			return
+		}
+		if replayed > 0 {
+			slog.Info("sse_replayed", "sid", client.sid, "events", replayed, "lastEventId", lastID)
+		}
+	}
+
 	// Keep the connection open until the client disconnects.
 	ctxDone := r.Context().Done()
-	client.writeSSE(w, flusher, ctxDone)
+	reason := DisconnectClientClose
+	hub.runConnLoop(func() { reason = client.writeSSE(w, flusher, ctxDone) })
 
-	hub.handleDisconnectSSE(client)
+	hub.handleDisconnectSSE(client, reason)
 }
 
 func handleSSEPost(hub *Hub, w http.ResponseWriter, r *http.Request) {
@@ -118,30 +192,59 @@ func handleSSEPost(hub *Hub, w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func (c *Client) writeSSE(w http.ResponseWriter, flusher http.Flusher, done <-chan struct{}) {
+// writeSSE streams c's messages until the request ends, the send channel is
+// closed or a write fails, and reports which as a disconnect reason. A closed
+// channel means the server already disconnected c.
+func (c *Client) writeSSE(w http.ResponseWriter, flusher http.Flusher, done <-chan struct{}) DisconnectReason {
 	ticker := time.NewTicker(ssePingPeriod)
 	defer ticker.Stop()
 
 	for {
+		// Checked first so a stopped writer never takes another message
+		// while the new stream drains send.
+		select {
+		case <-c.sseStop:
+			return DisconnectReplaced
+		default:
+		}
 		select {
 		case <-done:
-			return
+			return DisconnectClientClose
+		case <-c.sseStop:
+			return DisconnectReplaced
 		case msg, ok := <-c.send:
 			if !ok {
-				return
+				return DisconnectClientClose
 			}
-			if err := writeSSEMessage(w, flusher, msg); err != nil {
-				return
+			if err := c.writeSSEEntry(w, flusher, c.sseReplay.add(msg), msg); err != nil {
+				return DisconnectWriteError
 			}
 		case <-ticker.C:
 			if _, err := w.Write([]byte(": ping\n\n")); err != nil {
-				return
+				return DisconnectWriteError
 			}
 			flusher.Flush()
 		}
 	}
 }
 
+// stopSSEWriter ends the writer of a stream that was taken over and waits up
+// to timeout for it to return, so the caller can drain send without racing
+// it. It reports false if the writer is still blocked, e.g. in a write to a
+// dead connection.
+func (c *Client) stopSSEWriter(timeout time.Duration) bool {
+	if c.sseStop == nil {
+		return true
+	}
+	c.sseStopOnce.Do(func() { close(c.sseStop) })
+	select {
+	case <-c.sseWriterDone:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
 func writeSSEMessage(w http.ResponseWriter, flusher http.Flusher, data []byte) error {
 	lines := bytes.Split(data, []byte("\n"))
 	for _, line := range lines {
@@ -166,18 +269,21 @@ func (h *Hub) markSSESeen(c *Client) {
 	atomic.StoreInt64(&c.lastSeen, time.Now().UnixNano())
 }
 
-func (h *Hub) handleDisconnectSSE(c *Client) {
+// handleDisconnectSSE runs when c's stream ends. Unless another stream has
+// taken over the session, c is disconnected for reason after the grace
+// period, giving the client time to reopen its stream.
+func (h *Hub) handleDisconnectSSE(c *Client, reason DisconnectReason) {
 	if c.replaced {
 		h.mu.Lock()
 		delete(h.clients, c)
 		h.mu.Unlock()
+		stats.IncDisconnect(string(DisconnectReplaced))
 		return
 	}
-	stats.IncDisconnect("sse")
-	go h.delayDisconnectSSE(c)
+	h.goConn(func() { h.delayDisconnectSSE(c, reason) })
 }
 
-func (h *Hub) delayDisconnectSSE(c *Client) {
+func (h *Hub) delayDisconnectSSE(c *Client, reason DisconnectReason) {
 	time.Sleep(sseGracePeriod)
 	h.mu.RLock()
 	current := h.clientsBySID[c.sid]
@@ -185,37 +291,39 @@ func (h *Hub) delayDisconnectSSE(c *Client) {
 	if current != c {
 		return
 	}
-	h.disconnectClient(c)
+	h.disconnectClient(c, reason)
 }
 
 func (h *Hub) evictStaleSSE() {
-	now := time.Now().UnixNano()
-	cutoffIdle := now - sseStaleTimeoutIdle.Nanoseconds()
-	cutoffInRoom := now - sseStaleTimeoutInRoom.Nanoseconds()
+	now := time.Now()
 	stale := make([]*Client, 0)
+	warned := make(map[*Client]time.Duration)
 
 	h.mu.RLock()
 	for client := range h.clients {
 		if client.transport != TransportSSE || client.replaced {
 			continue
 		}
-		lastSeen := atomic.LoadInt64(&client.lastSeen)
-		if lastSeen == 0 {
-			continue
-		}
 		// Use longer timeout for clients in a room (active call participants)
-		cutoff := cutoffIdle
+		timeout := sseStaleTimeoutIdle
 		if client.rid != "" {
-			cutoff = cutoffInRoom
+			timeout = sseStaleTimeoutInRoom
 		}
-		if lastSeen < cutoff {
+		warnIn, evict := sseStaleAction(client, now, timeout)
+		if warnIn > 0 {
+			warned[client] = warnIn
+		}
+		if evict {
 			stale = append(stale, client)
 		}
 	}
 	h.mu.RUnlock()
 
+	for client, warnIn := range warned {
+		stats.IncSSEStaleWarning()
+		client.sendStaleWarning(now, warnIn)
+	}
 	for _, client := range stale {
-		stats.IncDisconnect("sse_stale")
-		h.disconnectClient(client)
+		h.disconnectClient(client, DisconnectIdleTimeout)
 	}
 }
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"net/http"
+
+	"serenada/server/internal/stats"
+)
+
+// sseCompressMinBytes is the smallest message worth compressing; below it the
+// base64 overhead outweighs the gzip savings. SDP offers and answers are well
+// above it, most control messages well below.
+const sseCompressMinBytes = 1024
+
+// writeSSEPayload writes one signaling message to the SSE stream. Clients that
+// opened the stream with ?compress=gzip receive large messages as an
+// "event: gzip" frame whose data is the base64 of the gzipped JSON; everything
+// else goes out as a plain data frame.
+func (c *Client) writeSSEPayload(w http.ResponseWriter, flusher http.Flusher, data []byte) error {
+	if c.sseCompress && len(data) >= sseCompressMinBytes {
+		if encoded, ok := gzipBase64(data); ok {
+			stats.IncSSEMessage(true)
+			return writeSSEEvent(w, flusher, "gzip", encoded)
+		}
+	}
+	stats.IncSSEMessage(false)
+	return writeSSEMessage(w, flusher, data)
+}
+
+// gzipBase64 returns the base64-encoded gzip of data, or false if that would
+// not be smaller than data itself.
+func gzipBase64(data []byte) ([]byte, bool) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		return nil, false
+	}
+	if err := gz.Close(); err != nil {
+		return nil, false
+	}
+	encoded := make([]byte, base64.StdEncoding.EncodedLen(buf.Len()))
+	base64.StdEncoding.Encode(encoded, buf.Bytes())
+	if len(encoded) >= len(data) {
+		return nil, false
+	}
+	return encoded, true
+}
+
+func writeSSEEvent(w http.ResponseWriter, flusher http.Flusher, event string, data []byte) error {
+	if _, err := w.Write([]byte("event: " + event + "\n")); err != nil {
+		return err
+	}
+	return writeSSEMessage(w, flusher, data)
+}
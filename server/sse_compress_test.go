@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteSSEPayloadCompressesLargeMessagesWhenNegotiated(t *testing.T) {
+	c := &Client{sseCompress: true}
+	msg := []byte(`{"v":1,"type":"offer","payload":{"sdp":"` + strings.Repeat("a=candidate ", 400) + `"}}`)
+
+	rec := httptest.NewRecorder()
+	if err := c.writeSSEPayload(rec, rec, msg); err != nil {
+		t.Fatal(err)
+	}
+
+	body := rec.Body.String()
+	if !strings.HasPrefix(body, "event: gzip\ndata: ") {
+		t.Fatalf("expected gzip event frame, got %q", body[:min(len(body), 40)])
+	}
+	encoded := strings.TrimSuffix(strings.TrimPrefix(body, "event: gzip\ndata: "), "\n\n")
+	compressed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("expected base64 data: %v", err)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded, msg) {
+		t.Fatal("decoded message does not match original")
+	}
+}
+
+func TestWriteSSEPayloadSendsRawFrames(t *testing.T) {
+	large := []byte(`{"v":1,"type":"offer","payload":{"sdp":"` + strings.Repeat("x", 2048) + `"}}`)
+	small := []byte(`{"v":1,"type":"pong"}`)
+
+	cases := []struct {
+		name string
+		c    *Client
+		msg  []byte
+	}{
+		{"not negotiated", &Client{}, large},
+		{"below threshold", &Client{sseCompress: true}, small},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		if err := tc.c.writeSSEPayload(rec, rec, tc.msg); err != nil {
+			t.Fatal(err)
+		}
+		if want := "data: " + string(tc.msg) + "\n\n"; rec.Body.String() != want {
+			t.Fatalf("%s: expected raw data frame", tc.name)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"serenada/server/internal/stats"
+)
+
+const (
+	defaultSSEReplayBufferSize = 64
+	maxSSEReplayBufferSize     = 1024
+)
+
+// sseReplayBufferSize is how many recent messages each SSE session keeps so a
+// reconnecting stream can resume from its Last-Event-ID instead of losing
+// what was sent while it was down. 0 disables event IDs and replay. Set from
+// SSE_REPLAY_BUFFER_SIZE at startup.
+var sseReplayBufferSize = defaultSSEReplayBufferSize
+
+func parseSSEReplayBufferSize(raw string) int {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return defaultSSEReplayBufferSize
+	}
+	size, err := strconv.Atoi(raw)
+	if err != nil || size < 0 {
+		return defaultSSEReplayBufferSize
+	}
+	return min(size, maxSSEReplayBufferSize)
+}
+
+type sseReplayEntry struct {
+	id   uint64
+	data []byte
+}
+
+// sseReplayBuffer numbers the messages of one SSE session and keeps the most
+// recent ones in a ring. It belongs to the session, not the stream: a
+// reconnect with the same sid takes it over along with the room membership.
+type sseReplayBuffer struct {
+	mu     sync.Mutex
+	lastID uint64
+	ring   []sseReplayEntry
+	start  int // index of the oldest entry
+	count  int
+}
+
+// newSSEReplayBuffer returns nil when size is 0; a nil buffer assigns no IDs.
+func newSSEReplayBuffer(size int) *sseReplayBuffer {
+	if size <= 0 {
+		return nil
+	}
+	return &sseReplayBuffer{ring: make([]sseReplayEntry, size)}
+}
+
+// add assigns data the next event ID and keeps it, evicting the oldest entry
+// when full.
+func (b *sseReplayBuffer) add(data []byte) uint64 {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastID++
+	end := (b.start + b.count) % len(b.ring)
+	b.ring[end] = sseReplayEntry{id: b.lastID, data: data}
+	if b.count < len(b.ring) {
+		b.count++
+	} else {
+		b.start = (b.start + 1) % len(b.ring)
+	}
+	return b.lastID
+}
+
+// since returns the kept entries newer than lastID, oldest first. complete is
+// false when entries after lastID were already evicted.
+func (b *sseReplayBuffer) since(lastID uint64) (entries []sseReplayEntry, complete bool) {
+	if b == nil {
+		return nil, false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if lastID >= b.lastID {
+		return nil, true
+	}
+	for i := 0; i < b.count; i++ {
+		entry := b.ring[(b.start+i)%len(b.ring)]
+		if entry.id > lastID {
+			entries = append(entries, entry)
+		}
+	}
+	complete = len(entries) > 0 && entries[0].id == lastID+1
+	return entries, complete
+}
+
+func (b *sseReplayBuffer) lastEventID() uint64 {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.lastID
+}
+
+// sseLastEventID reads the resume point from the Last-Event-ID header that
+// EventSource sends when it reconnects on its own, or from ?lastEventId= for
+// clients that open a new stream themselves.
+func sseLastEventID(r *http.Request) (uint64, bool) {
+	raw := strings.TrimSpace(r.Header.Get("Last-Event-ID"))
+	if raw == "" {
+		raw = strings.TrimSpace(r.URL.Query().Get("lastEventId"))
+	}
+	if raw == "" {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(raw, 10, 64)
+	return id, err == nil
+}
+
+// moveUndeliveredToReplay numbers the messages still queued on a replaced
+// stream, which its writer will never send, so the new stream can replay
+// them. Call after replaceClient and stopSSEWriter, before the new stream
+// starts writing.
+func (c *Client) moveUndeliveredToReplay() {
+	if c.sseReplay == nil {
+		return
+	}
+	for {
+		select {
+		case msg, ok := <-c.send:
+			if !ok {
+				return
+			}
+			c.sseReplay.add(msg)
+		default:
+			return
+		}
+	}
+}
+
+// replaySSE writes the session's messages newer than lastID ahead of live
+// traffic.
+func (c *Client) replaySSE(w http.ResponseWriter, flusher http.Flusher, lastID uint64) (int, error) {
+	entries, complete := c.sseReplay.since(lastID)
+	if !complete && lastID < c.sseReplay.lastEventID() {
+		stats.IncSSEReplayGap()
+	}
+	for i, entry := range entries {
+		if err := c.writeSSEEntry(w, flusher, entry.id, entry.data); err != nil {
+			return i, err
+		}
+	}
+	stats.AddSSEEventsReplayed(len(entries))
+	return len(entries), nil
+}
+
+// writeSSEEntry writes one message, preceded by its event ID when it has one.
+func (c *Client) writeSSEEntry(w http.ResponseWriter, flusher http.Flusher, id uint64, data []byte) error {
+	if id > 0 {
+		if _, err := w.Write([]byte("id: " + strconv.FormatUint(id, 10) + "\n")); err != nil {
+			return err
+		}
+	}
+	return c.writeSSEPayload(w, flusher, data)
+}
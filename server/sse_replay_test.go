@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSSEReplayBufferSize(t *testing.T) {
+	cases := map[string]int{
+		"":      defaultSSEReplayBufferSize,
+		"bogus": defaultSSEReplayBufferSize,
+		"-1":    defaultSSEReplayBufferSize,
+		"0":     0,
+		"16":    16,
+		"99999": maxSSEReplayBufferSize,
+	}
+	for raw, want := range cases {
+		if got := parseSSEReplayBufferSize(raw); got != want {
+			t.Errorf("parseSSEReplayBufferSize(%q) = %d, want %d", raw, got, want)
+		}
+	}
+}
+
+func TestSSEReplayBufferKeepsNewestInOrder(t *testing.T) {
+	buf := newSSEReplayBuffer(3)
+	for _, msg := range []string{"a", "b", "c", "d", "e"} {
+		buf.add([]byte(msg))
+	}
+
+	entries, complete := buf.since(3)
+	if !complete || len(entries) != 2 || entries[0].id != 4 || string(entries[1].data) != "e" {
+		t.Fatalf("unexpected replay after 3: %+v complete=%v", entries, complete)
+	}
+	if entries, complete = buf.since(2); !complete || len(entries) != 3 {
+		t.Fatalf("expected the whole ring after 2, got %+v complete=%v", entries, complete)
+	}
+	if entries, complete = buf.since(1); complete || len(entries) != 3 || entries[0].id != 3 {
+		t.Fatalf("expected evicted events to be reported as a gap, got %+v complete=%v", entries, complete)
+	}
+	if entries, complete = buf.since(5); !complete || len(entries) != 0 {
+		t.Fatalf("expected nothing to replay for an up-to-date stream, got %+v", entries)
+	}
+
+	var disabled *sseReplayBuffer
+	if id := disabled.add([]byte("x")); id != 0 {
+		t.Fatalf("expected a disabled buffer to assign no IDs, got %d", id)
+	}
+}
+
+func TestServeSSEReplaysMissedEventsAfterLastEventID(t *testing.T) {
+	hub := newHub(4)
+	old := fakeClient(hub)
+	old.transport = TransportSSE
+	old.sseReplay = newSSEReplayBuffer(8)
+	hub.registerClient(old)
+
+	// Events 1-3 reached the old stream; "four" was still queued when it died.
+	for _, msg := range []string{"one", "two", "three"} {
+		old.sseReplay.add([]byte(msg))
+	}
+	old.send <- []byte("four")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveSSE(hub, w, r)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"?sid="+old.sid, nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("sse request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var got []string
+	scanner := bufio.NewScanner(resp.Body)
+	for len(got) < 6 && scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "id: ") || strings.HasPrefix(line, "data: ") {
+			got = append(got, line)
+		}
+	}
+	want := []string{"id: 2", "data: two", "id: 3", "data: three", "id: 4", "data: four"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("expected replay %v, got %v", want, got)
+	}
+
+	hub.getClientBySID(old.sid).send <- []byte("five")
+	got = got[:0]
+	for len(got) < 2 && scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, "id: ") || strings.HasPrefix(line, "data: ") {
+			got = append(got, line)
+		}
+	}
+	if strings.Join(got, "|") != "id: 5|data: five" {
+		t.Fatalf("expected live events to continue the sequence, got %v", got)
+	}
+}
+
+func TestSSETakeoverEndsReplacedStream(t *testing.T) {
+	hub := newHub(4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveSSE(hub, w, r)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	open := func() *http.Response {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"?sid=S-takeover", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("sse request failed: %v", err)
+		}
+		return resp
+	}
+
+	first := open()
+	defer first.Body.Close()
+	firstLines := bufio.NewScanner(first.Body)
+	if !firstLines.Scan() || firstLines.Text() != ": ready" {
+		t.Fatalf("expected the first stream to start, got %q", firstLines.Text())
+	}
+	old := hub.getClientBySID("S-takeover")
+
+	second := open()
+	defer second.Body.Close()
+	for firstLines.Scan() {
+	}
+	if hub.getClientBySID("S-takeover") == old {
+		t.Fatal("expected the second stream to take over the session")
+	}
+
+	// Only the new stream reads send now.
+	hub.getClientBySID("S-takeover").send <- []byte("after")
+	secondLines := bufio.NewScanner(second.Body)
+	for secondLines.Scan() {
+		if strings.HasPrefix(secondLines.Text(), "data: ") {
+			if secondLines.Text() != "data: after" {
+				t.Fatalf("unexpected event %q", secondLines.Text())
+			}
+			return
+		}
+	}
+	t.Fatal("expected the message on the new stream")
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minSSESessionMaxAge keeps a misconfigured SSE_SESSION_MAX_AGE_SECONDS from
+// renewing sessions in the middle of ordinary calls.
+const minSSESessionMaxAge = time.Hour
+
+// sseSessionMaxAge bounds how long one SSE sid may be reused across stream
+// reconnects. Once exceeded, the next stream opened with that sid gets a fresh
+// server-issued sid and a session_renewed message; room membership carries
+// over, so no rejoin is needed. Zero disables renewal. Set from
+// SSE_SESSION_MAX_AGE_SECONDS at startup.
+var sseSessionMaxAge time.Duration
+
+func parseSSESessionMaxAge(raw string) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	age := time.Duration(seconds) * time.Second
+	if age < minSSESessionMaxAge {
+		return minSSESessionMaxAge
+	}
+	return age
+}
+
+// sseSessionExpired reports whether existing, the live client holding a
+// reused sid, has outlived sseSessionMaxAge. A sid whose client has already
+// been disconnected starts a new session, since nothing is left to carry over.
+func sseSessionExpired(existing *Client, now time.Time) bool {
+	if sseSessionMaxAge <= 0 || existing == nil || existing.sseSessionStartedAt == 0 {
+		return false
+	}
+	return now.Sub(time.Unix(0, existing.sseSessionStartedAt)) >= sseSessionMaxAge
+}
+
+func sessionRenewedMessage(sid, previousSID string) Message {
+	payload, _ := json.Marshal(map[string]string{
+		"sid":         sid,
+		"previousSid": previousSID,
+	})
+	return Message{V: 1, Type: "session_renewed", SID: sid, Payload: payload}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSSESessionMaxAge(t *testing.T) {
+	cases := map[string]time.Duration{
+		"":      0,
+		"0":     0,
+		"-5":    0,
+		"bogus": 0,
+		"60":    minSSESessionMaxAge,
+		"86400": 24 * time.Hour,
+	}
+	for raw, want := range cases {
+		if got := parseSSESessionMaxAge(raw); got != want {
+			t.Errorf("parseSSESessionMaxAge(%q) = %s, want %s", raw, got, want)
+		}
+	}
+}
+
+func TestSSESessionExpired(t *testing.T) {
+	prev := sseSessionMaxAge
+	t.Cleanup(func() { sseSessionMaxAge = prev })
+
+	now := time.Now()
+	old := &Client{sseSessionStartedAt: now.Add(-2 * time.Hour).UnixNano()}
+	fresh := &Client{sseSessionStartedAt: now.Add(-time.Minute).UnixNano()}
+
+	sseSessionMaxAge = 0
+	if sseSessionExpired(old, now) {
+		t.Fatal("expected renewal to be disabled when no max age is set")
+	}
+
+	sseSessionMaxAge = time.Hour
+	if !sseSessionExpired(old, now) {
+		t.Fatal("expected session older than max age to be expired")
+	}
+	if sseSessionExpired(fresh, now) {
+		t.Fatal("expected session younger than max age to be kept")
+	}
+	if sseSessionExpired(nil, now) {
+		t.Fatal("expected unknown sid to start a new session")
+	}
+}
+
+func TestServeSSERenewsExpiredSessionAndKeepsRoom(t *testing.T) {
+	prev := sseSessionMaxAge
+	sseSessionMaxAge = time.Hour
+	t.Cleanup(func() { sseSessionMaxAge = prev })
+
+	hub := newHub(4)
+	rid := mustTestRoomID(t)
+	old := fakeClient(hub)
+	old.transport = TransportSSE
+	old.sseSessionStartedAt = time.Now().Add(-2 * time.Hour).UnixNano()
+	hub.registerClient(old)
+	hub.handleMessage(old, joinPayload(rid, 4, 4))
+	cid := old.cid
+	if cid == "" {
+		t.Fatal("expected old client to join the room")
+	}
+	oldSID := old.sid
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveSSE(hub, w, r)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"?sid="+oldSID, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("sse request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var renewed Message
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &renewed); err != nil {
+			t.Fatalf("bad sse frame %q: %v", line, err)
+		}
+		break
+	}
+	if renewed.Type != "session_renewed" {
+		t.Fatalf("expected session_renewed, got %q", renewed.Type)
+	}
+
+	var payload struct {
+		SID         string `json:"sid"`
+		PreviousSID string `json:"previousSid"`
+	}
+	if err := json.Unmarshal(renewed.Payload, &payload); err != nil {
+		t.Fatalf("bad session_renewed payload: %v", err)
+	}
+	if payload.PreviousSID != oldSID || payload.SID == "" || payload.SID == oldSID {
+		t.Fatalf("unexpected session_renewed payload %+v (old sid %s)", payload, oldSID)
+	}
+	if hub.getClientBySID(oldSID) != nil {
+		t.Fatal("expected expired sid to be released")
+	}
+	renewedClient := hub.getClientBySID(payload.SID)
+	if renewedClient == nil {
+		t.Fatal("expected renewed sid to be registered")
+	}
+	if renewedClient.cid != cid || renewedClient.rid != rid {
+		t.Fatalf("expected room membership to carry over, got cid=%q rid=%q", renewedClient.cid, renewedClient.rid)
+	}
+}
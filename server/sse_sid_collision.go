@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"serenada/server/internal/stats"
+)
+
+// SSESIDCollisionPolicy selects what serveSSE does when a stream is opened
+// with the sid of a live session.
+type SSESIDCollisionPolicy string
+
+const (
+	SSESIDCollisionReplace SSESIDCollisionPolicy = "replace" // take over the session (default)
+	SSESIDCollisionVerify  SSESIDCollisionPolicy = "verify"  // in-room sessions also need a resume ticket
+)
+
+// sseSIDCollisionPolicy is set from SSE_SID_COLLISION_POLICY at startup. It
+// stays replace by default until the native clients request resume tickets;
+// under verify their in-room SSE reconnects are refused.
+var sseSIDCollisionPolicy = SSESIDCollisionReplace
+
+func parseSSESIDCollisionPolicy(raw string) SSESIDCollisionPolicy {
+	if SSESIDCollisionPolicy(strings.ToLower(strings.TrimSpace(raw))) == SSESIDCollisionVerify {
+		return SSESIDCollisionVerify
+	}
+	return SSESIDCollisionReplace
+}
+
+// sseTakeoverAllowed reports whether a new stream may replace existing, the
+// live client holding the requested sid. Under the verify policy a session in
+// a room carries its room and CID, so taking it over needs a resume ticket,
+// which is only issued for the reconnectToken of that (cid, rid). Without a
+// configured token secret no token can be presented, and takeovers are
+// allowed.
+func sseTakeoverAllowed(existing *Client, resumed bool) bool {
+	if sseSIDCollisionPolicy != SSESIDCollisionVerify || existing.rid == "" {
+		return true
+	}
+	if reconnectTokenSecret() == "" {
+		return true
+	}
+	return resumed
+}
+
+// sseResumeTicketTTL bounds how long a resume ticket waits for its stream.
+const sseResumeTicketTTL = 30 * time.Second
+
+// sseResumeTickets holds one-time tickets that let GET /sse take over an
+// in-room session. EventSource cannot send headers, so the client posts its
+// reconnectToken to /sse/resume and opens the stream with the short-lived
+// ticket instead, keeping the token itself out of URLs and access logs.
+type sseResumeTickets struct {
+	mu      sync.Mutex
+	tickets map[string]sseResumeTicket
+}
+
+type sseResumeTicket struct {
+	sid     string
+	expires time.Time
+}
+
+func newSSEResumeTickets() *sseResumeTickets {
+	return &sseResumeTickets{tickets: make(map[string]sseResumeTicket)}
+}
+
+// issue returns a new ticket for sid and drops expired ones.
+func (t *sseResumeTickets) issue(sid string, now time.Time) string {
+	ticket := randomToken()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key, entry := range t.tickets {
+		if !now.Before(entry.expires) {
+			delete(t.tickets, key)
+		}
+	}
+	t.tickets[ticket] = sseResumeTicket{sid: sid, expires: now.Add(sseResumeTicketTTL)}
+	return ticket
+}
+
+// redeem consumes ticket and reports whether it was issued for sid and is
+// still live. A ticket works at most once.
+func (t *sseResumeTickets) redeem(ticket, sid string, now time.Time) bool {
+	if ticket == "" {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.tickets[ticket]
+	if !ok {
+		return false
+	}
+	delete(t.tickets, ticket)
+	return entry.sid == sid && now.Before(entry.expires)
+}
+
+// handleSSEResume serves POST /sse/resume?sid=<sid> with a JSON body of
+// {"reconnectToken": "..."} and answers {"resumeTicket": "..."} when the token
+// matches the live session's cid and room. The ticket goes on the next
+// GET /sse?sid=<sid>&resumeTicket=<ticket>.
+func handleSSEResume(hub *Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		sid := strings.TrimSpace(r.URL.Query().Get("sid"))
+		if sid == "" {
+			http.Error(w, "Missing SSE session", http.StatusBadRequest)
+			return
+		}
+
+		var body struct {
+			ReconnectToken string `json:"reconnectToken"`
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxMessageSize)
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		existing := hub.getClientBySID(sid)
+		if existing == nil {
+			http.Error(w, "Unknown SSE session", http.StatusGone)
+			return
+		}
+		if existing.rid != "" && reconnectTokenSecret() != "" && !validateReconnectToken(body.ReconnectToken, existing.cid, existing.rid) {
+			slog.Warn("sse_resume_rejected", "sid", sid, "ip", getClientIP(r))
+			stats.IncSSETakeoverRejected()
+			http.Error(w, "Invalid reconnect token", http.StatusForbidden)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(map[string]string{"resumeTicket": hub.sseResumes.issue(sid, time.Now())})
+	}
+}
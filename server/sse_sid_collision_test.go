@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSSESIDCollisionPolicy(t *testing.T) {
+	cases := map[string]SSESIDCollisionPolicy{
+		"":          SSESIDCollisionReplace,
+		"bogus":     SSESIDCollisionReplace,
+		" Replace ": SSESIDCollisionReplace,
+		" Verify ":  SSESIDCollisionVerify,
+	}
+	for raw, want := range cases {
+		if got := parseSSESIDCollisionPolicy(raw); got != want {
+			t.Errorf("parseSSESIDCollisionPolicy(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestSSETakeoverAllowed(t *testing.T) {
+	prev := sseSIDCollisionPolicy
+	t.Cleanup(func() { sseSIDCollisionPolicy = prev })
+	t.Setenv("TURN_TOKEN_SECRET", "test-reconnect-secret")
+
+	inRoom := &Client{cid: "C-1", rid: "room"}
+	idle := &Client{}
+
+	sseSIDCollisionPolicy = SSESIDCollisionReplace
+	if !sseTakeoverAllowed(inRoom, false) {
+		t.Fatal("expected replace policy to allow takeover without a ticket")
+	}
+
+	sseSIDCollisionPolicy = SSESIDCollisionVerify
+	if !sseTakeoverAllowed(idle, false) {
+		t.Fatal("expected a session outside any room to be replaceable without a ticket")
+	}
+	if sseTakeoverAllowed(inRoom, false) {
+		t.Fatal("expected in-room takeover without a resume ticket to be rejected")
+	}
+	if !sseTakeoverAllowed(inRoom, true) {
+		t.Fatal("expected a redeemed resume ticket to allow takeover")
+	}
+
+	t.Setenv("TURN_TOKEN_SECRET", "")
+	t.Setenv("TURN_SECRET", "")
+	if !sseTakeoverAllowed(inRoom, false) {
+		t.Fatal("expected takeover to be allowed when no token secret is configured")
+	}
+}
+
+func TestSSEResumeTicketsAreSingleUseAndBoundToSID(t *testing.T) {
+	tickets := newSSEResumeTickets()
+	now := time.Now()
+
+	ticket := tickets.issue("S-1", now)
+	if tickets.redeem(ticket, "S-2", now) {
+		t.Fatal("expected a ticket not to work for another sid")
+	}
+	ticket = tickets.issue("S-1", now)
+	if !tickets.redeem(ticket, "S-1", now) {
+		t.Fatal("expected the ticket to work once for its sid")
+	}
+	if tickets.redeem(ticket, "S-1", now) {
+		t.Fatal("expected a redeemed ticket to be spent")
+	}
+	ticket = tickets.issue("S-1", now)
+	if tickets.redeem(ticket, "S-1", now.Add(sseResumeTicketTTL)) {
+		t.Fatal("expected an expired ticket to be rejected")
+	}
+}
+
+func TestServeSSEAllowsInRoomTakeoverWithResumeTicket(t *testing.T) {
+	prev := sseSIDCollisionPolicy
+	sseSIDCollisionPolicy = SSESIDCollisionVerify
+	t.Cleanup(func() { sseSIDCollisionPolicy = prev })
+	t.Setenv("TURN_TOKEN_SECRET", "test-reconnect-secret")
+
+	hub := newHub(4)
+	rid := mustTestRoomID(t)
+	old := fakeClient(hub)
+	old.transport = TransportSSE
+	hub.registerClient(old)
+	hub.handleMessage(old, joinPayload(rid, 4, 4))
+
+	resume := func(token string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		body := strings.NewReader(`{"reconnectToken":"` + token + `"}`)
+		handleSSEResume(hub).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/sse/resume?sid="+old.sid, body))
+		return rec
+	}
+	if rec := resume("forged"); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a forged token, got %d", rec.Code)
+	}
+	rec := resume(issueReconnectToken(old.cid, rid))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var payload struct {
+		ResumeTicket string `json:"resumeTicket"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil || payload.ResumeTicket == "" {
+		t.Fatalf("expected a resume ticket, got %s", rec.Body.String())
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveSSE(hub, w, r)
+	}))
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "?sid=" + old.sid + "&resumeTicket=" + payload.ResumeTicket)
+	if err != nil {
+		t.Fatalf("sse request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the ticket to allow the takeover, got %d", resp.StatusCode)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for hub.getClientBySID(old.sid) == old {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the resumed stream to replace the old client")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !hub.IsClientInRoom(rid, old.cid) {
+		t.Fatal("expected the resumed session to keep its place in the room")
+	}
+}
+
+func TestServeSSERejectsInRoomTakeoverWithoutToken(t *testing.T) {
+	prev := sseSIDCollisionPolicy
+	sseSIDCollisionPolicy = SSESIDCollisionVerify
+	t.Cleanup(func() { sseSIDCollisionPolicy = prev })
+	t.Setenv("TURN_TOKEN_SECRET", "test-reconnect-secret")
+
+	hub := newHub(4)
+	rid := mustTestRoomID(t)
+	victim := fakeClient(hub)
+	victim.transport = TransportSSE
+	hub.registerClient(victim)
+	hub.handleMessage(victim, joinPayload(rid, 4, 4))
+
+	req := httptest.NewRequest(http.MethodGet, "/sse?sid="+victim.sid, nil)
+	rec := httptest.NewRecorder()
+	serveSSE(hub, rec, req)
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected %d, got %d", http.StatusConflict, rec.Code)
+	}
+	if hub.getClientBySID(victim.sid) != victim || victim.replaced {
+		t.Fatal("expected the victim's session to be left in place")
+	}
+	if !hub.IsClientInRoom(rid, victim.cid) {
+		t.Fatal("expected the victim to stay in the room")
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// defaultSSEStaleGrace is how long a warned client has to show activity when
+// SSE_STALE_GRACE_SECONDS is unset: one reaper pass.
+const defaultSSEStaleGrace = sseReaperInterval
+
+// sseStaleWarning is how long before the stale timeout evictStaleSSE sends a
+// stale_warning; zero (the default) evicts without warning. Once warned, a
+// client is evicted only after the timeout has passed and sseStaleGrace has
+// elapsed since the warning with no activity. Set from
+// SSE_STALE_WARNING_SECONDS and SSE_STALE_GRACE_SECONDS at startup.
+var (
+	sseStaleWarning time.Duration
+	sseStaleGrace   = defaultSSEStaleGrace
+)
+
+func parseSSEStaleWarning(raw string) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func parseSSEStaleGrace(raw string) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || seconds < 0 {
+		return defaultSSEStaleGrace
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// sseStaleAction decides what evictStaleSSE does with c at now, given the
+// client's stale timeout. A positive warnIn asks for a stale_warning saying
+// eviction is due in warnIn. The warning lead is capped at half the timeout
+// so a quiet client is never warned right after it was last seen.
+func sseStaleAction(c *Client, now time.Time, timeout time.Duration) (warnIn time.Duration, evict bool) {
+	lastSeen := atomic.LoadInt64(&c.lastSeen)
+	if lastSeen == 0 {
+		return 0, false
+	}
+	idle := now.Sub(time.Unix(0, lastSeen))
+	if sseStaleWarning <= 0 {
+		return 0, idle >= timeout
+	}
+
+	warnedAt := atomic.LoadInt64(&c.staleWarnedAt)
+	if warnedAt != 0 && lastSeen > warnedAt {
+		// Activity since the warning: the client is alive.
+		atomic.CompareAndSwapInt64(&c.staleWarnedAt, warnedAt, 0)
+		warnedAt = 0
+	}
+	if warnedAt == 0 {
+		if idle < timeout-min(sseStaleWarning, timeout/2) {
+			return 0, false
+		}
+		return max(timeout-idle, sseStaleGrace), false
+	}
+	return 0, idle >= timeout && now.Sub(time.Unix(0, warnedAt)) >= sseStaleGrace
+}
+
+// sendStaleWarning tells c it will be evicted unless it shows activity (any
+// POST, e.g. a keepalive ping) within evictIn.
+func (c *Client) sendStaleWarning(now time.Time, evictIn time.Duration) {
+	atomic.StoreInt64(&c.staleWarnedAt, now.UnixNano())
+	payload, _ := json.Marshal(map[string]int64{"evictInMs": evictIn.Milliseconds()})
+	c.sendMessage(Message{V: 1, Type: "stale_warning", Payload: payload})
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseSSEStaleWarningAndGrace(t *testing.T) {
+	if got := parseSSEStaleWarning(""); got != 0 {
+		t.Fatalf("expected warnings disabled by default, got %s", got)
+	}
+	if got := parseSSEStaleWarning("20"); got != 20*time.Second {
+		t.Fatalf("expected 20s, got %s", got)
+	}
+	if got := parseSSEStaleGrace(""); got != defaultSSEStaleGrace {
+		t.Fatalf("expected default grace, got %s", got)
+	}
+	if got := parseSSEStaleGrace("0"); got != 0 {
+		t.Fatalf("expected explicit zero grace, got %s", got)
+	}
+}
+
+func setSSEStaleWarning(t *testing.T, warning, grace time.Duration) {
+	t.Helper()
+	prevWarning, prevGrace := sseStaleWarning, sseStaleGrace
+	sseStaleWarning, sseStaleGrace = warning, grace
+	t.Cleanup(func() { sseStaleWarning, sseStaleGrace = prevWarning, prevGrace })
+}
+
+func staleSSEClient(hub *Hub, idle time.Duration) *Client {
+	c := fakeClient(hub)
+	c.transport = TransportSSE
+	hub.registerClient(c)
+	atomic.StoreInt64(&c.lastSeen, time.Now().Add(-idle).UnixNano())
+	return c
+}
+
+func TestEvictStaleSSEWarnsBeforeEvicting(t *testing.T) {
+	setSSEStaleWarning(t, 20*time.Second, time.Hour)
+	hub := newHub(4)
+
+	c := staleSSEClient(hub, sseStaleTimeoutIdle-10*time.Second)
+	hub.evictStaleSSE()
+
+	warning := findMessage(drainMessages(c), "stale_warning")
+	if warning == nil {
+		t.Fatal("expected stale_warning once inside the warning window")
+	}
+	var payload struct {
+		EvictInMs int64 `json:"evictInMs"`
+	}
+	if err := json.Unmarshal(warning.Payload, &payload); err != nil || payload.EvictInMs <= 0 {
+		t.Fatalf("expected positive evictInMs, got %s", warning.Payload)
+	}
+
+	// Past the timeout but within the grace window after the warning.
+	atomic.StoreInt64(&c.lastSeen, time.Now().Add(-2*sseStaleTimeoutIdle).UnixNano())
+	hub.evictStaleSSE()
+	if !hub.isClientActive(c) {
+		t.Fatal("expected warned client to be kept during the grace window")
+	}
+	if findMessage(drainMessages(c), "stale_warning") != nil {
+		t.Fatal("expected a single warning per quiet period")
+	}
+
+	// Grace elapsed with no activity.
+	atomic.StoreInt64(&c.lastSeen, time.Now().Add(-3*time.Hour).UnixNano())
+	atomic.StoreInt64(&c.staleWarnedAt, time.Now().Add(-2*time.Hour).UnixNano())
+	hub.evictStaleSSE()
+	if hub.isClientActive(c) {
+		t.Fatal("expected client to be evicted after the grace window")
+	}
+}
+
+func TestEvictStaleSSEActivityClearsWarning(t *testing.T) {
+	setSSEStaleWarning(t, 20*time.Second, 0)
+	hub := newHub(4)
+
+	c := staleSSEClient(hub, sseStaleTimeoutIdle-10*time.Second)
+	hub.evictStaleSSE()
+	if findMessage(drainMessages(c), "stale_warning") == nil {
+		t.Fatal("expected stale_warning")
+	}
+
+	hub.markSSESeen(c)
+	hub.evictStaleSSE()
+	if !hub.isClientActive(c) || atomic.LoadInt64(&c.staleWarnedAt) != 0 {
+		t.Fatal("expected activity after the warning to clear it and keep the client")
+	}
+}
+
+func TestEvictStaleSSEWithoutWarningEvictsImmediately(t *testing.T) {
+	setSSEStaleWarning(t, 0, defaultSSEStaleGrace)
+	hub := newHub(4)
+
+	c := staleSSEClient(hub, 2*sseStaleTimeoutIdle)
+	hub.evictStaleSSE()
+
+	if hub.isClientActive(c) {
+		t.Fatal("expected stale client to be evicted when warnings are disabled")
+	}
+	if findMessage(drainMessages(c), "stale_warning") != nil {
+		t.Fatal("expected no stale_warning when warnings are disabled")
+	}
+}
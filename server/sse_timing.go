@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultSSEPingPeriod         = 12 * time.Second
+	defaultSSEStaleTimeoutIdle   = 60 * time.Second
+	defaultSSEStaleTimeoutInRoom = 5 * time.Minute
+
+	minSSEPingPeriod   = time.Second
+	maxSSEPingPeriod   = 5 * time.Minute
+	minSSEStaleTimeout = 10 * time.Second
+)
+
+// SSE keepalive and eviction windows. ssePingPeriod is how often writeSSE
+// sends a ": ping" comment to keep NAT mappings and proxies open; the stale
+// timeouts are how long evictStaleSSE lets a session go without a POST, for
+// clients outside a room and in one. Set from SSE_PING_SECONDS,
+// SSE_STALE_TIMEOUT_IDLE_SECONDS and SSE_STALE_TIMEOUT_IN_ROOM_SECONDS at
+// startup.
+var (
+	ssePingPeriod         = defaultSSEPingPeriod
+	sseStaleTimeoutIdle   = defaultSSEStaleTimeoutIdle
+	sseStaleTimeoutInRoom = defaultSSEStaleTimeoutInRoom
+)
+
+// parseSSESeconds reads a positive number of seconds clamped to [lo, hi]
+// (hi of zero means no upper bound), falling back to def when raw is unset or
+// invalid.
+func parseSSESeconds(raw string, def, lo, hi time.Duration) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || seconds <= 0 {
+		return def
+	}
+	d := max(time.Duration(seconds)*time.Second, lo)
+	if hi > 0 {
+		d = min(d, hi)
+	}
+	return d
+}
+
+func parseSSEPingPeriod(raw string) time.Duration {
+	return parseSSESeconds(raw, defaultSSEPingPeriod, minSSEPingPeriod, maxSSEPingPeriod)
+}
+
+func parseSSEStaleTimeoutIdle(raw string) time.Duration {
+	return parseSSESeconds(raw, defaultSSEStaleTimeoutIdle, minSSEStaleTimeout, 0)
+}
+
+// parseSSEStaleTimeoutInRoom is never shorter than idle: a client in a call
+// must not be evicted sooner than one that is not.
+func parseSSEStaleTimeoutInRoom(raw string, idle time.Duration) time.Duration {
+	return max(parseSSESeconds(raw, defaultSSEStaleTimeoutInRoom, minSSEStaleTimeout, 0), idle)
+}
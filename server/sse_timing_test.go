@@ -0,0 +1,90 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseSSETimings(t *testing.T) {
+	if got := parseSSEPingPeriod(""); got != defaultSSEPingPeriod {
+		t.Fatalf("expected default ping period, got %s", got)
+	}
+	if got := parseSSEPingPeriod("5"); got != 5*time.Second {
+		t.Fatalf("expected 5s, got %s", got)
+	}
+	if got := parseSSEPingPeriod("3600"); got != maxSSEPingPeriod {
+		t.Fatalf("expected ping period capped at %s, got %s", maxSSEPingPeriod, got)
+	}
+	if got := parseSSEStaleTimeoutIdle("-1"); got != defaultSSEStaleTimeoutIdle {
+		t.Fatalf("expected default idle timeout for invalid input, got %s", got)
+	}
+	if got := parseSSEStaleTimeoutIdle("2"); got != minSSEStaleTimeout {
+		t.Fatalf("expected idle timeout raised to %s, got %s", minSSEStaleTimeout, got)
+	}
+	if got := parseSSEStaleTimeoutInRoom("", 60*time.Second); got != defaultSSEStaleTimeoutInRoom {
+		t.Fatalf("expected default in-room timeout, got %s", got)
+	}
+	if got := parseSSEStaleTimeoutInRoom("30", 90*time.Second); got != 90*time.Second {
+		t.Fatalf("expected in-room timeout raised to the idle timeout, got %s", got)
+	}
+}
+
+func setSSETimings(t *testing.T, ping, idle, inRoom time.Duration) {
+	t.Helper()
+	prevPing, prevIdle, prevInRoom := ssePingPeriod, sseStaleTimeoutIdle, sseStaleTimeoutInRoom
+	ssePingPeriod, sseStaleTimeoutIdle, sseStaleTimeoutInRoom = ping, idle, inRoom
+	t.Cleanup(func() {
+		ssePingPeriod, sseStaleTimeoutIdle, sseStaleTimeoutInRoom = prevPing, prevIdle, prevInRoom
+	})
+}
+
+func TestEvictStaleSSEUsesConfiguredTimeouts(t *testing.T) {
+	setSSETimings(t, defaultSSEPingPeriod, 40*time.Millisecond, 120*time.Millisecond)
+	hub, _, _, inRoom, _ := joinedPair(t)
+	inRoom.transport = TransportSSE
+	idle := staleSSEClient(hub, 0)
+	atomic.StoreInt64(&inRoom.lastSeen, atomic.LoadInt64(&idle.lastSeen))
+
+	hub.evictStaleSSE()
+	if !hub.isClientActive(idle) || !hub.isClientActive(inRoom) {
+		t.Fatal("expected no eviction before either timeout")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	hub.evictStaleSSE()
+	if hub.isClientActive(idle) {
+		t.Fatal("expected the idle client evicted after the idle timeout")
+	}
+	if !hub.isClientActive(inRoom) {
+		t.Fatal("expected the in-room client kept until the in-room timeout")
+	}
+
+	time.Sleep(90 * time.Millisecond)
+	hub.evictStaleSSE()
+	if hub.isClientActive(inRoom) {
+		t.Fatal("expected the in-room client evicted after the in-room timeout")
+	}
+}
+
+func TestWriteSSEPingsAtConfiguredPeriod(t *testing.T) {
+	setSSETimings(t, 10*time.Millisecond, defaultSSEStaleTimeoutIdle, defaultSSEStaleTimeoutInRoom)
+	c := fakeClient(newHub(4))
+	rec := httptest.NewRecorder()
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		c.writeSSE(rec, rec, done)
+		close(finished)
+	}()
+
+	time.Sleep(45 * time.Millisecond)
+	close(done)
+	<-finished
+
+	if pings := strings.Count(rec.Body.String(), ": ping\n\n"); pings < 2 {
+		t.Fatalf("expected several pings at a 10ms period, got %d", pings)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+
+	"serenada/server/internal/stats"
+)
+
+// A client estimating clock offset sends a short burst of samples and then
+// resyncs occasionally; anything faster is not improving the estimate.
+const (
+	timeSyncBurst      = 8
+	timeSyncRefillRate = 1.0 // tokens per second
+)
+
+// handleTimeSync answers an NTP-style clock sample. The reply echoes the
+// client's own timestamp untouched and adds the server's receive and send
+// times in Unix milliseconds with microsecond precision, so the client can
+// compute RTT and its offset from the shared server clock.
+func (h *Hub) handleTimeSync(c *Client, msg Message) {
+	received := time.Now()
+
+	h.mu.Lock()
+	if c.timeSyncLimiter == nil {
+		c.timeSyncLimiter = NewSimpleTokenBucket(timeSyncBurst, timeSyncRefillRate)
+	}
+	limiter := c.timeSyncLimiter
+	h.mu.Unlock()
+
+	if !limiter.Allow() {
+		stats.IncTimeSyncRateLimited()
+		c.sendError(msg.RID, "TIME_SYNC_RATE_LIMITED", "Too many time_sync requests")
+		return
+	}
+	stats.IncTimeSync()
+
+	var request struct {
+		ClientTime json.RawMessage `json:"clientTime"`
+	}
+	if len(msg.Payload) > 0 {
+		_ = json.Unmarshal(msg.Payload, &request)
+	}
+
+	payload, _ := json.Marshal(struct {
+		ClientTime        json.RawMessage `json:"clientTime,omitempty"`
+		ServerReceiveTime float64         `json:"serverReceiveTime"`
+		ServerSendTime    float64         `json:"serverSendTime"`
+	}{
+		ClientTime:        request.ClientTime,
+		ServerReceiveTime: unixMillisFloat(received),
+		ServerSendTime:    unixMillisFloat(time.Now()),
+	})
+	c.sendMessage(Message{V: 1, Type: "time_sync", RID: msg.RID, Payload: payload})
+}
+
+func unixMillisFloat(t time.Time) float64 {
+	return float64(t.UnixMicro()) / 1000
+}
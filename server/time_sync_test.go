@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"serenada/server/internal/stats"
+)
+
+func timeSyncPayload(clientTime string) []byte {
+	return []byte(`{"v":1,"type":"time_sync","payload":{"clientTime":` + clientTime + `}}`)
+}
+
+func TestTimeSyncEchoesClientTimeWithServerTimes(t *testing.T) {
+	hub := newHub(4)
+	c := fakeClient(hub)
+	hub.registerClient(c)
+	before := stats.SnapshotNow().Counters.TimeSyncTotal
+	start := float64(time.Now().UnixMicro()) / 1000
+
+	hub.handleMessage(c, timeSyncPayload("12345.678"))
+
+	msg := findMessage(drainMessages(c), "time_sync")
+	if msg == nil {
+		t.Fatal("expected a time_sync reply")
+	}
+	var payload struct {
+		ClientTime        json.RawMessage `json:"clientTime"`
+		ServerReceiveTime float64         `json:"serverReceiveTime"`
+		ServerSendTime    float64         `json:"serverSendTime"`
+	}
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		t.Fatalf("bad payload: %v", err)
+	}
+	if string(payload.ClientTime) != "12345.678" {
+		t.Fatalf("expected clientTime echoed verbatim, got %s", payload.ClientTime)
+	}
+	if payload.ServerReceiveTime < start || payload.ServerSendTime < payload.ServerReceiveTime {
+		t.Fatalf("unexpected server times receive=%f send=%f (start %f)", payload.ServerReceiveTime, payload.ServerSendTime, start)
+	}
+	if got := stats.SnapshotNow().Counters.TimeSyncTotal; got != before+1 {
+		t.Fatalf("expected timeSyncTotal to increase by 1, got %d -> %d", before, got)
+	}
+}
+
+func TestTimeSyncIsRateLimited(t *testing.T) {
+	hub := newHub(4)
+	c := fakeClient(hub)
+	hub.registerClient(c)
+
+	for i := 0; i < timeSyncBurst; i++ {
+		hub.handleMessage(c, timeSyncPayload("1"))
+	}
+	if msg := findMessage(drainMessages(c), "error"); msg != nil {
+		t.Fatalf("expected burst to be allowed, got %+v", msg)
+	}
+
+	hub.handleMessage(c, timeSyncPayload("1"))
+	if code := errorCode(findMessage(drainMessages(c), "error")); code != "TIME_SYNC_RATE_LIMITED" {
+		t.Fatalf("expected TIME_SYNC_RATE_LIMITED, got %q", code)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+)
+
+// tlsCipherSuites is the TLS 1.2 suite list: ECDHE key exchange with AEAD
+// ciphers only. TLS 1.3 suites are fixed by the Go runtime and always secure.
+var tlsCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+}
+
+// buildTLSConfig returns the policy for in-process TLS. minVersion is
+// TLS_MIN_VERSION: "1.2" (default) or "1.3".
+func buildTLSConfig(minVersion string) (*tls.Config, error) {
+	config := &tls.Config{
+		CipherSuites: tlsCipherSuites,
+	}
+	switch strings.TrimSpace(minVersion) {
+	case "", "1.2":
+		config.MinVersion = tls.VersionTLS12
+	case "1.3":
+		config.MinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("unsupported TLS_MIN_VERSION %q (use 1.2 or 1.3)", minVersion)
+	}
+	return config, nil
+}
+
+func describeTLSConfig(config *tls.Config) string {
+	if config.MinVersion >= tls.VersionTLS13 {
+		return "min=TLS1.3"
+	}
+	names := make([]string, 0, len(config.CipherSuites))
+	for _, id := range config.CipherSuites {
+		names = append(names, tls.CipherSuiteName(id))
+	}
+	return fmt.Sprintf("min=TLS1.2 ciphers=%s", strings.Join(names, ","))
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"crypto/tls"
+	"testing"
+)
+
+func TestBuildTLSConfigDefaultsToTLS12WithAEADSuites(t *testing.T) {
+	config, err := buildTLSConfig("")
+	if err != nil {
+		t.Fatalf("buildTLSConfig: %v", err)
+	}
+	if config.MinVersion != tls.VersionTLS12 {
+		t.Fatalf("expected TLS 1.2 minimum, got %x", config.MinVersion)
+	}
+	insecure := make(map[uint16]bool)
+	for _, suite := range tls.InsecureCipherSuites() {
+		insecure[suite.ID] = true
+	}
+	for _, id := range config.CipherSuites {
+		if insecure[id] {
+			t.Fatalf("insecure cipher suite %s in policy", tls.CipherSuiteName(id))
+		}
+	}
+}
+
+func TestBuildTLSConfigTLS13Only(t *testing.T) {
+	config, err := buildTLSConfig("1.3")
+	if err != nil {
+		t.Fatalf("buildTLSConfig: %v", err)
+	}
+	if config.MinVersion != tls.VersionTLS13 {
+		t.Fatalf("expected TLS 1.3 minimum, got %x", config.MinVersion)
+	}
+}
+
+func TestBuildTLSConfigRejectsOldVersions(t *testing.T) {
+	for _, v := range []string{"1.0", "1.1", "tls1.2"} {
+		if _, err := buildTLSConfig(v); err == nil {
+			t.Fatalf("expected error for TLS_MIN_VERSION=%q", v)
+		}
+	}
+}
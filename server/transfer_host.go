@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+)
+
+// handleTransferHost serves transfer_host: the host hands the role to another
+// participant, e.g. before leaving, instead of relying on the implicit
+// transfer in removeClientFromRoom. The change is announced through room_state.
+func (h *Hub) handleTransferHost(c *Client, msg Message) {
+	rid := c.rid
+	if rid == "" {
+		c.sendError(msg.RID, "NOT_IN_ROOM", "Must be in a room to transfer host")
+		return
+	}
+
+	var payload struct {
+		TargetCID string `json:"targetCid"`
+	}
+	if len(msg.Payload) > 0 {
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			c.sendError(rid, "BAD_REQUEST", "Invalid payload")
+			return
+		}
+	}
+	if payload.TargetCID == "" {
+		c.sendError(rid, "BAD_REQUEST", "Missing targetCid")
+		return
+	}
+
+	h.mu.RLock()
+	room, exists := h.rooms[rid]
+	h.mu.RUnlock()
+	if !exists {
+		c.sendError(rid, "NOT_IN_ROOM", "Must be in a room to transfer host")
+		return
+	}
+
+	room.mu.Lock()
+	if room.HostCID != c.cid {
+		room.mu.Unlock()
+		log.Printf("[TRANSFER_HOST] Client %s (CID: %s) tried to transfer host of room %s but is not host", c.sid, c.cid, rid)
+		c.sendError(rid, "NOT_HOST", "Only host can transfer host")
+		return
+	}
+	if payload.TargetCID == c.cid {
+		room.mu.Unlock()
+		return
+	}
+	isParticipant := false
+	for _, cid := range room.Participants {
+		if cid == payload.TargetCID {
+			isParticipant = true
+			break
+		}
+	}
+	if !isParticipant {
+		room.mu.Unlock()
+		c.sendError(rid, "TARGET_NOT_IN_ROOM", "Target is not a participant in this room")
+		return
+	}
+	room.HostCID = payload.TargetCID
+	room.recordEventLocked("host_change", payload.TargetCID, "transfer")
+	room.mu.Unlock()
+
+	log.Printf("[TRANSFER_HOST] Host %s handed room %s to %s", c.cid, rid, payload.TargetCID)
+	h.broadcastRoomState(room)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func transferHostPayload(rid, targetCID string) []byte {
+	payload, _ := json.Marshal(map[string]string{"targetCid": targetCID})
+	b, _ := json.Marshal(Message{V: 1, Type: "transfer_host", RID: rid, Payload: payload})
+	return b
+}
+
+func roomHostCID(hub *Hub, rid string) string {
+	hub.mu.RLock()
+	room := hub.rooms[rid]
+	hub.mu.RUnlock()
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	return room.HostCID
+}
+
+func TestHostTransfersHostToParticipant(t *testing.T) {
+	hub, rid, host, guest, guestCID := joinedPair(t)
+	drainMessages(guest)
+
+	hub.handleMessage(host, transferHostPayload(rid, guestCID))
+
+	if got := roomHostCID(hub, rid); got != guestCID {
+		t.Fatalf("expected host %s, got %s", guestCID, got)
+	}
+	for _, c := range []*Client{host, guest} {
+		state := findMessage(drainMessages(c), "room_state")
+		if state == nil {
+			t.Fatalf("expected room_state broadcast to %s", c.cid)
+		}
+		var payload struct {
+			HostCID string `json:"hostCid"`
+		}
+		_ = json.Unmarshal(state.Payload, &payload)
+		if payload.HostCID != guestCID {
+			t.Fatalf("expected room_state hostCid %s, got %s", guestCID, payload.HostCID)
+		}
+	}
+
+	// The former host is now a regular participant.
+	hub.handleMessage(host, transferHostPayload(rid, host.cid))
+	if code := errorCode(findMessage(drainMessages(host), "error")); code != "NOT_HOST" {
+		t.Fatalf("expected NOT_HOST for the former host, got %q", code)
+	}
+}
+
+func TestNonHostCannotTransferHost(t *testing.T) {
+	hub, rid, host, guest, guestCID := joinedPair(t)
+	drainMessages(guest)
+
+	hub.handleMessage(guest, transferHostPayload(rid, guestCID))
+
+	if code := errorCode(findMessage(drainMessages(guest), "error")); code != "NOT_HOST" {
+		t.Fatalf("expected NOT_HOST, got %q", code)
+	}
+	if got := roomHostCID(hub, rid); got != host.cid {
+		t.Fatalf("expected host to stay %s, got %s", host.cid, got)
+	}
+}
+
+func TestTransferHostRejectsUnknownTarget(t *testing.T) {
+	hub, rid, host, _, _ := joinedPair(t)
+
+	hub.handleMessage(host, transferHostPayload(rid, "C-not-in-room"))
+	if code := errorCode(findMessage(drainMessages(host), "error")); code != "TARGET_NOT_IN_ROOM" {
+		t.Fatalf("expected TARGET_NOT_IN_ROOM, got %q", code)
+	}
+
+	hub.handleMessage(host, transferHostPayload(rid, ""))
+	if code := errorCode(findMessage(drainMessages(host), "error")); code != "BAD_REQUEST" {
+		t.Fatalf("expected BAD_REQUEST for a missing target, got %q", code)
+	}
+	if got := roomHostCID(hub, rid); got != host.cid {
+		t.Fatalf("expected host to stay %s, got %s", host.cid, got)
+	}
+}
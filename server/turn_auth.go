@@ -11,8 +11,12 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
+
+	"serenada/server/internal/stats"
 )
 
 type TurnConfig struct {
@@ -28,11 +32,50 @@ const (
 	turnTokenKindDiagnostic = "diagnostic"
 )
 
+// TURN credential lifetimes. Call credentials default to 15 minutes and can be
+// set with TURN_CREDENTIAL_TTL_SECONDS within [min, max]; diagnostic
+// credentials stay short-lived.
+const (
+	defaultTurnCredentialTTL    = 15 * time.Minute
+	minTurnCredentialTTL        = time.Minute
+	maxTurnCredentialTTL        = 24 * time.Hour
+	diagnosticTurnCredentialTTL = 5 * time.Second
+)
+
+var turnCredentialTTL = defaultTurnCredentialTTL
+
+// turnRequireSession makes every call token need a session in the room it was
+// issued for. Until it is set (TURN_REQUIRE_SESSION=1), tokens minted before
+// the room binding and requests from builds that send no sid are still
+// accepted and counted as turnUnboundCallTokens; a token presented from a
+// session in another room is always rejected.
+var turnRequireSession = false
+
+func parseTurnCredentialTTL(raw string) time.Duration {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return defaultTurnCredentialTTL
+	}
+	seconds, err := strconv.Atoi(raw)
+	ttl := time.Duration(seconds) * time.Second
+	if err != nil || ttl < minTurnCredentialTTL || ttl > maxTurnCredentialTTL {
+		log.Printf("Ignoring TURN_CREDENTIAL_TTL_SECONDS %q: must be %d-%d seconds", raw, int(minTurnCredentialTTL.Seconds()), int(maxTurnCredentialTTL.Seconds()))
+		return defaultTurnCredentialTTL
+	}
+	return ttl
+}
+
 // Token claims no longer include IP for robustness
 type turnTokenClaims struct {
 	V    int    `json:"v"`
 	Kind string `json:"k"`
 	Exp  int64  `json:"exp"`
+	// Nonce makes tokens issued in the same second distinct, so revoking one
+	// (see /api/turn-revoke) cannot invalidate another client's.
+	Nonce string `json:"n,omitempty"`
+	// RID binds a call token to the room it was issued in, so a leaked token
+	// cannot be presented from a session in another room.
+	RID string `json:"rid,omitempty"`
 }
 
 func getTurnTokenSecret() (string, error) {
@@ -46,7 +89,9 @@ func getTurnTokenSecret() (string, error) {
 	return secret, nil
 }
 
-func issueTurnToken(ttl time.Duration, kind string) (string, time.Time, error) {
+// issueTurnToken signs a token of kind expiring after ttl. rid is the room a
+// call token is bound to; diagnostic tokens pass "".
+func issueTurnToken(ttl time.Duration, kind, rid string) (string, time.Time, error) {
 	secret, err := getTurnTokenSecret()
 	if err != nil {
 		return "", time.Time{}, err
@@ -54,9 +99,11 @@ func issueTurnToken(ttl time.Duration, kind string) (string, time.Time, error) {
 
 	expiresAt := time.Now().Add(ttl)
 	claims := turnTokenClaims{
-		V:    turnTokenVersion,
-		Kind: kind,
-		Exp:  expiresAt.Unix(),
+		V:     turnTokenVersion,
+		Kind:  kind,
+		Exp:   expiresAt.Unix(),
+		Nonce: generateID(""),
+		RID:   rid,
 	}
 
 	payloadBytes, err := json.Marshal(claims)
@@ -108,7 +155,11 @@ func parseTurnToken(token string) (turnTokenClaims, bool) {
 	return claims, true
 }
 
-func validateTurnToken(token, kind string) bool {
+// validateTurnToken reports whether token is a live token of kind. rid is
+// the room the presenting session is in, "" if there is none; a call token
+// must have been issued for that room, unless turnRequireSession is off and
+// the token or the session is unbound.
+func validateTurnToken(token, kind, rid string) bool {
 	claims, ok := parseTurnToken(token)
 	if !ok {
 		return false
@@ -119,14 +170,83 @@ func validateTurnToken(token, kind string) bool {
 	if claims.Kind != kind {
 		return false
 	}
+	unbound := false
+	if kind == turnTokenKindCall {
+		switch {
+		case claims.RID != "" && rid != "":
+			if claims.RID != rid {
+				return false
+			}
+		case turnRequireSession:
+			return false
+		default:
+			unbound = true
+		}
+	}
 	if time.Now().Unix() > claims.Exp {
 		return false
 	}
+	if revokedTurnTokens.isRevoked(token) {
+		return false
+	}
 	// IP check removed
+	if unbound {
+		stats.IncTurnUnboundCallToken()
+	}
 	return true
 }
 
-func handleTurnCredentials() http.HandlerFunc {
+// turnHosts is the STUN/TURN host configuration, read once when the
+// credentials handler is built.
+type turnHosts struct {
+	stun       []string
+	turn       []string
+	order      string // TURN_URI_ORDER, as parsed by parseTurnURIOrder
+	roundRobin bool   // TURN_HOSTS_ROUND_ROBIN=1 rotates host order per request
+}
+
+// turnHostsFromEnv reads TURN_HOSTS, falling back to the older TURN_HOST; both
+// take a comma-separated list, as does STUN_HOST.
+func turnHostsFromEnv() turnHosts {
+	turn := os.Getenv("TURN_HOSTS")
+	if strings.TrimSpace(turn) == "" {
+		turn = os.Getenv("TURN_HOST")
+	}
+	return turnHosts{
+		stun:       splitTurnHosts(os.Getenv("STUN_HOST")),
+		turn:       splitTurnHosts(turn),
+		order:      parseTurnURIOrder(os.Getenv("TURN_URI_ORDER")),
+		roundRobin: strings.TrimSpace(os.Getenv("TURN_HOSTS_ROUND_ROBIN")) == "1",
+	}
+}
+
+// uris lists every host for the n-th request. With roundRobin each request
+// starts one host further along, so clients that try the first URI of a kind
+// spread across servers while still being able to fail over to the rest.
+func (t turnHosts) uris(n uint64) []string {
+	stun, turn := t.stun, t.turn
+	if t.roundRobin {
+		stun, turn = rotateTurnHosts(stun, n), rotateTurnHosts(turn, n)
+	}
+	return buildTurnURIs(stun, turn, t.order)
+}
+
+func rotateTurnHosts(hosts []string, n uint64) []string {
+	if len(hosts) < 2 {
+		return hosts
+	}
+	shift := int(n % uint64(len(hosts)))
+	return append(append([]string(nil), hosts[shift:]...), hosts[:shift]...)
+}
+
+// handleTurnCredentials serves GET /api/turn-credentials?token=<token>&sid=<sid>.
+// sid names the caller's signaling session: a call token is only accepted
+// from a session in the room it was minted for (see turnRequireSession for
+// the rollout). Diagnostic tokens need no sid.
+func handleTurnCredentials(hub *Hub) http.HandlerFunc {
+	hosts := turnHostsFromEnv()
+	var requests atomic.Uint64
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
@@ -142,14 +262,15 @@ func handleTurnCredentials() http.HandlerFunc {
 			return
 		}
 
-		credentialTTL := 15 * 60 // default: 15 minutes
+		credentialTTL := turnCredentialTTL
 		isAuthorized := false
+		sessionRID := hub.sessionRoomID(strings.TrimSpace(r.URL.Query().Get("sid")))
 
-		if validateTurnToken(token, turnTokenKindCall) {
+		if validateTurnToken(token, turnTokenKindCall, sessionRID) {
 			isAuthorized = true
-		} else if validateTurnToken(token, turnTokenKindDiagnostic) {
+		} else if validateTurnToken(token, turnTokenKindDiagnostic, "") {
 			isAuthorized = true
-			credentialTTL = 5
+			credentialTTL = diagnosticTurnCredentialTTL
 		}
 
 		if !isAuthorized {
@@ -160,51 +281,129 @@ func handleTurnCredentials() http.HandlerFunc {
 
 		log.Printf("[AUTH_OK] TURN Credentials requested by %s", clientIP)
 
-		// 1. Get Secret and Host from Env
+		// 1. Get Secret from Env
 		secret := os.Getenv("TURN_SECRET")
-		turn_host := os.Getenv("TURN_HOST")
-		stun_host := os.Getenv("STUN_HOST")
-		if secret == "" || stun_host == "" {
+		if secret == "" || len(hosts.stun) == 0 {
 			http.Error(w, "STUN not configured", http.StatusServiceUnavailable)
 			return
 		}
 
 		// 2. Generate Credentials (Time-limited)
-		// Standard TURN REST API: username = timestamp:user
-		ttl := credentialTTL
-		timestamp := time.Now().Unix() + int64(ttl)
-		userPart := clientIP
-		if userPart == "" {
-			userPart = "unknown"
+		config := newTurnConfig(secret, clientIP, credentialTTL, hosts.uris(requests.Add(1)-1))
+
+		w.Header().Set("Content-Type", "application/json")
+		setNoStoreHeaders(w)
+		json.NewEncoder(w).Encode(config)
+	}
+}
+
+// newTurnConfig issues time-limited TURN credentials for clientIP.
+// Standard TURN REST API: username = timestamp:user, password =
+// HMAC-SHA1(secret, username). The username's expiry timestamp and the
+// returned TTL come from the same value, so clients refresh exactly when
+// coturn stops accepting.
+func newTurnConfig(secret, clientIP string, credentialTTL time.Duration, uris []string) TurnConfig {
+	ttl := int(credentialTTL / time.Second)
+	timestamp := time.Now().Unix() + int64(ttl)
+	userPart := clientIP
+	if userPart == "" {
+		userPart = "unknown"
+	}
+	userPart = strings.ReplaceAll(userPart, ":", "-")
+	userPart = strings.ReplaceAll(userPart, "%", "-")
+	username := fmt.Sprintf("%d:%s", timestamp, userPart)
+
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write([]byte(username))
+	password := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	return TurnConfig{
+		Username: username,
+		Password: password,
+		URIs:     uris,
+		TTL:      ttl,
+	}
+}
+
+// setNoStoreHeaders keeps per-client, time-limited secrets out of shared
+// caches (including HTTP/1.0 intermediaries that ignore Cache-Control).
+func setNoStoreHeaders(w http.ResponseWriter) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+}
+
+const (
+	turnURIOrderUDPFirst = "udp-first"
+	turnURIOrderTLSFirst = "tls-first"
+)
+
+// parseTurnURIOrder normalizes TURN_URI_ORDER, falling back to udp-first
+// for unknown values.
+func parseTurnURIOrder(raw string) string {
+	switch order := strings.ToLower(strings.TrimSpace(raw)); order {
+	case turnURIOrderTLSFirst:
+		return turnURIOrderTLSFirst
+	case "", turnURIOrderUDPFirst:
+		return turnURIOrderUDPFirst
+	default:
+		log.Printf("[TURN] Unknown TURN_URI_ORDER %q, using %s", raw, turnURIOrderUDPFirst)
+		return turnURIOrderUDPFirst
+	}
+}
+
+// buildTurnURIs returns the ICE server URIs in the order clients should
+// gather candidates. stun and turn are the STUN_HOST and TURN_HOSTS (or
+// TURN_HOST) hosts as split by splitTurnHosts. order comes from
+// parseTurnURIOrder: "udp-first" lists stun:/turn: before turns:,
+// "tls-first" puts turns: first. Identical URIs are emitted once.
+func buildTurnURIs(stun, turn []string, order string) []string {
+	var plain, tls []string
+	for _, host := range stun {
+		plain = append(plain, "stun:"+host, "turn:"+host)
+	}
+	if len(turn) > 0 {
+		for _, host := range turn {
+			tls = append(tls, "turns:"+host+":443?transport=tcp")
 		}
-		userPart = strings.ReplaceAll(userPart, ":", "-")
-		userPart = strings.ReplaceAll(userPart, "%", "-")
-		username := fmt.Sprintf("%d:%s", timestamp, userPart)
-
-		// Password = HMAC-SHA1(secret, username)
-		mac := hmac.New(sha1.New, []byte(secret))
-		mac.Write([]byte(username))
-		password := base64.StdEncoding.EncodeToString(mac.Sum(nil))
-
-		config := TurnConfig{
-			Username: username,
-			Password: password,
-			URIs: []string{
-				"stun:" + stun_host,
-				"turn:" + stun_host,
-			},
-			TTL: ttl,
+	} else {
+		for _, host := range stun {
+			tls = append(tls, "turns:"+host+":5349?transport=tcp")
 		}
+	}
+
+	var ordered []string
+	if order == turnURIOrderTLSFirst {
+		ordered = append(tls, plain...)
+	} else {
+		ordered = append(plain, tls...)
+	}
 
-		if turn_host != "" {
-			config.URIs = append(config.URIs, "turns:"+turn_host+":443?transport=tcp")
-		} else {
-			config.URIs = append(config.URIs, "turns:"+stun_host+":5349?transport=tcp")
+	seen := make(map[string]bool, len(ordered))
+	uris := make([]string, 0, len(ordered))
+	for _, uri := range ordered {
+		if seen[uri] {
+			continue
 		}
+		seen[uri] = true
+		uris = append(uris, uri)
+	}
+	return uris
+}
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(config)
+// splitTurnHosts splits a comma-separated host list, dropping repeats
+// (compared case-insensitively).
+func splitTurnHosts(raw string) []string {
+	var hosts []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(raw, ",") {
+		host := strings.ToLower(strings.TrimSpace(part))
+		if host == "" || seen[host] {
+			continue
+		}
+		seen[host] = true
+		hosts = append(hosts, host)
 	}
+	return hosts
 }
 
 // TODO: Remove this
@@ -215,16 +414,44 @@ func handleDiagnosticToken() http.HandlerFunc {
 			return
 		}
 
-		token, expires, err := issueTurnToken(5*time.Second, turnTokenKindDiagnostic)
+		token, expires, err := issueTurnToken(diagnosticTurnCredentialTTL, turnTokenKindDiagnostic, "")
 		if err != nil {
 			http.Error(w, "TURN token unavailable", http.StatusServiceUnavailable)
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
+		setNoStoreHeaders(w)
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"token":   token,
 			"expires": expires.Unix(),
 		})
 	}
 }
+
+// handleDiagnosticICEServers issues diagnostic TURN credentials in one step,
+// for the device-check relay self-test. It is /api/diagnostic-token followed
+// by /api/turn-credentials without the token round trip, so the credentials
+// are just as short-lived.
+func handleDiagnosticICEServers() http.HandlerFunc {
+	hosts := turnHostsFromEnv()
+	var requests atomic.Uint64
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost && r.Method != http.MethodGet {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		secret := os.Getenv("TURN_SECRET")
+		if secret == "" || len(hosts.stun) == 0 {
+			http.Error(w, "STUN not configured", http.StatusServiceUnavailable)
+			return
+		}
+
+		config := newTurnConfig(secret, getClientIP(r), diagnosticTurnCredentialTTL, hosts.uris(requests.Add(1)-1))
+		w.Header().Set("Content-Type", "application/json")
+		setNoStoreHeaders(w)
+		json.NewEncoder(w).Encode(config)
+	}
+}
@@ -4,15 +4,18 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
+
+	"serenada/server/internal/stats"
 )
 
 func TestIssueTurnTokenRoundTrip(t *testing.T) {
 	t.Setenv("TURN_TOKEN_SECRET", "test-secret-1234")
 
-	token, expiresAt, err := issueTurnToken(10*time.Minute, turnTokenKindCall)
+	token, expiresAt, err := issueTurnToken(10*time.Minute, turnTokenKindCall, "")
 	if err != nil {
 		t.Fatalf("issueTurnToken: %v", err)
 	}
@@ -56,7 +59,7 @@ func TestParseTurnTokenMalformedBadBase64(t *testing.T) {
 func TestParseTurnTokenTamperedSignature(t *testing.T) {
 	t.Setenv("TURN_TOKEN_SECRET", "test-secret-1234")
 
-	token, _, err := issueTurnToken(10*time.Minute, turnTokenKindCall)
+	token, _, err := issueTurnToken(10*time.Minute, turnTokenKindCall, "")
 	if err != nil {
 		t.Fatalf("issueTurnToken: %v", err)
 	}
@@ -72,11 +75,11 @@ func TestParseTurnTokenTamperedSignature(t *testing.T) {
 func TestValidateTurnTokenCallKind(t *testing.T) {
 	t.Setenv("TURN_TOKEN_SECRET", "test-secret-1234")
 
-	token, _, err := issueTurnToken(10*time.Minute, turnTokenKindCall)
+	token, _, err := issueTurnToken(10*time.Minute, turnTokenKindCall, "room-a")
 	if err != nil {
 		t.Fatalf("issueTurnToken: %v", err)
 	}
-	if !validateTurnToken(token, turnTokenKindCall) {
+	if !validateTurnToken(token, turnTokenKindCall, "room-a") {
 		t.Fatalf("expected valid call token to pass validation")
 	}
 }
@@ -84,11 +87,11 @@ func TestValidateTurnTokenCallKind(t *testing.T) {
 func TestValidateTurnTokenDiagnosticKind(t *testing.T) {
 	t.Setenv("TURN_TOKEN_SECRET", "test-secret-1234")
 
-	token, _, err := issueTurnToken(5*time.Second, turnTokenKindDiagnostic)
+	token, _, err := issueTurnToken(5*time.Second, turnTokenKindDiagnostic, "")
 	if err != nil {
 		t.Fatalf("issueTurnToken: %v", err)
 	}
-	if !validateTurnToken(token, turnTokenKindDiagnostic) {
+	if !validateTurnToken(token, turnTokenKindDiagnostic, "") {
 		t.Fatalf("expected valid diagnostic token to pass validation")
 	}
 }
@@ -96,17 +99,17 @@ func TestValidateTurnTokenDiagnosticKind(t *testing.T) {
 func TestValidateTurnTokenWrongKind(t *testing.T) {
 	t.Setenv("TURN_TOKEN_SECRET", "test-secret-1234")
 
-	token, _, err := issueTurnToken(10*time.Minute, turnTokenKindCall)
+	token, _, err := issueTurnToken(10*time.Minute, turnTokenKindCall, "")
 	if err != nil {
 		t.Fatalf("issueTurnToken: %v", err)
 	}
-	if validateTurnToken(token, turnTokenKindDiagnostic) {
+	if validateTurnToken(token, turnTokenKindDiagnostic, "") {
 		t.Fatalf("expected call token to fail validation as diagnostic")
 	}
 }
 
 func TestValidateTurnTokenMissingSecret(t *testing.T) {
-	_, _, err := issueTurnToken(10*time.Minute, turnTokenKindCall)
+	_, _, err := issueTurnToken(10*time.Minute, turnTokenKindCall, "")
 	if err == nil {
 		t.Fatalf("expected error when secret is missing")
 	}
@@ -115,7 +118,7 @@ func TestValidateTurnTokenMissingSecret(t *testing.T) {
 func TestHandleTurnCredentialsMissingToken(t *testing.T) {
 	t.Setenv("TURN_TOKEN_SECRET", "test-secret-1234")
 
-	handler := handleTurnCredentials()
+	handler := handleTurnCredentials(newHub(4))
 	req := httptest.NewRequest(http.MethodGet, "/api/turn-credentials", nil)
 	w := httptest.NewRecorder()
 	handler.ServeHTTP(w, req)
@@ -128,7 +131,7 @@ func TestHandleTurnCredentialsMissingToken(t *testing.T) {
 func TestHandleTurnCredentialsInvalidToken(t *testing.T) {
 	t.Setenv("TURN_TOKEN_SECRET", "test-secret-1234")
 
-	handler := handleTurnCredentials()
+	handler := handleTurnCredentials(newHub(4))
 	req := httptest.NewRequest(http.MethodGet, "/api/turn-credentials?token=bogus", nil)
 	w := httptest.NewRecorder()
 	handler.ServeHTTP(w, req)
@@ -143,19 +146,18 @@ func TestHandleTurnCredentialsValidCallToken(t *testing.T) {
 	t.Setenv("TURN_SECRET", "coturn-secret")
 	t.Setenv("STUN_HOST", "stun.example.com")
 
-	token, _, err := issueTurnToken(10*time.Minute, turnTokenKindCall)
-	if err != nil {
-		t.Fatalf("issueTurnToken: %v", err)
-	}
+	hub := newHub(4)
+	token, sid := joinedTurnSession(t, hub)
 
-	handler := handleTurnCredentials()
-	req := httptest.NewRequest(http.MethodGet, "/api/turn-credentials?token="+token, nil)
+	handler := handleTurnCredentials(hub)
+	req := httptest.NewRequest(http.MethodGet, "/api/turn-credentials?token="+token+"&sid="+sid, nil)
 	w := httptest.NewRecorder()
 	handler.ServeHTTP(w, req)
 
 	if w.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d", w.Code)
 	}
+	assertNoStoreHeaders(t, w)
 
 	var config TurnConfig
 	if err := json.NewDecoder(w.Body).Decode(&config); err != nil {
@@ -177,12 +179,12 @@ func TestHandleTurnCredentialsDiagnosticTokenShortTTL(t *testing.T) {
 	t.Setenv("TURN_SECRET", "coturn-secret")
 	t.Setenv("STUN_HOST", "stun.example.com")
 
-	token, _, err := issueTurnToken(30*time.Second, turnTokenKindDiagnostic)
+	token, _, err := issueTurnToken(30*time.Second, turnTokenKindDiagnostic, "")
 	if err != nil {
 		t.Fatalf("issueTurnToken: %v", err)
 	}
 
-	handler := handleTurnCredentials()
+	handler := handleTurnCredentials(newHub(4))
 	req := httptest.NewRequest(http.MethodGet, "/api/turn-credentials?token="+token, nil)
 	w := httptest.NewRecorder()
 	handler.ServeHTTP(w, req)
@@ -200,8 +202,39 @@ func TestHandleTurnCredentialsDiagnosticTokenShortTTL(t *testing.T) {
 	}
 }
 
+func TestHandleDiagnosticICEServersIssuesShortLivedCredentials(t *testing.T) {
+	t.Setenv("TURN_SECRET", "coturn-secret")
+	t.Setenv("STUN_HOST", "stun.example.com")
+
+	handler := handleDiagnosticICEServers()
+	req := httptest.NewRequest(http.MethodPost, "/api/diagnostic-ice-servers", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if got := w.Header().Get("Cache-Control"); got != "no-store" {
+		t.Fatalf("expected no-store, got %q", got)
+	}
+	var config TurnConfig
+	if err := json.NewDecoder(w.Body).Decode(&config); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if config.TTL != 5 || config.Username == "" || config.Password == "" || len(config.URIs) == 0 {
+		t.Fatalf("expected short-lived diagnostic credentials, got %+v", config)
+	}
+
+	t.Setenv("STUN_HOST", "")
+	w = httptest.NewRecorder()
+	handleDiagnosticICEServers().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/diagnostic-ice-servers", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 without STUN_HOST, got %d", w.Code)
+	}
+}
+
 func TestHandleTurnCredentialsWrongMethod(t *testing.T) {
-	handler := handleTurnCredentials()
+	handler := handleTurnCredentials(newHub(4))
 	req := httptest.NewRequest(http.MethodPost, "/api/turn-credentials", nil)
 	w := httptest.NewRecorder()
 	handler.ServeHTTP(w, req)
@@ -216,13 +249,11 @@ func TestHandleTurnCredentialsMissingSTUN(t *testing.T) {
 	t.Setenv("TURN_SECRET", "coturn-secret")
 	// STUN_HOST not set
 
-	token, _, err := issueTurnToken(10*time.Minute, turnTokenKindCall)
-	if err != nil {
-		t.Fatalf("issueTurnToken: %v", err)
-	}
+	hub := newHub(4)
+	token, sid := joinedTurnSession(t, hub)
 
-	handler := handleTurnCredentials()
-	req := httptest.NewRequest(http.MethodGet, "/api/turn-credentials?token="+token, nil)
+	handler := handleTurnCredentials(hub)
+	req := httptest.NewRequest(http.MethodGet, "/api/turn-credentials?token="+token+"&sid="+sid, nil)
 	w := httptest.NewRecorder()
 	handler.ServeHTTP(w, req)
 
@@ -242,6 +273,7 @@ func TestHandleDiagnosticTokenSuccess(t *testing.T) {
 	if w.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d", w.Code)
 	}
+	assertNoStoreHeaders(t, w)
 
 	var resp map[string]interface{}
 	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
@@ -276,3 +308,334 @@ func TestHandleDiagnosticTokenMissingSecret(t *testing.T) {
 		t.Fatalf("expected 503, got %d", w.Code)
 	}
 }
+
+func TestBuildTurnURIsDefaultOrderIsUDPFirst(t *testing.T) {
+	uris := buildTurnURIs(splitTurnHosts("stun.example.com"), splitTurnHosts("turn.example.com"), "")
+	want := []string{
+		"stun:stun.example.com",
+		"turn:stun.example.com",
+		"turns:turn.example.com:443?transport=tcp",
+	}
+	if strings.Join(uris, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected %v, got %v", want, uris)
+	}
+}
+
+func TestBuildTurnURIsTLSFirst(t *testing.T) {
+	uris := buildTurnURIs(splitTurnHosts("stun.example.com"), nil, "tls-first")
+	if len(uris) != 3 || uris[0] != "turns:stun.example.com:5349?transport=tcp" {
+		t.Fatalf("expected turns: URI first, got %v", uris)
+	}
+}
+
+func TestParseTurnURIOrder(t *testing.T) {
+	cases := map[string]string{
+		"":            turnURIOrderUDPFirst,
+		"udp-first":   turnURIOrderUDPFirst,
+		" TLS-First ": turnURIOrderTLSFirst,
+		"bogus":       turnURIOrderUDPFirst,
+	}
+	for raw, want := range cases {
+		if got := parseTurnURIOrder(raw); got != want {
+			t.Errorf("parseTurnURIOrder(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestBuildTurnURIsDeduplicatesHosts(t *testing.T) {
+	for _, order := range []string{"udp-first", "tls-first", "bogus"} {
+		uris := buildTurnURIs(splitTurnHosts("stun.example.com, STUN.example.com ,backup.example.com"), splitTurnHosts("turn.example.com,turn.example.com"), order)
+		if len(uris) != 5 {
+			t.Fatalf("order %q: expected 5 URIs, got %v", order, uris)
+		}
+		seen := make(map[string]bool)
+		for _, uri := range uris {
+			if seen[uri] {
+				t.Fatalf("order %q: duplicate URI %q in %v", order, uri, uris)
+			}
+			seen[uri] = true
+		}
+	}
+}
+
+func assertNoStoreHeaders(t *testing.T, w *httptest.ResponseRecorder) {
+	t.Helper()
+	if got := w.Header().Get("Cache-Control"); got != "no-store" {
+		t.Fatalf("expected Cache-Control no-store, got %q", got)
+	}
+	if got := w.Header().Get("Pragma"); got != "no-cache" {
+		t.Fatalf("expected Pragma no-cache, got %q", got)
+	}
+}
+
+func TestParseTurnCredentialTTL(t *testing.T) {
+	cases := map[string]time.Duration{
+		"":       15 * time.Minute,
+		"bogus":  15 * time.Minute,
+		"59":     15 * time.Minute,
+		"86401":  15 * time.Minute,
+		"60":     time.Minute,
+		" 3600 ": time.Hour,
+		"86400":  24 * time.Hour,
+	}
+	for raw, want := range cases {
+		if got := parseTurnCredentialTTL(raw); got != want {
+			t.Errorf("parseTurnCredentialTTL(%q) = %s, want %s", raw, got, want)
+		}
+	}
+}
+
+func TestHandleTurnCredentialsConfiguredTTL(t *testing.T) {
+	t.Setenv("TURN_TOKEN_SECRET", "test-secret-1234")
+	t.Setenv("TURN_SECRET", "coturn-secret")
+	t.Setenv("STUN_HOST", "stun.example.com")
+	prev := turnCredentialTTL
+	turnCredentialTTL = 2 * time.Hour
+	t.Cleanup(func() { turnCredentialTTL = prev })
+
+	hub := newHub(4)
+	fetch := func(token, sid string) TurnConfig {
+		w := httptest.NewRecorder()
+		handleTurnCredentials(hub).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/turn-credentials?token="+token+"&sid="+sid, nil))
+		var config TurnConfig
+		if err := json.NewDecoder(w.Body).Decode(&config); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return config
+	}
+
+	before := time.Now().Unix()
+	config := fetch(joinedTurnSession(t, hub))
+	if config.TTL != 7200 {
+		t.Fatalf("expected TTL=7200 for call token, got %d", config.TTL)
+	}
+	expiresRaw, _, _ := strings.Cut(config.Username, ":")
+	expires, err := strconv.ParseInt(expiresRaw, 10, 64)
+	if err != nil || expires < before+7200 || expires > time.Now().Unix()+7200 {
+		t.Fatalf("expected username timestamp to match the TTL, got %q", config.Username)
+	}
+
+	diagnosticToken, _, err := issueTurnToken(5*time.Second, turnTokenKindDiagnostic, "")
+	if err != nil {
+		t.Fatalf("issueTurnToken: %v", err)
+	}
+	if config := fetch(diagnosticToken, ""); config.TTL != 5 {
+		t.Fatalf("expected diagnostic TTL to stay 5, got %d", config.TTL)
+	}
+}
+
+func TestTurnHostsFromEnvPrefersTurnHosts(t *testing.T) {
+	t.Setenv("STUN_HOST", "stun.example.com")
+	t.Setenv("TURN_HOST", "legacy.example.com")
+	t.Setenv("TURN_HOSTS", "")
+	if hosts := turnHostsFromEnv(); strings.Join(hosts.turn, ",") != "legacy.example.com" {
+		t.Fatalf("expected TURN_HOST fallback, got %v", hosts.turn)
+	}
+
+	t.Setenv("TURN_HOSTS", "turn-a.example.com, turn-b.example.com")
+	if hosts := turnHostsFromEnv(); strings.Join(hosts.turn, ",") != "turn-a.example.com,turn-b.example.com" {
+		t.Fatalf("expected TURN_HOSTS to win, got %v", hosts.turn)
+	}
+}
+
+func TestTurnHostsRoundRobinRotatesEachKind(t *testing.T) {
+	hosts := turnHosts{
+		stun: []string{"stun-a", "stun-b"},
+		turn: []string{"turn-a", "turn-b", "turn-c"},
+	}
+	if first, second := hosts.uris(0), hosts.uris(1); strings.Join(first, ",") != strings.Join(second, ",") {
+		t.Fatalf("expected a fixed order without round-robin, got %v and %v", first, second)
+	}
+
+	hosts.roundRobin = true
+	want := [][]string{
+		{"stun:stun-a", "turn:stun-a", "stun:stun-b", "turn:stun-b", "turns:turn-a:443?transport=tcp", "turns:turn-b:443?transport=tcp", "turns:turn-c:443?transport=tcp"},
+		{"stun:stun-b", "turn:stun-b", "stun:stun-a", "turn:stun-a", "turns:turn-b:443?transport=tcp", "turns:turn-c:443?transport=tcp", "turns:turn-a:443?transport=tcp"},
+		{"stun:stun-a", "turn:stun-a", "stun:stun-b", "turn:stun-b", "turns:turn-c:443?transport=tcp", "turns:turn-a:443?transport=tcp", "turns:turn-b:443?transport=tcp"},
+	}
+	for n, expected := range want {
+		if got := hosts.uris(uint64(n)); strings.Join(got, ",") != strings.Join(expected, ",") {
+			t.Fatalf("request %d: expected %v, got %v", n, expected, got)
+		}
+	}
+	if strings.Join(hosts.turn, ",") != "turn-a,turn-b,turn-c" {
+		t.Fatalf("expected rotation not to modify the configured hosts, got %v", hosts.turn)
+	}
+}
+
+func TestHandleTurnCredentialsRotatesTurnHosts(t *testing.T) {
+	t.Setenv("TURN_TOKEN_SECRET", "test-secret-1234")
+	t.Setenv("TURN_SECRET", "coturn-secret")
+	t.Setenv("STUN_HOST", "stun.example.com")
+	t.Setenv("TURN_HOSTS", "turn-a.example.com,turn-b.example.com")
+	t.Setenv("TURN_HOSTS_ROUND_ROBIN", "1")
+
+	hub := newHub(4)
+	token, sid := joinedTurnSession(t, hub)
+	handler := handleTurnCredentials(hub)
+	var firstTLS []string
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/turn-credentials?token="+token+"&sid="+sid, nil))
+		var config TurnConfig
+		if err := json.NewDecoder(w.Body).Decode(&config); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(config.URIs) != 4 {
+			t.Fatalf("expected every host listed, got %v", config.URIs)
+		}
+		firstTLS = append(firstTLS, config.URIs[2])
+	}
+	if firstTLS[0] != "turns:turn-a.example.com:443?transport=tcp" || firstTLS[1] != "turns:turn-b.example.com:443?transport=tcp" {
+		t.Fatalf("expected consecutive requests to lead with different TURN hosts, got %v", firstTLS)
+	}
+}
+
+// requireTurnSession turns on TURN_REQUIRE_SESSION for the test.
+func requireTurnSession(t *testing.T) {
+	t.Helper()
+	prev := turnRequireSession
+	turnRequireSession = true
+	t.Cleanup(func() { turnRequireSession = prev })
+}
+
+func TestValidateTurnTokenBindsCallTokensToRoom(t *testing.T) {
+	t.Setenv("TURN_TOKEN_SECRET", "test-secret-1234")
+	requireTurnSession(t)
+
+	token, _, err := issueTurnToken(10*time.Minute, turnTokenKindCall, "room-a")
+	if err != nil {
+		t.Fatalf("issueTurnToken: %v", err)
+	}
+	if claims, _ := parseTurnToken(token); claims.RID != "room-a" {
+		t.Fatalf("expected rid claim room-a, got %q", claims.RID)
+	}
+	if !validateTurnToken(token, turnTokenKindCall, "room-a") {
+		t.Fatal("expected the token to validate in its own room")
+	}
+	if validateTurnToken(token, turnTokenKindCall, "") {
+		t.Fatal("expected the token to be rejected without a session room")
+	}
+	if validateTurnToken(token, turnTokenKindCall, "room-b") {
+		t.Fatal("expected the token to be rejected in another room")
+	}
+}
+
+func TestUnboundCallTokensAcceptedUntilSessionRequired(t *testing.T) {
+	t.Setenv("TURN_TOKEN_SECRET", "test-secret-1234")
+
+	legacy, _, err := issueTurnToken(10*time.Minute, turnTokenKindCall, "")
+	if err != nil {
+		t.Fatalf("issueTurnToken: %v", err)
+	}
+	bound, _, err := issueTurnToken(10*time.Minute, turnTokenKindCall, "room-a")
+	if err != nil {
+		t.Fatalf("issueTurnToken: %v", err)
+	}
+
+	before := stats.SnapshotNow().Counters.TurnUnboundCallTokens
+	if !validateTurnToken(legacy, turnTokenKindCall, "room-a") {
+		t.Fatal("expected a token without a room to be accepted during the rollout")
+	}
+	if !validateTurnToken(bound, turnTokenKindCall, "") {
+		t.Fatal("expected a request without a session to be accepted during the rollout")
+	}
+	if validateTurnToken(bound, turnTokenKindCall, "room-b") {
+		t.Fatal("expected a token from another room's session to be rejected")
+	}
+	if got := stats.SnapshotNow().Counters.TurnUnboundCallTokens - before; got != 2 {
+		t.Fatalf("expected 2 unbound call tokens counted, got %d", got)
+	}
+
+	requireTurnSession(t)
+	if validateTurnToken(legacy, turnTokenKindCall, "room-a") || validateTurnToken(bound, turnTokenKindCall, "") {
+		t.Fatal("expected unbound call tokens rejected once sessions are required")
+	}
+}
+
+func TestHandleTurnCredentialsRejectsTokenFromAnotherRoomSession(t *testing.T) {
+	t.Setenv("TURN_TOKEN_SECRET", "test-secret-1234")
+	requireTurnSession(t)
+	t.Setenv("TURN_SECRET", "coturn-secret")
+	t.Setenv("STUN_HOST", "stun.example.com")
+
+	hub := newHub(4)
+	ridA, ridB := mustTestRoomID(t), mustTestRoomID(t)
+	inA, inB := fakeClient(hub), fakeClient(hub)
+	hub.registerClient(inA)
+	hub.registerClient(inB)
+	hub.handleMessage(inA, joinPayload(ridA, 4, 4))
+	hub.handleMessage(inB, joinPayload(ridB, 4, 4))
+
+	joined := findMessage(drainMessages(inA), "joined")
+	if joined == nil {
+		t.Fatal("expected a joined message")
+	}
+	var payload struct {
+		TurnToken string `json:"turnToken"`
+	}
+	if err := json.Unmarshal(joined.Payload, &payload); err != nil || payload.TurnToken == "" {
+		t.Fatalf("expected a TURN token in joined: %v", err)
+	}
+
+	handler := handleTurnCredentials(hub)
+	for _, tc := range []struct {
+		sid  string
+		want int
+	}{
+		{inA.sid, http.StatusOK},
+		{"", http.StatusUnauthorized},
+		{"unknown-sid", http.StatusUnauthorized},
+		{inB.sid, http.StatusUnauthorized},
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/turn-credentials?token="+payload.TurnToken+"&sid="+tc.sid, nil))
+		if w.Code != tc.want {
+			t.Fatalf("sid %q: expected %d, got %d", tc.sid, tc.want, w.Code)
+		}
+	}
+}
+
+// joinedTurnSession joins a fresh client to a new room on hub and returns the
+// TURN token from its joined message with the client's sid.
+func joinedTurnSession(t *testing.T, hub *Hub) (token, sid string) {
+	t.Helper()
+	c := fakeClient(hub)
+	hub.registerClient(c)
+	hub.handleMessage(c, joinPayload(mustTestRoomID(t), 4, 4))
+
+	joined := findMessage(drainMessages(c), "joined")
+	if joined == nil {
+		t.Fatal("expected a joined message")
+	}
+	var payload struct {
+		TurnToken string `json:"turnToken"`
+	}
+	if err := json.Unmarshal(joined.Payload, &payload); err != nil || payload.TurnToken == "" {
+		t.Fatalf("expected a TURN token in joined: %v", err)
+	}
+	return payload.TurnToken, c.sid
+}
+
+func TestHandleTurnCredentialsRejectsCallTokenWithoutSession(t *testing.T) {
+	t.Setenv("TURN_TOKEN_SECRET", "test-secret-1234")
+	requireTurnSession(t)
+	t.Setenv("TURN_SECRET", "coturn-secret")
+	t.Setenv("STUN_HOST", "stun.example.com")
+
+	hub := newHub(4)
+	token, sid := joinedTurnSession(t, hub)
+	handler := handleTurnCredentials(hub)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/turn-credentials?token="+token, nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for a call token without sid, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/turn-credentials?token="+token+"&sid="+sid, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 with the session's sid, got %d", w.Code)
+	}
+}
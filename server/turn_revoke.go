@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// revokedTurnTokens lists TURN tokens invalidated before their expiry through
+// /api/turn-revoke. TURN tokens are stateless, so revocation is a denylist;
+// entries are dropped once the token would have expired anyway.
+var revokedTurnTokens = newTurnTokenRevocations()
+
+type turnTokenRevocations struct {
+	mu      sync.Mutex
+	revoked map[string]int64 // token -> its exp claim (unix seconds)
+}
+
+func newTurnTokenRevocations() *turnTokenRevocations {
+	return &turnTokenRevocations{revoked: make(map[string]int64)}
+}
+
+// revoke records token, which expires at exp, as revoked. It reports false if
+// the token was already revoked.
+func (r *turnTokenRevocations) revoke(token string, exp int64, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for revoked, revokedExp := range r.revoked {
+		if now.Unix() > revokedExp {
+			delete(r.revoked, revoked)
+		}
+	}
+	if _, exists := r.revoked[token]; exists {
+		return false
+	}
+	r.revoked[token] = exp
+	return true
+}
+
+func (r *turnTokenRevocations) isRevoked(token string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, revoked := r.revoked[token]
+	return revoked
+}
+
+// handleTurnRevoke serves POST /api/turn-revoke?roomId=<rid> with body
+// {"cid","token"}. Like /api/push/notify, only a current participant of the
+// room may call it. It answers 204 once the token is revoked and 404 if the
+// token is not a live TURN token (invalid, expired, already revoked or
+// minted for another room).
+func handleTurnRevoke(hub *Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			return
+		}
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		roomID := strings.TrimSpace(r.URL.Query().Get("roomId"))
+		if writeRoomIDValidationError(w, roomID) {
+			return
+		}
+
+		var body struct {
+			CID   string `json:"cid"`
+			Token string `json:"token"`
+		}
+		decoder := json.NewDecoder(io.LimitReader(r.Body, 4096))
+		if err := decoder.Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, "Invalid body", http.StatusBadRequest)
+			return
+		}
+
+		cid := strings.TrimSpace(body.CID)
+		token := strings.TrimSpace(body.Token)
+		if cid == "" || token == "" {
+			http.Error(w, "Missing cid or token", http.StatusBadRequest)
+			return
+		}
+
+		if !hub.IsClientInRoom(roomID, cid) {
+			http.Error(w, "Not a room participant", http.StatusForbidden)
+			return
+		}
+
+		now := time.Now()
+		claims, ok := parseTurnToken(token)
+		// A participant may only revoke tokens minted for its own room.
+		if !ok || claims.V != turnTokenVersion || now.Unix() > claims.Exp || (claims.RID != "" && claims.RID != roomID) ||
+			!revokedTurnTokens.revoke(token, claims.Exp, now) {
+			http.Error(w, "Unknown TURN token", http.StatusNotFound)
+			return
+		}
+
+		log.Printf("[TURN] CID %s in room %s revoked a %s TURN token", cid, roomID, claims.Kind)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
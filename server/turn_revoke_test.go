@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func turnRevokeRequest(roomID, body string) *http.Request {
+	return httptest.NewRequest(http.MethodPost, "/api/turn-revoke?roomId="+roomID, strings.NewReader(body))
+}
+
+func TestHandleTurnRevokeInvalidatesToken(t *testing.T) {
+	t.Setenv("TURN_TOKEN_SECRET", "test-secret-1234")
+	roomID := mustTestRoomID(t)
+	handler := handleTurnRevoke(makeTestHubWithParticipant(roomID, "cid-1"))
+
+	token, _, err := issueTurnToken(10*time.Minute, turnTokenKindCall, roomID)
+	if err != nil {
+		t.Fatalf("issueTurnToken: %v", err)
+	}
+	if !validateTurnToken(token, turnTokenKindCall, roomID) {
+		t.Fatal("expected a fresh token to validate")
+	}
+
+	rec := httptest.NewRecorder()
+	handler(rec, turnRevokeRequest(roomID, `{"cid":"cid-1","token":"`+token+`"}`))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if validateTurnToken(token, turnTokenKindCall, roomID) {
+		t.Fatal("expected the revoked token to be rejected")
+	}
+
+	rec = httptest.NewRecorder()
+	handler(rec, turnRevokeRequest(roomID, `{"cid":"cid-1","token":"`+token+`"}`))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an already revoked token, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	handler(rec, turnRevokeRequest(roomID, `{"cid":"cid-1","token":"bogus.token"}`))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown token, got %d", rec.Code)
+	}
+}
+
+func TestHandleTurnRevokeRequiresRoomParticipant(t *testing.T) {
+	t.Setenv("TURN_TOKEN_SECRET", "test-secret-1234")
+	roomID := mustTestRoomID(t)
+	handler := handleTurnRevoke(makeTestHubWithParticipant(roomID, "cid-1"))
+
+	token, _, err := issueTurnToken(10*time.Minute, turnTokenKindCall, roomID)
+	if err != nil {
+		t.Fatalf("issueTurnToken: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler(rec, turnRevokeRequest(roomID, `{"cid":"cid-2","token":"`+token+`"}`))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a non-participant, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	handler(rec, turnRevokeRequest(roomID, `{"cid":"cid-1"}`))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a token, got %d", rec.Code)
+	}
+	if !validateTurnToken(token, turnTokenKindCall, roomID) {
+		t.Fatal("expected rejected revocations to leave the token valid")
+	}
+}
+
+func TestTurnTokenRevocationsPruneExpired(t *testing.T) {
+	revocations := newTurnTokenRevocations()
+	now := time.Now()
+	revocations.revoke("old", now.Add(-time.Minute).Unix(), now.Add(-2*time.Minute))
+	revocations.revoke("live", now.Add(time.Minute).Unix(), now)
+
+	if revocations.isRevoked("old") || !revocations.isRevoked("live") {
+		t.Fatalf("expected only the unexpired revocation kept, got %v", revocations.revoked)
+	}
+}
+
+func TestHandleTurnRevokeRejectsTokenFromAnotherRoom(t *testing.T) {
+	t.Setenv("TURN_TOKEN_SECRET", "test-secret-1234")
+	roomID := mustTestRoomID(t)
+	handler := handleTurnRevoke(makeTestHubWithParticipant(roomID, "cid-1"))
+
+	token, _, err := issueTurnToken(10*time.Minute, turnTokenKindCall, "another-room")
+	if err != nil {
+		t.Fatalf("issueTurnToken: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler(rec, turnRevokeRequest(roomID, `{"cid":"cid-1","token":"`+token+`"}`))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for another room's token, got %d", rec.Code)
+	}
+	if !validateTurnToken(token, turnTokenKindCall, "another-room") {
+		t.Fatal("expected another room's token to stay valid")
+	}
+}
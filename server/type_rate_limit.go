@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"serenada/server/internal/stats"
+)
+
+// defaultMessageTypeRates applies when RELAY_TYPE_RATE_LIMITS is unset. It
+// leaves room for a full ICE gathering burst and several renegotiations while
+// stopping a client from relaying at line rate. Presence hints are chatty by
+// design but still bounded.
+const defaultMessageTypeRates = "offer=5:10,answer=5:10,ice=50:200,presence=10:20"
+
+// messageTypeRate is a per-second refill rate and bucket size.
+type messageTypeRate struct {
+	rate  float64
+	burst float64
+}
+
+// messageTypeRates caps how many messages of a given type one client may send.
+// ICE trickles at a high rate while offer and answer are rare, so a single
+// per-client limit would either throttle legitimate ICE or let offers be
+// spammed. Set from RELAY_TYPE_RATE_LIMITS (e.g. "ice=100,offer=2:5") at
+// startup; "off" disables it.
+var messageTypeRates map[string]messageTypeRate
+
+// parseMessageTypeRates reads type=rate[:burst] entries. The burst defaults to
+// one second's worth of messages.
+func parseMessageTypeRates(raw string) map[string]messageTypeRate {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		raw = defaultMessageTypeRates
+	}
+	if strings.EqualFold(raw, "off") {
+		return nil
+	}
+	rates := make(map[string]messageTypeRate)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		msgType, value, ok := strings.Cut(part, "=")
+		msgType = strings.TrimSpace(msgType)
+		rateRaw, burstRaw, hasBurst := strings.Cut(value, ":")
+		rate, err := strconv.ParseFloat(strings.TrimSpace(rateRaw), 64)
+		if !ok || msgType == "" || err != nil || rate <= 0 {
+			log.Printf("Ignoring invalid RELAY_TYPE_RATE_LIMITS entry %q", part)
+			continue
+		}
+		burst := max(rate, 1)
+		if hasBurst {
+			burst, err = strconv.ParseFloat(strings.TrimSpace(burstRaw), 64)
+			if err != nil || burst < 1 {
+				log.Printf("Ignoring invalid RELAY_TYPE_RATE_LIMITS entry %q", part)
+				continue
+			}
+		}
+		rates[msgType] = messageTypeRate{rate: rate, burst: burst}
+	}
+	if len(rates) == 0 {
+		return nil
+	}
+	return rates
+}
+
+// typeLimiters holds a client's per-type token buckets, created lazily. SSE
+// clients can post concurrently, so access is locked.
+type typeLimiters struct {
+	mu      sync.Mutex
+	buckets map[string]*SimpleTokenBucket
+}
+
+// allowMessageType reports whether c may send another message of msgType
+// under messageTypeRates, and if not, how long until it may. Types without a
+// configured rate are always allowed.
+func (c *Client) allowMessageType(msgType string) (bool, time.Duration) {
+	limit, limited := messageTypeRates[msgType]
+	if !limited {
+		return true, 0
+	}
+
+	c.typeLimiters.mu.Lock()
+	if c.typeLimiters.buckets == nil {
+		c.typeLimiters.buckets = make(map[string]*SimpleTokenBucket)
+	}
+	bucket := c.typeLimiters.buckets[msgType]
+	if bucket == nil {
+		bucket = NewSimpleTokenBucket(limit.burst, limit.rate)
+		c.typeLimiters.buckets[msgType] = bucket
+	}
+	c.typeLimiters.mu.Unlock()
+
+	if bucket.Allow() {
+		return true, 0
+	}
+	stats.IncMessageRateLimited(msgType)
+	return false, bucket.retryAfter()
+}
+
+// sendTypeRateLimited tells c its msgType message was dropped. retryAfterMs
+// is when the next one will be accepted.
+func (c *Client) sendTypeRateLimited(rid, msgType string, retryAfter time.Duration) {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"code":         "TYPE_RATE_LIMITED",
+		"message":      "Too many " + msgType + " messages",
+		"retryAfterMs": retryAfter.Milliseconds(),
+	})
+	c.sendMessage(Message{
+		V:       1,
+		Type:    "error",
+		RID:     rid,
+		Payload: payload,
+	})
+}
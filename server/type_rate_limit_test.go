@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"serenada/server/internal/stats"
+)
+
+func TestParseMessageTypeRates(t *testing.T) {
+	if rates := parseMessageTypeRates(""); rates["offer"] != (messageTypeRate{rate: 5, burst: 10}) || rates["ice"] != (messageTypeRate{rate: 50, burst: 200}) {
+		t.Fatalf("expected default relay limits, got %v", rates)
+	}
+	if rates := parseMessageTypeRates("off"); rates != nil {
+		t.Fatalf("expected off to disable limits, got %v", rates)
+	}
+	rates := parseMessageTypeRates(" ice=100, offer=2:6,answer=0.5,bogus,=3,ice-restart=x,pong=1:0 ")
+	if len(rates) != 3 || rates["ice"] != (messageTypeRate{rate: 100, burst: 100}) ||
+		rates["offer"] != (messageTypeRate{rate: 2, burst: 6}) || rates["answer"] != (messageTypeRate{rate: 0.5, burst: 1}) {
+		t.Fatalf("unexpected rates: %v", rates)
+	}
+}
+
+func TestMessageTypeRateLimitIsPerType(t *testing.T) {
+	original := messageTypeRates
+	messageTypeRates = map[string]messageTypeRate{"offer": {rate: 2, burst: 2}}
+	defer func() { messageTypeRates = original }()
+
+	hub, rid, sender, peer, _ := joinedPair(t)
+	payloadBytes, _ := json.Marshal(map[string]interface{}{"sdp": "v=0"})
+	offer, _ := json.Marshal(Message{V: 1, Type: "offer", RID: rid, To: peer.cid, Payload: payloadBytes})
+	ice, _ := json.Marshal(Message{V: 1, Type: "ice", RID: rid, To: peer.cid, Payload: payloadBytes})
+
+	before := stats.SnapshotNow().Messages.RateLimitedByType["offer"]
+	for i := 0; i < 3; i++ {
+		hub.handleMessage(sender, offer)
+	}
+	rejection := findMessage(drainMessages(sender), "error")
+	if code := errorCode(rejection); code != "TYPE_RATE_LIMITED" {
+		t.Fatalf("expected TYPE_RATE_LIMITED on the third offer, got %q", code)
+	}
+	var hint struct {
+		RetryAfterMs int64 `json:"retryAfterMs"`
+	}
+	if err := json.Unmarshal(rejection.Payload, &hint); err != nil || hint.RetryAfterMs <= 0 || hint.RetryAfterMs > 500 {
+		t.Fatalf("expected a retryAfterMs hint of at most 500ms, got %+v (%v)", hint, err)
+	}
+	offers := 0
+	for _, m := range drainMessages(peer) {
+		if m.Type == "offer" {
+			offers++
+		}
+	}
+	if offers != 2 {
+		t.Fatalf("expected 2 offers relayed within the burst, got %d", offers)
+	}
+	if after := stats.SnapshotNow().Messages.RateLimitedByType["offer"]; after-before != 1 {
+		t.Fatalf("expected one rate-limited offer counted, got %d", after-before)
+	}
+
+	for i := 0; i < 10; i++ {
+		hub.handleMessage(sender, ice)
+	}
+	if findMessage(drainMessages(sender), "error") != nil {
+		t.Fatal("expected unlimited types not to be throttled")
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// minWatcherTTL keeps a misconfigured WATCHER_TTL_SECONDS from dropping
+// subscriptions of clients that refresh on a normal home-screen cadence.
+const minWatcherTTL = 5 * time.Minute
+
+// watcherTTL is how long watch_rooms subscriptions survive without a
+// watch_rooms or watch_keepalive from their client. Zero disables expiry.
+// Set from WATCHER_TTL_SECONDS at startup.
+var watcherTTL time.Duration
+
+func parseWatcherTTL(raw string) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	ttl := time.Duration(seconds) * time.Second
+	if ttl < minWatcherTTL {
+		return minWatcherTTL
+	}
+	return ttl
+}
+
+func (c *Client) touchWatch() {
+	atomic.StoreInt64(&c.watchRefreshedAt, time.Now().UnixNano())
+}
+
+func (h *Hub) handleWatchKeepalive(c *Client) {
+	c.touchWatch()
+}
+
+// expireStaleWatchers drops every subscription held by clients that have not
+// refreshed their watch interest within ttl.
+func (h *Hub) expireStaleWatchers(ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	cutoff := time.Now().Add(-ttl).UnixNano()
+
+	expired := make(map[*Client]bool)
+	h.mu.Lock()
+	for rid, clientSet := range h.watchers {
+		for client := range clientSet {
+			if atomic.LoadInt64(&client.watchRefreshedAt) >= cutoff {
+				continue
+			}
+			delete(clientSet, client)
+			expired[client] = true
+		}
+		if len(clientSet) == 0 {
+			delete(h.watchers, rid)
+		}
+	}
+	h.mu.Unlock()
+
+	if len(expired) == 0 {
+		return
+	}
+	for client := range expired {
+		log.Printf("[WATCH] Expired room subscriptions for client %s after %s without refresh", client.sid, ttl)
+	}
+	h.refreshStatsGauges()
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"serenada/server/internal/stats"
+)
+
+func watchKeepalivePayload() []byte {
+	b, _ := json.Marshal(Message{V: 1, Type: "watch_keepalive"})
+	return b
+}
+
+func TestParseWatcherTTL(t *testing.T) {
+	cases := map[string]time.Duration{
+		"":      0,
+		"0":     0,
+		"-5":    0,
+		"bogus": 0,
+		"60":    minWatcherTTL,
+		"3600":  time.Hour,
+	}
+	for raw, want := range cases {
+		if got := parseWatcherTTL(raw); got != want {
+			t.Errorf("parseWatcherTTL(%q) = %s, want %s", raw, got, want)
+		}
+	}
+}
+
+func TestExpireStaleWatchersRemovesIdleSubscriptions(t *testing.T) {
+	hub := newHub(4)
+	rid := mustTestRoomID(t)
+	idle := fakeClient(hub)
+	active := fakeClient(hub)
+	hub.registerClient(idle)
+	hub.registerClient(active)
+
+	hub.handleMessage(idle, watchRoomsPayload([]string{rid}))
+	hub.handleMessage(active, watchRoomsPayload([]string{rid}))
+	drainMessages(idle)
+	drainMessages(active)
+
+	atomic.StoreInt64(&idle.watchRefreshedAt, time.Now().Add(-2*time.Hour).UnixNano())
+	hub.expireStaleWatchers(time.Hour)
+
+	hub.mu.RLock()
+	_, idleWatching := hub.watchers[rid][idle]
+	_, activeWatching := hub.watchers[rid][active]
+	hub.mu.RUnlock()
+	if idleWatching {
+		t.Fatal("expected idle watcher to be expired")
+	}
+	if !activeWatching {
+		t.Fatal("expected refreshed watcher to be kept")
+	}
+	if got := stats.SnapshotNow().Gauges.WatcherSubscriptions; got != 1 {
+		t.Fatalf("expected 1 watcher subscription gauge, got %d", got)
+	}
+
+	atomic.StoreInt64(&active.watchRefreshedAt, time.Now().Add(-2*time.Hour).UnixNano())
+	hub.expireStaleWatchers(time.Hour)
+
+	hub.mu.RLock()
+	_, exists := hub.watchers[rid]
+	hub.mu.RUnlock()
+	if exists {
+		t.Fatal("expected empty watcher set to be removed")
+	}
+}
+
+func TestWatchKeepaliveRefreshesSubscriptions(t *testing.T) {
+	hub := newHub(4)
+	rid := mustTestRoomID(t)
+	c := fakeClient(hub)
+	hub.registerClient(c)
+
+	hub.handleMessage(c, watchRoomsPayload([]string{rid}))
+	drainMessages(c)
+	atomic.StoreInt64(&c.watchRefreshedAt, time.Now().Add(-2*time.Hour).UnixNano())
+
+	hub.handleMessage(c, watchKeepalivePayload())
+	hub.expireStaleWatchers(time.Hour)
+
+	hub.mu.RLock()
+	_, watching := hub.watchers[rid][c]
+	hub.mu.RUnlock()
+	if !watching {
+		t.Fatal("expected watch_keepalive to keep the subscription alive")
+	}
+	if msgs := drainMessages(c); len(msgs) != 0 {
+		t.Fatalf("expected no reply to watch_keepalive, got %d messages", len(msgs))
+	}
+}
+
+func TestExpireStaleWatchersDisabled(t *testing.T) {
+	hub := newHub(4)
+	rid := mustTestRoomID(t)
+	c := fakeClient(hub)
+	hub.registerClient(c)
+
+	hub.handleMessage(c, watchRoomsPayload([]string{rid}))
+	atomic.StoreInt64(&c.watchRefreshedAt, 1)
+	hub.expireStaleWatchers(0)
+
+	hub.mu.RLock()
+	_, watching := hub.watchers[rid][c]
+	hub.mu.RUnlock()
+	if !watching {
+		t.Fatal("expected subscriptions to be kept when TTL is disabled")
+	}
+}
+
+func TestSSEReconnectCarriesClientState(t *testing.T) {
+	hub := newHub(4)
+	rid := mustTestRoomID(t)
+	old := fakeClient(hub)
+	old.transport = TransportSSE
+	old.relayReceipts = true
+	hub.registerClient(old)
+	hub.handleMessage(old, watchRoomsPayload([]string{rid}))
+	drainMessages(old)
+	lastActivity := time.Now().Add(-time.Minute).UnixNano()
+	atomic.StoreInt64(&old.lastActivity, lastActivity)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveSSE(hub, w, r)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"?sid="+old.sid, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("sse request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for hub.getClientBySID(old.sid) == old {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the reconnect to replace the old client")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	reconnected := hub.getClientBySID(old.sid)
+
+	hub.expireStaleWatchers(time.Hour)
+
+	hub.mu.RLock()
+	_, watching := hub.watchers[rid][reconnected]
+	hub.mu.RUnlock()
+	if !watching {
+		t.Fatal("expected the reconnected client to keep its fresh subscription")
+	}
+	if !reconnected.relayReceipts {
+		t.Fatal("expected the reconnected client to keep its relayReceipts capability")
+	}
+	if got := atomic.LoadInt64(&reconnected.lastActivity); got != lastActivity {
+		t.Fatalf("expected lastActivity %d to carry over, got %d", lastActivity, got)
+	}
+}
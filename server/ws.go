@@ -3,6 +3,7 @@ package main
 import (
 	"log"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -25,41 +26,67 @@ var wsUpgrader = websocket.Upgrader{
 }
 
 type wsClient struct {
-	client *Client
-	conn   *websocket.Conn
+	client  *Client
+	conn    *websocket.Conn
+	release func() // frees the connection's per-IP slot; see connLimiter
+
+	writeFailed atomic.Bool // set when writePump gave up on a failed write
 }
 
 func serveWs(hub *Hub, w http.ResponseWriter, r *http.Request) {
 	stats.IncConnectionAttempt("ws")
 
+	ip := getClientIP(r)
+	if hub.isDraining() {
+		rejectDraining(w, "ws", ip)
+		return
+	}
+	release, retryAfter, ok := hub.connLimit.acquire(ip)
+	if !ok {
+		rejectConnLimited(w, "ws", ip, retryAfter)
+		return
+	}
+	sid := generateID("S-")
+	client := hub.newClient(sid, ip, TransportWS)
+
+	// Take the slot before upgrading so a full server can still answer 503.
+	if !hub.tryRegisterClient(client) {
+		release()
+		rejectAtClientCap(w, "ws", ip)
+		return
+	}
+
 	conn, err := wsUpgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Println(err)
+		release()
+		hub.unregisterClient(client)
 		stats.IncConnectionFailure("ws")
 		return
 	}
 
-	ip := getClientIP(r)
-	sid := generateID("S-")
-	client := &Client{hub: hub, send: make(chan []byte, 256), sid: sid, ip: ip, transport: TransportWS}
-
-	hub.registerClient(client)
 	stats.IncConnectionSuccess("ws")
 	stats.AddActiveWSClients(1)
 
-	ws := &wsClient{client: client, conn: conn}
-	go ws.writePump()
-	go ws.readPump()
+	ws := &wsClient{client: client, conn: conn, release: release}
+	hub.goConnLoop(ws.writePump)
+	hub.goConnLoop(ws.readPump)
 }
 
 func (c *wsClient) readPump() {
+	var readErr error
 	defer func() {
-		c.client.hub.handleDisconnectWS(c.client)
+		c.client.hub.handleDisconnectWS(c.client, wsReadDisconnectReason(readErr, c.writeFailed.Load()))
 		c.conn.Close()
+		c.release()
 	}()
 	c.conn.SetReadLimit(maxMessageSize)
 	c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
-	c.conn.SetPongHandler(func(string) error { c.conn.SetReadDeadline(time.Now().Add(wsPongWait)); return nil })
+	c.conn.SetPongHandler(func(string) error {
+		c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		atomic.StoreInt64(&c.client.lastSeen, time.Now().UnixNano())
+		return nil
+	})
 
 	for {
 		_, message, err := c.conn.ReadMessage()
@@ -67,18 +94,19 @@ func (c *wsClient) readPump() {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Printf("error: %v", err)
 			}
+			readErr = err
 			break
 		}
+		atomic.StoreInt64(&c.client.lastSeen, time.Now().UnixNano())
 		c.client.hub.handleMessage(c.client, message)
 	}
 }
 
-func (h *Hub) handleDisconnectWS(c *Client) {
-	stats.IncDisconnect("ws")
-	go h.delayDisconnectWS(c)
+func (h *Hub) handleDisconnectWS(c *Client, reason DisconnectReason) {
+	h.goConn(func() { h.delayDisconnectWS(c, reason) })
 }
 
-func (h *Hub) delayDisconnectWS(c *Client) {
+func (h *Hub) delayDisconnectWS(c *Client, reason DisconnectReason) {
 	time.Sleep(wsGracePeriod)
 	h.mu.RLock()
 	_, exists := h.clients[c]
@@ -86,7 +114,7 @@ func (h *Hub) delayDisconnectWS(c *Client) {
 	if !exists {
 		return
 	}
-	h.disconnectClient(c)
+	h.disconnectClient(c, reason)
 }
 
 func (c *wsClient) writePump() {
@@ -100,12 +128,13 @@ func (c *wsClient) writePump() {
 		case message, ok := <-c.client.send:
 			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
 			if !ok {
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				c.conn.WriteMessage(websocket.CloseMessage, c.client.closeMessage())
 				return
 			}
 
 			w, err := c.conn.NextWriter(websocket.TextMessage)
 			if err != nil {
+				c.writeFailed.Store(true)
 				return
 			}
 			w.Write(message)
@@ -114,11 +143,13 @@ func (c *wsClient) writePump() {
 			// if multiple messages are sent in one frame.
 
 			if err := w.Close(); err != nil {
+				c.writeFailed.Store(true)
 				return
 			}
 		case <-ticker.C:
 			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				c.writeFailed.Store(true)
 				return
 			}
 		}
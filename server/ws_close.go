@@ -0,0 +1,81 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket close codes sent after a join rejection or kick, so a client can
+// tell "do not retry" apart from a network drop. The close reason is the
+// error code. See docs/serenada_protocol_v1.md §1.1 for which ones are
+// permanent.
+const (
+	wsCloseRoomFull                = 4001
+	wsCloseRoomLocked              = 4002
+	wsCloseRoomCapacityUnsupported = 4003
+	wsCloseCapabilityRequired      = 4004
+	wsCloseRoomBlocked             = 4005
+	wsCloseInvalidRoomID           = 4006
+	wsCloseRoomIDInUse             = 4007
+	wsCloseInvalidReconnectToken   = 4008
+	wsCloseReconnectTokenExpired   = 4009
+	wsCloseReconnectBlocked        = 4010
+	wsCloseServerDraining          = 4011
+	wsCloseKicked                  = 4012
+)
+
+// wsJoinCloseCodes maps the join error codes that close a WebSocket. Errors
+// not listed here (rate limits, SERVER_BUSY, CID_IN_USE, BAD_REQUEST) leave
+// the connection open, since retrying the join on it may succeed.
+var wsJoinCloseCodes = map[string]int{
+	"ROOM_FULL":                 wsCloseRoomFull,
+	"ROOM_LOCKED":               wsCloseRoomLocked,
+	"ROOM_CAPACITY_UNSUPPORTED": wsCloseRoomCapacityUnsupported,
+	"CAPABILITY_REQUIRED":       wsCloseCapabilityRequired,
+	"ROOM_BLOCKED":              wsCloseRoomBlocked,
+	"INVALID_ROOM_ID":           wsCloseInvalidRoomID,
+	"ROOM_ID_IN_USE":            wsCloseRoomIDInUse,
+	"INVALID_RECONNECT_TOKEN":   wsCloseInvalidReconnectToken,
+	"RECONNECT_TOKEN_EXPIRED":   wsCloseReconnectTokenExpired,
+	"RECONNECT_BLOCKED":         wsCloseReconnectBlocked,
+	"SERVER_DRAINING":           wsCloseServerDraining,
+}
+
+// rejectJoin sends a join error and, for a WebSocket client, closes the
+// connection with the matching close code. SSE clients only get the error.
+// Must be called without hub or room locks held.
+func (h *Hub) rejectJoin(c *Client, rid, code, message string) {
+	c.sendError(rid, code, message)
+	closeCode, ok := wsJoinCloseCodes[code]
+	if !ok || c.transport != TransportWS {
+		return
+	}
+	disconnectReason := DisconnectJoinRejected
+	if code == "SERVER_DRAINING" {
+		disconnectReason = DisconnectDraining
+	}
+	h.closeWS(c, closeCode, code, disconnectReason)
+}
+
+// closeWS disconnects c after its queued messages, ending the WebSocket with
+// code and reason instead of a bare close frame. why is the disconnect reason
+// counted in stats.
+func (h *Hub) closeWS(c *Client, code int, reason string, why DisconnectReason) {
+	c.sendMu.Lock()
+	c.closeFrame = websocket.FormatCloseMessage(code, reason)
+	c.sendMu.Unlock()
+	slog.Info("ws_close", "sid", c.sid, "code", code, "reason", reason)
+	h.disconnectClient(c, why)
+}
+
+// closeMessage returns the close frame payload writePump sends once send is
+// closed: the one set by closeWS, or an empty payload.
+func (c *Client) closeMessage() []byte {
+	c.sendMu.RLock()
+	defer c.sendMu.RUnlock()
+	if c.closeFrame == nil {
+		return []byte{}
+	}
+	return c.closeFrame
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestRoomFullClosesWebSocketWithCode(t *testing.T) {
+	rid := mustTestRoomID(t)
+	hub := newHub(4)
+	for i := 0; i < 2; i++ {
+		c := fakeClient(hub)
+		hub.registerClient(c)
+		hub.handleMessage(c, joinPayload(rid, 2, 2))
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveWs(hub, w, r)
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.WriteMessage(websocket.TextMessage, joinPayload(rid, 2, 2)); err != nil {
+		t.Fatalf("write join: %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	_, raw, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("expected the error message before the close frame: %v", err)
+	}
+	var msg Message
+	_ = json.Unmarshal(raw, &msg)
+	if code := errorCode(&msg); code != "ROOM_FULL" {
+		t.Fatalf("expected ROOM_FULL, got %q", code)
+	}
+
+	_, _, err = conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) {
+		t.Fatalf("expected a close frame, got %v", err)
+	}
+	if closeErr.Code != wsCloseRoomFull || closeErr.Text != "ROOM_FULL" {
+		t.Fatalf("expected close %d ROOM_FULL, got %d %q", wsCloseRoomFull, closeErr.Code, closeErr.Text)
+	}
+}
+
+func TestNonPermanentJoinErrorKeepsWebSocketOpen(t *testing.T) {
+	hub := newHub(4)
+	c := fakeClient(hub)
+	c.transport = TransportWS
+	hub.registerClient(c)
+
+	hub.handleMessage(c, joinPayload("", 4, 4))
+
+	if code := errorCode(findMessage(drainMessages(c), "error")); code != "BAD_REQUEST" {
+		t.Fatalf("expected BAD_REQUEST, got %q", code)
+	}
+	if !hub.isClientActive(c) {
+		t.Fatal("expected BAD_REQUEST to leave the connection open")
+	}
+}
+
+func TestKickSetsWebSocketCloseCode(t *testing.T) {
+	hub, rid, host, guest, guestCID := joinedPair(t)
+	guest.transport = TransportWS
+
+	hub.handleMessage(host, kickPayload(rid, guestCID))
+
+	frame := guest.closeMessage()
+	if len(frame) < 2 {
+		t.Fatalf("expected a close frame payload, got %v", frame)
+	}
+	code, reason := int(frame[0])<<8|int(frame[1]), string(frame[2:])
+	if code != wsCloseKicked || reason != "kicked" {
+		t.Fatalf("expected close %d kicked, got %d %q", wsCloseKicked, code, reason)
+	}
+}